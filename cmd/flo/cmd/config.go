@@ -6,6 +6,7 @@ import (
 
 	"github.com/richgo/flo/pkg/secrets"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var configCmd = &cobra.Command{
@@ -18,7 +19,7 @@ var configShowCmd = &cobra.Command{
 	Use:   "show",
 	Short: "Show current configuration",
 	Long: `Display current configuration including environment variables and secrets.
-Secret values are masked for security.
+Secret values and provider base URLs are masked for security.
 
 Supported environment variables:
   - CLAUDE_API_KEY: API key for Claude backend
@@ -79,5 +80,16 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Model: %s\n", model)
 	}
 
+	// Display workspace config, if any, with secrets masked
+	if ws, err := loadWorkspace(); err == nil {
+		data, err := yaml.Marshal(ws.Config.Redacted())
+		if err != nil {
+			return fmt.Errorf("failed to serialize config: %w", err)
+		}
+		fmt.Println()
+		fmt.Println("Workspace Config (.flo/config.yaml):")
+		fmt.Print(string(data))
+	}
+
 	return nil
 }
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/richgo/flo/pkg/github"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/spf13/cobra"
+)
+
+var exportRepo string
+var exportClose bool
+var exportDryRun bool
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Publish task outcomes to external trackers",
+}
+
+var exportGithubCmd = &cobra.Command{
+	Use:   "github",
+	Short: "Comment on the GitHub issues of finished tasks",
+	Long: `Post a comment summarizing each finished task (backend, tokens,
+duration) on the GitHub issue it records (set with 'flo task create --issue').
+
+With --close, issues of complete tasks are also closed; failed tasks are only
+commented on. --dry-run prints what would be posted without calling GitHub.
+
+Authenticates with GITHUB_TOKEN from the environment or a .env file.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		manager, err := secrets.LoadDefault()
+		if err != nil {
+			return fmt.Errorf("failed to load secrets: %w", err)
+		}
+		token := manager.Get("GITHUB_TOKEN")
+		if token == "" && !exportDryRun {
+			return fmt.Errorf("GITHUB_TOKEN is not set")
+		}
+
+		client, err := github.NewClient(exportRepo, token)
+		if err != nil {
+			return err
+		}
+
+		summary := github.Export(context.Background(), client, ws.Tasks.List(), github.ExportOptions{
+			Close:  exportClose,
+			DryRun: exportDryRun,
+			Out:    out.Progress(),
+		})
+
+		verb := "Commented on"
+		if exportDryRun {
+			verb = "Would comment on"
+		}
+		fmt.Fprintf(out.Progress(), "%s %d issue(s), closed %d\n", verb, len(summary.Commented), len(summary.Closed))
+		for id, err := range summary.Errors {
+			fmt.Fprintf(out.Progress(), "  ❌ %s: %v\n", id, err)
+		}
+		if len(summary.Errors) > 0 {
+			return fmt.Errorf("%d task(s) failed to export", len(summary.Errors))
+		}
+		return nil
+	},
+}
+
+func init() {
+	exportGithubCmd.Flags().StringVar(&exportRepo, "repo", "", "GitHub repository (owner/name)")
+	exportGithubCmd.Flags().BoolVar(&exportClose, "close", false, "Close the issues of complete tasks")
+	exportGithubCmd.Flags().BoolVar(&exportDryRun, "dry-run", false, "Print what would be posted without calling GitHub")
+	exportGithubCmd.MarkFlagRequired("repo")
+	exportCmd.AddCommand(exportGithubCmd)
+	rootCmd.AddCommand(exportCmd)
+}
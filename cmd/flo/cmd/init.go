@@ -3,31 +3,71 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"sort"
+	"strings"
 
-	"github.com/spf13/cobra"
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/config"
 	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
 )
 
 var initBackend string
+var initFeature string
+var initRepos string
+var initReinit bool
 
 var initCmd = &cobra.Command{
-	Use:   "init <feature-name>",
+	Use:   "init [feature-name]",
 	Short: "Initialize a new feature workspace",
 	Long: `Initialize a new EAS feature workspace in the current directory.
 
+The feature name can be given as an argument or with --feature.
+
 Creates:
   .flo/config.yaml    - Feature configuration
   .flo/SPEC.md        - Feature specification template
-  .flo/tasks/         - Task manifest directory`,
-	Args: cobra.ExactArgs(1),
+  .flo/tasks/         - Task manifest directory
+
+--repos adds repos to the config as a comma-separated list of names or
+name=path pairs, e.g. --repos android=../android,ios.
+
+An existing workspace is left alone unless --reinit is given, which rewrites
+config.yaml and keeps the existing SPEC.md and tasks.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		featureName := args[0]
+		featureName := initFeature
+		if len(args) > 0 {
+			if featureName != "" && featureName != args[0] {
+				return fmt.Errorf("feature name given both as argument and --feature")
+			}
+			featureName = args[0]
+		}
+		if featureName == "" {
+			return fmt.Errorf("a feature name is required (argument or --feature)")
+		}
+
+		if !agent.IsRegistered(initBackend) {
+			backends := agent.ListBackends()
+			return fmt.Errorf("unknown backend '%s' (available: %s)", initBackend, strings.Join(backends, ", "))
+		}
+
+		repos, err := parseRepos(initRepos)
+		if err != nil {
+			return err
+		}
+
 		cwd, err := os.Getwd()
 		if err != nil {
 			return fmt.Errorf("failed to get current directory: %w", err)
 		}
 
-		ws, err := workspace.Init(cwd, featureName, initBackend)
+		ws, err := workspace.InitWithOptions(cwd, workspace.InitOptions{
+			Feature: featureName,
+			Backend: initBackend,
+			Repos:   repos,
+			Force:   initReinit,
+		})
 		if err != nil {
 			return err
 		}
@@ -36,6 +76,14 @@ Creates:
 		fmt.Printf("  Backend: %s\n", ws.Backend)
 		fmt.Printf("  Config:  .flo/config.yaml\n")
 		fmt.Printf("  Spec:    .flo/SPEC.md\n")
+		if len(repos) > 0 {
+			names := make([]string, 0, len(repos))
+			for name := range repos {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			fmt.Printf("  Repos:   %s\n", strings.Join(names, ", "))
+		}
 		fmt.Println()
 		fmt.Println("Next steps:")
 		fmt.Println("  1. Edit .flo/SPEC.md with your feature specification")
@@ -46,6 +94,34 @@ Creates:
 	},
 }
 
+// parseRepos parses a comma-separated list of repo names or name=path pairs.
+func parseRepos(s string) (map[string]config.Repo, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	repos := make(map[string]config.Repo)
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, path, _ := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid repo %q: name is required", entry)
+		}
+		if _, dup := repos[name]; dup {
+			return nil, fmt.Errorf("repo %q given more than once", name)
+		}
+		repos[name] = config.Repo{Path: strings.TrimSpace(path)}
+	}
+	return repos, nil
+}
+
 func init() {
-	initCmd.Flags().StringVar(&initBackend, "backend", "claude", "Agent backend (claude or copilot)")
+	initCmd.Flags().StringVar(&initBackend, "backend", "claude", "Agent backend (claude, copilot, codex, ...)")
+	initCmd.Flags().StringVar(&initFeature, "feature", "", "Feature name (alternative to the argument)")
+	initCmd.Flags().StringVar(&initRepos, "repos", "", "Comma-separated repos as name or name=path")
+	initCmd.Flags().BoolVar(&initReinit, "reinit", false, "Rewrite the config of an existing workspace")
 }
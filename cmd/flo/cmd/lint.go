@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Lint warning kinds.
+const (
+	lintDuplicateTitle = "duplicate_title"
+)
+
+// lintWarning is a workspace problem worth a look that does not block work.
+type lintWarning struct {
+	Kind    string   `json:"kind"`
+	Message string   `json:"message"`
+	Tasks   []string `json:"tasks,omitempty"`
+}
+
+// lintResult is the output of flo lint.
+type lintResult struct {
+	Warnings []lintWarning `json:"warnings"`
+}
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Report likely mistakes in the task list",
+	Long: `Check the workspace for likely mistakes and print them as warnings.
+
+Currently reported:
+  - tasks sharing a title, which often means tasks were generated twice
+
+Warnings never fail the command.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		result := lintResult{Warnings: []lintWarning{}}
+
+		dups := ws.Tasks.DuplicateTitles()
+		titles := make([]string, 0, len(dups))
+		for title := range dups {
+			titles = append(titles, title)
+		}
+		sort.Strings(titles)
+		for _, title := range titles {
+			result.Warnings = append(result.Warnings, lintWarning{
+				Kind:    lintDuplicateTitle,
+				Message: fmt.Sprintf("duplicate title %q", title),
+				Tasks:   dups[title],
+			})
+		}
+
+		return out.Print(result, func(w io.Writer) error {
+			if len(result.Warnings) == 0 {
+				fmt.Fprintln(w, "✓ No problems found")
+				return nil
+			}
+			for _, warning := range result.Warnings {
+				fmt.Fprintf(w, "⚠️  %s: %s\n", warning.Message, strings.Join(warning.Tasks, ", "))
+			}
+			fmt.Fprintf(w, "\n%d warning(s)\n", len(result.Warnings))
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+}
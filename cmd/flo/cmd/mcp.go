@@ -1,11 +1,16 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"os"
+	"path/filepath"
 
 	"github.com/spf13/cobra"
 	"github.com/richgo/flo/pkg/mcp"
+	"github.com/richgo/flo/pkg/task"
 	"github.com/richgo/flo/pkg/tools"
+	"github.com/richgo/flo/pkg/workspace"
 )
 
 var mcpCmd = &cobra.Command{
@@ -38,28 +43,88 @@ Configure in Claude Code with:
 			return err
 		}
 
-		// Create tools with workspace context
-		toolReg := tools.NewEASTools(ws.Tasks, nil)
+		toolReg := newToolRegistry(ws)
+		lockMutatingTools(ws, toolReg)
 
-		// Add eas_spec_read tool
-		toolReg.Register(tools.New(
-			"eas_spec_read",
-			"Read the feature specification (SPEC.md)",
-			map[string]any{
-				"type":       "object",
-				"properties": map[string]any{},
-			},
-			func(args tools.Args) (string, error) {
-				return ws.ReadSpec()
-			},
-		))
+		// Cancel running tools, such as eas_run_tests, on SIGINT or SIGTERM
+		ctx, stop := signalContext()
+		defer stop()
 
 		// Start MCP server on stdio
 		server := mcp.NewServer(toolReg)
-		return server.Serve(os.Stdin, os.Stdout)
+		return server.ServeContext(ctx, os.Stdin, os.Stdout)
 	},
 }
 
+// newToolRegistry creates the EAS tools bound to the workspace, including
+// the workspace-only eas_spec_read tool.
+func newToolRegistry(ws *workspace.Workspace) *tools.Registry {
+	testRunner := tools.NewCommandTestRunner(ws.Tasks, ws.TestCommand)
+	testRunner.SetCompletionChecks(ws.CompletionChecks)
+	quotaGuard := &tools.QuotaGuard{
+		Tracker: initQuotaTracker(filepath.Join(ws.Root, ".flo", "quota.json"), ws),
+		Backend: func(t *task.Task) string {
+			backend, _, _ := ws.Config.ResolveModel(t)
+			return backend
+		},
+	}
+	cfg := tools.EASToolsConfig{SpecPath: ws.SpecPath()}
+	if ws.Config.MCP != nil {
+		cfg.ConcurrentTaskCalls = ws.Config.MCP.ConcurrentTaskCalls
+	}
+	toolReg := tools.NewEASToolsWithConfig(ws.Tasks, testRunner, quotaGuard, cfg)
+
+	// Add eas_spec_read tool
+	toolReg.Register(tools.New(
+		"eas_spec_read",
+		"Read the feature specification (SPEC.md)",
+		map[string]any{
+			"type":       "object",
+			"properties": map[string]any{},
+		},
+		func(args tools.Args) (string, error) {
+			return ws.ReadSpec()
+		},
+	))
+
+	return toolReg
+}
+
+// mutatingTools are the EAS tools that change tasks.
+var mutatingTools = []string{"eas_task_claim", "eas_task_complete", "eas_task_note"}
+
+// lockMutatingTools makes each tool that changes tasks run under the
+// workspace lock, on tasks reloaded from disk, and save its change, so the
+// MCP server does not overwrite or miss changes made by other flo commands.
+func lockMutatingTools(ws *workspace.Workspace, toolReg *tools.Registry) {
+	for _, name := range mutatingTools {
+		tool, err := toolReg.Get(name)
+		if err != nil {
+			continue
+		}
+		handler := tool.HandlerContext
+		if handler == nil {
+			plain := tool.Handler
+			handler = func(ctx context.Context, args tools.Args) (string, error) {
+				return plain(args)
+			}
+		}
+		tool.HandlerContext = func(ctx context.Context, args tools.Args) (string, error) {
+			var result string
+			err := ws.WithLock(func() error {
+				var err error
+				result, err = handler(ctx, args)
+				return err
+			})
+			var locked *workspace.LockedError
+			if errors.As(err, &locked) {
+				return "", tools.ErrConflict("%v", err)
+			}
+			return result, err
+		}
+	}
+}
+
 func init() {
 	mcpCmd.AddCommand(mcpServeCmd)
 	rootCmd.AddCommand(mcpCmd)
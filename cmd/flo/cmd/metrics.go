@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/richgo/flo/pkg/metrics"
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var metricsAddr string
+
+var metricsCmd = &cobra.Command{
+	Use:   "metrics",
+	Short: "Metrics commands",
+	Long:  "Commands for exposing workspace metrics.",
+}
+
+var metricsServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve Prometheus metrics over HTTP",
+	Long: `Serve Prometheus metrics on /metrics.
+
+Exposes per-backend request and token counters and an exhaustion gauge from
+the quota tracker, and task counts by status. Values are read from the
+workspace on every scrape, so they track changes made by other flo processes.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", metrics.Handler(&workspaceSource{root: ws.Root, profile: activeProfile()}))
+
+		fmt.Fprintf(out.Progress(), "📈 Serving metrics on http://%s/metrics\n", metricsAddr)
+		return http.ListenAndServe(metricsAddr, mux)
+	},
+}
+
+// workspaceSource reads metrics from the workspace on disk.
+type workspaceSource struct {
+	root    string
+	profile string
+}
+
+func (s *workspaceSource) Usage() (map[string]*quota.Usage, error) {
+	tracker := quota.New(filepath.Join(s.root, ".flo", "quota.json"))
+	if err := tracker.Load(); err != nil {
+		return nil, err
+	}
+	return tracker.ListUsage(), nil
+}
+
+func (s *workspaceSource) Tasks() ([]*task.Task, error) {
+	ws, err := workspace.LoadProfile(s.root, s.profile)
+	if err != nil {
+		return nil, err
+	}
+	return ws.Tasks.List(), nil
+}
+
+func init() {
+	metricsServeCmd.Flags().StringVar(&metricsAddr, "addr", ":9090", "Address to listen on")
+	metricsCmd.AddCommand(metricsServeCmd)
+	rootCmd.AddCommand(metricsCmd)
+}
@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
-	"text/tabwriter"
+	"sort"
 	"time"
 
+	"github.com/richgo/flo/pkg/agent"
 	"github.com/richgo/flo/pkg/quota"
 	"github.com/spf13/cobra"
 )
@@ -19,113 +21,108 @@ tokens consumed, and remaining quota.`,
 	RunE: runQuota,
 }
 
+var quotaExportCSV string
+
+var quotaExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export backend usage as CSV",
+	Long: `Write one CSV row per backend, and per backend/model, with requests,
+tokens, estimated cost, window start and whether the quota is exhausted.
+Costs come from the workspace pricing config and are left empty for
+backends without a price. Use --csv - to write to stdout.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if quotaExportCSV == "" {
+			return fmt.Errorf("--csv is required")
+		}
+
+		tracker, err := loadQuotaTracker()
+		if err != nil {
+			return err
+		}
+		if ws, err := loadWorkspace(); err == nil && len(ws.Config.Pricing) > 0 {
+			tracker.SetCostFunc(func(key string, tokens int) (float64, bool) {
+				cost, err := agent.UsageCost(key, tokens, ws.Config.Pricing)
+				return cost, err == nil
+			})
+		}
+
+		if quotaExportCSV == "-" {
+			return tracker.ExportCSV(os.Stdout)
+		}
+		f, err := os.Create(quotaExportCSV)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", quotaExportCSV, err)
+		}
+		if err := tracker.ExportCSV(f); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("failed to write %s: %w", quotaExportCSV, err)
+		}
+		fmt.Fprintf(os.Stderr, "%s Usage exported to %s\n", out.Symbols().OK, quotaExportCSV)
+		return nil
+	},
+}
+
 func init() {
+	quotaExportCmd.Flags().StringVar(&quotaExportCSV, "csv", "", "CSV file to write (- for stdout)")
+	quotaCmd.AddCommand(quotaExportCmd)
 	rootCmd.AddCommand(quotaCmd)
 }
 
-func runQuota(cmd *cobra.Command, args []string) error {
-	// Get quota file path from .flo directory
+// loadQuotaTracker loads the usage recorded in ~/.flo/quota.json.
+func loadQuotaTracker() (*quota.Tracker, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
-		return fmt.Errorf("failed to get home directory: %w", err)
+		return nil, fmt.Errorf("failed to get home directory: %w", err)
 	}
-	
-	quotaPath := filepath.Join(homeDir, ".flo", "quota.json")
-	tracker := quota.New(quotaPath)
-	
-	// Load existing quota data
+
+	tracker := quota.New(filepath.Join(homeDir, ".flo", "quota.json"))
 	if err := tracker.Load(); err != nil {
-		return fmt.Errorf("failed to load quota data: %w", err)
+		return nil, fmt.Errorf("failed to load quota data: %w", err)
+	}
+	return tracker, nil
+}
+
+func runQuota(cmd *cobra.Command, args []string) error {
+	tracker, err := loadQuotaTracker()
+	if err != nil {
+		return err
 	}
-	
+	// Show which pool each backend belongs to, when run in a workspace
+	if ws, err := loadWorkspace(); err == nil {
+		ws.Config.ApplyQuota(tracker)
+	}
+
 	// Get all usage data
 	allUsage := tracker.ListUsage()
-	
-	if len(allUsage) == 0 {
-		fmt.Println("No usage data recorded yet.")
-		return nil
-	}
-	
-	// Create table writer
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
-	defer w.Flush()
-	
-	fmt.Fprintln(w, "BACKEND\tREQUESTS\tTOKENS\tSTATUS\tLAST REQUEST\tWINDOW")
-	fmt.Fprintln(w, "-------\t--------\t------\t------\t------------\t------")
-	
-	for backend, usage := range allUsage {
-		status := "✓ OK"
-		if usage.IsExhausted {
-			status = fmt.Sprintf("✗ EXHAUSTED (retry after %s)", 
-				formatDuration(time.Until(usage.RetryAfter)))
-		}
-		
-		lastReq := "never"
-		if !usage.LastRequest.IsZero() {
-			lastReq = formatRelativeTime(usage.LastRequest)
-		}
-		
-		windowAge := formatDuration(time.Since(usage.WindowStart))
-		
-		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%s\t%s\n",
-			backend,
-			usage.Requests,
-			usage.Tokens,
-			status,
-			lastReq,
-			windowAge,
-		)
+
+	backends := make([]string, 0, len(allUsage))
+	for backend := range allUsage {
+		backends = append(backends, backend)
 	}
-	
-	fmt.Fprintln(w)
-	fmt.Fprintln(w, "Use 'flo config' to set backend limits and quotas.")
-	
-	return nil
-}
+	sort.Strings(backends)
 
-func formatRelativeTime(t time.Time) string {
-	dur := time.Since(t)
-	
-	if dur < time.Minute {
-		return "just now"
+	usageList := make([]*quota.Usage, 0, len(backends))
+	for _, backend := range backends {
+		usageList = append(usageList, allUsage[backend])
 	}
-	if dur < time.Hour {
-		mins := int(dur.Minutes())
-		if mins == 1 {
-			return "1 minute ago"
+
+	return out.Print(usageList, func(ow io.Writer) error {
+		if len(usageList) == 0 {
+			fmt.Fprintln(ow, "No usage data recorded yet.")
+			return nil
 		}
-		return fmt.Sprintf("%d minutes ago", mins)
-	}
-	if dur < 24*time.Hour {
-		hours := int(dur.Hours())
-		if hours == 1 {
-			return "1 hour ago"
+
+		sym := out.Symbols()
+		if err := quota.WriteTable(ow, usageList, sym.OK+" OK", sym.Exhausted+" EXHAUSTED", time.Now()); err != nil {
+			return err
 		}
-		return fmt.Sprintf("%d hours ago", hours)
-	}
-	
-	days := int(dur.Hours() / 24)
-	if days == 1 {
-		return "1 day ago"
-	}
-	return fmt.Sprintf("%d days ago", days)
-}
+		fmt.Fprintln(ow)
+		fmt.Fprintln(ow, "Use 'flo config' to set backend limits and quotas.")
 
-func formatDuration(d time.Duration) string {
-	if d < 0 {
-		return "expired"
-	}
-	
-	if d < time.Minute {
-		return fmt.Sprintf("%.0fs", d.Seconds())
-	}
-	if d < time.Hour {
-		return fmt.Sprintf("%.0fm", d.Minutes())
-	}
-	if d < 24*time.Hour {
-		return fmt.Sprintf("%.1fh", d.Hours())
-	}
-	
-	days := d.Hours() / 24
-	return fmt.Sprintf("%.1fd", days)
+		return nil
+	})
 }
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Compare estimated and actual task durations",
+	Long: `Print estimated vs actual minutes for each completed task.
+
+The accuracy ratio is total actual time over total estimated time for tasks
+with an estimate: above 1 means work took longer than planned.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		report := ws.Report()
+
+		return out.Print(report, func(w io.Writer) error {
+			if len(report.Tasks) == 0 {
+				fmt.Fprintln(w, "No completed tasks with recorded durations.")
+				return nil
+			}
+
+			tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "ID\tTITLE\tBACKEND\tESTIMATE\tACTUAL\tRATIO")
+			for _, e := range report.Tasks {
+				estimate, ratio := "-", "-"
+				if e.EstimatedMinutes > 0 {
+					estimate = fmt.Sprintf("%dm", e.EstimatedMinutes)
+					ratio = fmt.Sprintf("%.2f", e.Ratio)
+				}
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.0fm\t%s\n", e.ID, e.Title, usedBy(e.UsedBackend, e.UsedModel), estimate, e.ActualMinutes, ratio)
+			}
+			tw.Flush()
+
+			fmt.Fprintln(w)
+			if report.EstimatedMinutes == 0 {
+				fmt.Fprintln(w, "Accuracy: no estimated tasks")
+			} else {
+				fmt.Fprintf(w, "Accuracy: %.2f (%.0fm actual / %dm estimated)\n",
+					report.Accuracy, report.ActualMinutes, report.EstimatedMinutes)
+			}
+			return nil
+		})
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var resumeReset bool
+
+var resumeCmd = &cobra.Command{
+	Use:   "resume",
+	Short: "Recover tasks interrupted while in progress",
+	Long: `Find tasks stuck in in_progress (e.g. after a crash) and recover them.
+
+By default each task is re-run with the same backend and failover logic as
+'flo work'. When the backend recorded a conversation for the task and
+supports resuming it, the conversation is continued instead of started over. With --reset the tasks are moved back to pending instead, so
+they can be claimed again by 'flo work' or 'flo run'.
+
+Tasks whose dependencies are no longer complete are skipped.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := lockWorkspace()
+		if err != nil {
+			return err
+		}
+		defer ws.Unlock()
+
+		if len(ws.InProgressTasks()) == 0 {
+			fmt.Fprintln(out.Progress(), "No in-progress tasks to resume")
+			return nil
+		}
+
+		quotaPath := filepath.Join(ws.Root, ".flo", "quota.json")
+		quotaTracker := initQuotaTracker(quotaPath, ws)
+		sink, closeSink, err := eventSink()
+		if err != nil {
+			return err
+		}
+		defer closeSink()
+
+		ctx, stop := signalContext()
+		defer stop()
+		opts := workspace.ResumeOptions{Reset: resumeReset}
+		summary, err := ws.ResumeInProgress(ctx, opts, func(ctx context.Context, t *task.Task) error {
+			backendName, model, fallbacks, err := prepareTask(ws, t, "")
+			if err != nil {
+				return err
+			}
+
+			run, err := runWithFailover(ctx, ws, t, backendName, model, fallbacks, quotaTracker, sink, true)
+			if ctx.Err() == nil {
+				notifyTask(ws, t, run, err)
+			}
+			if err != nil {
+				fmt.Fprintf(out.Progress(), "\n❌ Task %s failed: %v\n\n", t.ID, err)
+				return err
+			}
+			if !run.Result.Success {
+				fmt.Fprintf(out.Progress(), "\n❌ Task %s failed: %s\n\n", t.ID, run.Result.Error)
+				return fmt.Errorf("%s", run.Result.Error)
+			}
+
+			fmt.Fprintf(out.Progress(), "\n✅ Task %s completed successfully\n\n", t.ID)
+			return nil
+		})
+
+		fmt.Fprintln(out.Progress(), "Resume summary:")
+		if resumeReset {
+			fmt.Fprintf(out.Progress(), "  ↩️  Reset:     %d\n", len(summary.Reset))
+			for _, id := range summary.Reset {
+				fmt.Fprintf(out.Progress(), "     %s\n", id)
+			}
+		} else {
+			fmt.Fprintf(out.Progress(), "  ✅ Completed: %d\n", len(summary.Completed))
+			for _, id := range summary.Completed {
+				fmt.Fprintf(out.Progress(), "     %s\n", id)
+			}
+			fmt.Fprintf(out.Progress(), "  ❌ Failed:    %d\n", len(summary.Failed))
+			for _, id := range summary.Failed {
+				fmt.Fprintf(out.Progress(), "     %s: %v\n", id, summary.Errors[id])
+			}
+		}
+		if len(summary.Skipped) > 0 {
+			fmt.Fprintf(out.Progress(), "  ⏭️  Skipped:   %d\n", len(summary.Skipped))
+			for _, id := range summary.Skipped {
+				fmt.Fprintf(out.Progress(), "     %s: %v\n", id, summary.Errors[id])
+			}
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted: in-progress task reverted to pending")
+		}
+		if err != nil {
+			return err
+		}
+		if len(summary.Failed) > 0 {
+			return fmt.Errorf("%d task(s) failed", len(summary.Failed))
+		}
+		return nil
+	},
+}
+
+func init() {
+	resumeCmd.Flags().BoolVar(&resumeReset, "reset", false, "Move interrupted tasks back to pending instead of re-running them")
+	rootCmd.AddCommand(resumeCmd)
+}
@@ -1,9 +1,32 @@
 package cmd
 
 import (
+	"os"
+
+	"github.com/richgo/flo/pkg/logging"
+	"github.com/richgo/flo/pkg/output"
 	"github.com/spf13/cobra"
 )
 
+// profileFlag selects a named config profile (falls back to FLO_PROFILE).
+var profileFlag string
+
+// outputFlag selects text or json output; out is the resulting printer.
+var outputFlag string
+var out = output.New(output.FormatText, os.Stdout)
+
+// asciiFlag and noColorFlag switch text output to plain ASCII markers, as
+// does setting NO_COLOR.
+var asciiFlag bool
+var noColorFlag bool
+
+// logLevelFlag and logFormatFlag configure diagnostic logging to stderr.
+var logLevelFlag string
+var logFormatFlag string
+
+// forceFlag breaks an existing workspace lock before acquiring it.
+var forceFlag bool
+
 var rootCmd = &cobra.Command{
 	Use:   "flo",
 	Short: "Flo - Engineer Flow for AI-powered development",
@@ -12,6 +35,25 @@ test-driven development.
 
 Create tasks, define specs, and let AI agents implement them while
 you stay in the zone.`,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, err := output.ParseFormat(outputFlag)
+		if err != nil {
+			return err
+		}
+		out = output.New(format, os.Stdout)
+		out.SetASCII(output.WantASCII(asciiFlag || noColorFlag, os.Getenv))
+
+		level, err := logging.ParseLevel(logLevelFlag)
+		if err != nil {
+			return err
+		}
+		logFormat, err := logging.ParseFormat(logFormatFlag)
+		if err != nil {
+			return err
+		}
+		logging.Setup(os.Stderr, level, logFormat)
+		return nil
+	},
 }
 
 // Execute runs the root command.
@@ -20,7 +62,23 @@ func Execute() error {
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Config profile to apply (default $FLO_PROFILE)")
+	rootCmd.PersistentFlags().StringVarP(&outputFlag, "output", "o", "text", "Output format (text or json)")
+	rootCmd.PersistentFlags().BoolVar(&asciiFlag, "ascii", false, "Use plain ASCII status markers instead of emoji (also set by NO_COLOR)")
+	rootCmd.PersistentFlags().BoolVar(&noColorFlag, "no-color", false, "Disable emoji and color in text output; same as --ascii")
+	rootCmd.PersistentFlags().StringVar(&logLevelFlag, "log-level", "warn", "Diagnostic log level (debug, info, warn, or error)")
+	rootCmd.PersistentFlags().StringVar(&logFormatFlag, "log-format", "text", "Diagnostic log format (text or json)")
+	rootCmd.PersistentFlags().BoolVar(&forceFlag, "force", false, "Break an existing workspace lock held by another process")
+
 	rootCmd.AddCommand(initCmd)
 	rootCmd.AddCommand(taskCmd)
 	rootCmd.AddCommand(statusCmd)
 }
+
+// activeProfile returns the profile selected by flag or environment.
+func activeProfile() string {
+	if profileFlag != "" {
+		return profileFlag
+	}
+	return os.Getenv("FLO_PROFILE")
+}
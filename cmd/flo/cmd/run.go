@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
+	"github.com/spf13/cobra"
+)
+
+var runMax int
+var runKeepGoing bool
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Work through all ready tasks",
+	Long: `Run agents on ready tasks one at a time until the queue is drained.
+
+Each iteration picks the highest-priority ready task (lowest priority value),
+runs it with the same backend and failover logic as 'flo work', and marks it
+complete or failed. Tasks unblocked by a completion are picked up next.
+
+Stops at the first failure unless --keep-going is set.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := lockWorkspace()
+		if err != nil {
+			return err
+		}
+		defer ws.Unlock()
+
+		quotaPath := filepath.Join(ws.Root, ".flo", "quota.json")
+		quotaTracker := initQuotaTracker(quotaPath, ws)
+		sink, closeSink, err := eventSink()
+		if err != nil {
+			return err
+		}
+		defer closeSink()
+
+		ctx, stop := signalContext()
+		defer stop()
+		opts := workspace.RunOptions{
+			Max:       runMax,
+			KeepGoing: runKeepGoing,
+			Preflight: func(t *task.Task) error {
+				backendName, _, fallbacks, err := resolveTask(ws, t, "")
+				if err != nil || !needsMCPConfig(backendName, fallbacks) {
+					return err
+				}
+				return ws.WriteMCPConfig()
+			},
+		}
+		summary, err := ws.RunReady(ctx, opts, func(ctx context.Context, t *task.Task) error {
+			backendName, model, fallbacks, err := prepareTask(ws, t, "")
+			if err != nil {
+				return err
+			}
+
+			run, err := runWithFailover(ctx, ws, t, backendName, model, fallbacks, quotaTracker, sink, false)
+			if ctx.Err() == nil {
+				notifyTask(ws, t, run, err)
+			}
+			if err != nil {
+				fmt.Fprintf(out.Progress(), "\n❌ Task %s failed: %v\n\n", t.ID, err)
+				return err
+			}
+			if !run.Result.Success {
+				fmt.Fprintf(out.Progress(), "\n❌ Task %s failed: %s\n\n", t.ID, run.Result.Error)
+				return fmt.Errorf("%s", run.Result.Error)
+			}
+
+			fmt.Fprintf(out.Progress(), "\n✅ Task %s completed successfully\n\n", t.ID)
+			return nil
+		})
+
+		fmt.Fprintln(out.Progress(), "Run summary:")
+		fmt.Fprintf(out.Progress(), "  ✅ Completed: %d\n", len(summary.Completed))
+		for _, id := range summary.Completed {
+			fmt.Fprintf(out.Progress(), "     %s\n", id)
+		}
+		fmt.Fprintf(out.Progress(), "  ❌ Failed:    %d\n", len(summary.Failed))
+		for _, id := range summary.Failed {
+			fmt.Fprintf(out.Progress(), "     %s: %v\n", id, summary.Errors[id])
+		}
+
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted: in-progress task reverted to pending")
+		}
+		if err != nil {
+			return err
+		}
+		if len(summary.Failed) > 0 {
+			return fmt.Errorf("%d task(s) failed", len(summary.Failed))
+		}
+		return nil
+	},
+}
+
+func init() {
+	runCmd.Flags().IntVar(&runMax, "max", 0, "Maximum number of tasks to run (0 = no limit)")
+	runCmd.Flags().BoolVar(&runKeepGoing, "keep-going", false, "Continue with other ready tasks after a failure")
+	addQuotaWaitFlags(runCmd)
+	addTimeoutFlag(runCmd)
+	rootCmd.AddCommand(runCmd)
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
@@ -28,9 +29,53 @@ If no path is provided, validates .flo/SPEC.md in the current directory.`,
 
 func init() {
 	specCmd.AddCommand(specValidateCmd)
+	specCmd.AddCommand(specDiffCmd)
 	rootCmd.AddCommand(specCmd)
 }
 
+var specDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Show tasks created against an older spec",
+	Long: `Compare the current .flo/SPEC.md against the spec each task was created from.
+
+Tasks are listed as stale when SPEC.md has changed since they were created,
+and as untracked when they predate spec hashing.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		diff, err := ws.SpecDiff()
+		if err != nil {
+			return err
+		}
+
+		return out.Print(diff, func(w io.Writer) error {
+			fmt.Fprintf(w, "Spec hash: %s\n\n", diff.CurrentHash[:12])
+
+			if !diff.Changed {
+				fmt.Fprintln(w, "✓ All tracked tasks were created against the current spec")
+			} else {
+				fmt.Fprintf(w, "⚠️  Spec changed since %d task(s) were created:\n", len(diff.Stale))
+				for _, id := range diff.Stale {
+					t, _ := ws.GetTask(id)
+					fmt.Fprintf(w, "  %s  %s\n", id, t.Title)
+				}
+			}
+
+			if len(diff.Untracked) > 0 {
+				fmt.Fprintf(w, "\n%d task(s) have no recorded spec hash:\n", len(diff.Untracked))
+				for _, id := range diff.Untracked {
+					fmt.Fprintf(w, "  %s\n", id)
+				}
+			}
+			return nil
+		})
+	},
+}
+
 func runSpecValidate(cmd *cobra.Command, args []string) error {
 	// Determine spec file path
 	specPath := ".flo/SPEC.md"
@@ -1,42 +1,208 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/richgo/flo/pkg/notify"
+	"github.com/richgo/flo/pkg/output"
+	"github.com/richgo/flo/pkg/task"
+	"github.com/richgo/flo/pkg/workspace"
 	"github.com/spf13/cobra"
 )
 
+// recentCompletedLimit caps the recently completed tasks in a Slack summary.
+const recentCompletedLimit = 5
+
+var statusFormat string
+var statusPost bool
+var statusGroup string
+
 var statusCmd = &cobra.Command{
 	Use:   "status",
 	Short: "Show workspace status",
-	Long:  "Display an overview of the current feature workspace.",
+	Long: `Display an overview of the current feature workspace.
+
+With --format slack the summary is rendered as Slack Block Kit JSON: counts
+by status, in-progress tasks with their backend, and recently completed
+tasks. Add --post to send it to notifications.slack.url instead of printing.
+
+With --group only the tasks in that group (epic) are shown, along with the
+group's progress. Dependencies on tasks outside the group still block.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		ws, err := loadWorkspace()
 		if err != nil {
 			return err
 		}
 
-		status := ws.Status()
+		switch statusFormat {
+		case "text":
+			if statusPost {
+				return fmt.Errorf("--post requires --format slack")
+			}
+		case "slack":
+			if statusGroup != "" {
+				return fmt.Errorf("--group is not supported with --format slack")
+			}
+			return postSlackStatus(ws)
+		default:
+			return fmt.Errorf("unknown format '%s' (must be text or slack)", statusFormat)
+		}
 
-		fmt.Printf("Feature: %s\n", status.Feature)
-		fmt.Printf("Backend: %s\n", status.Backend)
-		fmt.Println()
-		fmt.Printf("Tasks: %d total\n", status.TotalTasks)
-		fmt.Printf("  📋 Pending:     %d\n", status.PendingTasks)
-		fmt.Printf("  🔄 In Progress: %d\n", status.InProgressTasks)
-		fmt.Printf("  ✅ Complete:    %d\n", status.CompleteTasks)
-		fmt.Printf("  ❌ Failed:      %d\n", status.FailedTasks)
-		fmt.Println()
-		fmt.Printf("Ready to start: %d\n", status.ReadyTasks)
-
-		if status.ReadyTasks > 0 {
-			fmt.Println()
-			fmt.Println("Ready tasks:")
-			for _, t := range ws.GetReadyTasks() {
-				fmt.Printf("  %s: %s\n", t.ID, t.Title)
+		status := ws.Status()
+		if statusGroup != "" {
+			status = ws.GroupStatus(statusGroup)
+			if status.TotalTasks == 0 {
+				return fmt.Errorf("no tasks in group '%s'", statusGroup)
 			}
 		}
 
-		return nil
+		sym := out.Symbols()
+		return out.Print(status, func(w io.Writer) error {
+			fmt.Fprintf(w, "Feature: %s\n", status.Feature)
+			fmt.Fprintf(w, "Backend: %s\n", status.Backend)
+			if status.Group != "" {
+				fmt.Fprintf(w, "Group:   %s (%d/%d complete)\n", status.Group, status.CompleteTasks, status.TotalTasks)
+			}
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "Tasks: %d total\n", status.TotalTasks)
+			fmt.Fprintf(w, "  %s Pending:     %d\n", sym.Pending, status.PendingTasks)
+			fmt.Fprintf(w, "  %s In Progress: %d\n", sym.InProgress, status.InProgressTasks)
+			fmt.Fprintf(w, "  %s Complete:    %d\n", sym.Complete, status.CompleteTasks)
+			fmt.Fprintf(w, "  %s Failed:      %d\n", sym.Failed, status.FailedTasks)
+			fmt.Fprintln(w)
+			fmt.Fprintf(w, "Ready to start: %d\n", status.ReadyTasks)
+			fmt.Fprintf(w, "Blocked:        %d\n", status.BlockedTasks)
+
+			if len(status.Pending) > 0 {
+				fmt.Fprintln(w)
+				tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(tw, "  ID\tTITLE\tBLOCKED BY\tRELATED")
+				for _, p := range status.Pending {
+					blockedBy := "READY"
+					if !p.Ready {
+						blockedBy = strings.Join(p.BlockedBy, ", ")
+					}
+					related := "-"
+					if len(p.Related) > 0 {
+						related = strings.Join(p.Related, ", ")
+					}
+					fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", p.ID, p.Title, blockedBy, related)
+				}
+				tw.Flush()
+			}
+
+			if len(status.Completed) > 0 {
+				fmt.Fprintln(w)
+				tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(tw, "  ID\tTITLE\tCOMPLETED BY")
+				for _, c := range status.Completed {
+					fmt.Fprintf(tw, "  %s\t%s\t%s\n", c.ID, c.Title, usedBy(c.UsedBackend, c.UsedModel))
+				}
+				tw.Flush()
+			}
+
+			if len(status.Failed) > 0 {
+				fmt.Fprintln(w)
+				tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+				fmt.Fprintln(tw, "  ID\tTITLE\tCATEGORY\tREASON")
+				for _, f := range status.Failed {
+					category := string(f.Category)
+					if category == "" {
+						category = "-"
+					}
+					fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", f.ID, f.Title, category, failureSummary(f.Reason))
+				}
+				tw.Flush()
+			}
+
+			return nil
+		})
 	},
 }
+
+// postSlackStatus renders the workspace summary as Slack blocks and either
+// posts it to the configured Slack webhook or prints it.
+func postSlackStatus(ws *workspace.Workspace) error {
+	status := ws.Status()
+	summary := notify.StatusSummary{
+		Feature:    status.Feature,
+		Pending:    status.PendingTasks,
+		InProgress: status.InProgressTasks,
+		Complete:   status.CompleteTasks,
+		Failed:     status.FailedTasks,
+	}
+	for _, t := range ws.InProgressTasks() {
+		backend, _, _ := ws.Config.ResolveModel(t)
+		summary.Active = append(summary.Active, notify.SummaryTask{ID: t.ID, Title: t.Title, Assignee: backend})
+	}
+
+	completed := ws.Tasks.ListByStatus(task.StatusComplete)
+	sort.Slice(completed, func(i, j int) bool {
+		return finishedAt(completed[i]).After(finishedAt(completed[j]))
+	})
+	if len(completed) > recentCompletedLimit {
+		completed = completed[:recentCompletedLimit]
+	}
+	for _, t := range completed {
+		summary.Recent = append(summary.Recent, notify.SummaryTask{ID: t.ID, Title: t.Title, Assignee: t.UsedBackend})
+	}
+
+	msg := notify.SlackStatus(summary)
+	if !statusPost {
+		return output.New(output.FormatJSON, os.Stdout).Print(msg, nil)
+	}
+
+	n := ws.Config.Notifications
+	if n == nil || n.Slack == nil {
+		return fmt.Errorf("no Slack webhook configured (set notifications.slack.url)")
+	}
+	if err := notify.NewWebhook(n.Slack.Webhook()).Post(context.Background(), msg); err != nil {
+		return fmt.Errorf("failed to post to Slack: %w", err)
+	}
+	fmt.Fprintf(out.Progress(), "%s Posted status to Slack\n", out.Symbols().Complete)
+	return nil
+}
+
+// usedBy formats the backend and model that completed a task, or "-" for
+// tasks completed before they were recorded.
+func usedBy(backend, model string) string {
+	switch {
+	case backend == "":
+		return "-"
+	case model == "":
+		return backend
+	}
+	return backend + "/" + model
+}
+
+// failureSummary shortens a failure reason to its first line for the status
+// table, or "-" when none was recorded.
+func failureSummary(reason string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(reason), "\n")
+	if line == "" {
+		return "-"
+	}
+	return line
+}
+
+// finishedAt returns when a completed task finished, falling back to its
+// last update for tasks completed before completion times were recorded.
+func finishedAt(t *task.Task) time.Time {
+	if t.CompletedAt != nil {
+		return *t.CompletedAt
+	}
+	return t.UpdatedAt
+}
+
+func init() {
+	statusCmd.Flags().StringVar(&statusFormat, "format", "text", "Summary format (text or slack)")
+	statusCmd.Flags().StringVar(&statusGroup, "group", "", "Only show tasks in this group")
+	statusCmd.Flags().BoolVar(&statusPost, "post", false, "Post the Slack summary to notifications.slack.url instead of printing")
+}
@@ -1,12 +1,22 @@
 package cmd
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"sort"
 	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/output"
+	"github.com/richgo/flo/pkg/task"
 	"github.com/richgo/flo/pkg/workspace"
 )
 
@@ -33,82 +43,162 @@ var taskListCmd = &cobra.Command{
 		tasks := ws.ListTasks(listStatus, listRepo)
 
 		if listJSON {
-			data, _ := json.MarshalIndent(tasks, "", "  ")
-			fmt.Println(string(data))
-			return nil
-		}
-
-		if len(tasks) == 0 {
-			fmt.Println("No tasks found.")
-			return nil
+			out = output.New(output.FormatJSON, os.Stdout)
 		}
 
-		fmt.Printf("Tasks (%d):\n", len(tasks))
-		for _, t := range tasks {
-			deps := ""
-			if len(t.Deps) > 0 {
-				deps = fmt.Sprintf(" [deps: %s]", strings.Join(t.Deps, ", "))
+		return out.Print(tasks, func(w io.Writer) error {
+			if len(tasks) == 0 {
+				fmt.Fprintln(w, "No tasks found.")
+				return nil
 			}
-			repo := ""
-			if t.Repo != "" {
-				repo = fmt.Sprintf(" (%s)", t.Repo)
+
+			fmt.Fprintf(w, "Tasks (%d):\n", len(tasks))
+			for _, t := range tasks {
+				deps := ""
+				if len(t.Deps) > 0 {
+					deps = fmt.Sprintf(" [deps: %s]", strings.Join(t.Deps, ", "))
+				}
+				repo := ""
+				if t.Repo != "" {
+					repo = fmt.Sprintf(" (%s)", t.Repo)
+				}
+				fmt.Fprintf(w, "  %s [%s] %s%s%s\n", t.ID, t.Status, t.Title, repo, deps)
 			}
-			fmt.Printf("  %s [%s] %s%s%s\n", t.ID, t.Status, t.Title, repo, deps)
-		}
 
-		return nil
+			return nil
+		})
 	},
 }
 
 // Create flags
+var createID string
+var createIDPrefix string
+var createTitle string
+var createDesc string
 var createRepo string
 var createDeps string
+var createOptionalDeps string
 var createPriority int
 var createType string
+var createModel string
+var createEstimate int
+var createIssue int
+var createGroup string
+var createRelated string
+var createLabels string
+var createSpecRef string
+var createDryRun bool
 
 var taskCreateCmd = &cobra.Command{
-	Use:   "create <title>",
+	Use:   "create [title]",
 	Short: "Create a new task",
-	Args:  cobra.ExactArgs(1),
+	Long: `Create a new task in the current workspace.
+
+The title can be given as an argument or with --title. If --id is omitted,
+the next sequential ID with --id-prefix (t-NNN by default) is generated,
+one past the highest existing ID with that prefix. Use --dry-run to print the task
+JSON without writing anything.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ws, err := loadWorkspace()
+		ws, err := lockWorkspace()
 		if err != nil {
 			return err
 		}
+		defer ws.Unlock()
 
-		title := args[0]
-		var deps []string
-		if createDeps != "" {
-			deps = strings.Split(createDeps, ",")
-			for i := range deps {
-				deps[i] = strings.TrimSpace(deps[i])
+		title := createTitle
+		if len(args) > 0 {
+			if title != "" && title != args[0] {
+				return fmt.Errorf("title given both as argument and --title")
 			}
+			title = args[0]
+		}
+		if title == "" {
+			return fmt.Errorf("a title is required (argument or --title)")
 		}
 
-		task, err := ws.CreateTaskWithType(title, createType, createRepo, deps, createPriority)
-		if err != nil {
+		deps := splitIDs(createDeps)
+
+		if err := config.ValidateModelRef(createModel); err != nil {
+			return err
+		}
+
+		id := createID
+		if id == "" {
+			id = task.NextID(ws.Tasks, createIDPrefix)
+		}
+
+		t := ws.NewTask(id, title, createType)
+		t.Description = createDesc
+		t.Repo = createRepo
+		t.Deps = deps
+		t.OptionalDeps = splitIDs(createOptionalDeps)
+		t.Related = splitIDs(createRelated)
+		t.Priority = createPriority
+		t.EstimatedMinutes = createEstimate
+		t.Issue = createIssue
+		t.Group = createGroup
+		t.Labels = splitIDs(createLabels)
+		if createSpecRef != "" {
+			if _, err := ws.ReadSpecSection(createSpecRef); err != nil {
+				return err
+			}
+			t.SpecRef = createSpecRef
+		}
+		if createModel != "" {
+			t.Model = createModel
+		}
+
+		if createDryRun {
+			if err := ws.ValidateNewTask(t); err != nil {
+				return err
+			}
+			data, _ := json.MarshalIndent(t, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		if err := ws.AddTask(t); err != nil {
 			return err
 		}
 
-		fmt.Printf("✓ Created task: %s\n", task.ID)
-		fmt.Printf("  Title: %s\n", task.Title)
-		if task.Type != "" {
-			fmt.Printf("  Type:  %s\n", task.Type)
+		fmt.Printf("✓ Created task: %s\n", t.ID)
+		fmt.Printf("  Title: %s\n", t.Title)
+		if t.Type != "" {
+			fmt.Printf("  Type:  %s\n", t.Type)
+		}
+		if t.Model != "" {
+			fmt.Printf("  Model: %s\n", t.Model)
 		}
-		if task.Model != "" {
-			fmt.Printf("  Model: %s\n", task.Model)
+		if t.Repo != "" {
+			fmt.Printf("  Repo:  %s\n", t.Repo)
 		}
-		if task.Repo != "" {
-			fmt.Printf("  Repo:  %s\n", task.Repo)
+		if len(t.Deps) > 0 {
+			fmt.Printf("  Deps:  %s\n", strings.Join(t.Deps, ", "))
 		}
-		if len(task.Deps) > 0 {
-			fmt.Printf("  Deps:  %s\n", strings.Join(task.Deps, ", "))
+		if len(t.OptionalDeps) > 0 {
+			fmt.Printf("  Optional deps: %s\n", strings.Join(t.OptionalDeps, ", "))
+		}
+		if len(t.Related) > 0 {
+			fmt.Printf("  Related: %s\n", strings.Join(t.Related, ", "))
 		}
 
 		return nil
 	},
 }
 
+// splitIDs splits a comma-separated list of task IDs.
+func splitIDs(list string) []string {
+	if list == "" {
+		return nil
+	}
+	ids := strings.Split(list, ",")
+	for i := range ids {
+		ids[i] = strings.TrimSpace(ids[i])
+	}
+	return ids
+}
+
 var taskGetCmd = &cobra.Command{
 	Use:   "get <task-id>",
 	Short: "Get task details",
@@ -131,15 +221,107 @@ var taskGetCmd = &cobra.Command{
 	},
 }
 
+var showDepth int
+
+var taskShowCmd = &cobra.Command{
+	Use:   "show <task-id>",
+	Short: "Show a task with its dependency tree",
+	Long: `Show a task's fields, the tree of tasks it depends on (directly or
+through other tasks) with their statuses, and the tasks that depend on it.
+
+--depth limits how many levels of the tree are shown; deeper levels are
+marked with "...".`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		t, err := ws.GetTask(args[0])
+		if err != nil {
+			return err
+		}
+		tree, err := ws.Tasks.DepTree(t.ID, showDepth)
+		if err != nil {
+			return err
+		}
+		dependents, _ := ws.Tasks.GetDependents(t.ID)
+		sort.Slice(dependents, func(i, j int) bool { return dependents[i].ID < dependents[j].ID })
+
+		view := struct {
+			*task.Task
+			DepTree    *task.DepNode `json:"dep_tree"`
+			Dependents []string      `json:"dependents"`
+		}{Task: t, DepTree: tree, Dependents: []string{}}
+		for _, d := range dependents {
+			view.Dependents = append(view.Dependents, d.ID)
+		}
+
+		return out.Print(view, func(w io.Writer) error {
+			fmt.Fprintf(w, "%s  %s\n", t.ID, t.Title)
+			tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+			fmt.Fprintf(tw, "  Status:\t%s\n", t.Status)
+			for _, field := range []struct{ name, value string }{
+				{"Type", t.Type},
+				{"Repo", t.Repo},
+				{"Group", t.Group},
+				{"Model", t.Model},
+				{"Optional deps", strings.Join(t.OptionalDeps, ", ")},
+				{"Related", strings.Join(t.Related, ", ")},
+			} {
+				if field.value != "" {
+					fmt.Fprintf(tw, "  %s:\t%s\n", field.name, field.value)
+				}
+			}
+			if t.Priority != 0 {
+				fmt.Fprintf(tw, "  Priority:\t%d\n", t.Priority)
+			}
+			tw.Flush()
+			if t.Description != "" {
+				fmt.Fprintf(w, "\n%s\n", t.Description)
+			}
+
+			fmt.Fprintln(w, "\nDependencies:")
+			if len(tree.Deps) == 0 {
+				fmt.Fprintln(w, "  none")
+			} else {
+				tree.Render(w)
+			}
+
+			fmt.Fprintln(w, "\nDependents:")
+			if len(dependents) == 0 {
+				fmt.Fprintln(w, "  none")
+			}
+			for _, d := range dependents {
+				fmt.Fprintf(w, "  %s %s [%s]\n", d.ID, d.Title, d.Status)
+			}
+
+			if len(t.Notes) > 0 {
+				fmt.Fprintln(w, "\nNotes:")
+				for _, note := range t.Notes {
+					author := note.Author
+					if author == "" {
+						author = "unknown"
+					}
+					fmt.Fprintf(w, "  %s %s: %s\n", note.Time.Format(time.DateTime), author, note.Message)
+				}
+			}
+			return nil
+		})
+	},
+}
+
 var taskStartCmd = &cobra.Command{
 	Use:   "start <task-id>",
 	Short: "Mark task as in progress",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ws, err := loadWorkspace()
+		ws, err := lockWorkspace()
 		if err != nil {
 			return err
 		}
+		defer ws.Unlock()
 
 		if err := ws.SetTaskStatus(args[0], "in_progress"); err != nil {
 			return err
@@ -155,10 +337,11 @@ var taskCompleteCmd = &cobra.Command{
 	Short: "Mark task as complete",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ws, err := loadWorkspace()
+		ws, err := lockWorkspace()
 		if err != nil {
 			return err
 		}
+		defer ws.Unlock()
 
 		if err := ws.SetTaskStatus(args[0], "complete"); err != nil {
 			return err
@@ -174,10 +357,11 @@ var taskFailCmd = &cobra.Command{
 	Short: "Mark task as failed",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		ws, err := loadWorkspace()
+		ws, err := lockWorkspace()
 		if err != nil {
 			return err
 		}
+		defer ws.Unlock()
 
 		if err := ws.SetTaskStatus(args[0], "failed"); err != nil {
 			return err
@@ -188,24 +372,249 @@ var taskFailCmd = &cobra.Command{
 	},
 }
 
+var (
+	deleteCascade bool
+	deleteDryRun  bool
+)
+
+var taskDeleteCmd = &cobra.Command{
+	Use:   "delete <task-id>",
+	Short: "Delete a task",
+	Long: `Delete a task and its task file. A task other tasks depend on is only
+deleted with --cascade, which deletes those dependents, and theirs, too.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := lockWorkspace()
+		if err != nil {
+			return err
+		}
+		defer ws.Unlock()
+
+		ids, err := ws.Tasks.CascadeOrder(args[0])
+		if err != nil {
+			return err
+		}
+		dependents := ids[:len(ids)-1]
+		if len(dependents) > 0 && !deleteCascade {
+			fmt.Fprintf(os.Stderr, "Task %s is needed by:\n", args[0])
+			printTaskIDs(os.Stderr, ws, dependents)
+			return fmt.Errorf("task %s has %d dependent task(s); use --cascade to delete them too", args[0], len(dependents))
+		}
+
+		if deleteDryRun {
+			fmt.Printf("Would delete %d task(s):\n", len(ids))
+			printTaskIDs(os.Stdout, ws, ids)
+			return nil
+		}
+
+		deleted, err := ws.DeleteTask(args[0], deleteCascade)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Deleted %s\n", strings.Join(deleted, ", "))
+		return nil
+	},
+}
+
+// printTaskIDs writes each task ID with its title, one per line.
+func printTaskIDs(w io.Writer, ws *workspace.Workspace, ids []string) {
+	for _, id := range ids {
+		title := ""
+		if t, err := ws.GetTask(id); err == nil {
+			title = t.Title
+		}
+		fmt.Fprintf(w, "  %s  %s\n", id, title)
+	}
+}
+
+var noteAuthor string
+
+var taskNoteCmd = &cobra.Command{
+	Use:   "note <task-id> <text>",
+	Short: "Leave a note on a task",
+	Long: `Append a note to a task, such as why it was reopened or a gotcha found
+while working on it. Notes are shown by 'flo task show' and cannot be
+edited or removed.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := lockWorkspace()
+		if err != nil {
+			return err
+		}
+		defer ws.Unlock()
+
+		author := noteAuthor
+		if author == "" {
+			author = os.Getenv("USER")
+		}
+		if err := ws.AddTaskNote(args[0], author, args[1]); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Note added to task %s\n", args[0])
+		return nil
+	},
+}
+
+var taskEditCmd = &cobra.Command{
+	Use:   "edit <task-id>",
+	Short: "Edit a task in $EDITOR",
+	Long: `Open a task's markdown in $EDITOR (vi if unset) and apply the changes
+when the editor exits. The frontmatter and the "# Title" heading and
+description below it can be edited; the task ID cannot. If the edited task
+is invalid, for example because it adds a dependency cycle, you are asked
+whether to edit it again. Nothing changes if the editor exits with an
+error or the file is saved unchanged.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := lockWorkspace()
+		if err != nil {
+			return err
+		}
+		defer ws.Unlock()
+
+		stdin := bufio.NewReader(os.Stdin)
+		_, err = ws.EditTask(args[0], runEditor, func(err error) bool {
+			fmt.Fprintf(os.Stderr, "Error: %v\nEdit again? [Y/n] ", err)
+			answer, _ := stdin.ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			return answer != "n" && answer != "no"
+		})
+		if errors.Is(err, workspace.ErrEditUnchanged) {
+			fmt.Printf("No changes to task %s\n", args[0])
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Task %s updated\n", args[0])
+		return nil
+	},
+}
+
+// runEditor opens path in $EDITOR, which may include arguments such as
+// "code --wait", attached to the terminal.
+func runEditor(path string) error {
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	c := exec.Command(editor[0], append(editor[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return c.Run()
+}
+
+var importDryRun bool
+
+var taskImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Create tasks from a markdown bundle",
+	Long: `Create several tasks from one markdown file. Each task is a frontmatter
+block between "---" lines, with at least an id, followed by a "# Title"
+heading and description:
+
+  ---
+  id: t-010
+  ---
+  # Add schema
+
+  ---
+  id: t-011
+  deps: [t-010]
+  ---
+  # Migrate data
+
+Deps may name tasks in the file or already in the workspace. Nothing is
+created unless every task in the file is valid.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		tasks, err := task.ParseTaskBundle(args[0])
+		if err != nil {
+			return err
+		}
+
+		if importDryRun {
+			ws, err := loadWorkspace()
+			if err != nil {
+				return err
+			}
+			if err := ws.CheckImport(tasks); err != nil {
+				return err
+			}
+			data, _ := json.MarshalIndent(tasks, "", "  ")
+			fmt.Println(string(data))
+			return nil
+		}
+
+		ws, err := lockWorkspace()
+		if err != nil {
+			return err
+		}
+		defer ws.Unlock()
+
+		if err := ws.ImportTasks(tasks); err != nil {
+			return err
+		}
+
+		fmt.Printf("✓ Imported %d tasks\n", len(tasks))
+		for _, t := range tasks {
+			fmt.Printf("  %s  %s\n", t.ID, t.Title)
+		}
+		return nil
+	},
+}
+
 func init() {
 	// List command
 	taskListCmd.Flags().StringVar(&listStatus, "status", "", "Filter by status (pending, in_progress, complete, failed)")
 	taskListCmd.Flags().StringVar(&listRepo, "repo", "", "Filter by repository")
-	taskListCmd.Flags().BoolVar(&listJSON, "json", false, "Output as JSON")
+	taskListCmd.Flags().BoolVar(&listJSON, "json", false, "Output as JSON (same as --output json)")
 
 	// Create command
+	taskCreateCmd.Flags().StringVar(&createID, "id", "", "Task ID (default: next ID with --id-prefix)")
+	taskCreateCmd.Flags().StringVar(&createIDPrefix, "id-prefix", task.DefaultIDPrefix, "Prefix for generated task IDs (e.g. ua-)")
+	taskCreateCmd.Flags().StringVar(&createTitle, "title", "", "Task title")
+	taskCreateCmd.Flags().StringVar(&createDesc, "desc", "", "Task description")
+	taskCreateCmd.Flags().StringVar(&createModel, "model", "", "Model as backend/model (e.g., claude/sonnet)")
+	taskCreateCmd.Flags().BoolVar(&createDryRun, "dry-run", false, "Print the task JSON without writing")
 	taskCreateCmd.Flags().StringVar(&createRepo, "repo", "", "Target repository")
 	taskCreateCmd.Flags().StringVar(&createDeps, "deps", "", "Comma-separated dependency task IDs")
+	taskCreateCmd.Flags().StringVar(&createOptionalDeps, "optional-deps", "", "Comma-separated IDs of optional dependencies (must finish, may fail)")
+	taskCreateCmd.Flags().StringVar(&createRelated, "related", "", "Comma-separated IDs of related tasks (informational, never blocking)")
 	taskCreateCmd.Flags().IntVar(&createPriority, "priority", 0, "Task priority (0 = highest)")
 	taskCreateCmd.Flags().StringVar(&createType, "type", "", "Task type (e.g., build, refactor, test, fix)")
+	taskCreateCmd.Flags().IntVar(&createEstimate, "estimate", 0, "Estimated effort in minutes")
+	taskCreateCmd.Flags().IntVar(&createIssue, "issue", 0, "GitHub issue number this task tracks")
+	taskCreateCmd.Flags().StringVar(&createGroup, "group", "", "Group (epic) the task belongs to")
+	taskCreateCmd.Flags().StringVar(&createLabels, "labels", "", "Comma-separated labels (e.g. security,ui)")
+	taskCreateCmd.Flags().StringVar(&createSpecRef, "spec-ref", "", "Spec section the task implements (e.g. SPEC.md#oauth); only it is put in the prompt")
+
+	// Show command
+	taskShowCmd.Flags().IntVar(&showDepth, "depth", task.DefaultTreeDepth, "Maximum dependency tree depth")
+
+	// Import command
+	taskImportCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Check the tasks and print them as JSON without creating them")
+
+	// Delete command
+	taskDeleteCmd.Flags().BoolVar(&deleteCascade, "cascade", false, "Also delete tasks that depend on the task")
+	taskDeleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "List the tasks that would be deleted without deleting them")
+
+	// Note command
+	taskNoteCmd.Flags().StringVar(&noteAuthor, "author", "", "Note author (default $USER)")
 
 	taskCmd.AddCommand(taskListCmd)
 	taskCmd.AddCommand(taskCreateCmd)
 	taskCmd.AddCommand(taskGetCmd)
+	taskCmd.AddCommand(taskShowCmd)
+	taskCmd.AddCommand(taskImportCmd)
 	taskCmd.AddCommand(taskStartCmd)
 	taskCmd.AddCommand(taskCompleteCmd)
 	taskCmd.AddCommand(taskFailCmd)
+	taskCmd.AddCommand(taskDeleteCmd)
+	taskCmd.AddCommand(taskNoteCmd)
+	taskCmd.AddCommand(taskEditCmd)
 }
 
 func loadWorkspace() (*workspace.Workspace, error) {
@@ -213,5 +622,23 @@ func loadWorkspace() (*workspace.Workspace, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current directory: %w", err)
 	}
-	return workspace.Load(cwd)
+	return workspace.LoadProfile(cwd, activeProfile())
+}
+
+// lockWorkspace loads the workspace and acquires its lock before mutating
+// it. Callers must release the lock with ws.Unlock.
+func lockWorkspace() (*workspace.Workspace, error) {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return nil, err
+	}
+	if forceFlag {
+		if err := ws.BreakLock(); err != nil {
+			return nil, err
+		}
+	}
+	if err := ws.Lock(); err != nil {
+		return nil, err
+	}
+	return ws, nil
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/richgo/flo/pkg/tools"
+	"github.com/spf13/cobra"
+)
+
+var toolsSchemas bool
+var toolsExportFile string
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "List the EAS tools exposed to agents",
+	Long: `List the tools served by flo mcp serve, with their descriptions.
+
+This runs the eas_help tool, so it shows exactly what agents see.
+Use --schemas with --output json to include each tool's input schema.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		result, err := newToolRegistry(ws).Execute("eas_help", tools.Args{"schemas": toolsSchemas})
+		if err != nil {
+			return err
+		}
+
+		var help struct {
+			Tools []tools.ToolInfo `json:"tools"`
+		}
+		if err := json.Unmarshal([]byte(result), &help); err != nil {
+			return fmt.Errorf("failed to parse eas_help output: %w", err)
+		}
+
+		return out.Print(help, func(w io.Writer) error {
+			tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+			fmt.Fprintln(tw, "NAME\tDESCRIPTION")
+			for _, info := range help.Tools {
+				fmt.Fprintf(tw, "%s\t%s\n", info.Name, info.Description)
+			}
+			return tw.Flush()
+		})
+	},
+}
+
+var toolsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write every tool's schema as stable JSON",
+	Long: `Write the name, description and input schema of every EAS tool as JSON,
+for generating typed clients.
+
+Tools are sorted by name and object keys are sorted, so the output only
+changes when a tool does and can be diffed or checked in.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ws, err := loadWorkspace()
+		if err != nil {
+			return err
+		}
+
+		data, err := newToolRegistry(ws).ExportSchemas()
+		if err != nil {
+			return err
+		}
+
+		if toolsExportFile == "" {
+			_, err := os.Stdout.Write(data)
+			return err
+		}
+		if err := os.WriteFile(toolsExportFile, data, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", toolsExportFile, err)
+		}
+		fmt.Fprintf(out.Progress(), "✅ Wrote tool schemas to %s\n", toolsExportFile)
+		return nil
+	},
+}
+
+func init() {
+	toolsCmd.Flags().BoolVar(&toolsSchemas, "schemas", false, "Include input schemas (JSON output)")
+	toolsExportCmd.Flags().StringVarP(&toolsExportFile, "file", "f", "", "Write to this file instead of stdout")
+	toolsCmd.AddCommand(toolsExportCmd)
+	rootCmd.AddCommand(toolsCmd)
+}
@@ -2,24 +2,60 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
 	"path/filepath"
-	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/logging"
+	"github.com/richgo/flo/pkg/notify"
 	"github.com/richgo/flo/pkg/quota"
 	"github.com/richgo/flo/pkg/task"
 	"github.com/richgo/flo/pkg/workspace"
 )
 
 var workBackend string
+var workEstimate bool
+
+// Event stream settings. Only 'flo work' sets them; other commands that
+// run tasks stream to the terminal.
+var workQuiet bool
+var workLogFile string
+
+// startLimiter spaces out backend sessions started by this process.
+var (
+	startLimiter     *agent.RateLimiter
+	startLimiterOnce sync.Once
+)
+
+// Quota wait settings, shared by 'flo work' and 'flo run'.
+var waitForQuota bool
+var maxQuotaWait time.Duration
+
+// defaultMaxQuotaWait is the default --max-quota-wait.
+const defaultMaxQuotaWait = 15 * time.Minute
+
+// taskTimeout is --timeout, shared by 'flo work' and 'flo run'. When it
+// is not given, the config's default_task_timeout applies.
+var taskTimeout durationFlag
+
+// Bounds of the printed cost range, as multiples of the point estimate.
+// Agent sessions re-send context across turns, so the range skews high.
+const (
+	costRangeLow  = 0.5
+	costRangeHigh = 3.0
+)
 
 var workCmd = &cobra.Command{
-	Use:   "work <task-id>",
+	Use:   "work [task-id]",
 	Short: "Start agent work on a task",
 	Long: `Start an AI agent to work on the specified task.
 
@@ -29,15 +65,34 @@ The agent will:
 3. Run tests (TDD enforcement)
 4. Complete the task when tests pass
 
-Uses the configured backend (claude or copilot) unless overridden.`,
-	Args: cobra.ExactArgs(1),
+Uses the configured backend (claude, copilot or openai) unless overridden.
+
+With --estimate, prints an estimated cost range for the task from the
+resolved model's configured pricing and exits without running it.
+
+--log-file appends the agent's streamed output to a file as well, and
+--quiet stops it streaming to the terminal, e.g. for CI runs.
+
+Without a task ID, lists the ready tasks and asks which to work on when
+run from a terminal; otherwise prints the ready tasks and exits.`,
+	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		taskID := args[0]
+		var taskID string
+		if len(args) == 1 {
+			taskID = args[0]
+		} else {
+			picked, err := pickReadyTask()
+			if err != nil {
+				return err
+			}
+			taskID = picked
+		}
 
-		ws, err := loadWorkspace()
+		ws, err := lockWorkspace()
 		if err != nil {
 			return err
 		}
+		defer ws.Unlock()
 
 		// Get the task
 		t, err := ws.GetTask(taskID)
@@ -45,6 +100,14 @@ Uses the configured backend (claude or copilot) unless overridden.`,
 			return err
 		}
 
+		if workEstimate {
+			backendName, model, _, err := resolveTask(ws, t, workBackend)
+			if err != nil {
+				return err
+			}
+			return printEstimate(ws, t, backendName, model)
+		}
+
 		// Check task is ready
 		if t.Status != task.StatusPending {
 			return fmt.Errorf("task %s is not pending (status: %s)", taskID, t.Status)
@@ -63,147 +126,508 @@ Uses the configured backend (claude or copilot) unless overridden.`,
 			return fmt.Errorf("task %s has incomplete dependencies", taskID)
 		}
 
-		// Try to read task.md file to get model from frontmatter
-		taskMDPath := filepath.Join(ws.Root, ".flo", "tasks", fmt.Sprintf("TASK-%s.md", taskID))
-		if taskFromFile, err := task.ParseTaskFile(taskMDPath); err == nil && taskFromFile.Model != "" {
-			// Update task with model from frontmatter
-			t.Model = taskFromFile.Model
-			t.Fallback = taskFromFile.Fallback
+		// Open the log file before claiming, so a bad path leaves the task pending
+		sink, closeSink, err := eventSink()
+		if err != nil {
+			return err
 		}
+		defer closeSink()
 
-		// Determine backend and model
-		backendName := ws.Backend
-		model := ""
-		
-		if workBackend != "" {
-			backendName = workBackend
-		} else if t.Model != "" {
-			// Parse model format: "backend/model" (e.g., "claude/sonnet", "copilot/gpt-4")
-			parts := strings.Split(t.Model, "/")
-			if len(parts) == 2 {
-				backendName = parts[0]
-				model = parts[1]
-			}
+		backendName, model, fallbacks, err := prepareTask(ws, t, workBackend)
+		if err != nil {
+			return err
 		}
 
-		fmt.Printf("🚀 Starting work on task: %s\n", taskID)
-		fmt.Printf("   Title: %s\n", t.Title)
-		fmt.Printf("   Backend: %s\n", backendName)
-		if model != "" {
-			fmt.Printf("   Model: %s\n", model)
+		// Claim the task, once the MCP config the backend needs is known to be writable
+		var preflight func() error
+		if needsMCPConfig(backendName, fallbacks) {
+			preflight = ws.WriteMCPConfig
 		}
-
-		// Claim the task
-		if err := t.SetStatus(task.StatusInProgress); err != nil {
+		if err := ws.ClaimTask(t.ID, preflight); err != nil {
 			return err
 		}
-		ws.Tasks.Update(t)
-		ws.Save()
 
 		// Initialize quota tracker
 		quotaPath := filepath.Join(ws.Root, ".flo", "quota.json")
 		quotaTracker := initQuotaTracker(quotaPath, ws)
 
 		// Attempt to run with primary backend, fallback if needed
-		ctx := context.Background()
-		result, err := runWithFailover(ctx, ws, t, backendName, model, quotaTracker)
-		
+		ctx, stop := signalContext()
+		defer stop()
+		run, err := runWithFailover(ctx, ws, t, backendName, model, fallbacks, quotaTracker, sink, false)
+		if ctx.Err() != nil {
+			if err := ws.RevertInterrupted(t); err != nil {
+				return err
+			}
+			return fmt.Errorf("interrupted: task %s reverted to pending", taskID)
+		}
+		notifyTask(ws, t, run, err)
 		if err != nil {
-			return fmt.Errorf("agent failed: %w", err)
+			// Keep the failure category the runner recorded for triage
+			t.SetStatus(task.StatusFailed)
+			ws.Tasks.Update(t)
+			ws.Save()
+
+			terminated := agent.TerminatedFailed
+			if run.Result != nil && run.Result.Terminated != "" {
+				terminated = run.Result.Terminated
+			}
+			if out.JSON() {
+				if perr := out.Print(workResult{
+					TaskID:     taskID,
+					Terminated: terminated,
+					Backend:    run.Backend,
+					Model:      run.Model,
+					FailedOver: run.FailedOver,
+					Duration:   run.Duration.String(),
+					Error:      err.Error(),
+				}, nil); perr != nil {
+					return perr
+				}
+			}
+			return fmt.Errorf("agent failed (%s): %w", terminated, err)
 		}
+		result := run.Result
 
 		if result.Success {
-			fmt.Printf("\n✅ Task %s completed successfully\n", taskID)
+			fmt.Fprintf(out.Progress(), "\n✅ Task %s completed successfully\n", taskID)
+			// Mark it complete, as 'flo run' does, along with the backend and
+			// model the runner recorded
+			if t.Status == task.StatusInProgress {
+				t.SetStatus(task.StatusComplete)
+			}
+			ws.Tasks.Update(t)
+			ws.Save()
 		} else {
-			fmt.Printf("\n❌ Task %s failed: %s\n", taskID, result.Error)
+			reason := ""
+			if result.Terminated != "" && result.Terminated != agent.TerminatedFailed {
+				reason = fmt.Sprintf(" (%s)", result.Terminated)
+			}
+			fmt.Fprintf(out.Progress(), "\n❌ Task %s failed%s: %s\n", taskID, reason, result.Error)
 			// Revert status
 			t.SetStatus(task.StatusFailed)
 			ws.Tasks.Update(t)
 			ws.Save()
 		}
 
+		if out.JSON() {
+			return out.Print(workResult{
+				TaskID:     taskID,
+				Success:    result.Success,
+				Terminated: result.Terminated,
+				Backend:    run.Backend,
+				Model:      run.Model,
+				FailedOver: run.FailedOver,
+				Duration:   run.Duration.String(),
+				Output:     result.Output,
+				Error:      result.Error,
+			}, nil)
+		}
+
 		return nil
 	},
 }
 
-// runWithFailover attempts to run a task with the primary backend, and falls back to the fallback model if quota is exhausted.
-func runWithFailover(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, tracker *quota.Tracker) (*agent.Result, error) {
-	// Try primary backend
-	result, err := runBackend(ctx, ws, t, backendName, model, tracker)
-	
-	// Check if we hit quota exhaustion
-	if err != nil && isQuotaError(err) && t.Fallback != "" {
-		fmt.Printf("\n⚠️  Quota exhausted for %s, failing over to %s\n", backendName, t.Fallback)
-		
-		// Parse fallback model
-		parts := strings.Split(t.Fallback, "/")
-		if len(parts) == 2 {
-			fallbackBackend := parts[0]
-			fallbackModel := parts[1]
-			
-			// Record the failover
-			tracker.RecordError(backendName, time.Hour)
-			
-			fmt.Printf("🔄 Retrying with fallback backend: %s/%s\n", fallbackBackend, fallbackModel)
-			
-			// Try fallback
-			result, err = runBackend(ctx, ws, t, fallbackBackend, fallbackModel, tracker)
+// pickReadyTask asks which ready task to work on when stdin is a terminal.
+// Otherwise it prints the ready tasks and returns an error asking for an ID.
+func pickReadyTask() (string, error) {
+	ws, err := loadWorkspace()
+	if err != nil {
+		return "", err
+	}
+	ready := ws.Tasks.GetReadyFiltered(task.ReadyFilter{})
+	if len(ready) == 0 {
+		return "", fmt.Errorf("no tasks are ready to work on")
+	}
+
+	if !stdinIsTerminal() {
+		fmt.Fprintln(os.Stderr, "Ready tasks:")
+		task.WriteReadyList(os.Stderr, ready)
+		return "", fmt.Errorf("no task ID given; run 'flo work <task-id>' with one of the tasks above")
+	}
+
+	t, err := task.PickTask(os.Stdin, os.Stderr, ready)
+	if err != nil {
+		return "", err
+	}
+	return t.ID, nil
+}
+
+// stdinIsTerminal reports whether stdin is an interactive terminal. The
+// null device is a character device too, so it is ruled out by name.
+func stdinIsTerminal() bool {
+	fi, err := os.Stdin.Stat()
+	if err != nil || fi.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(fi, null)
+}
+
+// estimateResult is the JSON object emitted by flo work --estimate.
+type estimateResult struct {
+	TaskID       string  `json:"task_id"`
+	Backend      string  `json:"backend"`
+	Model        string  `json:"model"`
+	PromptTokens int     `json:"prompt_tokens"`
+	Priced       bool    `json:"priced"`
+	MinCost      float64 `json:"min_cost_usd,omitempty"`
+	MaxCost      float64 `json:"max_cost_usd,omitempty"`
+}
+
+// printEstimate prints the estimated cost range of running t. A model with
+// no configured price is reported as such rather than guessed.
+func printEstimate(ws *workspace.Workspace, t *task.Task, backendName, model string) error {
+	spec := ws.PromptSpec(t)
+	result := estimateResult{
+		TaskID:       t.ID,
+		Backend:      backendName,
+		Model:        effectiveModel(ws, backendName, model),
+		PromptTokens: agent.EstimateTokens(buildPrompt(t, spec)),
+	}
+
+	cost, err := agent.EstimateCost(result.Backend, result.Model, result.PromptTokens, ws.Config.Pricing)
+	switch {
+	case errors.Is(err, agent.ErrNoPricing):
+	case err != nil:
+		return err
+	default:
+		result.Priced = true
+		result.MinCost = cost * costRangeLow
+		result.MaxCost = cost * costRangeHigh
+	}
+
+	return out.Print(result, func(w io.Writer) error {
+		ref := result.Backend
+		if result.Model != "" {
+			ref += "/" + result.Model
 		}
+		fmt.Fprintf(w, "Task %s on %s (~%d prompt tokens)\n", result.TaskID, ref, result.PromptTokens)
+		if !result.Priced {
+			fmt.Fprintf(w, "No pricing configured for %s; add it under 'pricing' in config.yaml to estimate cost\n", ref)
+			return nil
+		}
+		fmt.Fprintf(w, "Estimated cost: $%.2f – $%.2f\n", result.MinCost, result.MaxCost)
+		return nil
+	})
+}
+
+// workResult is the final JSON object emitted by flo work --output json.
+type workResult struct {
+	TaskID     string            `json:"task_id"`
+	Success    bool              `json:"success"`
+	Terminated agent.Termination `json:"terminated,omitempty"`
+	Backend    string            `json:"backend"`
+	Model      string            `json:"model,omitempty"`
+	FailedOver bool              `json:"failed_over,omitempty"`
+	Duration   string            `json:"duration"`
+	Output     string            `json:"output,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+// resolveTask refreshes the task's model and env from its task.md frontmatter,
+// validates it, and resolves the backend, model and fallback to run with.
+// A non-empty backendOverride takes precedence over everything else.
+func resolveTask(ws *workspace.Workspace, t *task.Task, backendOverride string) (string, string, []string, error) {
+	// Try to read task.md file to get model from frontmatter
+	taskMDPath := filepath.Join(ws.Root, ".flo", "tasks", fmt.Sprintf("TASK-%s.md", t.ID))
+	if taskFromFile, err := task.ParseTaskFile(taskMDPath); err == nil {
+		if taskFromFile.Model != "" {
+			// Update task with model from frontmatter
+			t.Model = taskFromFile.Model
+			t.Fallback = taskFromFile.Fallback
+			t.Fallbacks = taskFromFile.Fallbacks
+		}
+		if len(taskFromFile.Env) > 0 {
+			t.Env = taskFromFile.Env
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		logging.L().Warn("ignoring task file", "task", t.ID, "error", err)
 	}
-	
-	return result, err
+
+	// Catch typos in backend prefixes before claiming the task
+	if err := config.ValidateModelRef(t.Model); err != nil {
+		return "", "", nil, fmt.Errorf("task %s: %w", t.ID, err)
+	}
+	for _, ref := range t.FallbackChain() {
+		if err := config.ValidateModelRef(ref); err != nil {
+			return "", "", nil, fmt.Errorf("task %s fallback: %w", t.ID, err)
+		}
+	}
+
+	// Reject tasks that point at a repo missing from config
+	if _, err := ws.RepoPath(t.Repo); err != nil {
+		return "", "", nil, fmt.Errorf("task %s: %w", t.ID, err)
+	}
+
+	// Determine backend and model: flag, task model, task type, repo override, workspace default
+	backendName, model, fallbacks := ws.Config.ResolveModel(t)
+	if backendOverride != "" {
+		backendName = backendOverride
+		model = ""
+	}
+	logging.L().Info("backend selected",
+		"task", t.ID, "backend", backendName, "model", model,
+		"fallbacks", fallbacks, "source", modelSource(ws, t, backendOverride))
+	return backendName, model, fallbacks, nil
 }
 
-// runBackend executes a task with a specific backend.
-func runBackend(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, tracker *quota.Tracker) (*agent.Result, error) {
-	// Check if backend is exhausted before starting
-	if tracker.IsExhausted(backendName) {
-		return nil, fmt.Errorf("quota exhausted for backend %s", backendName)
+// needsMCPConfig reports whether a run on backendName, or a failover along
+// fallbacks, may start a CLI backend that reads the MCP client config.
+func needsMCPConfig(backendName string, fallbacks []string) bool {
+	if backendName == "claude" {
+		return true
+	}
+	for _, ref := range fallbacks {
+		if backend, _, err := agent.ParseModelRef(ref); err == nil && backend == "claude" {
+			return true
+		}
 	}
+	return false
+}
 
-	// Create backend
+// modelSource describes where resolveTask took the backend and model from.
+func modelSource(ws *workspace.Workspace, t *task.Task, backendOverride string) string {
+	if backendOverride != "" {
+		return "--backend flag"
+	}
+	switch source := ws.Config.ModelSource(t); source {
+	case config.ModelSourceTaskType:
+		return fmt.Sprintf("%s %s", source, t.Type)
+	case config.ModelSourceRepo:
+		return fmt.Sprintf("%s %s", source, t.Repo)
+	default:
+		return source
+	}
+}
+
+// prepareTask resolves the task like resolveTask and announces the start of work.
+func prepareTask(ws *workspace.Workspace, t *task.Task, backendOverride string) (string, string, []string, error) {
+	backendName, model, fallbacks, err := resolveTask(ws, t, backendOverride)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	fmt.Fprintf(out.Progress(), "🚀 Starting work on task: %s\n", t.ID)
+	fmt.Fprintf(out.Progress(), "   Title: %s\n", t.Title)
+	if t.Repo != "" {
+		fmt.Fprintf(out.Progress(), "   Repo: %s\n", t.Repo)
+	}
+	fmt.Fprintf(out.Progress(), "   Backend: %s (from %s)\n", backendName, modelSource(ws, t, backendOverride))
+	if model != "" {
+		fmt.Fprintf(out.Progress(), "   Model: %s\n", model)
+	}
+	if thinking := ws.Config.ThinkingFor(t); thinking != "" {
+		fmt.Fprintf(out.Progress(), "   Thinking: %s\n", thinking)
+	}
+
+	return backendName, model, fallbacks, nil
+}
+
+// runWithFailover runs a task with the primary backend, failing over along the fallback chain while quota is exhausted.
+// The agent's output goes to sink. With resume set, the task's last conversation is continued where the backend supports it.
+// Like agent.Runner.Run, the returned RunResult is never nil.
+func runWithFailover(ctx context.Context, ws *workspace.Workspace, t *task.Task, backendName, model string, fallbacks []string, tracker *quota.Tracker, sink agent.EventSink, resume bool) (*agent.RunResult, error) {
+	failed := func(err error) (*agent.RunResult, error) {
+		return &agent.RunResult{Backend: backendName, Model: model, Err: err}, err
+	}
+
+	// Run in the task's repo checkout
+	worktree, err := ws.RepoPath(t.Repo)
+	if err != nil {
+		return failed(err)
+	}
+
+	// Read spec for context
+	spec := ws.PromptSpec(t)
+
+	if timeout := ws.Config.TaskTimeout(taskTimeout.ptr()); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	runner := &agent.Runner{
+		NewBackend: func(name, model string) (agent.Backend, error) {
+			return newBackend(ws, name, model, ws.Config.ThinkingFor(t))
+		},
+		Quota:        tracker,
+		QuotaBackoff: ws.Config.QuotaBackoff(),
+		Limiter:      rateLimiter(ws),
+		Sink:         sink,
+		OnFailover: func(from, to string) {
+			fmt.Fprintf(out.Progress(), "\n⚠️  Quota exhausted for %s, failing over to %s\n", from, to)
+			fmt.Fprintf(out.Progress(), "🔄 Retrying with fallback backend: %s\n", to)
+		},
+		OnRetry: func(n, max int, reason string) {
+			fmt.Fprintf(out.Progress(), "\n⚠️  Task %s failed: %s\n", t.ID, reason)
+			fmt.Fprintf(out.Progress(), "🔄 Retrying (%d of %d)\n", n, max)
+		},
+		OnQuotaWait: func(backend string, wait time.Duration) {
+			fmt.Fprintf(out.Progress(), "\n⏳ Quota exhausted for %s, waiting %s for it to reopen\n", backend, wait.Round(time.Second))
+		},
+		// Save the conversation as soon as it starts so a crashed or
+		// killed run can still be resumed
+		OnConversation: func(backend, conversationID string) {
+			ws.Tasks.Update(t)
+			if err := ws.Save(); err != nil {
+				logging.L().Warn("failed to save conversation", "task", t.ID, "backend", backend, "error", err)
+			}
+		},
+	}
+	if waitForQuota {
+		runner.MaxQuotaWait = maxQuotaWait
+	}
+
+	return runner.Run(ctx, agent.RunRequest{
+		Task:      t,
+		Worktree:  worktree,
+		Prompt:    buildPrompt(t, spec),
+		Backend:   backendName,
+		Model:     model,
+		Fallbacks: fallbacks,
+
+		Resume:       resume,
+		ResumePrompt: buildResumePrompt(t),
+	})
+}
+
+// notifyTask posts the outcome of a finished run to the configured webhook.
+// Notification failures are logged and never fail the task.
+func notifyTask(ws *workspace.Workspace, t *task.Task, run *agent.RunResult, runErr error) {
+	n := ws.Config.Notifications
+	if n == nil || n.Webhook == nil {
+		return
+	}
+
+	event := notify.TaskEvent{
+		Event:  config.EventCompleted,
+		TaskID: t.ID,
+		Title:  t.Title,
+		Status: string(task.StatusComplete),
+		Time:   time.Now().UTC(),
+	}
+	if run != nil {
+		event.Backend = run.Backend
+		event.Model = run.Model
+		event.Duration = run.Duration.Seconds()
+		event.Tokens = run.Tokens
+	}
+	switch {
+	case runErr != nil:
+		event.Error = runErr.Error()
+	case run == nil || run.Result == nil:
+		event.Error = "no result"
+	case !run.Result.Success:
+		event.Error = run.Result.Error
+	}
+	if event.Error != "" {
+		event.Event = config.EventFailed
+		event.Status = string(task.StatusFailed)
+	}
+
+	// The run's context may already be cancelled; the webhook has its own timeout
+	notify.NewWebhook(*n.Webhook).Send(context.Background(), event)
+}
+
+// newBackend creates a backend configured from the workspace, wrapped with
+// the workspace retry policy. thinking is the task type's thinking mode.
+func newBackend(ws *workspace.Workspace, backendName, model, thinking string) (agent.Backend, error) {
 	var backend agent.Backend
 	switch backendName {
 	case "claude":
-		mcpConfig := filepath.Join(ws.Root, ".eas", "mcp.json")
-		// Generate MCP config
-		if err := generateMCPConfig(mcpConfig, ws.Root); err != nil {
-			return nil, fmt.Errorf("failed to generate MCP config: %w", err)
+		if err := ws.WriteMCPConfig(); err != nil {
+			return nil, err
 		}
-		claudeModel := ws.Config.Claude.Model
-		if model != "" {
-			claudeModel = model
+		var extraArgs []string
+		var maxOutput, maxLine int
+		if ws.Config.Claude != nil {
+			extraArgs = ws.Config.Claude.ExtraArgs
+			maxOutput = ws.Config.Claude.MaxOutputBytes
+			maxLine = ws.Config.Claude.MaxLineBytes
 		}
 		backend = agent.NewClaudeBackend(agent.ClaudeConfig{
-			MCPConfig: mcpConfig,
-			Model:     claudeModel,
+			MCPConfig:      ws.MCPConfigPath(),
+			Model:          effectiveModel(ws, backendName, model),
+			Thinking:       thinking,
+			ExtraArgs:      extraArgs,
+			MaxOutputBytes: maxOutput,
+			MaxLineBytes:   maxLine,
 		})
 	case "copilot":
-		copilotModel := ws.Config.Copilot.Model
-		if model != "" {
-			copilotModel = model
+		var provider *agent.ProviderConfig
+		if ws.Config.Copilot != nil && ws.Config.Copilot.Provider != nil {
+			if err := ws.Config.Copilot.Provider.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid copilot provider: %w", err)
+			}
+			provider = ws.Config.Copilot.Provider.AgentConfig()
 		}
 		backend = agent.NewCopilotBackend(agent.CopilotConfig{
-			Model: copilotModel,
+			Model:    effectiveModel(ws, backendName, model),
+			Provider: provider,
+		})
+	case "openai":
+		oc := ws.Config.OpenAI
+		if oc == nil || oc.Provider == nil {
+			return nil, fmt.Errorf("the openai backend needs an openai.provider section with a base_url")
+		}
+		if err := oc.Provider.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid openai provider: %w", err)
+		}
+		backend = agent.NewOpenAICompatBackend(agent.OpenAICompatConfig{
+			Model:          effectiveModel(ws, backendName, model),
+			Provider:       oc.Provider.AgentConfig(),
+			MaxOutputBytes: oc.MaxOutputBytes,
+			MaxLineBytes:   oc.MaxLineBytes,
 		})
 	default:
 		return nil, fmt.Errorf("unknown backend: %s", backendName)
 	}
+	return agent.NewRetryableBackend(backend, ws.Config.RetryFor(backendName).AgentConfig()), nil
+}
 
-	if err := backend.Start(ctx); err != nil {
-		// Check if this is a quota error
-		if isQuotaError(err) {
-			tracker.RecordError(backendName, time.Hour)
-		}
-		return nil, fmt.Errorf("failed to start backend: %w", err)
+// effectiveModel returns model, or the backend's configured default model
+// when model is empty.
+func effectiveModel(ws *workspace.Workspace, backendName, model string) string {
+	if model != "" {
+		return model
 	}
-	defer backend.Stop()
+	switch {
+	case backendName == "claude" && ws.Config.Claude != nil:
+		return ws.Config.Claude.Model
+	case backendName == "copilot" && ws.Config.Copilot != nil:
+		return ws.Config.Copilot.Model
+	case backendName == "openai" && ws.Config.OpenAI != nil:
+		return ws.Config.OpenAI.Model
+	}
+	return ""
+}
 
-	// Read spec for context
-	spec, _ := ws.ReadSpec()
+// eventSink returns the sink for streamed session events: the progress
+// output unless --quiet is set, and the --log-file if one is given. The
+// returned func closes the log file.
+func eventSink() (agent.EventSink, func() error, error) {
+	var terminal agent.EventSink
+	if !workQuiet {
+		terminal = agent.NewTextSink(out.Progress())
+	}
+	if workLogFile == "" {
+		return agent.MultiSink(terminal), func() error { return nil }, nil
+	}
+
+	f, err := os.OpenFile(workLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	return agent.MultiSink(terminal, agent.NewTextSink(f)), f.Close, nil
+}
 
-	// Build prompt
-	prompt := fmt.Sprintf(`You are working on task %s in a TDD workflow.
+// buildPrompt builds the agent prompt for a task.
+func buildPrompt(t *task.Task, spec string) string {
+	if spec == "" {
+		spec = "Not included; call eas_spec_read if you need it."
+	}
+	return fmt.Sprintf(`You are working on task %s in a TDD workflow.
 
 ## Task
 Title: %s
@@ -224,99 +648,96 @@ Available tools:
 - eas_spec_read: Read the feature specification
 
 Begin implementing the task.`, t.ID, t.Title, t.Description, spec)
+}
 
-	// Create session
-	session, err := backend.CreateSession(ctx, t, ws.Root)
-	if err != nil {
-		if isQuotaError(err) {
-			tracker.RecordError(backendName, time.Hour)
-		}
-		return nil, fmt.Errorf("failed to create session: %w", err)
-	}
-	defer session.Destroy(ctx)
-
-	// Stream events
-	go func() {
-		for event := range session.Events() {
-			switch event.Type {
-			case "message":
-				fmt.Print(event.Content)
-			case "tool_call":
-				fmt.Printf("\n🔧 %s\n", event.Content)
-			case "complete":
-				fmt.Println("\n✅ Complete")
-			case "error":
-				fmt.Printf("\n❌ Error: %s\n", event.Content)
-			}
-		}
-	}()
+// buildResumePrompt creates the follow-up prompt for a resumed conversation,
+// which already holds the task and spec.
+func buildResumePrompt(t *task.Task) string {
+	return fmt.Sprintf(`Your work on task %s (%s) was interrupted before it finished.
 
-	// Run the agent
-	result, err := session.Run(ctx, prompt)
-	if err != nil {
-		if isQuotaError(err) {
-			tracker.RecordError(backendName, time.Hour)
-		}
-		return nil, err
-	}
-	
-	// Record successful usage (approximate token count)
-	if result.Success {
-		tracker.Record(backendName, 10000) // Estimate, actual would come from API
-	}
-	
-	return result, nil
+Check the current state of the code and tests, then continue where you left off.
+When tests pass, call eas_task_complete to finish the task.`, t.ID, t.Title)
 }
 
-// isQuotaError checks if an error is related to quota exhaustion.
-func isQuotaError(err error) bool {
-	if err == nil {
-		return false
-	}
-	errStr := strings.ToLower(err.Error())
-	return strings.Contains(errStr, "429") ||
-		strings.Contains(errStr, "rate limit") ||
-		strings.Contains(errStr, "quota") ||
-		strings.Contains(errStr, "too many requests")
+// signalContext returns a context cancelled on SIGINT or SIGTERM, so a
+// running agent session is torn down and its task reverted.
+func signalContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 }
 
 // initQuotaTracker initializes the quota tracker with limits from config.
 func initQuotaTracker(path string, ws *workspace.Workspace) *quota.Tracker {
 	tracker := quota.New(path)
 	tracker.Load()
-	
-	// Set limits from config if available
-	// Default limits for common backends
-	tracker.SetLimit("claude", 50)  // 50 requests per hour for premium
-	tracker.SetLimit("copilot", 100) // Higher limit for copilot
-	
+	ws.Config.ApplyQuota(tracker)
 	return tracker
 }
 
+// rateLimiter returns the process-wide session start limiter, configured
+// from the workspace quota limits on first use.
+func rateLimiter(ws *workspace.Workspace) *agent.RateLimiter {
+	startLimiterOnce.Do(func() {
+		startLimiter = agent.NewRateLimiter()
+		ws.Config.ApplyRateLimits(startLimiter)
+	})
+	return startLimiter
+}
+
 func init() {
-	workCmd.Flags().StringVar(&workBackend, "backend", "", "Override backend (claude or copilot)")
+	workCmd.Flags().StringVar(&workBackend, "backend", "", "Override backend (claude, copilot or openai)")
+	workCmd.Flags().BoolVar(&workEstimate, "estimate", false, "Print an estimated cost range and exit without running")
+	workCmd.Flags().BoolVar(&workQuiet, "quiet", false, "Don't stream the agent's output to the terminal")
+	workCmd.Flags().StringVar(&workLogFile, "log-file", "", "Append the agent's streamed output to this file")
+	addQuotaWaitFlags(workCmd)
+	addTimeoutFlag(workCmd)
 	rootCmd.AddCommand(workCmd)
 }
 
-func generateMCPConfig(path, workspaceRoot string) error {
-	cwd, _ := os.Getwd()
-	easBinary := filepath.Join(cwd, "eas")
-	
-	// Check if eas exists in current dir, otherwise use PATH
-	if _, err := os.Stat(easBinary); os.IsNotExist(err) {
-		easBinary = "eas"
-	}
-
-	config := map[string]any{
-		"mcpServers": map[string]any{
-			"eas": map[string]any{
-				"command": easBinary,
-				"args":    []string{"mcp", "serve"},
-				"cwd":     workspaceRoot,
-			},
-		},
+// addQuotaWaitFlags registers --wait-for-quota and --max-quota-wait on cmd.
+func addQuotaWaitFlags(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&waitForQuota, "wait-for-quota", false, "Wait for an exhausted primary backend's quota to reopen before failing over")
+	cmd.Flags().DurationVar(&maxQuotaWait, "max-quota-wait", defaultMaxQuotaWait, "Longest wait allowed by --wait-for-quota; longer waits fail over instead")
+}
+
+// addTimeoutFlag registers --timeout on cmd.
+func addTimeoutFlag(cmd *cobra.Command) {
+	cmd.Flags().Var(&taskTimeout, "timeout", "Longest a task's run may take, e.g. 30m (0 = no timeout; default from default_task_timeout)")
+}
+
+// durationFlag is a duration flag that remembers whether it was given, so
+// that an explicit 0 can override a configured default.
+type durationFlag struct {
+	value time.Duration
+	set   bool
+}
+
+func (f *durationFlag) String() string {
+	if !f.set {
+		return ""
 	}
+	return f.value.String()
+}
+
+func (f *durationFlag) Set(s string) error {
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("must be non-negative, got %s", d)
+	}
+	f.value, f.set = d, true
+	return nil
+}
+
+func (f *durationFlag) Type() string {
+	return "duration"
+}
 
-	data, _ := json.MarshalIndent(config, "", "  ")
-	return os.WriteFile(path, data, 0644)
+// ptr returns the flag's value, or nil when it was not given.
+func (f *durationFlag) ptr() *time.Duration {
+	if !f.set {
+		return nil
+	}
+	return &f.value
 }
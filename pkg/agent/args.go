@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/richgo/flo/pkg/logging"
+)
+
+// streamFlags are the CLI flags the stream-json backends set themselves,
+// mapped to whether they take a value. Stream parsing depends on them, so
+// ExtraArgs may not override them.
+var streamFlags = map[string]bool{
+	"--print":                    false,
+	"-p":                         false,
+	"--output-format":            true,
+	"--include-partial-messages": false,
+}
+
+// reservedFlag returns the reserved flag arg sets, if any, and whether its
+// value is the next arg rather than attached with "=".
+func reservedFlag(arg string) (flag string, separateValue bool) {
+	name, _, attached := strings.Cut(arg, "=")
+	takesValue, ok := streamFlags[name]
+	if !ok {
+		return "", false
+	}
+	return name, takesValue && !attached
+}
+
+// CheckExtraArgs reports an error if extra would override a flag the
+// stream-json backends rely on, or end option parsing before the prompt.
+func CheckExtraArgs(extra []string) error {
+	for _, arg := range extra {
+		if arg == "--" {
+			return fmt.Errorf("extra args may not contain '--'; the prompt is always passed last")
+		}
+		if flag, _ := reservedFlag(arg); flag != "" {
+			return fmt.Errorf("extra args may not set %s; flo sets it to read the backend's output", flag)
+		}
+	}
+	return nil
+}
+
+// appendPrompt appends extra, minus any reserved flags, then the prompt.
+// The prompt follows "--" so a trailing flag in extra that expects a value
+// cannot consume it, and a prompt starting with "-" is not read as a flag.
+func appendPrompt(backend string, args, extra []string, prompt string) []string {
+	for i := 0; i < len(extra); i++ {
+		arg := extra[i]
+		if arg == "--" {
+			logging.L().Warn("dropping '--' from extra args", "backend", backend)
+			continue
+		}
+		flag, separateValue := reservedFlag(arg)
+		if flag == "" {
+			args = append(args, arg)
+			continue
+		}
+		dropped := arg
+		if separateValue && i+1 < len(extra) {
+			i++
+			dropped += " " + extra[i]
+		}
+		logging.L().Warn("dropping reserved flag from extra args", "backend", backend, "args", dropped)
+	}
+	return append(args, "--", prompt)
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestBuildArgsReservedExtraArgs(t *testing.T) {
+	extra := []string{"--output-format", "text", "--verbose", "-p", "--output-format=json", "--", "--allowedTools"}
+	builders := map[string]func(string) []string{
+		"claude": func(prompt string) []string {
+			return NewClaudeBackend(ClaudeConfig{ExtraArgs: extra}).buildArgs(task.New("t-001", "Test"), "", prompt)
+		},
+		"codex": func(prompt string) []string {
+			return NewCodexBackend(CodexConfig{ExtraArgs: extra}).buildArgs(task.New("t-001", "Test"), "", prompt)
+		},
+		"gemini": func(prompt string) []string {
+			return NewGeminiBackend(GeminiConfig{ExtraArgs: extra}).buildArgs(task.New("t-001", "Test"), "", prompt)
+		},
+	}
+
+	for name, build := range builders {
+		t.Run(name, func(t *testing.T) {
+			args := build("-v is not a flag")
+			joined := strings.Join(args, " ")
+
+			if strings.Count(joined, "--output-format") != 1 || !strings.Contains(joined, "--output-format stream-json") {
+				t.Errorf("expected only our --output-format stream-json, got %v", args)
+			}
+			if strings.Count(joined, "--print") != 1 || strings.Contains(joined, " -p ") {
+				t.Errorf("expected a single --print, got %v", args)
+			}
+			if !strings.Contains(joined, "--verbose") {
+				t.Errorf("expected unreserved extra args to be kept, got %v", args)
+			}
+
+			// The prompt is the last arg, after a single "--" that a trailing
+			// value flag cannot swallow
+			n := len(args)
+			if args[n-1] != "-v is not a flag" || args[n-2] != "--" || args[n-3] != "--allowedTools" {
+				t.Errorf("expected ... --allowedTools -- prompt, got %v", args)
+			}
+			if strings.Count(joined, " -- ") != 1 {
+				t.Errorf("expected one '--', got %v", args)
+			}
+		})
+	}
+}
+
+func TestCheckExtraArgs(t *testing.T) {
+	tests := []struct {
+		extra   []string
+		wantErr bool
+	}{
+		{[]string{"--dangerously-skip-permissions", "--allowedTools", "Bash"}, false},
+		{[]string{"--output-format", "text"}, true},
+		{[]string{"--output-format=text"}, true},
+		{[]string{"--print"}, true},
+		{[]string{"-p"}, true},
+		{[]string{"--", "extra prompt"}, true},
+	}
+
+	for _, tt := range tests {
+		if err := CheckExtraArgs(tt.extra); (err != nil) != tt.wantErr {
+			t.Errorf("CheckExtraArgs(%v) error = %v, wantErr %v", tt.extra, err, tt.wantErr)
+		}
+	}
+}
+
+func TestClaudeSessionIgnoresOutputFormatExtraArg(t *testing.T) {
+	// A fake claude CLI that honors the last --output-format it is given,
+	// printing plain text for "text" the way the real CLI would
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+format=text
+while [ $# -gt 0 ]; do
+	case "$1" in
+	--output-format) format="$2"; shift ;;
+	--) shift; break ;;
+	esac
+	shift
+done
+if [ "$format" = stream-json ]; then
+	echo "{\"type\":\"assistant\",\"message\":{\"content\":[{\"type\":\"text\",\"text\":\"done: $1\"}]}}"
+else
+	echo "done: $1"
+fi
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli, ExtraArgs: []string{"--output-format", "text"}})
+	session, err := backend.CreateSession(context.Background(), task.New("t-001", "Stream"), "")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	go func() {
+		for range session.Events() {
+		}
+	}()
+
+	result, err := session.Run(context.Background(), "Fix the bug")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if result.Output != "done: Fix the bug" || len(result.Diagnostics) != 0 {
+		t.Errorf("expected parsed stream output, got %q (unparsed %v)", result.Output, result.Diagnostics)
+	}
+}
@@ -3,6 +3,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 
 	"github.com/richgo/flo/pkg/task"
 )
@@ -15,9 +16,18 @@ type Backend interface {
 	CreateSession(ctx context.Context, task *task.Task, worktree string) (Session, error)
 }
 
+// ErrResumeUnsupported is returned by Session.Resume on backends that
+// cannot continue a previous conversation.
+var ErrResumeUnsupported = errors.New("backend does not support resuming conversations")
+
 // Session represents an agent session for executing a task.
 type Session interface {
 	Run(ctx context.Context, prompt string) (*Result, error)
+	// Resume is like Run, but continues the conversation identified by
+	// conversationID, as reported in an earlier Result. Backends without
+	// conversation support return ErrResumeUnsupported before doing any
+	// work, leaving the session usable for Run.
+	Resume(ctx context.Context, conversationID, prompt string) (*Result, error)
 	Events() <-chan Event
 	Destroy(ctx context.Context) error
 }
@@ -27,19 +37,89 @@ type Result struct {
 	Success bool   `json:"success"`
 	Output  string `json:"output"`
 	Error   string `json:"error,omitempty"`
+	Tokens  int    `json:"tokens,omitempty"` // Tokens used, if the backend reports them
+
+	// ConversationID identifies the conversation for Session.Resume, if the
+	// backend reports one
+	ConversationID string `json:"conversation_id,omitempty"`
+
+	// Diagnostics describe backend output that could not be parsed
+	Diagnostics []string `json:"diagnostics,omitempty"`
+
+	// Terminated says how the run ended
+	Terminated Termination `json:"terminated,omitempty"`
+}
+
+// Termination is the reason a run ended.
+type Termination string
+
+// Termination reasons.
+const (
+	TerminatedCompleted Termination = "completed"
+	TerminatedFailed    Termination = "failed"
+	TerminatedCancelled Termination = "cancelled" // The run's context was cancelled
+	TerminatedTimeout   Termination = "timeout"   // The run's context deadline passed
+	TerminatedQuota     Termination = "quota"     // The backend reported a quota error
+	TerminatedTruncated Termination = "truncated" // The agent exceeded the output limit
+)
+
+// terminate sets result.Terminated from ctx and err, the outcome of a
+// session run. A failed run also gets a Result when it has none, so callers
+// can tell a cancelled or quota-limited run from other failures; err is
+// returned unchanged. ErrResumeUnsupported is passed through as is, since
+// no run happened.
+func terminate(ctx context.Context, result *Result, err error) (*Result, error) {
+	if errors.Is(err, ErrResumeUnsupported) {
+		return result, err
+	}
+	if result == nil {
+		if err == nil {
+			return nil, nil
+		}
+		result = &Result{Error: err.Error()}
+	}
+
+	switch {
+	case result.Terminated == TerminatedTruncated:
+		// The session stopped the run itself
+	case err == nil && result.Success:
+		result.Terminated = TerminatedCompleted
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		result.Terminated = TerminatedTimeout
+	case ctx.Err() != nil:
+		result.Terminated = TerminatedCancelled
+	case IsQuotaExhausted(err):
+		result.Terminated = TerminatedQuota
+	default:
+		result.Terminated = TerminatedFailed
+	}
+	return result, err
 }
 
 // Event represents a streaming event during agent execution.
 type Event struct {
-	Type    string `json:"type"`    // "message", "tool_call", "complete", "error"
+	Type    string `json:"type"`    // "message", "tool_call", "usage", "session", "complete", "error"
 	Content string `json:"content"`
+	Usage   *Usage `json:"usage,omitempty"` // Running token totals, set on "usage" events
+}
+
+// Usage holds token counts reported by a backend.
+type Usage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Total returns the combined input and output token count.
+func (u Usage) Total() int {
+	return u.InputTokens + u.OutputTokens
 }
 
 // Call records a call to a mock backend for verification.
 type Call struct {
-	TaskID   string
-	Worktree string
-	Prompt   string
+	TaskID         string
+	Worktree       string
+	Prompt         string
+	ConversationID string // Conversation continued by Resume; empty for Run
 }
 
 // NewBackendByName creates a backend by name.
@@ -65,6 +145,11 @@ func NewBackendByName(name string, config any) Backend {
 			return NewGeminiBackend(*cfg)
 		}
 		return NewGeminiBackend(GeminiConfig{})
+	case "openai":
+		if cfg, ok := config.(*OpenAICompatConfig); ok {
+			return NewOpenAICompatBackend(*cfg)
+		}
+		return NewOpenAICompatBackend(OpenAICompatConfig{})
 	case "mock":
 		return NewMockBackend()
 	default:
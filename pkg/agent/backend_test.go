@@ -2,7 +2,11 @@ package agent
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/richgo/flo/pkg/task"
 )
@@ -163,6 +167,54 @@ func TestClaudeBackendBuildCommand(t *testing.T) {
 	}
 }
 
+func TestClaudeBackendArgsOmitUnset(t *testing.T) {
+	args := NewClaudeBackend(ClaudeConfig{}).buildArgs(task.New("t-001", "Test"), "", "Do something")
+
+	for _, arg := range args {
+		if arg == "--model" || arg == "--mcp-config" || arg == "--cwd" {
+			t.Errorf("unexpected %s without a value configured: %v", arg, args)
+		}
+	}
+	if args[len(args)-1] != "Do something" {
+		t.Errorf("expected the prompt last, got %v", args)
+	}
+}
+
+func TestClaudeBackendThinkingArgs(t *testing.T) {
+	tests := []struct {
+		thinking string
+		want     bool
+	}{
+		{"", false},
+		{ThinkingNormal, false},
+		{ThinkingExtended, true},
+	}
+
+	for _, tt := range tests {
+		backend := NewClaudeBackend(ClaudeConfig{Thinking: tt.thinking})
+		args := backend.buildArgs(task.New("t-001", "Test"), "", "Do something")
+
+		found := false
+		for i, arg := range args {
+			if arg == "--max-thinking-tokens" && i+1 < len(args) && args[i+1] == "31999" {
+				found = true
+			}
+		}
+		if found != tt.want {
+			t.Errorf("thinking %q: expected thinking budget flag %v, got args %v", tt.thinking, tt.want, args)
+		}
+	}
+}
+
+func TestClaudeBackendInvalidThinking(t *testing.T) {
+	backend := NewClaudeBackend(ClaudeConfig{Thinking: "deep"})
+
+	_, err := backend.CreateSession(context.Background(), task.New("t-001", "Test"), "")
+	if err == nil {
+		t.Fatal("expected error for invalid thinking mode")
+	}
+}
+
 func TestNewBackendByName(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -191,3 +243,147 @@ func TestNewBackendByNameUnknown(t *testing.T) {
 		t.Error("expected nil for unknown backend")
 	}
 }
+
+func TestSessionTermination(t *testing.T) {
+	// A fake claude CLI that runs until it is killed, or reports a quota
+	// error when asked to
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+for arg; do prompt="$arg"; done
+if [ "$prompt" = quota ]; then
+	echo '{"type":"result","is_error":true,"result":"rate limit","status":429}'
+	exit 1
+fi
+if [ "$prompt" = fail ]; then
+	exit 1
+fi
+if [ "$prompt" = hang ]; then
+	sleep 30
+fi
+echo '{"type":"assistant","message":{"content":[{"type":"text","text":"done"}]}}'
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	background := func() (context.Context, context.CancelFunc) {
+		return context.WithCancel(context.Background())
+	}
+	cancelled := func() (context.Context, context.CancelFunc) {
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(100*time.Millisecond, cancel)
+		return ctx, cancel
+	}
+	timeout := func() (context.Context, context.CancelFunc) {
+		return context.WithTimeout(context.Background(), 100*time.Millisecond)
+	}
+
+	tests := []struct {
+		prompt  string
+		ctx     func() (context.Context, context.CancelFunc)
+		want    Termination
+		wantErr bool
+	}{
+		{"work", background, TerminatedCompleted, false},
+		{"fail", background, TerminatedFailed, false},
+		{"hang", cancelled, TerminatedCancelled, false},
+		{"hang", timeout, TerminatedTimeout, false},
+		{"quota", background, TerminatedQuota, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.want), func(t *testing.T) {
+			backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli})
+			session, err := backend.CreateSession(context.Background(), task.New("t-001", "Terminate"), "")
+			if err != nil {
+				t.Fatalf("CreateSession failed: %v", err)
+			}
+			go func() {
+				for range session.Events() {
+				}
+			}()
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			result, err := session.Run(ctx, tt.prompt)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Run error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if result == nil || result.Terminated != tt.want {
+				t.Errorf("expected termination %q, got %+v", tt.want, result)
+			}
+		})
+	}
+}
+
+func TestSessionOutputLimit(t *testing.T) {
+	// A fake claude CLI that streams messages until it is killed
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+while :; do
+	echo '{"type":"assistant","message":{"content":[{"type":"text","text":"still going"}]}}'
+done
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	const limit = 4096
+	backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli, MaxOutputBytes: limit})
+	session, err := backend.CreateSession(context.Background(), task.New("t-001", "Runaway"), "")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	var errorEvents []string
+	drained := make(chan struct{})
+	go func() {
+		for ev := range session.Events() {
+			if ev.Type == "error" {
+				errorEvents = append(errorEvents, ev.Content)
+			}
+		}
+		close(drained)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result, err := session.Run(ctx, "Talk forever")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Run only returned once the context expired; the session was not stopped")
+	}
+	<-drained
+
+	if result.Success || result.Terminated != TerminatedTruncated {
+		t.Errorf("expected a failed, truncated result, got %+v", result)
+	}
+	if !strings.Contains(result.Error, "output limit exceeded") {
+		t.Errorf("expected an output limit error, got %q", result.Error)
+	}
+	if len(result.Output) > limit {
+		t.Errorf("expected output capped at %d bytes, got %d", limit, len(result.Output))
+	}
+	if len(errorEvents) != 1 || !strings.Contains(errorEvents[0], "output limit exceeded") {
+		t.Errorf("expected one output limit error event, got %q", errorEvents)
+	}
+}
+
+func TestMockSessionTermination(t *testing.T) {
+	backend := NewScriptedMockBackend(MockQuotaError(), MockError(context.Canceled))
+
+	session, _ := backend.CreateSession(context.Background(), task.New("t-001", "Quota"), "")
+	result, err := session.Run(context.Background(), "Implement it")
+	if !IsQuotaExhausted(err) || result == nil || result.Terminated != TerminatedQuota {
+		t.Errorf("expected quota termination, got %+v (%v)", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	session, _ = backend.CreateSession(ctx, task.New("t-002", "Cancelled"), "")
+	result, err = session.Run(ctx, "Implement it")
+	if err == nil || result == nil || result.Terminated != TerminatedCancelled {
+		t.Errorf("expected cancelled termination, got %+v (%v)", result, err)
+	}
+}
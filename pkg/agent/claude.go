@@ -1,21 +1,45 @@
 package agent
 
 import (
-	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
+	"strings"
 
 	"github.com/richgo/flo/pkg/task"
 )
 
+// Thinking modes accepted by ClaudeConfig.Thinking.
+const (
+	ThinkingNormal   = "normal"
+	ThinkingExtended = "extended"
+)
+
+// ExtendedThinkingBudget is the thinking token budget passed to the Claude
+// CLI in extended thinking mode.
+const ExtendedThinkingBudget = 31999
+
 // ClaudeConfig holds configuration for the Claude backend.
 type ClaudeConfig struct {
-	CLIPath   string   // Path to claude binary
-	Model     string   // Model name
-	MCPConfig string   // Path to MCP config file
-	ExtraArgs []string // Additional CLI arguments
+	CLIPath        string   // Path to claude binary
+	Model          string   // Model name
+	MCPConfig      string   // Path to MCP config file
+	Thinking       string   // Thinking mode: "normal" or "extended" (empty = normal)
+	ExtraArgs      []string // Additional CLI arguments
+	MaxLineBytes   int      // Longest stream-json line accepted (default DefaultMaxLineBytes)
+	MaxOutputBytes int      // Most output read before the run is stopped (default DefaultMaxOutputBytes)
+}
+
+// ValidateThinking checks that mode is a known thinking mode.
+// An empty mode is valid and means normal thinking.
+func ValidateThinking(mode string) error {
+	switch mode {
+	case "", ThinkingNormal, ThinkingExtended:
+		return nil
+	default:
+		return fmt.Errorf("invalid thinking mode '%s' (must be %s or %s)", mode, ThinkingNormal, ThinkingExtended)
+	}
 }
 
 // ClaudeBackend executes tasks using Claude Code CLI.
@@ -44,6 +68,9 @@ func (b *ClaudeBackend) Stop() error {
 }
 
 func (b *ClaudeBackend) CreateSession(ctx context.Context, t *task.Task, worktree string) (Session, error) {
+	if err := ValidateThinking(b.config.Thinking); err != nil {
+		return nil, err
+	}
 	return &ClaudeSession{
 		backend:  b,
 		task:     t,
@@ -56,6 +83,7 @@ func (b *ClaudeBackend) buildArgs(t *task.Task, worktree, prompt string) []strin
 	args := []string{
 		"--print",
 		"--output-format", "stream-json",
+		"--include-partial-messages",
 	}
 
 	if b.config.Model != "" {
@@ -66,14 +94,15 @@ func (b *ClaudeBackend) buildArgs(t *task.Task, worktree, prompt string) []strin
 		args = append(args, "--mcp-config", b.config.MCPConfig)
 	}
 
+	if b.config.Thinking == ThinkingExtended {
+		args = append(args, "--max-thinking-tokens", fmt.Sprint(ExtendedThinkingBudget))
+	}
+
 	if worktree != "" {
 		args = append(args, "--cwd", worktree)
 	}
 
-	args = append(args, b.config.ExtraArgs...)
-	args = append(args, prompt)
-
-	return args
+	return appendPrompt(b.Name(), args, b.config.ExtraArgs, prompt)
 }
 
 // ClaudeSession represents a Claude CLI session.
@@ -82,59 +111,73 @@ type ClaudeSession struct {
 	task     *task.Task
 	worktree string
 	events   chan Event
-	cmd      *exec.Cmd
+	proc     procHandle
 }
 
 func (s *ClaudeSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	result, err := s.run(ctx, "", prompt)
+	return terminate(ctx, result, err)
+}
+
+// Resume continues the Claude session conversationID with --resume.
+func (s *ClaudeSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	if conversationID == "" {
+		return nil, fmt.Errorf("conversation ID is required to resume")
+	}
+	result, err := s.run(ctx, conversationID, prompt)
+	return terminate(ctx, result, err)
+}
+
+// run runs prompt, continuing conversationID when it is non-empty.
+func (s *ClaudeSession) run(ctx context.Context, conversationID, prompt string) (*Result, error) {
 	args := s.backend.buildArgs(s.task, s.worktree, prompt)
-	s.cmd = exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	if conversationID != "" {
+		args = append([]string{"--resume", conversationID}, args...)
+	}
+	cmd := exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	applyTaskEnv(cmd, s.task)
+	startGroup(cmd)
 
-	stdout, err := s.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
-	if err := s.cmd.Start(); err != nil {
+	if err := s.proc.start(cmd); err != nil {
 		return nil, fmt.Errorf("failed to start claude: %w", err)
 	}
 
 	// Read and process output
-	var lastMessage string
-	scanner := bufio.NewScanner(stdout)
-	for scanner.Scan() {
-		line := scanner.Text()
-		
-		var event streamEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			continue // Skip non-JSON lines
-		}
+	output := parseStream(stdout, s.events, s.backend.config.MaxLineBytes, s.backend.config.MaxOutputBytes)
+	close(s.events)
+	logUnparsed("claude", s.task, output)
 
-		switch event.Type {
-		case "assistant":
-			if event.Message != nil && event.Message.Content != nil {
-				for _, block := range event.Message.Content {
-					if block.Type == "text" {
-						lastMessage = block.Text
-						s.events <- Event{Type: "message", Content: block.Text}
-					}
-				}
-			}
-		case "result":
-			s.events <- Event{Type: "complete", Content: "done"}
-		}
+	if output.Truncated {
+		// Stop the runaway agent rather than read the rest of its output
+		s.Destroy(ctx)
+		cmd.Wait()
+		return output.truncatedResult(), nil
 	}
-	close(s.events)
 
-	if err := s.cmd.Wait(); err != nil {
+	if err := cmd.Wait(); err != nil {
+		// Quota failures surface as errors so the runner can fail over
+		if qe := classifyExit("claude", err, output.Failure); qe != nil {
+			return nil, qe
+		}
 		return &Result{
-			Success: false,
-			Error:   err.Error(),
+			Success:        false,
+			Error:          output.ErrorText(err),
+			Diagnostics:    output.Diagnostics(),
+			ConversationID: output.SessionID,
 		}, nil
 	}
 
 	return &Result{
-		Success: true,
-		Output:  lastMessage,
+		Success:        true,
+		Output:         output.LastMessage,
+		Tokens:         output.Usage.Total(),
+		Diagnostics:    output.Diagnostics(),
+		ConversationID: output.SessionID,
 	}, nil
 }
 
@@ -143,23 +186,64 @@ func (s *ClaudeSession) Events() <-chan Event {
 }
 
 func (s *ClaudeSession) Destroy(ctx context.Context) error {
-	if s.cmd != nil && s.cmd.Process != nil {
-		s.cmd.Process.Kill()
-	}
-	return nil
+	return s.proc.kill()
 }
 
 // streamEvent represents a Claude CLI stream-json event.
 type streamEvent struct {
-	Type    string        `json:"type"`
+	Type    string         `json:"type"`
 	Message *streamMessage `json:"message,omitempty"`
+	Usage   *streamUsage   `json:"usage,omitempty"`
+	IsError bool           `json:"is_error,omitempty"` // Set on a failed result
+	Result  string         `json:"result,omitempty"`   // Final text; the error message when IsError
+	Error   *ErrorPayload  `json:"error,omitempty"`    // Structured provider error, if reported
+	Event   *partialEvent  `json:"event,omitempty"`    // Wrapped API event on a stream_event
+	Delta   *contentDelta  `json:"delta,omitempty"`    // Incremental content on a content_block_delta
+
+	SessionID string `json:"session_id,omitempty"` // Conversation the event belongs to
+}
+
+// partialEvent is an API streaming event wrapped in a stream_event, as
+// emitted with --include-partial-messages.
+type partialEvent struct {
+	Type  string        `json:"type"`
+	Delta *contentDelta `json:"delta,omitempty"`
+}
+
+type contentDelta struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
 }
 
 type streamMessage struct {
 	Content []contentBlock `json:"content,omitempty"`
+	Usage   *streamUsage   `json:"usage,omitempty"`
+}
+
+type streamUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
 }
 
 type contentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type  string          `json:"type"`
+	Text  string          `json:"text,omitempty"`
+	Name  string          `json:"name,omitempty"`  // Tool name on a tool_use block
+	Input json.RawMessage `json:"input,omitempty"` // Tool arguments on a tool_use block
+}
+
+// maxToolCallInput caps the tool arguments shown in a tool_call event.
+const maxToolCallInput = 200
+
+// toolCall describes a tool_use block for a tool_call event: the tool name
+// followed by its arguments, truncated to maxToolCallInput bytes.
+func (b contentBlock) toolCall() string {
+	input := strings.TrimSpace(string(b.Input))
+	if input == "" || input == "{}" {
+		return b.Name
+	}
+	if len(input) > maxToolCallInput {
+		input = input[:maxToolCallInput] + "..."
+	}
+	return b.Name + " " + input
 }
@@ -1,9 +1,7 @@
 package agent
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os/exec"
 
@@ -12,10 +10,12 @@ import (
 
 // CodexConfig holds configuration for the Codex backend.
 type CodexConfig struct {
-	CLIPath   string   // Path to codex binary
-	Model     string   // Model name
-	MCPConfig string   // Path to MCP config file
-	ExtraArgs []string // Additional CLI arguments
+	CLIPath        string   // Path to codex binary
+	Model          string   // Model name
+	MCPConfig      string   // Path to MCP config file
+	ExtraArgs      []string // Additional CLI arguments
+	MaxLineBytes   int      // Longest stream-json line accepted (default DefaultMaxLineBytes)
+	MaxOutputBytes int      // Most output read before the run is stopped (default DefaultMaxOutputBytes)
 }
 
 // CodexBackend executes tasks using Codex CLI.
@@ -70,10 +70,7 @@ func (b *CodexBackend) buildArgs(t *task.Task, worktree, prompt string) []string
 		args = append(args, "--cwd", worktree)
 	}
 
-	args = append(args, b.config.ExtraArgs...)
-	args = append(args, prompt)
-
-	return args
+	return appendPrompt(b.Name(), args, b.config.ExtraArgs, prompt)
 }
 
 // CodexSession represents a Codex CLI session.
@@ -82,69 +79,70 @@ type CodexSession struct {
 	task     *task.Task
 	worktree string
 	events   chan Event
-	cmd      *exec.Cmd
+	proc     procHandle
 }
 
 func (s *CodexSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	result, err := s.run(ctx, prompt)
+	return terminate(ctx, result, err)
+}
+
+func (s *CodexSession) run(ctx context.Context, prompt string) (*Result, error) {
 	args := s.backend.buildArgs(s.task, s.worktree, prompt)
-	s.cmd = exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	cmd := exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	applyTaskEnv(cmd, s.task)
+	startGroup(cmd)
 
-	stdout, err := s.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
-	if err := s.cmd.Start(); err != nil {
+	if err := s.proc.start(cmd); err != nil {
 		return nil, fmt.Errorf("failed to start codex: %w", err)
 	}
 
 	// Read and process output
-	var lastMessage string
-	scanner := bufio.NewScanner(stdout)
-	for scanner.Scan() {
-		line := scanner.Text()
-		
-		var event streamEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			continue // Skip non-JSON lines
-		}
+	output := parseStream(stdout, s.events, s.backend.config.MaxLineBytes, s.backend.config.MaxOutputBytes)
+	close(s.events)
+	logUnparsed("codex", s.task, output)
 
-		switch event.Type {
-		case "assistant":
-			if event.Message != nil && event.Message.Content != nil {
-				for _, block := range event.Message.Content {
-					if block.Type == "text" {
-						lastMessage = block.Text
-						s.events <- Event{Type: "message", Content: block.Text}
-					}
-				}
-			}
-		case "result":
-			s.events <- Event{Type: "complete", Content: "done"}
-		}
+	if output.Truncated {
+		// Stop the runaway agent rather than read the rest of its output
+		s.Destroy(ctx)
+		cmd.Wait()
+		return output.truncatedResult(), nil
 	}
-	close(s.events)
 
-	if err := s.cmd.Wait(); err != nil {
+	if err := cmd.Wait(); err != nil {
+		// Quota failures surface as errors so the runner can fail over
+		if qe := classifyExit("codex", err, output.Failure); qe != nil {
+			return nil, qe
+		}
 		return &Result{
-			Success: false,
-			Error:   err.Error(),
+			Success:     false,
+			Error:       output.ErrorText(err),
+			Diagnostics: output.Diagnostics(),
 		}, nil
 	}
 
 	return &Result{
-		Success: true,
-		Output:  lastMessage,
+		Success:     true,
+		Output:      output.LastMessage,
+		Tokens:      output.Usage.Total(),
+		Diagnostics: output.Diagnostics(),
 	}, nil
 }
 
+// Resume is not supported; the CLI runs every prompt in a fresh conversation.
+func (s *CodexSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	return nil, ErrResumeUnsupported
+}
+
 func (s *CodexSession) Events() <-chan Event {
 	return s.events
 }
 
 func (s *CodexSession) Destroy(ctx context.Context) error {
-	if s.cmd != nil && s.cmd.Process != nil {
-		s.cmd.Process.Kill()
-	}
-	return nil
+	return s.proc.kill()
 }
@@ -71,11 +71,17 @@ func (s *CopilotSession) Run(ctx context.Context, prompt string) (*Result, error
 	// For now, return a placeholder
 	close(s.events)
 	return &Result{
-		Success: false,
-		Error:   fmt.Sprintf("Copilot backend not yet implemented - requires SDK dependency"),
+		Success:    false,
+		Error:      fmt.Sprintf("Copilot backend not yet implemented - requires SDK dependency"),
+		Terminated: TerminatedFailed,
 	}, nil
 }
 
+// Resume is not supported until the SDK integration lands.
+func (s *CopilotSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	return nil, ErrResumeUnsupported
+}
+
 func (s *CopilotSession) Events() <-chan Event {
 	return s.events
 }
@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/richgo/flo/pkg/logging"
+	"github.com/richgo/flo/pkg/secrets"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// taskEnv returns the environment for a backend process running t: the
+// parent environment with t.Env merged over it. It returns nil, meaning
+// "inherit the parent environment", when the task sets no variables.
+func taskEnv(t *task.Task) []string {
+	if t == nil || len(t.Env) == 0 {
+		return nil
+	}
+
+	env := make([]string, 0, len(os.Environ())+len(t.Env))
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if _, overridden := t.Env[key]; !overridden {
+			env = append(env, kv)
+		}
+	}
+
+	keys := make([]string, 0, len(t.Env))
+	for key := range t.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		env = append(env, key+"="+t.Env[key])
+	}
+	return env
+}
+
+// applyTaskEnv sets cmd's environment from t. Values are redacted before
+// they are logged.
+func applyTaskEnv(cmd *exec.Cmd, t *task.Task) {
+	env := taskEnv(t)
+	if env == nil {
+		return
+	}
+	cmd.Env = env
+	logging.L().Debug("task environment", "task", t.ID, "env", secrets.RedactEnv(t.Env))
+}
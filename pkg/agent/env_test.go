@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestClaudeSessionTaskEnv(t *testing.T) {
+	// A fake claude CLI that reports its environment as the assistant message
+	cli := filepath.Join(t.TempDir(), "claude")
+	script := `#!/bin/sh
+echo "{\"type\":\"assistant\",\"message\":{\"content\":[{\"type\":\"text\",\"text\":\"$FLO_TEST_DB|$FLO_TEST_INHERITED\"}]}}"
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("FLO_TEST_DB", "parent")
+	t.Setenv("FLO_TEST_INHERITED", "kept")
+
+	tk := task.New("t-001", "Needs a database")
+	tk.Env = map[string]string{"FLO_TEST_DB": "postgres://localhost/test"}
+
+	backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli})
+	session, err := backend.CreateSession(context.Background(), tk, "")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	go func() {
+		for range session.Events() {
+		}
+	}()
+
+	result, err := session.Run(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if want := "postgres://localhost/test|kept"; result.Output != want {
+		t.Errorf("expected child env %q, got %q", want, result.Output)
+	}
+}
+
+func TestTaskEnvNoOverrides(t *testing.T) {
+	if env := taskEnv(task.New("t-001", "Plain")); env != nil {
+		t.Errorf("expected nil env to inherit the parent, got %d entries", len(env))
+	}
+}
@@ -1,9 +1,7 @@
 package agent
 
 import (
-	"bufio"
 	"context"
-	"encoding/json"
 	"fmt"
 	"os/exec"
 
@@ -12,10 +10,12 @@ import (
 
 // GeminiConfig holds configuration for the Gemini backend.
 type GeminiConfig struct {
-	CLIPath   string   // Path to gemini binary
-	Model     string   // Model name
-	MCPConfig string   // Path to MCP config file
-	ExtraArgs []string // Additional CLI arguments
+	CLIPath        string   // Path to gemini binary
+	Model          string   // Model name
+	MCPConfig      string   // Path to MCP config file
+	ExtraArgs      []string // Additional CLI arguments
+	MaxLineBytes   int      // Longest stream-json line accepted (default DefaultMaxLineBytes)
+	MaxOutputBytes int      // Most output read before the run is stopped (default DefaultMaxOutputBytes)
 }
 
 // GeminiBackend executes tasks using Gemini CLI.
@@ -70,10 +70,7 @@ func (b *GeminiBackend) buildArgs(t *task.Task, worktree, prompt string) []strin
 		args = append(args, "--cwd", worktree)
 	}
 
-	args = append(args, b.config.ExtraArgs...)
-	args = append(args, prompt)
-
-	return args
+	return appendPrompt(b.Name(), args, b.config.ExtraArgs, prompt)
 }
 
 // GeminiSession represents a Gemini CLI session.
@@ -82,69 +79,70 @@ type GeminiSession struct {
 	task     *task.Task
 	worktree string
 	events   chan Event
-	cmd      *exec.Cmd
+	proc     procHandle
 }
 
 func (s *GeminiSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	result, err := s.run(ctx, prompt)
+	return terminate(ctx, result, err)
+}
+
+func (s *GeminiSession) run(ctx context.Context, prompt string) (*Result, error) {
 	args := s.backend.buildArgs(s.task, s.worktree, prompt)
-	s.cmd = exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	cmd := exec.CommandContext(ctx, s.backend.config.CLIPath, args...)
+	applyTaskEnv(cmd, s.task)
+	startGroup(cmd)
 
-	stdout, err := s.cmd.StdoutPipe()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
 	}
 
-	if err := s.cmd.Start(); err != nil {
+	if err := s.proc.start(cmd); err != nil {
 		return nil, fmt.Errorf("failed to start gemini: %w", err)
 	}
 
 	// Read and process output
-	var lastMessage string
-	scanner := bufio.NewScanner(stdout)
-	for scanner.Scan() {
-		line := scanner.Text()
-		
-		var event streamEvent
-		if err := json.Unmarshal([]byte(line), &event); err != nil {
-			continue // Skip non-JSON lines
-		}
+	output := parseStream(stdout, s.events, s.backend.config.MaxLineBytes, s.backend.config.MaxOutputBytes)
+	close(s.events)
+	logUnparsed("gemini", s.task, output)
 
-		switch event.Type {
-		case "assistant":
-			if event.Message != nil && event.Message.Content != nil {
-				for _, block := range event.Message.Content {
-					if block.Type == "text" {
-						lastMessage = block.Text
-						s.events <- Event{Type: "message", Content: block.Text}
-					}
-				}
-			}
-		case "result":
-			s.events <- Event{Type: "complete", Content: "done"}
-		}
+	if output.Truncated {
+		// Stop the runaway agent rather than read the rest of its output
+		s.Destroy(ctx)
+		cmd.Wait()
+		return output.truncatedResult(), nil
 	}
-	close(s.events)
 
-	if err := s.cmd.Wait(); err != nil {
+	if err := cmd.Wait(); err != nil {
+		// Quota failures surface as errors so the runner can fail over
+		if qe := classifyExit("gemini", err, output.Failure); qe != nil {
+			return nil, qe
+		}
 		return &Result{
-			Success: false,
-			Error:   err.Error(),
+			Success:     false,
+			Error:       output.ErrorText(err),
+			Diagnostics: output.Diagnostics(),
 		}, nil
 	}
 
 	return &Result{
-		Success: true,
-		Output:  lastMessage,
+		Success:     true,
+		Output:      output.LastMessage,
+		Tokens:      output.Usage.Total(),
+		Diagnostics: output.Diagnostics(),
 	}, nil
 }
 
+// Resume is not supported; the CLI runs every prompt in a fresh conversation.
+func (s *GeminiSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	return nil, ErrResumeUnsupported
+}
+
 func (s *GeminiSession) Events() <-chan Event {
 	return s.events
 }
 
 func (s *GeminiSession) Destroy(ctx context.Context) error {
-	if s.cmd != nil && s.cmd.Process != nil {
-		s.cmd.Process.Kill()
-	}
-	return nil
+	return s.proc.kill()
 }
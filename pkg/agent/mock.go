@@ -2,6 +2,7 @@ package agent
 
 import (
 	"context"
+	"fmt"
 	"sync"
 
 	"github.com/richgo/flo/pkg/task"
@@ -13,6 +14,41 @@ type MockBackend struct {
 	calls    []Call
 	response Result
 	events   []Event
+	steps    []MockStep // Scripted outcomes, consumed one per run
+	scripted bool
+}
+
+// MockStep is one scripted outcome of a mock session run.
+type MockStep struct {
+	Result Result  // Returned when Err is nil
+	Err    error   // Returned instead of a result when set
+	Events []Event // Emitted before the run returns
+}
+
+// MockSuccess scripts a successful run with the given output and tokens.
+func MockSuccess(output string, tokens int) MockStep {
+	return MockStep{Result: Result{Success: true, Output: output, Tokens: tokens}}
+}
+
+// MockConversation scripts a successful run that reports conversationID,
+// which later runs can pass to Resume.
+func MockConversation(output, conversationID string) MockStep {
+	return MockStep{Result: Result{Success: true, Output: output, ConversationID: conversationID}}
+}
+
+// MockFailure scripts a run that completes unsuccessfully with errMsg.
+func MockFailure(errMsg string) MockStep {
+	return MockStep{Result: Result{Success: false, Error: errMsg}}
+}
+
+// MockError scripts a run that fails with err.
+func MockError(err error) MockStep {
+	return MockStep{Err: err}
+}
+
+// MockQuotaError scripts a run rejected with a 429 quota error.
+func MockQuotaError() MockStep {
+	return MockStep{Err: &QuotaError{Backend: "mock", Status: 429, Message: "simulated rate limit"}}
 }
 
 // NewMockBackend creates a new mock backend.
@@ -22,6 +58,16 @@ func NewMockBackend() *MockBackend {
 	}
 }
 
+// NewScriptedMockBackend creates a mock backend whose runs return steps in
+// order, across all of its sessions. Runs beyond the script fail, so an
+// unexpected extra run is caught rather than silently succeeding.
+func NewScriptedMockBackend(steps ...MockStep) *MockBackend {
+	return &MockBackend{
+		steps:    append([]MockStep(nil), steps...),
+		scripted: true,
+	}
+}
+
 func (m *MockBackend) Name() string {
 	return "mock"
 }
@@ -64,22 +110,37 @@ func (m *MockBackend) GetCalls() []Call {
 	return append([]Call{}, m.calls...)
 }
 
-func (m *MockBackend) recordCall(call Call) {
+// Prompts returns the prompts received by all runs, in order.
+func (m *MockBackend) Prompts() []string {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	m.calls = append(m.calls, call)
+	prompts := make([]string, len(m.calls))
+	for i, call := range m.calls {
+		prompts[i] = call.Prompt
+	}
+	return prompts
 }
 
-func (m *MockBackend) getResponse() Result {
+func (m *MockBackend) recordCall(call Call) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.response
+	m.calls = append(m.calls, call)
 }
 
-func (m *MockBackend) getEvents() []Event {
+// nextStep returns the outcome of the next run: the next scripted step, or
+// the configured response and events when the backend is not scripted.
+func (m *MockBackend) nextStep() MockStep {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return append([]Event{}, m.events...)
+	if !m.scripted {
+		return MockStep{Result: m.response, Events: append([]Event{}, m.events...)}
+	}
+	if len(m.steps) == 0 {
+		return MockStep{Err: fmt.Errorf("mock script has no step for run %d", len(m.calls))}
+	}
+	step := m.steps[0]
+	m.steps = m.steps[1:]
+	return step
 }
 
 // MockSession is a mock session for testing.
@@ -91,21 +152,38 @@ type MockSession struct {
 }
 
 func (s *MockSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	result, err := s.run("", prompt)
+	return terminate(ctx, result, err)
+}
+
+// Resume records conversationID on the call and otherwise behaves like Run,
+// so tests can check which conversation a run continued.
+func (s *MockSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	result, err := s.run(conversationID, prompt)
+	return terminate(ctx, result, err)
+}
+
+func (s *MockSession) run(conversationID, prompt string) (*Result, error) {
 	// Record the call
 	s.backend.recordCall(Call{
-		TaskID:   s.task.ID,
-		Worktree: s.worktree,
-		Prompt:   prompt,
+		TaskID:         s.task.ID,
+		Worktree:       s.worktree,
+		Prompt:         prompt,
+		ConversationID: conversationID,
 	})
 
+	step := s.backend.nextStep()
+
 	// Emit events
-	for _, event := range s.backend.getEvents() {
+	for _, event := range step.Events {
 		s.events <- event
 	}
 	close(s.events)
 
-	// Return configured response
-	result := s.backend.getResponse()
+	if step.Err != nil {
+		return nil, step.Err
+	}
+	result := step.Result
 	return &result, nil
 }
 
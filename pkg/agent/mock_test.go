@@ -0,0 +1,82 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// runMock runs one session on backend with prompt.
+func runMock(t *testing.T, backend Backend, prompt string) (*Result, []Event, error) {
+	t.Helper()
+	session, err := backend.CreateSession(context.Background(), task.New("t-001", "Mock"), "")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	result, err := session.Run(context.Background(), prompt)
+
+	var events []Event
+	for e := range session.Events() {
+		events = append(events, e)
+	}
+	return result, events, err
+}
+
+func TestScriptedMockBackend(t *testing.T) {
+	boom := errors.New("boom")
+	backend := NewScriptedMockBackend(
+		MockStep{Result: Result{Success: true, Output: "first"}, Events: []Event{{Type: "message", Content: "hi"}}},
+		MockFailure("tests failed"),
+		MockError(boom),
+		MockQuotaError(),
+	)
+
+	result, events, err := runMock(t, backend, "one")
+	if err != nil || !result.Success || result.Output != "first" {
+		t.Errorf("step 1: expected success, got %+v, %v", result, err)
+	}
+	if len(events) != 1 || events[0].Content != "hi" {
+		t.Errorf("step 1: expected scripted event, got %+v", events)
+	}
+
+	result, _, err = runMock(t, backend, "two")
+	if err != nil || result.Success || result.Error != "tests failed" {
+		t.Errorf("step 2: expected unsuccessful result, got %+v, %v", result, err)
+	}
+
+	if _, _, err = runMock(t, backend, "three"); !errors.Is(err, boom) {
+		t.Errorf("step 3: expected scripted error, got %v", err)
+	}
+
+	if _, _, err = runMock(t, backend, "four"); !IsQuotaExhausted(err) {
+		t.Errorf("step 4: expected quota error, got %v", err)
+	}
+
+	if _, _, err = runMock(t, backend, "five"); err == nil {
+		t.Error("expected error once the script is exhausted")
+	}
+
+	want := []string{"one", "two", "three", "four", "five"}
+	got := backend.Prompts()
+	if len(got) != len(want) {
+		t.Fatalf("expected %d prompts, got %q", len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("prompt %d: expected %q, got %q", i, want[i], got[i])
+		}
+	}
+}
+
+func TestMockBackendUnscriptedDefaults(t *testing.T) {
+	backend := NewMockBackend()
+
+	for i := 0; i < 2; i++ {
+		result, _, err := runMock(t, backend, "again")
+		if err != nil || !result.Success {
+			t.Errorf("run %d: expected canned success, got %+v, %v", i, result, err)
+		}
+	}
+}
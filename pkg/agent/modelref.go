@@ -0,0 +1,17 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseModelRef splits a "backend/model" reference, such as "claude/opus",
+// into its backend and model. Both parts must be non-empty, and the model
+// may not contain another slash.
+func ParseModelRef(s string) (backend, model string, err error) {
+	backend, model, ok := strings.Cut(s, "/")
+	if !ok || backend == "" || model == "" || strings.Contains(model, "/") {
+		return "", "", fmt.Errorf("model '%s' must be in backend/model form", s)
+	}
+	return backend, model, nil
+}
@@ -0,0 +1,31 @@
+package agent
+
+import "testing"
+
+func TestParseModelRef(t *testing.T) {
+	tests := []struct {
+		ref         string
+		wantBackend string
+		wantModel   string
+		wantErr     bool
+	}{
+		{"claude/opus", "claude", "opus", false},
+		{"copilot/gpt-4.1", "copilot", "gpt-4.1", false},
+		{"claude", "", "", true},
+		{"claude/", "", "", true},
+		{"/opus", "", "", true},
+		{"a/b/c", "", "", true},
+		{"", "", "", true},
+	}
+
+	for _, tt := range tests {
+		backend, model, err := ParseModelRef(tt.ref)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseModelRef(%q) error = %v, wantErr %v", tt.ref, err, tt.wantErr)
+			continue
+		}
+		if backend != tt.wantBackend || model != tt.wantModel {
+			t.Errorf("ParseModelRef(%q) = %q, %q, want %q, %q", tt.ref, backend, model, tt.wantBackend, tt.wantModel)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+package agent
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// OpenAICompatConfig holds configuration for the OpenAI-compatible backend.
+type OpenAICompatConfig struct {
+	Model        string          // Model name sent with each request
+	Provider     *ProviderConfig // Endpoint; BaseURL is required, APIKeyEnv optional
+	Client       *http.Client    // HTTP client (default http.DefaultClient)
+	MaxLineBytes int             // Longest SSE line accepted (default DefaultMaxLineBytes)
+
+	// MaxOutputBytes is the most of the stream read before the run is
+	// stopped (default DefaultMaxOutputBytes)
+	MaxOutputBytes int
+}
+
+// OpenAICompatBackend executes tasks against any server implementing the
+// OpenAI chat completions API, such as vLLM. It sends the prompt as a single
+// user message; the model has no tool or MCP access.
+type OpenAICompatBackend struct {
+	config OpenAICompatConfig
+}
+
+// NewOpenAICompatBackend creates a new OpenAI-compatible backend.
+func NewOpenAICompatBackend(config OpenAICompatConfig) *OpenAICompatBackend {
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &OpenAICompatBackend{config: config}
+}
+
+func (b *OpenAICompatBackend) Name() string {
+	return "openai"
+}
+
+func (b *OpenAICompatBackend) Start(ctx context.Context) error {
+	return nil
+}
+
+func (b *OpenAICompatBackend) Stop() error {
+	return nil
+}
+
+func (b *OpenAICompatBackend) CreateSession(ctx context.Context, t *task.Task, worktree string) (Session, error) {
+	if b.config.Provider == nil || b.config.Provider.BaseURL == "" {
+		return nil, fmt.Errorf("openai backend requires a provider base URL")
+	}
+	return &OpenAICompatSession{
+		backend:  b,
+		task:     t,
+		worktree: worktree,
+		events:   make(chan Event, 100),
+	}, nil
+}
+
+// chatRequest is the body of a /chat/completions request.
+type chatRequest struct {
+	Model         string             `json:"model,omitempty"`
+	Messages      []chatMessage      `json:"messages"`
+	Stream        bool               `json:"stream"`
+	StreamOptions *chatStreamOptions `json:"stream_options,omitempty"`
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatStreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// chatChunk is one streamed chat.completion.chunk.
+type chatChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+	} `json:"usage,omitempty"`
+}
+
+// chatError is the error body returned on a failed request.
+type chatError struct {
+	Error struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// OpenAICompatSession represents one streamed chat completion.
+type OpenAICompatSession struct {
+	backend  *OpenAICompatBackend
+	task     *task.Task
+	worktree string
+	events   chan Event
+
+	// mu guards cancel and destroyed, which Destroy uses from another
+	// goroutine
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	destroyed bool
+}
+
+func (s *OpenAICompatSession) Run(ctx context.Context, prompt string) (*Result, error) {
+	result, err := s.run(ctx, prompt)
+	return terminate(ctx, result, err)
+}
+
+func (s *OpenAICompatSession) run(ctx context.Context, prompt string) (*Result, error) {
+	defer close(s.events)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	s.mu.Lock()
+	s.cancel = cancel
+	if s.destroyed {
+		cancel()
+	}
+	s.mu.Unlock()
+
+	cfg := s.backend.config
+	body, err := json.Marshal(chatRequest{
+		Model:         cfg.Model,
+		Messages:      []chatMessage{{Role: "user", Content: prompt}},
+		Stream:        true,
+		StreamOptions: &chatStreamOptions{IncludeUsage: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	url := strings.TrimRight(cfg.Provider.BaseURL, "/") + "/chat/completions"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if cfg.Provider.APIKeyEnv != "" {
+		if key := os.Getenv(cfg.Provider.APIKeyEnv); key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+	}
+
+	resp, err := cfg.Client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("openai request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		payload := readChatError(resp)
+		if payload.IsQuota() {
+			retryAfter, ok := parseRetryAfterHeader(resp.Header.Get("Retry-After"), time.Now())
+			if !ok {
+				retryAfter, _ = ParseRetryAfter(payload.Message)
+			}
+			return nil, &QuotaError{
+				Backend:    "openai",
+				Status:     payload.Status,
+				Type:       payload.Type,
+				Message:    payload.Message,
+				RetryAfter: retryAfter,
+			}
+		}
+		return &Result{Success: false, Error: fmt.Sprintf("openai request failed: %s", payload.Message)}, nil
+	}
+
+	output, usage, err := s.readStream(resp.Body)
+	if errors.Is(err, errOutputLimit) {
+		// Returning cancels the request, closing the stream
+		return &Result{Success: false, Output: output, Error: err.Error(), Tokens: usage.Total(), Terminated: TerminatedTruncated}, nil
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return &Result{Success: false, Output: output, Error: err.Error(), Tokens: usage.Total()}, nil
+	}
+
+	return &Result{
+		Success: true,
+		Output:  output,
+		Tokens:  usage.Total(),
+	}, nil
+}
+
+// readStream reads server-sent chat chunks from r, forwarding content deltas
+// as message events and, once the stream ends, usage and complete events.
+// It returns the accumulated content and the reported usage. Reading stops
+// with an error event and errOutputLimit once the stream passes
+// MaxOutputBytes.
+func (s *OpenAICompatSession) readStream(r io.Reader) (string, Usage, error) {
+	var content strings.Builder
+	var usage Usage
+	reported := false
+	limit := outputLimit(s.backend.config.MaxOutputBytes)
+	read := 0
+
+	maxLine := s.backend.config.MaxLineBytes
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineBytes
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	done := false
+	for !done && scanner.Scan() {
+		if read += len(scanner.Bytes()) + 1; read > limit {
+			err := outputLimitError(limit)
+			s.events <- Event{Type: "error", Content: err.Error()}
+			return content.String(), usage, err
+		}
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue // Blank separators, comments and other SSE fields
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			done = true
+			continue
+		}
+
+		var chunk chatChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return content.String(), usage, fmt.Errorf("invalid stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				content.WriteString(choice.Delta.Content)
+				s.events <- Event{Type: "message", Content: choice.Delta.Content}
+			}
+		}
+		if chunk.Usage != nil {
+			usage = Usage{InputTokens: chunk.Usage.PromptTokens, OutputTokens: chunk.Usage.CompletionTokens}
+			reported = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), usage, fmt.Errorf("failed to read stream: %w", err)
+	}
+	if !done {
+		return content.String(), usage, fmt.Errorf("stream ended before completion")
+	}
+
+	if reported {
+		u := usage
+		s.events <- Event{
+			Type:    "usage",
+			Content: fmt.Sprintf("%d in / %d out", u.InputTokens, u.OutputTokens),
+			Usage:   &u,
+		}
+	}
+	s.events <- Event{Type: "complete", Content: "done"}
+	return content.String(), usage, nil
+}
+
+// readChatError reads the error payload of a failed response, falling back
+// to the status text when the body is not an OpenAI error object.
+func readChatError(resp *http.Response) *ErrorPayload {
+	payload := &ErrorPayload{Status: resp.StatusCode, Message: resp.Status}
+
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	var body chatError
+	if err := json.Unmarshal(data, &body); err == nil && body.Error.Message != "" {
+		payload.Type = body.Error.Type
+		payload.Message = body.Error.Message
+	} else if text := strings.TrimSpace(string(data)); text != "" {
+		payload.Message = text
+	}
+	return payload
+}
+
+// Resume is not supported; chat completions are stateless and each run
+// sends a single message.
+func (s *OpenAICompatSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	return nil, ErrResumeUnsupported
+}
+
+func (s *OpenAICompatSession) Events() <-chan Event {
+	return s.events
+}
+
+// Destroy cancels the request. Destroying before Run cancels the request
+// as soon as Run starts it.
+func (s *OpenAICompatSession) Destroy(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.destroyed = true
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// sseServer serves chunks as a chat completions event stream and records
+// the decoded request.
+func sseServer(t *testing.T, chunks []string, got *chatRequest, auth *string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			http.NotFound(w, r)
+			return
+		}
+		if got != nil {
+			json.NewDecoder(r.Body).Decode(got)
+		}
+		if auth != nil {
+			*auth = r.Header.Get("Authorization")
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+			w.(http.Flusher).Flush()
+		}
+	}))
+}
+
+func runOpenAI(t *testing.T, cfg OpenAICompatConfig) (*Result, []Event, error) {
+	t.Helper()
+	backend := NewOpenAICompatBackend(cfg)
+	session, err := backend.CreateSession(context.Background(), task.New("t-001", "Chat"), "")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+
+	done := make(chan []Event)
+	go func() {
+		var events []Event
+		for e := range session.Events() {
+			events = append(events, e)
+		}
+		done <- events
+	}()
+
+	result, err := session.Run(context.Background(), "Write a haiku")
+	return result, <-done, err
+}
+
+func TestOpenAICompatStream(t *testing.T) {
+	t.Setenv("TEST_OPENAI_KEY", "sk-local")
+	chunks := []string{
+		`{"choices":[{"delta":{"role":"assistant"}}]}`,
+		`{"choices":[{"delta":{"content":"Hello"}}]}`,
+		`{"choices":[{"delta":{"content":", world"},"finish_reason":"stop"}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":12,"completion_tokens":4}}`,
+		`[DONE]`,
+	}
+	var req chatRequest
+	var auth string
+	server := sseServer(t, chunks, &req, &auth)
+	defer server.Close()
+
+	result, events, err := runOpenAI(t, OpenAICompatConfig{
+		Model:    "qwen-coder",
+		Provider: &ProviderConfig{Type: "openai", BaseURL: server.URL + "/v1/", APIKeyEnv: "TEST_OPENAI_KEY"},
+	})
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if !result.Success || result.Output != "Hello, world" || result.Tokens != 16 {
+		t.Errorf("unexpected result: %+v", result)
+	}
+
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	if got := strings.Join(types, " "); got != "message message usage complete" {
+		t.Errorf("expected message message usage complete, got %q", got)
+	}
+	if u := events[2].Usage; u == nil || *u != (Usage{12, 4}) {
+		t.Errorf("expected usage 12/4, got %+v", events[2].Usage)
+	}
+
+	if req.Model != "qwen-coder" || !req.Stream || len(req.Messages) != 1 || req.Messages[0].Content != "Write a haiku" {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if auth != "Bearer sk-local" {
+		t.Errorf("expected bearer auth from the key env, got %q", auth)
+	}
+}
+
+func TestOpenAICompatErrors(t *testing.T) {
+	t.Run("rate limit is a quota error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"rate_limit_exceeded"}}`)
+		}))
+		defer server.Close()
+
+		_, _, err := runOpenAI(t, OpenAICompatConfig{Provider: &ProviderConfig{BaseURL: server.URL}})
+		var qe *QuotaError
+		if !errors.As(err, &qe) || qe.Backend != "openai" || qe.Status != 429 {
+			t.Fatalf("expected openai QuotaError, got %v", err)
+		}
+	})
+
+	t.Run("retry-after header sets the cooldown", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "30")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"Rate limit reached","type":"rate_limit_exceeded"}}`)
+		}))
+		defer server.Close()
+
+		_, _, err := runOpenAI(t, OpenAICompatConfig{Provider: &ProviderConfig{BaseURL: server.URL}})
+		if got, ok := RetryAfterOf(err); !ok || got != 30*time.Second {
+			t.Fatalf("expected a 30s retry-after, got %s (%v)", got, err)
+		}
+	})
+
+	t.Run("output limit stops the stream", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for i := 0; i < 1000 && r.Context().Err() == nil; i++ {
+				fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"delta":{"content":"more "}}]}`)
+			}
+		}))
+		defer server.Close()
+
+		result, events, err := runOpenAI(t, OpenAICompatConfig{Provider: &ProviderConfig{BaseURL: server.URL}, MaxOutputBytes: 1024})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Success || result.Terminated != TerminatedTruncated || len(result.Output) > 1024 {
+			t.Errorf("expected a truncated result with capped output, got %+v", result)
+		}
+		if last := events[len(events)-1]; last.Type != "error" || !strings.Contains(last.Content, "output limit exceeded") {
+			t.Errorf("expected a final output limit error event, got %+v", last)
+		}
+	})
+
+	t.Run("server error fails the task", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "model not loaded", http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		result, _, err := runOpenAI(t, OpenAICompatConfig{Provider: &ProviderConfig{BaseURL: server.URL}})
+		if err != nil {
+			t.Fatalf("expected a failed result, got error %v", err)
+		}
+		if result.Success || !strings.Contains(result.Error, "model not loaded") {
+			t.Errorf("unexpected result: %+v", result)
+		}
+	})
+
+	t.Run("truncated stream fails the task", func(t *testing.T) {
+		server := sseServer(t, []string{`{"choices":[{"delta":{"content":"Hel"}}]}`}, nil, nil)
+		defer server.Close()
+
+		result, _, err := runOpenAI(t, OpenAICompatConfig{Provider: &ProviderConfig{BaseURL: server.URL + "/v1"}})
+		if err != nil {
+			t.Fatalf("expected a failed result, got error %v", err)
+		}
+		if result.Success || result.Output != "Hel" {
+			t.Errorf("expected failed result with partial output, got %+v", result)
+		}
+	})
+}
+
+func TestOpenAICompatRequiresBaseURL(t *testing.T) {
+	backend := NewOpenAICompatBackend(OpenAICompatConfig{})
+	if _, err := backend.CreateSession(context.Background(), task.New("t-001", "Chat"), ""); err == nil {
+		t.Error("expected error without a provider base URL")
+	}
+}
+
+func TestOpenAICompatDestroy(t *testing.T) {
+	// The server sends one chunk and then holds the stream open
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprintf(w, "data: %s\n\n", `{"choices":[{"delta":{"content":"Thinking"}}]}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+	backend := NewOpenAICompatBackend(OpenAICompatConfig{Provider: &ProviderConfig{BaseURL: server.URL}})
+
+	newSession := func() Session {
+		session, err := backend.CreateSession(context.Background(), task.New("t-001", "Chat"), "")
+		if err != nil {
+			t.Fatalf("CreateSession failed: %v", err)
+		}
+		return session
+	}
+	run := func(session Session) <-chan *Result {
+		done := make(chan *Result, 1)
+		go func() {
+			result, _ := session.Run(context.Background(), "Think forever")
+			done <- result
+		}()
+		return done
+	}
+	wait := func(done <-chan *Result) {
+		t.Helper()
+		select {
+		case result := <-done:
+			if result != nil && result.Success {
+				t.Error("expected a destroyed run not to succeed")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("Run did not return after Destroy")
+		}
+	}
+
+	t.Run("while streaming", func(t *testing.T) {
+		session := newSession()
+		done := run(session)
+		<-session.Events() // The first chunk has arrived
+		go func() {
+			for range session.Events() {
+			}
+		}()
+		if err := session.Destroy(context.Background()); err != nil {
+			t.Fatalf("Destroy failed: %v", err)
+		}
+		wait(done)
+	})
+
+	t.Run("before run", func(t *testing.T) {
+		session := newSession()
+		session.Destroy(context.Background())
+		go func() {
+			for range session.Events() {
+			}
+		}()
+		wait(run(session))
+	})
+}
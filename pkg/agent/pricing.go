@@ -0,0 +1,63 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrNoPricing is returned by EstimateCost when a model has no configured price.
+var ErrNoPricing = errors.New("no pricing configured")
+
+// ModelPrice is a model's price in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// PricingConfig maps "backend/model" references to their prices.
+type PricingConfig map[string]ModelPrice
+
+// EstimatedOutputRatio is the assumed output tokens per prompt token, used
+// when estimating a run before it happens.
+const EstimatedOutputRatio = 1.0
+
+// EstimateTokens roughly counts the tokens in text, at about four
+// characters per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// EstimateCost estimates the USD cost of a run sending promptTokens to
+// backend/model, assuming EstimatedOutputRatio output tokens per prompt
+// token. It returns an error wrapping ErrNoPricing when the model has no
+// price in cfg rather than guessing one.
+func EstimateCost(backend, model string, promptTokens int, cfg PricingConfig) (float64, error) {
+	if promptTokens < 0 {
+		return 0, fmt.Errorf("prompt tokens must be non-negative, got %d", promptTokens)
+	}
+
+	ref := backend + "/" + model
+	price, ok := cfg[ref]
+	if !ok {
+		return 0, fmt.Errorf("%w for %s", ErrNoPricing, ref)
+	}
+
+	input := float64(promptTokens)
+	output := input * EstimatedOutputRatio
+	return (input*price.Input + output*price.Output) / 1e6, nil
+}
+
+// UsageCost estimates the USD cost of tokens already used on ref
+// ("backend/model"), splitting them between input and output by
+// EstimatedOutputRatio. It returns an error wrapping ErrNoPricing when ref
+// has no price in cfg.
+func UsageCost(ref string, tokens int, cfg PricingConfig) (float64, error) {
+	price, ok := cfg[ref]
+	if !ok {
+		return 0, fmt.Errorf("%w for %s", ErrNoPricing, ref)
+	}
+
+	input := float64(tokens) / (1 + EstimatedOutputRatio)
+	output := float64(tokens) - input
+	return (input*price.Input + output*price.Output) / 1e6, nil
+}
@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEstimateCost(t *testing.T) {
+	cfg := PricingConfig{
+		"claude/opus": {Input: 15, Output: 75},
+	}
+
+	tests := []struct {
+		name    string
+		backend string
+		model   string
+		tokens  int
+		want    float64
+		wantErr error
+	}{
+		{name: "configured model", backend: "claude", model: "opus", tokens: 10000, want: 0.9},
+		{name: "zero tokens", backend: "claude", model: "opus", tokens: 0, want: 0},
+		{name: "unconfigured model", backend: "claude", model: "haiku", tokens: 10000, wantErr: ErrNoPricing},
+		{name: "same model other backend", backend: "copilot", model: "opus", tokens: 10000, wantErr: ErrNoPricing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EstimateCost(tt.backend, tt.model, tt.tokens, cfg)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("expected cost %f, got %f", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEstimateCostNegativeTokens(t *testing.T) {
+	cfg := PricingConfig{"claude/opus": {Input: 15, Output: 75}}
+	if _, err := EstimateCost("claude", "opus", -1, cfg); err == nil {
+		t.Error("expected error for negative tokens")
+	}
+}
+
+func TestUsageCost(t *testing.T) {
+	cfg := PricingConfig{"claude/opus": {Input: 15, Output: 75}}
+
+	// 2M tokens split evenly: 1M in at $15 and 1M out at $75
+	got, err := UsageCost("claude/opus", 2_000_000, cfg)
+	if err != nil || math.Abs(got-90) > 1e-9 {
+		t.Errorf("expected cost 90, got %f (%v)", got, err)
+	}
+	if _, err := UsageCost("claude", 1000, cfg); !errors.Is(err, ErrNoPricing) {
+		t.Errorf("expected ErrNoPricing for a bare backend, got %v", err)
+	}
+}
+
+func TestEstimateTokens(t *testing.T) {
+	if got := EstimateTokens(""); got != 0 {
+		t.Errorf("expected 0 tokens for empty text, got %d", got)
+	}
+	if got := EstimateTokens("12345678"); got != 2 {
+		t.Errorf("expected 2 tokens, got %d", got)
+	}
+	if got := EstimateTokens("123456789"); got != 3 {
+		t.Errorf("expected partial token to round up, got %d", got)
+	}
+}
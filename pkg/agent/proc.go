@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+)
+
+// startGroup configures cmd to run in its own process group, so that
+// procHandle.kill and context cancellation also reach any subprocesses the
+// CLI spawns. Call it before cmd.Start.
+func startGroup(cmd *exec.Cmd) {
+	setProcessGroup(cmd)
+	cmd.Cancel = func() error {
+		return killProcessGroup(cmd)
+	}
+}
+
+// errDestroyed is returned when a session is destroyed before its CLI
+// starts.
+var errDestroyed = errors.New("session destroyed before the CLI started")
+
+// procHandle holds a session's CLI process. Run starts it on one goroutine
+// while Destroy may kill it from another, so both go through the mutex.
+type procHandle struct {
+	mu        sync.Mutex
+	cmd       *exec.Cmd
+	destroyed bool
+}
+
+// start starts cmd, unless the session has already been destroyed.
+func (p *procHandle) start(cmd *exec.Cmd) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.destroyed {
+		return errDestroyed
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	p.cmd = cmd
+	return nil
+}
+
+// kill kills the process and its process group if it has started, and
+// stops a later start from launching one.
+func (p *procHandle) kill() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.destroyed = true
+	if p.cmd == nil || p.cmd.Process == nil {
+		return nil
+	}
+	return killProcessGroup(p.cmd)
+}
@@ -0,0 +1,131 @@
+//go:build unix
+
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestDestroyKillsProcessGroup(t *testing.T) {
+	// A fake CLI that forks a sleeper, records its PID and then waits
+	dir := t.TempDir()
+	pidFile := filepath.Join(dir, "sleeper.pid")
+	cli := filepath.Join(dir, "claude")
+	script := `#!/bin/sh
+sleep 60 &
+echo $! > ` + pidFile + `.tmp
+mv ` + pidFile + `.tmp ` + pidFile + `
+wait
+`
+	if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	backend := NewClaudeBackend(ClaudeConfig{CLIPath: cli})
+	session, err := backend.CreateSession(context.Background(), task.New("t-001", "Leak"), "")
+	if err != nil {
+		t.Fatalf("CreateSession failed: %v", err)
+	}
+	go func() {
+		for range session.Events() {
+		}
+	}()
+	done := make(chan struct{})
+	go func() {
+		session.Run(context.Background(), "Spawn a sleeper")
+		close(done)
+	}()
+
+	var pid int
+	deadline := time.Now().Add(5 * time.Second)
+	for pid == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("fake CLI did not start its sleeper")
+		}
+		if data, err := os.ReadFile(pidFile); err == nil {
+			pid, _ = strconv.Atoi(strings.TrimSpace(string(data)))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := session.Destroy(context.Background()); err != nil {
+		t.Fatalf("Destroy failed: %v", err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Destroy")
+	}
+
+	deadline = time.Now().Add(5 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("sleeper %d survived Destroy", pid)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestDestroyBeforeRun(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "started")
+	script := "#!/bin/sh\ntouch " + marker + "\n"
+
+	for _, name := range []string{"claude", "codex", "gemini"} {
+		t.Run(name, func(t *testing.T) {
+			cli := filepath.Join(dir, name)
+			if err := os.WriteFile(cli, []byte(script), 0755); err != nil {
+				t.Fatal(err)
+			}
+			var backend Backend
+			switch name {
+			case "claude":
+				backend = NewClaudeBackend(ClaudeConfig{CLIPath: cli})
+			case "codex":
+				backend = NewCodexBackend(CodexConfig{CLIPath: cli})
+			case "gemini":
+				backend = NewGeminiBackend(GeminiConfig{CLIPath: cli})
+			}
+			session, err := backend.CreateSession(context.Background(), task.New("t-001", "Early"), "")
+			if err != nil {
+				t.Fatalf("CreateSession failed: %v", err)
+			}
+			if err := session.Destroy(context.Background()); err != nil {
+				t.Fatalf("Destroy failed: %v", err)
+			}
+
+			if _, err := session.Run(context.Background(), "Never runs"); !errors.Is(err, errDestroyed) {
+				t.Errorf("expected errDestroyed, got %v", err)
+			}
+			if _, err := os.Stat(marker); !os.IsNotExist(err) {
+				t.Error("expected the CLI not to start after Destroy")
+			}
+		})
+	}
+}
+
+// processAlive reports whether pid is running. A zombie waiting to be reaped
+// by init counts as gone.
+func processAlive(pid int) bool {
+	if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+		return false
+	}
+	stat, err := os.ReadFile("/proc/" + strconv.Itoa(pid) + "/stat")
+	if err != nil {
+		return !os.IsNotExist(err)
+	}
+	// The state follows the parenthesised command name
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) == 0 || fields[0] != "Z"
+}
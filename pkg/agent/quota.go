@@ -0,0 +1,219 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExitTempFail is the sysexits EX_TEMPFAIL code, which agent CLIs use for
+// "try again later" failures such as rate limiting.
+const ExitTempFail = 75
+
+// QuotaError reports that a backend rejected work for quota or rate-limit
+// reasons. Detect it with errors.As or IsQuotaExhausted.
+type QuotaError struct {
+	Backend  string // Backend that is exhausted, if known
+	Status   int    // Provider HTTP status, if reported
+	Type     string // Provider error type, if reported
+	ExitCode int    // CLI exit code, if the backend is a CLI
+	Message  string
+	Err      error // Underlying error, if any
+
+	// RetryAfter is how long the provider asked to wait before retrying,
+	// if it said
+	RetryAfter time.Duration
+}
+
+func (e *QuotaError) Error() string {
+	msg := "quota exhausted"
+	if e.Backend != "" {
+		msg += " for backend " + e.Backend
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+func (e *QuotaError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorPayload is the error a backend reports in its final result event.
+type ErrorPayload struct {
+	Type    string `json:"type,omitempty"`
+	Status  int    `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// quotaErrorTypes are provider error types that mean quota exhaustion.
+var quotaErrorTypes = map[string]bool{
+	"rate_limit_error":    true, // Anthropic
+	"rate_limit_exceeded": true, // OpenAI
+	"insufficient_quota":  true, // OpenAI
+	"resource_exhausted":  true, // Google
+}
+
+// IsQuota reports whether the payload describes quota exhaustion. A status
+// or type, when present, is authoritative; otherwise the message is matched
+// against the text heuristics.
+func (p *ErrorPayload) IsQuota() bool {
+	if p.Status != 0 || p.Type != "" {
+		return p.Status == 429 || quotaErrorTypes[strings.ToLower(p.Type)]
+	}
+	return quotaText(p.Message)
+}
+
+// quotaPatterns match quota and rate-limit wording in unstructured errors.
+// They are deliberately narrower than the word "quota" alone, which appears
+// in unrelated messages such as quota file errors.
+var quotaPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\b429\b`),
+	regexp.MustCompile(`rate[ _-]?limit`),
+	regexp.MustCompile(`too many requests`),
+	regexp.MustCompile(`quota (exceeded|exhausted)`),
+	regexp.MustCompile(`exceeded (your|the) [a-z ]*quota`),
+	regexp.MustCompile(`insufficient_quota|resource_exhausted`),
+}
+
+func quotaText(s string) bool {
+	s = strings.ToLower(s)
+	for _, re := range quotaPatterns {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsQuotaExhausted reports whether err means the backend is out of quota.
+// A QuotaError anywhere in the chain is authoritative; otherwise the error
+// text is matched against known quota and rate-limit wording.
+func IsQuotaExhausted(err error) bool {
+	if err == nil {
+		return false
+	}
+	var qe *QuotaError
+	if errors.As(err, &qe) {
+		return true
+	}
+	return quotaText(err.Error())
+}
+
+// retryAfterPattern matches a retry delay in provider error text, such as
+// "retry after 30s", "Retry-After: 120" or "try again in 1m30s". A number
+// without a unit is in seconds, as in the Retry-After header.
+var retryAfterPattern = regexp.MustCompile(`(?i)(?:retry[ _-]after|try again in|retry in)\W*((?:\d+(?:\.\d+)?\s*[a-z]*\s*)+)`)
+
+// ParseRetryAfter extracts the retry delay from a provider error message,
+// reporting false when the message gives none.
+func ParseRetryAfter(message string) (time.Duration, bool) {
+	m := retryAfterPattern.FindStringSubmatch(message)
+	if m == nil {
+		return 0, false
+	}
+	return parseDelay(m[1])
+}
+
+// delayPart is one number and unit of a delay, e.g. "1m" or "30 seconds".
+var delayPart = regexp.MustCompile(`(\d+(?:\.\d+)?)\s*([a-z]*)`)
+
+// delayUnits maps the unit words providers use to durations.
+var delayUnits = map[string]time.Duration{
+	"": time.Second, "s": time.Second, "sec": time.Second, "secs": time.Second, "second": time.Second, "seconds": time.Second,
+	"ms": time.Millisecond, "millisecond": time.Millisecond, "milliseconds": time.Millisecond,
+	"m": time.Minute, "min": time.Minute, "mins": time.Minute, "minute": time.Minute, "minutes": time.Minute,
+	"h": time.Hour, "hr": time.Hour, "hrs": time.Hour, "hour": time.Hour, "hours": time.Hour,
+}
+
+// parseDelay sums the parts of a delay such as "1m30s" or "2 minutes",
+// stopping at the first word that is not a unit.
+func parseDelay(s string) (time.Duration, bool) {
+	var total time.Duration
+	found := false
+	for _, part := range delayPart.FindAllStringSubmatch(strings.ToLower(s), -1) {
+		unit, ok := delayUnits[part[2]]
+		if !ok {
+			break
+		}
+		n, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			break
+		}
+		total += time.Duration(n * float64(unit))
+		found = true
+		if part[2] == "" {
+			break // A bare number is the whole delay
+		}
+	}
+	return total, found && total > 0
+}
+
+// parseRetryAfterHeader parses an HTTP Retry-After header, which is either
+// a number of seconds or a date, relative to now.
+func parseRetryAfterHeader(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		return time.Duration(secs) * time.Second, secs > 0
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now), true
+	}
+	return 0, false
+}
+
+// RetryAfterOf returns how long err says to wait before retrying: a
+// QuotaError's RetryAfter, or else a delay in the error text.
+func RetryAfterOf(err error) (time.Duration, bool) {
+	if err == nil {
+		return 0, false
+	}
+	var qe *QuotaError
+	if errors.As(err, &qe) && qe.RetryAfter > 0 {
+		return qe.RetryAfter, true
+	}
+	return ParseRetryAfter(err.Error())
+}
+
+// classifyExit turns a failed CLI run into a QuotaError when the result
+// event's error payload or the exit code indicates quota exhaustion, and
+// returns nil otherwise. A structured payload takes precedence over the
+// exit code.
+func classifyExit(backend string, waitErr error, payload *ErrorPayload) error {
+	exitCode := 0
+	var exitErr *exec.ExitError
+	if errors.As(waitErr, &exitErr) {
+		exitCode = exitErr.ExitCode()
+	}
+
+	switch {
+	case payload != nil && payload.IsQuota():
+		retryAfter, _ := ParseRetryAfter(payload.Message)
+		return &QuotaError{
+			Backend:    backend,
+			Status:     payload.Status,
+			Type:       payload.Type,
+			ExitCode:   exitCode,
+			Message:    payload.Message,
+			Err:        waitErr,
+			RetryAfter: retryAfter,
+		}
+	case payload == nil && exitCode == ExitTempFail:
+		return &QuotaError{
+			Backend:  backend,
+			ExitCode: exitCode,
+			Message:  fmt.Sprintf("%s exited with code %d", backend, exitCode),
+			Err:      waitErr,
+		}
+	}
+	return nil
+}
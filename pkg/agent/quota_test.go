@@ -0,0 +1,194 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsQuotaExhausted(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"quota error", &QuotaError{Backend: "claude"}, true},
+		{"wrapped quota error", fmt.Errorf("failed to create session: %w", &QuotaError{Status: 429}), true},
+		{"http 429", errors.New("HTTP 429"), true},
+		{"rate limit", errors.New("Rate limit exceeded"), true},
+		{"too many requests", errors.New("429 Too Many Requests"), true},
+		{"quota exceeded", errors.New("API quota exceeded for project"), true},
+		{"exceeded your quota", errors.New("You exceeded your current quota, please check your plan"), true},
+		{"connection refused", errors.New("connection refused"), false},
+		{"quota file error", errors.New("failed to write quota.json: permission denied"), false},
+		{"quota in unrelated message", errors.New("updated quota settings for repo"), false},
+		{"number containing 429", errors.New("processed 14290 files"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsQuotaExhausted(tt.err); got != tt.want {
+				t.Errorf("IsQuotaExhausted(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuotaErrorAs(t *testing.T) {
+	err := fmt.Errorf("run failed: %w", &QuotaError{Backend: "claude", Status: 429, Message: "slow down"})
+
+	var qe *QuotaError
+	if !errors.As(err, &qe) {
+		t.Fatal("expected errors.As to find QuotaError")
+	}
+	if qe.Backend != "claude" || qe.Status != 429 {
+		t.Errorf("unexpected QuotaError: %+v", qe)
+	}
+	if !strings.Contains(err.Error(), "quota exhausted for backend claude: slow down") {
+		t.Errorf("unexpected message: %q", err.Error())
+	}
+}
+
+func TestErrorPayloadIsQuota(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload ErrorPayload
+		want    bool
+	}{
+		{"status 429", ErrorPayload{Status: 429}, true},
+		{"anthropic rate limit type", ErrorPayload{Type: "rate_limit_error"}, true},
+		{"openai insufficient quota", ErrorPayload{Type: "insufficient_quota", Status: 429}, true},
+		{"overloaded is not quota", ErrorPayload{Type: "overloaded_error", Status: 529}, false},
+		// Structured fields win over misleading text
+		{"server error mentioning quota", ErrorPayload{Type: "api_error", Status: 500, Message: "quota exceeded while logging"}, false},
+		{"message only", ErrorPayload{Message: "API Error: 429 rate_limit_error"}, true},
+		{"message only, unrelated", ErrorPayload{Message: "tool failed: quota.json not found"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.payload.IsQuota(); got != tt.want {
+				t.Errorf("IsQuota() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseStreamStructuredQuotaError(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Working"}]}}`,
+		`{"type":"result","is_error":true,"result":"API Error","error":{"type":"rate_limit_error","status":429,"message":"Number of request tokens has exceeded your per-minute rate limit"}}`,
+	}, "\n")
+
+	events := make(chan Event, 10)
+	failure := parseStream(strings.NewReader(stream), events, 0, 0).Failure
+	close(events)
+
+	if failure == nil {
+		t.Fatal("expected error payload from result event")
+	}
+	if failure.Status != 429 || failure.Type != "rate_limit_error" {
+		t.Errorf("unexpected payload: %+v", failure)
+	}
+
+	err := classifyExit("claude", exitError(t, 1), failure)
+	var qe *QuotaError
+	if !errors.As(err, &qe) {
+		t.Fatalf("expected QuotaError, got %v", err)
+	}
+	if qe.Backend != "claude" || qe.Status != 429 || qe.ExitCode != 1 {
+		t.Errorf("unexpected QuotaError: %+v", qe)
+	}
+}
+
+func TestParseStreamResultErrorText(t *testing.T) {
+	events := make(chan Event, 10)
+	failure := parseStream(strings.NewReader(`{"type":"result","is_error":true,"result":"tool call failed"}`), events, 0, 0).Failure
+	close(events)
+
+	if failure == nil || failure.Message != "tool call failed" {
+		t.Fatalf("expected payload from result text, got %+v", failure)
+	}
+	if err := classifyExit("claude", exitError(t, 1), failure); err != nil {
+		t.Errorf("expected non-quota failure to stay unclassified, got %v", err)
+	}
+}
+
+func TestClassifyExitCode(t *testing.T) {
+	if err := classifyExit("codex", exitError(t, ExitTempFail), nil); !IsQuotaExhausted(err) {
+		t.Errorf("expected EX_TEMPFAIL without payload to be quota, got %v", err)
+	}
+	if err := classifyExit("codex", exitError(t, 1), nil); err != nil {
+		t.Errorf("expected plain failure to stay unclassified, got %v", err)
+	}
+	// A structured non-quota payload overrides the exit code
+	if err := classifyExit("codex", exitError(t, ExitTempFail), &ErrorPayload{Type: "api_error", Status: 500}); err != nil {
+		t.Errorf("expected payload to take precedence, got %v", err)
+	}
+}
+
+// exitError runs a shell that exits with code and returns its error.
+func exitError(t *testing.T, code int) error {
+	t.Helper()
+	err := exec.Command("sh", "-c", fmt.Sprintf("exit %d", code)).Run()
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		t.Fatalf("expected exit error, got %v", err)
+	}
+	return err
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		message string
+		want    time.Duration
+		wantOK  bool
+	}{
+		{"rate limited, retry after 30s", 30 * time.Second, true},
+		{"Retry-After: 120", 2 * time.Minute, true},
+		{"Rate limit reached. Please try again in 1m30s.", 90 * time.Second, true},
+		{"quota exceeded; retry in 2 minutes", 2 * time.Minute, true},
+		{"try again in 1.5s", 1500 * time.Millisecond, true},
+		{"retry after 20 seconds or contact support", 20 * time.Second, true},
+		{"429 Too Many Requests", 0, false},
+		{"retry after a while", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			got, ok := ParseRetryAfter(tt.message)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParseRetryAfter(%q) = %s, %v, want %s, %v", tt.message, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfterHeader(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value  string
+		want   time.Duration
+		wantOK bool
+	}{
+		{"30", 30 * time.Second, true},
+		{now.Add(2 * time.Minute).Format(http.TimeFormat), 2 * time.Minute, true},
+		{now.Add(-time.Minute).Format(http.TimeFormat), 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseRetryAfterHeader(tt.value, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("parseRetryAfterHeader(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
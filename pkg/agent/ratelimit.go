@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter spaces out backend sessions with a token bucket per key
+// (a backend, or "backend/model"). A key's bucket refills at its rate, in
+// sessions per second, up to its burst. Starting a session takes a token
+// and holds one of burst slots until released, so at most burst sessions
+// of a key run at once. Keys without a limit are not limited.
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// bucket is the token bucket of one key.
+type bucket struct {
+	rate     float64 // Tokens added per second
+	burst    int     // Bucket size and concurrent session limit
+	tokens   float64
+	last     time.Time     // When tokens was last refilled
+	inFlight int           // Sessions holding a slot
+	released chan struct{} // Closed and replaced when a slot is released
+}
+
+// NewRateLimiter creates a rate limiter with no limits.
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetLimit limits key to rate session starts per second, with at most burst
+// sessions running at once. A burst below 1 is treated as 1.
+func (l *RateLimiter) SetLimit(key string, rate float64, burst int) error {
+	if rate <= 0 {
+		return fmt.Errorf("rate for '%s' must be positive, got %g", key, rate)
+	}
+	if burst < 1 {
+		burst = 1
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buckets[key] = &bucket{
+		rate:     rate,
+		burst:    burst,
+		tokens:   float64(burst),
+		last:     l.now(),
+		released: make(chan struct{}),
+	}
+	return nil
+}
+
+// Acquire waits until a session may start for every key, in order, and
+// returns a func releasing the slots taken. It returns ctx's error if ctx
+// is done first, holding nothing.
+func (l *RateLimiter) Acquire(ctx context.Context, keys ...string) (func(), error) {
+	var releases []func()
+	releaseAll := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+
+	for _, key := range keys {
+		release, err := l.acquire(ctx, key)
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+
+	var once sync.Once
+	return func() { once.Do(releaseAll) }, nil
+}
+
+// acquire takes a token and a slot from key's bucket.
+func (l *RateLimiter) acquire(ctx context.Context, key string) (func(), error) {
+	for {
+		l.mu.Lock()
+		b, ok := l.buckets[key]
+		if !ok {
+			l.mu.Unlock()
+			return func() {}, nil
+		}
+
+		now := l.now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > float64(b.burst) {
+			b.tokens = float64(b.burst)
+		}
+		b.last = now
+
+		if b.inFlight < b.burst && b.tokens >= 1 {
+			b.tokens--
+			b.inFlight++
+			l.mu.Unlock()
+			return func() { l.release(b) }, nil
+		}
+
+		// Wait for a slot to be released, or for the next token
+		released := b.released
+		var timer *time.Timer
+		var wait <-chan time.Time
+		if b.inFlight < b.burst {
+			timer = time.NewTimer(time.Duration((1 - b.tokens) / b.rate * float64(time.Second)))
+			wait = timer.C
+		}
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-released:
+		case <-wait:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// release frees a slot in b and wakes its waiters.
+func (l *RateLimiter) release(b *bucket) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b.inFlight--
+	close(b.released)
+	b.released = make(chan struct{})
+}
@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// concurrencyBackend is a mock backend whose runs take a while and record
+// how many run at once.
+type concurrencyBackend struct {
+	MockBackend
+	running, peak atomic.Int32
+}
+
+func (b *concurrencyBackend) CreateSession(ctx context.Context, t *task.Task, worktree string) (Session, error) {
+	return &concurrencySession{MockSession: MockSession{backend: &b.MockBackend, task: t, events: make(chan Event)}, owner: b}, nil
+}
+
+type concurrencySession struct {
+	MockSession
+	owner *concurrencyBackend
+}
+
+func (s *concurrencySession) Run(ctx context.Context, prompt string) (*Result, error) {
+	n := s.owner.running.Add(1)
+	for {
+		peak := s.owner.peak.Load()
+		if n <= peak || s.owner.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	time.Sleep(50 * time.Millisecond)
+	s.owner.running.Add(-1)
+	close(s.events)
+	return &Result{Success: true, Tokens: 1}, nil
+}
+
+func TestRunnerRateLimitSerializesBurstOne(t *testing.T) {
+	limiter := NewRateLimiter()
+	if err := limiter.SetLimit("mock", 1000, 1); err != nil {
+		t.Fatal(err)
+	}
+	backend := &concurrencyBackend{}
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) { return backend, nil },
+		Limiter:    limiter,
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := runner.Run(context.Background(), RunRequest{Task: task.New("t-001", "Limited"), Backend: "mock"}); err != nil {
+				t.Errorf("Run failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := backend.peak.Load(); peak != 1 {
+		t.Errorf("expected runs to be serialized, got %d at once", peak)
+	}
+}
+
+func TestRateLimiterSpacesStarts(t *testing.T) {
+	limiter := NewRateLimiter()
+	limiter.SetLimit("claude", 20, 1) // One start per 50ms
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		release, err := limiter.Acquire(context.Background(), "claude")
+		if err != nil {
+			t.Fatalf("Acquire failed: %v", err)
+		}
+		release()
+	}
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("expected the second and third starts to wait for tokens, took %s", elapsed)
+	}
+
+	// Unlimited keys never wait
+	release, err := limiter.Acquire(context.Background(), "copilot")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+	release()
+}
+
+func TestRateLimiterHonorsCancel(t *testing.T) {
+	limiter := NewRateLimiter()
+	limiter.SetLimit("claude", 1000, 1)
+	limiter.SetLimit("claude/opus", 1000, 1)
+
+	held, err := limiter.Acquire(context.Background(), "claude/opus")
+	if err != nil {
+		t.Fatalf("Acquire failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := limiter.Acquire(ctx, "claude", "claude/opus"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded while waiting, got %v", err)
+	}
+
+	// The cancelled wait gave back the claude slot it had taken
+	release, err := limiter.Acquire(context.Background(), "claude")
+	if err != nil {
+		t.Fatalf("expected claude to be free, got %v", err)
+	}
+	release()
+	held()
+}
+
+func TestRateLimiterSetLimitRejectsZeroRate(t *testing.T) {
+	if err := NewRateLimiter().SetLimit("claude", 0, 1); err == nil {
+		t.Error("expected error for a zero rate")
+	}
+}
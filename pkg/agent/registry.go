@@ -3,6 +3,7 @@ package agent
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 )
 
@@ -46,6 +47,13 @@ func init() {
 		return NewGeminiBackend(GeminiConfig{})
 	})
 
+	RegisterBackend("openai", func(config any) Backend {
+		if cfg, ok := config.(*OpenAICompatConfig); ok {
+			return NewOpenAICompatBackend(*cfg)
+		}
+		return NewOpenAICompatBackend(OpenAICompatConfig{})
+	})
+
 	RegisterBackend("mock", func(config any) Backend {
 		return NewMockBackend()
 	})
@@ -78,7 +86,7 @@ func GetBackend(name string, config any) (Backend, error) {
 	return backend, nil
 }
 
-// ListBackends returns a list of all registered backend names.
+// ListBackends returns the names of all registered backends, sorted.
 func ListBackends() []string {
 	mu.RLock()
 	defer mu.RUnlock()
@@ -87,6 +95,7 @@ func ListBackends() []string {
 	for name := range registry {
 		backends = append(backends, name)
 	}
+	sort.Strings(backends)
 	return backends
 }
 
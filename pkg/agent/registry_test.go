@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"sort"
 	"testing"
 )
 
@@ -72,22 +73,19 @@ func TestGetBackend(t *testing.T) {
 
 func TestListBackends(t *testing.T) {
 	backends := ListBackends()
-	
-	if len(backends) == 0 {
-		t.Error("expected at least one backend")
+
+	if !sort.StringsAreSorted(backends) {
+		t.Errorf("expected backends sorted, got %v", backends)
 	}
 
-	// Check for built-in backends
-	hasClaudeOrCopilot := false
+	listed := make(map[string]bool, len(backends))
 	for _, name := range backends {
-		if name == "claude" || name == "copilot" || name == "mock" {
-			hasClaudeOrCopilot = true
-			break
-		}
+		listed[name] = true
 	}
-	
-	if !hasClaudeOrCopilot {
-		t.Error("expected at least one built-in backend (claude, copilot, or mock)")
+	for _, name := range []string{"claude", "codex", "copilot", "gemini", "mock", "openai"} {
+		if !listed[name] {
+			t.Errorf("expected built-in backend %s in %v", name, backends)
+		}
 	}
 }
 
@@ -3,6 +3,7 @@ package agent
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -231,6 +232,26 @@ func (r *RetryableSession) Run(ctx context.Context, prompt string) (*Result, err
 	return result, err
 }
 
+// Resume continues a conversation with retry. ErrResumeUnsupported is
+// returned at once rather than retried.
+func (r *RetryableSession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	var result *Result
+	unsupported := false
+	err := r.retryWithBackoff(ctx, func() error {
+		var err error
+		result, err = r.session.Resume(ctx, conversationID, prompt)
+		if errors.Is(err, ErrResumeUnsupported) {
+			unsupported = true
+			return nil
+		}
+		return err
+	})
+	if unsupported {
+		return nil, ErrResumeUnsupported
+	}
+	return result, err
+}
+
 // Events returns the event channel.
 func (r *RetryableSession) Events() <-chan Event {
 	return r.session.Events()
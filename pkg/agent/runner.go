@@ -0,0 +1,480 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/logging"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultTokenEstimate is recorded against the quota when a backend does
+// not report token usage for a successful run.
+const DefaultTokenEstimate = 10000
+
+// DefaultQuotaBackoff is how long a backend is marked exhausted after a
+// quota error that does not say when to retry.
+const DefaultQuotaBackoff = time.Hour
+
+// QuotaTracker records backend usage and reports exhausted backends.
+// It is satisfied by *quota.Tracker.
+type QuotaTracker interface {
+	IsExhausted(backend string) bool
+	RetryAfter(backend string) (time.Time, bool)
+	Record(backend string, tokens int) error
+	RecordError(backend string, retryAfter time.Duration) error
+}
+
+// BackendBuilder creates a backend by name, using model when non-empty.
+type BackendBuilder func(name, model string) (Backend, error)
+
+// Runner runs a task on a primary backend, failing over along the task's
+// fallback chain while backends report quota errors.
+type Runner struct {
+	NewBackend   BackendBuilder
+	Quota        QuotaTracker          // Optional usage tracking
+	QuotaBackoff time.Duration         // Exhaustion period after a quota error without a retry-after (default DefaultQuotaBackoff)
+	Sink         EventSink             // Optional receiver of each streaming session event
+	OnFailover   func(from, to string) // Optional hook called before each fallback runs
+
+	// OnConversation is called as soon as a session reports its
+	// conversation ID, after the ID is recorded on the task, so callers
+	// can save it before the run ends and resume an interrupted run.
+	OnConversation func(backend, conversationID string)
+
+	// MaxQuotaWait is the longest the runner waits for an exhausted
+	// primary's retry-after before failing over (0 = never wait).
+	MaxQuotaWait time.Duration
+	OnQuotaWait  func(backend string, wait time.Duration)         // Optional hook called before waiting
+	OnRetry      func(n, max int, reason string)                  // Optional hook called before retry n of a failed task
+	Now          func() time.Time                                 // Clock (default time.Now)
+	Sleep        func(ctx context.Context, d time.Duration) error // Waits d or until ctx is done (default sleepContext)
+
+	Limiter *RateLimiter // Optional limit on session starts, keyed like the quota
+
+	Log *slog.Logger // Diagnostic logger (default logging.L())
+}
+
+// RunRequest describes a single task run.
+type RunRequest struct {
+	Task      *task.Task
+	Worktree  string
+	Prompt    string
+	Backend   string
+	Model     string
+	Fallbacks []string // "backend/model" refs tried in order; defaults to Task.FallbackChain()
+
+	// Resume continues the task's recorded conversation on a backend that
+	// owns it, sending ResumePrompt (default Prompt) instead of starting over.
+	// Other backends, and backends without resume support, run Prompt fresh.
+	Resume       bool
+	ResumePrompt string
+}
+
+// RunResult reports the outcome of Runner.Run.
+type RunResult struct {
+	Backend    string        `json:"backend"`
+	Model      string        `json:"model,omitempty"`
+	FailedOver bool          `json:"failed_over"`
+	Tokens     int           `json:"tokens,omitempty"`
+	Duration   time.Duration `json:"duration"`
+	Result     *Result       `json:"result,omitempty"`
+	Attempts   []Attempt     `json:"attempts,omitempty"` // Every backend tried, in order
+	Err        error         `json:"-"`
+}
+
+// Attempt records one backend tried during a run.
+type Attempt struct {
+	Backend string `json:"backend"`
+	Model   string `json:"model,omitempty"`
+	Tokens  int    `json:"tokens,omitempty"` // Tokens the attempt reported, or the estimate for a success without a count
+	Error   string `json:"error,omitempty"`
+}
+
+// Run executes req, failing over to each fallback in turn while the current
+// backend reports a quota error, and stopping at the first success or
+// non-quota failure. With MaxQuotaWait set, a primary whose quota reopens
+// within that wait is retried before failing over. On success the backend
+// and model that completed the run are recorded on req.Task. A non-quota
+// failure is rerun on the same backend up to req.Task.MaxRetries times, with
+// each failed attempt noted in the task's history. A run that still fails
+// records its FailureCategory and reason on req.Task, unless it was
+// cancelled. The returned RunResult is never nil; its Err matches the
+// returned error and reports the last backend tried.
+func (r *Runner) Run(ctx context.Context, req RunRequest) (*RunResult, error) {
+	start := time.Now()
+	fallbacks := req.Fallbacks
+	if len(fallbacks) == 0 && req.Task != nil {
+		fallbacks = req.Task.FallbackChain()
+	}
+	maxRetries := 0
+	if req.Task != nil {
+		maxRetries = req.Task.MaxRetries
+	}
+
+	res := &RunResult{Backend: req.Backend, Model: req.Model}
+	r.attempt(ctx, req, res)
+	if r.waitForQuota(ctx, res) {
+		r.attempt(ctx, req, res)
+	}
+
+	for retries := 0; ctx.Err() == nil; {
+		if res.Err != nil && IsQuotaExhausted(res.Err) {
+			if fallbacks = r.failover(ctx, req, res, fallbacks); fallbacks == nil {
+				break
+			}
+			continue
+		}
+		if !res.failed() || retries >= maxRetries {
+			break
+		}
+		retries++
+		r.retry(req, res, retries, maxRetries)
+		r.attempt(ctx, req, res)
+	}
+
+	res.Duration = time.Since(start)
+	switch {
+	case req.Task == nil:
+	case !res.failed():
+		req.Task.RecordRun(res.Backend, res.Model, res.Tokens)
+	case !errors.Is(ctx.Err(), context.Canceled):
+		req.Task.RecordFailure(res.failureCategory(), res.reason())
+	}
+	var terminated Termination
+	if res.Result != nil {
+		terminated = res.Result.Terminated
+	}
+	r.logger().Info("run finished",
+		"task", taskID(req.Task), "backend", res.Backend, "model", res.Model,
+		"failed_over", res.FailedOver, "tokens", res.Tokens, "duration", res.Duration,
+		"terminated", terminated, "error", errString(res.Err))
+	return res, res.Err
+}
+
+// failover runs req on the first usable ref in fallbacks, returning the refs
+// left after it, or nil once none remain.
+func (r *Runner) failover(ctx context.Context, req RunRequest, res *RunResult, fallbacks []string) []string {
+	for i, ref := range fallbacks {
+		backend, model, err := ParseModelRef(ref)
+		if err != nil {
+			r.logger().Warn("skipping fallback", "task", taskID(req.Task), "error", err.Error())
+			continue
+		}
+		r.logger().Info("failing over",
+			"task", taskID(req.Task),
+			"from_backend", res.Backend, "from_model", res.Model,
+			"to_backend", backend, "to_model", model)
+		if r.OnFailover != nil {
+			r.OnFailover(res.Backend, ref)
+		}
+		res.Backend, res.Model, res.FailedOver = backend, model, true
+		r.attempt(ctx, req, res)
+		return fallbacks[i+1:]
+	}
+	return nil
+}
+
+// retry notes res's failure before retry n of max reruns the task.
+func (r *Runner) retry(req RunRequest, res *RunResult, n, max int) {
+	reason := res.reason()
+	backend := res.Backend
+	if res.Model != "" {
+		backend += "/" + res.Model
+	}
+
+	r.logger().Info("retrying task",
+		"task", taskID(req.Task), "backend", res.Backend, "model", res.Model,
+		"retry", n, "max_retries", max, "error", reason)
+	req.Task.AddHistory(fmt.Sprintf("attempt %d failed on %s: %s; retrying (%d of %d)", len(res.Attempts), backend, reason, n, max))
+	if r.OnRetry != nil {
+		r.OnRetry(n, max, reason)
+	}
+}
+
+// failed reports whether the last attempt did not succeed.
+func (res *RunResult) failed() bool {
+	return res.Err != nil || res.Result == nil || !res.Result.Success
+}
+
+// reason describes why the last attempt failed.
+func (res *RunResult) reason() string {
+	if res.Err == nil && res.Result != nil {
+		return res.Result.Error
+	}
+	return errString(res.Err)
+}
+
+// testsFailedText is how eas_task_complete reports failing tests; an agent
+// that gives up after it usually repeats it in its error or output.
+const testsFailedText = "tests failed"
+
+// failureCategory classifies the last attempt's failure for triage.
+func (res *RunResult) failureCategory() task.FailureCategory {
+	var terminated Termination
+	if res.Result != nil {
+		terminated = res.Result.Terminated
+	}
+	switch {
+	case terminated == TerminatedQuota || IsQuotaExhausted(res.Err):
+		return task.FailureQuota
+	case terminated == TerminatedTimeout || errors.Is(res.Err, context.DeadlineExceeded):
+		return task.FailureTimeout
+	case res.Result != nil && (strings.Contains(strings.ToLower(res.Result.Error), testsFailedText) ||
+		strings.Contains(strings.ToLower(res.Result.Output), testsFailedText)):
+		return task.FailureTests
+	}
+	return task.FailureAgent
+}
+
+// logger returns the runner's diagnostic logger.
+func (r *Runner) logger() *slog.Logger {
+	if r.Log != nil {
+		return r.Log
+	}
+	return logging.L()
+}
+
+// taskID returns t's ID for logging, tolerating a nil task.
+func taskID(t *task.Task) string {
+	if t == nil {
+		return ""
+	}
+	return t.ID
+}
+
+// errString returns err's message, or "" for a nil error.
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// waitForQuota waits for the primary's quota to reopen when res failed on a
+// quota error whose retry-after is known and within MaxQuotaWait. The
+// reopen time comes from the quota tracker, or else from the retry-after
+// the error reports. It reports whether the primary should be retried.
+func (r *Runner) waitForQuota(ctx context.Context, res *RunResult) bool {
+	if r.MaxQuotaWait <= 0 || res.Err == nil || !IsQuotaExhausted(res.Err) {
+		return false
+	}
+
+	now := time.Now
+	if r.Now != nil {
+		now = r.Now
+	}
+
+	var reopen time.Time
+	if r.Quota != nil {
+		for _, key := range quotaKeys(res.Backend, res.Model) {
+			if at, ok := r.Quota.RetryAfter(key); ok && at.After(reopen) {
+				reopen = at
+			}
+		}
+	}
+	if reopen.IsZero() {
+		after, ok := RetryAfterOf(res.Err)
+		if !ok {
+			return false
+		}
+		reopen = now().Add(after)
+	}
+	wait := reopen.Sub(now())
+	if wait > r.MaxQuotaWait {
+		return false
+	}
+
+	if wait > 0 {
+		r.logger().Info("waiting for quota", "backend", res.Backend, "model", res.Model, "wait", wait)
+		if r.OnQuotaWait != nil {
+			r.OnQuotaWait(res.Backend, wait)
+		}
+		sleep := sleepContext
+		if r.Sleep != nil {
+			sleep = r.Sleep
+		}
+		if err := sleep(ctx, wait); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// sleepContext waits for d, returning early with ctx's error if ctx is done.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// attempt runs req on res's current backend and records the outcome in res.
+func (r *Runner) attempt(ctx context.Context, req RunRequest, res *RunResult) {
+	res.Result, res.Tokens, res.Err = r.runOnce(ctx, req, res.Backend, res.Model)
+
+	a := Attempt{Backend: res.Backend, Model: res.Model, Tokens: res.Tokens}
+	if res.Err != nil {
+		a.Error = res.Err.Error()
+	}
+	res.Attempts = append(res.Attempts, a)
+
+	// Tokens of a successful attempt are recorded with the run by Run
+	if req.Task != nil && res.failed() && res.Tokens > 0 {
+		req.Task.RecordTokens(res.Tokens)
+	}
+}
+
+// recordConversation records a conversation ID reported mid-run on t and
+// passes it to OnConversation.
+func (r *Runner) recordConversation(t *task.Task, backendName, id string) {
+	if t == nil {
+		return
+	}
+	t.RecordConversation(backendName, id)
+	if r.OnConversation != nil {
+		r.OnConversation(backendName, id)
+	}
+}
+
+// runOnce runs req on a single backend, recording usage and quota errors.
+func (r *Runner) runOnce(ctx context.Context, req RunRequest, backendName, model string) (*Result, int, error) {
+	if r.Quota != nil {
+		for _, key := range quotaKeys(backendName, model) {
+			if r.Quota.IsExhausted(key) {
+				r.logger().Info("backend exhausted", "backend", backendName, "model", model, "quota_key", key)
+				return nil, 0, &QuotaError{Backend: backendName, Message: fmt.Sprintf("%s marked exhausted by quota tracker", key)}
+			}
+		}
+	}
+
+	if r.Limiter != nil {
+		release, err := r.Limiter.Acquire(ctx, quotaKeys(backendName, model)...)
+		if err != nil {
+			return nil, 0, err
+		}
+		defer release()
+	}
+
+	r.logger().Debug("running backend", "task", taskID(req.Task), "backend", backendName, "model", model)
+	backend, err := r.NewBackend(backendName, model)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := backend.Start(ctx); err != nil {
+		r.recordQuotaError(backendName, err)
+		return nil, 0, fmt.Errorf("failed to start backend: %w", err)
+	}
+	defer backend.Stop()
+
+	session, err := backend.CreateSession(ctx, req.Task, req.Worktree)
+	if err != nil {
+		r.recordQuotaError(backendName, err)
+		return nil, 0, fmt.Errorf("failed to create session: %w", err)
+	}
+	defer session.Destroy(ctx)
+
+	streamDone := make(chan struct{})
+	go func() {
+		defer close(streamDone)
+		for event := range session.Events() {
+			if r.Sink != nil {
+				r.Sink.Handle(event)
+			}
+			if event.Type == "session" && event.Content != "" {
+				r.recordConversation(req.Task, backendName, event.Content)
+			}
+		}
+	}()
+
+	result, err := r.runSession(ctx, session, req, backendName)
+
+	// Let the stream drain so no events are printed after Run returns,
+	// without hanging on a session that never closes its channel
+	select {
+	case <-streamDone:
+	case <-ctx.Done():
+	}
+
+	if result != nil && result.ConversationID != "" && req.Task != nil &&
+		req.Task.ConversationID != result.ConversationID {
+		req.Task.RecordConversation(backendName, result.ConversationID)
+	}
+
+	// A failed run still used the tokens it reported; only a successful
+	// run without a count is charged the estimate
+	tokens := 0
+	if result != nil {
+		tokens = result.Tokens
+		if tokens == 0 && err == nil && result.Success {
+			tokens = DefaultTokenEstimate
+		}
+	}
+	if r.Quota != nil && tokens > 0 {
+		for _, key := range quotaKeys(backendName, model) {
+			r.Quota.Record(key, tokens)
+			r.logger().Debug("quota usage recorded", "quota_key", key, "tokens", tokens)
+		}
+	}
+
+	if err != nil {
+		// Keep the result, if any, for how the run terminated
+		r.recordQuotaError(backendName, err)
+		return result, tokens, err
+	}
+	return result, tokens, nil
+}
+
+// runSession runs req on session, continuing the task's conversation when
+// req.Resume is set and the conversation belongs to backendName.
+func (r *Runner) runSession(ctx context.Context, session Session, req RunRequest, backendName string) (*Result, error) {
+	t := req.Task
+	if req.Resume && t != nil && t.ConversationID != "" && t.ConversationBackend == backendName {
+		prompt := req.ResumePrompt
+		if prompt == "" {
+			prompt = req.Prompt
+		}
+		result, err := session.Resume(ctx, t.ConversationID, prompt)
+		if !errors.Is(err, ErrResumeUnsupported) {
+			r.logger().Info("conversation resumed", "task", t.ID, "backend", backendName, "conversation", t.ConversationID)
+			return result, err
+		}
+		r.logger().Debug("resume unsupported, starting over", "task", t.ID, "backend", backendName)
+	}
+	return session.Run(ctx, req.Prompt)
+}
+
+// quotaKeys returns the quota tracker keys a run is counted against: the
+// backend, and "backend/model" when a model is set.
+func quotaKeys(backendName, model string) []string {
+	if model == "" {
+		return []string{backendName}
+	}
+	return []string{backendName, backendName + "/" + model}
+}
+
+// recordQuotaError marks backendName exhausted if err is a quota error, for
+// as long as the provider asked or QuotaBackoff when it did not say.
+func (r *Runner) recordQuotaError(backendName string, err error) {
+	if r.Quota == nil || !IsQuotaExhausted(err) {
+		return
+	}
+	backoff, ok := RetryAfterOf(err)
+	if !ok {
+		backoff = r.QuotaBackoff
+	}
+	if backoff <= 0 {
+		backoff = DefaultQuotaBackoff
+	}
+	r.Quota.RecordError(backendName, backoff)
+	r.logger().Info("quota exhaustion recorded", "backend", backendName, "backoff", backoff, "error", err.Error())
+}
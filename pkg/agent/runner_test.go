@@ -0,0 +1,827 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/logging"
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// quotaBackend is a backend whose Start always fails with a rate-limit error.
+type quotaBackend struct {
+	MockBackend
+}
+
+func (b *quotaBackend) Start(ctx context.Context) error {
+	return errors.New("429 Too Many Requests: rate limit exceeded")
+}
+
+func TestRunnerFailsOverOnQuotaError(t *testing.T) {
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	fallback := NewMockBackend()
+
+	var built []string
+	var failedOver string
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			built = append(built, name+"/"+model)
+			if name == "claude" {
+				return &quotaBackend{}, nil
+			}
+			return fallback, nil
+		},
+		Quota: tracker,
+		OnFailover: func(from, to string) {
+			failedOver = from + "->" + to
+		},
+	}
+
+	tk := task.New("t-001", "Failover")
+	tk.Fallback = "copilot/gpt-4.1"
+
+	res, err := runner.Run(context.Background(), RunRequest{
+		Task:    tk,
+		Prompt:  "Implement it",
+		Backend: "claude",
+		Model:   "opus",
+	})
+	if err != nil {
+		t.Fatalf("expected fallback to succeed, got: %v", err)
+	}
+
+	if !res.FailedOver {
+		t.Error("expected FailedOver to be true")
+	}
+	if res.Backend != "copilot" || res.Model != "gpt-4.1" {
+		t.Errorf("expected result to report copilot/gpt-4.1, got %s/%s", res.Backend, res.Model)
+	}
+	if res.Result == nil || !res.Result.Success {
+		t.Errorf("expected successful result, got %+v", res.Result)
+	}
+	if res.Tokens != DefaultTokenEstimate {
+		t.Errorf("expected estimated tokens %d, got %d", DefaultTokenEstimate, res.Tokens)
+	}
+	if res.Duration <= 0 {
+		t.Error("expected duration to be recorded")
+	}
+	if fmt.Sprint(built) != "[claude/opus copilot/gpt-4.1]" {
+		t.Errorf("unexpected backends built: %v", built)
+	}
+	if failedOver != "claude->copilot/gpt-4.1" {
+		t.Errorf("expected failover hook to fire, got %q", failedOver)
+	}
+	if tk.UsedBackend != "copilot" || tk.UsedModel != "gpt-4.1" {
+		t.Errorf("expected task to record fallback copilot/gpt-4.1, got %s/%s", tk.UsedBackend, tk.UsedModel)
+	}
+	if tk.Tokens != DefaultTokenEstimate {
+		t.Errorf("expected task tokens %d, got %d", DefaultTokenEstimate, tk.Tokens)
+	}
+
+	if !tracker.IsExhausted("claude") {
+		t.Error("expected primary backend to be marked exhausted")
+	}
+	if usage, ok := tracker.GetUsage("copilot"); !ok || usage.Requests != 1 {
+		t.Errorf("expected one request recorded for copilot, got %+v", usage)
+	}
+	if len(fallback.GetCalls()) != 1 {
+		t.Errorf("expected fallback to run once, got %d calls", len(fallback.GetCalls()))
+	}
+}
+
+func TestRunnerNoFallback(t *testing.T) {
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return &quotaBackend{}, nil
+		},
+	}
+
+	res, err := runner.Run(context.Background(), RunRequest{
+		Task:    task.New("t-001", "No fallback"),
+		Backend: "claude",
+	})
+	if err == nil {
+		t.Fatal("expected quota error without fallback")
+	}
+	if res.Err != err {
+		t.Errorf("expected result error to match returned error")
+	}
+	if res.FailedOver || res.Backend != "claude" {
+		t.Errorf("expected no failover, got %+v", res)
+	}
+}
+
+func TestRunnerFallbackChain(t *testing.T) {
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	final := NewMockBackend()
+
+	var built, failovers []string
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			built = append(built, name+"/"+model)
+			if name == "copilot" {
+				return final, nil
+			}
+			return &quotaBackend{}, nil
+		},
+		Quota: tracker,
+		OnFailover: func(from, to string) {
+			failovers = append(failovers, from+"->"+to)
+		},
+	}
+
+	tk := task.New("t-001", "Chain")
+	tk.Fallback = "gemini/pro"
+	tk.Fallbacks = []string{"copilot/gpt-4", "claude/haiku"}
+
+	res, err := runner.Run(context.Background(), RunRequest{
+		Task:    tk,
+		Backend: "claude",
+		Model:   "opus",
+	})
+	if err != nil {
+		t.Fatalf("expected third backend to succeed, got: %v", err)
+	}
+
+	if res.Backend != "copilot" || res.Model != "gpt-4" || !res.FailedOver {
+		t.Errorf("expected failover to copilot/gpt-4, got %s/%s (failed over: %v)", res.Backend, res.Model, res.FailedOver)
+	}
+	if fmt.Sprint(built) != "[claude/opus gemini/pro copilot/gpt-4]" {
+		t.Errorf("expected chain to stop at first success, built: %v", built)
+	}
+	if fmt.Sprint(failovers) != "[claude->gemini/pro gemini->copilot/gpt-4]" {
+		t.Errorf("unexpected failovers: %v", failovers)
+	}
+
+	if len(res.Attempts) != 3 {
+		t.Fatalf("expected 3 attempts, got %+v", res.Attempts)
+	}
+	for i, a := range res.Attempts[:2] {
+		if a.Error == "" {
+			t.Errorf("attempt %d (%s): expected error to be recorded", i, a.Backend)
+		}
+	}
+	if last := res.Attempts[2]; last.Backend != "copilot" || last.Error != "" {
+		t.Errorf("expected successful final attempt, got %+v", last)
+	}
+	if !tracker.IsExhausted("claude") || !tracker.IsExhausted("gemini") {
+		t.Error("expected exhausted backends to be marked")
+	}
+}
+
+func TestRunnerFallbackChainExhausted(t *testing.T) {
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return &quotaBackend{}, nil
+		},
+	}
+
+	res, err := runner.Run(context.Background(), RunRequest{
+		Task:      task.New("t-001", "All exhausted"),
+		Backend:   "claude",
+		Model:     "opus",
+		Fallbacks: []string{"claude/sonnet", "copilot/gpt-4"},
+	})
+	if err == nil || !IsQuotaExhausted(err) {
+		t.Fatalf("expected quota error after exhausting chain, got %v", err)
+	}
+	if len(res.Attempts) != 3 || res.Backend != "copilot" {
+		t.Errorf("expected all 3 backends tried ending on copilot, got %s with %+v", res.Backend, res.Attempts)
+	}
+}
+
+func TestRunnerFallbackChainStopsOnOtherErrors(t *testing.T) {
+	var built []string
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			built = append(built, name)
+			if name == "claude" {
+				return &quotaBackend{}, nil
+			}
+			return nil, errors.New("binary not found")
+		},
+	}
+
+	_, err := runner.Run(context.Background(), RunRequest{
+		Task:      task.New("t-001", "Broken fallback"),
+		Backend:   "claude",
+		Fallbacks: []string{"gemini/pro", "copilot/gpt-4"},
+	})
+	if err == nil || IsQuotaExhausted(err) {
+		t.Fatalf("expected non-quota error, got %v", err)
+	}
+	if fmt.Sprint(built) != "[claude gemini]" {
+		t.Errorf("expected chain to stop after non-quota error, built: %v", built)
+	}
+}
+
+func TestRunnerScriptedFailover(t *testing.T) {
+	primary := NewScriptedMockBackend(MockQuotaError())
+	fallback := NewScriptedMockBackend(MockSuccess("implemented", 2500))
+
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			if name == "claude" {
+				return primary, nil
+			}
+			return fallback, nil
+		},
+	}
+
+	tk := task.New("t-001", "Scripted")
+	res, err := runner.Run(context.Background(), RunRequest{
+		Task:      tk,
+		Prompt:    "Implement t-001",
+		Backend:   "claude",
+		Model:     "opus",
+		Fallbacks: []string{"copilot/gpt-4"},
+	})
+	if err != nil {
+		t.Fatalf("expected failover to succeed, got: %v", err)
+	}
+
+	if res.Result.Output != "implemented" || res.Tokens != 2500 {
+		t.Errorf("expected fallback output and tokens, got %+v (tokens %d)", res.Result, res.Tokens)
+	}
+	if len(res.Attempts) != 2 || !strings.Contains(res.Attempts[0].Error, "simulated rate limit") {
+		t.Errorf("expected quota attempt then success, got %+v", res.Attempts)
+	}
+	if got := primary.Prompts(); len(got) != 1 || got[0] != "Implement t-001" {
+		t.Errorf("expected primary to receive the prompt once, got %q", got)
+	}
+	if got := fallback.Prompts(); len(got) != 1 || got[0] != "Implement t-001" {
+		t.Errorf("expected fallback to receive the same prompt, got %q", got)
+	}
+}
+
+func TestRunnerRetriesFailedTask(t *testing.T) {
+	primary := NewScriptedMockBackend(MockFailure("network test flaked"), MockSuccess("fixed", 100))
+	var retries []string
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return primary, nil
+		},
+		OnRetry: func(n, max int, reason string) {
+			retries = append(retries, fmt.Sprintf("%d/%d %s", n, max, reason))
+		},
+	}
+
+	tk := task.New("t-001", "Flaky")
+	tk.MaxRetries = 2
+	res, err := runner.Run(context.Background(), RunRequest{Task: tk, Backend: "claude", Model: "sonnet"})
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got: %v", err)
+	}
+
+	if !res.Result.Success || res.Result.Output != "fixed" {
+		t.Errorf("expected successful retry, got %+v", res.Result)
+	}
+	if len(res.Attempts) != 2 || res.Attempts[1].Backend != "claude" {
+		t.Errorf("expected two attempts on claude, got %+v", res.Attempts)
+	}
+	if fmt.Sprint(retries) != "[1/2 network test flaked]" {
+		t.Errorf("unexpected retries: %v", retries)
+	}
+	if len(tk.History) != 1 || !strings.Contains(tk.History[0].Message, "attempt 1 failed on claude/sonnet: network test flaked") {
+		t.Errorf("expected the failed attempt in history, got %+v", tk.History)
+	}
+	if tk.UsedBackend != "claude" {
+		t.Errorf("expected the successful run to be recorded, got %q", tk.UsedBackend)
+	}
+}
+
+func TestRunnerRetryLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		maxRetries int
+		steps      []MockStep
+		attempts   int
+	}{
+		{"no retries by default", 0, []MockStep{MockFailure("boom")}, 1},
+		{"retries exhausted", 1, []MockStep{MockFailure("boom"), MockError(errors.New("crashed"))}, 2},
+		{"quota errors are not retried", 2, []MockStep{MockQuotaError()}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewScriptedMockBackend(tt.steps...)
+			runner := &Runner{
+				NewBackend: func(name, model string) (Backend, error) {
+					return backend, nil
+				},
+			}
+
+			tk := task.New("t-001", "Failing")
+			tk.MaxRetries = tt.maxRetries
+			res, _ := runner.Run(context.Background(), RunRequest{Task: tk, Backend: "claude"})
+			if len(res.Attempts) != tt.attempts {
+				t.Errorf("expected %d attempts, got %+v", tt.attempts, res.Attempts)
+			}
+			if !res.failed() {
+				t.Errorf("expected the run to fail, got %+v", res.Result)
+			}
+			if len(tk.History) != tt.attempts-1 {
+				t.Errorf("expected %d history notes, got %+v", tt.attempts-1, tk.History)
+			}
+		})
+	}
+}
+
+func TestRunnerFailureCategory(t *testing.T) {
+	tests := []struct {
+		name         string
+		maxRetries   int
+		steps        []MockStep
+		wantCategory task.FailureCategory
+		wantReason   string
+	}{
+		{"failover exhausted on quota", 0, []MockStep{MockQuotaError(), MockQuotaError()}, task.FailureQuota, "quota exhausted"},
+		{"tests failed", 0, []MockStep{MockFailure("tests failed - cannot complete task:\nFAIL: TestAuth")}, task.FailureTests, "FAIL: TestAuth"},
+		{"timed out", 0, []MockStep{MockError(context.DeadlineExceeded)}, task.FailureTimeout, "deadline exceeded"},
+		{"agent gave up", 0, []MockStep{MockFailure("could not find the handler")}, task.FailureAgent, "could not find the handler"},
+		{"success clears an earlier failure", 1, []MockStep{MockFailure("boom"), MockSuccess("done", 1)}, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := NewScriptedMockBackend(tt.steps...)
+			runner := &Runner{
+				NewBackend: func(name, model string) (Backend, error) {
+					return backend, nil
+				},
+			}
+
+			tk := task.New("t-001", "Failing")
+			tk.Fallback = "copilot/gpt-4.1"
+			tk.MaxRetries = tt.maxRetries
+			tk.RecordFailure(task.FailureAgent, "earlier run")
+			runner.Run(context.Background(), RunRequest{Task: tk, Backend: "claude"})
+
+			if tk.FailureCategory != tt.wantCategory {
+				t.Errorf("expected category %q, got %q (reason %q)", tt.wantCategory, tk.FailureCategory, tk.FailureReason)
+			}
+			if !strings.Contains(tk.FailureReason, tt.wantReason) || (tt.wantReason == "") != (tk.FailureReason == "") {
+				t.Errorf("expected reason containing %q, got %q", tt.wantReason, tk.FailureReason)
+			}
+		})
+	}
+}
+
+func TestRunnerPrimarySuccess(t *testing.T) {
+	primary := NewMockBackend()
+	primary.SetResponse(Result{Success: true, Tokens: 1234})
+
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return primary, nil
+		},
+	}
+
+	tk := task.New("t-001", "Primary")
+	tk.Fallback = "copilot/gpt-4.1"
+
+	res, err := runner.Run(context.Background(), RunRequest{Task: tk, Backend: "claude"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.FailedOver || res.Backend != "claude" {
+		t.Errorf("expected primary backend without failover, got %+v", res)
+	}
+	if res.Tokens != 1234 {
+		t.Errorf("expected reported tokens to be used, got %d", res.Tokens)
+	}
+}
+
+func TestRunnerDeliversAllEvents(t *testing.T) {
+	backend := NewMockBackend()
+	backend.SetEvents([]Event{
+		{Type: "message", Content: "one"},
+		{Type: "message", Content: "two"},
+		{Type: "complete", Content: "done"},
+	})
+
+	var mu sync.Mutex
+	var got []string
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return backend, nil
+		},
+		Sink: EventSinkFunc(func(e Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			got = append(got, e.Content)
+		}),
+	}
+
+	if _, err := runner.Run(context.Background(), RunRequest{Task: task.New("t-001", "Events"), Backend: "mock"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// All events are delivered before Run returns
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(got) != "[one two done]" {
+		t.Errorf("expected all events delivered before Run returns, got %v", got)
+	}
+}
+
+func TestRunnerRecordsTokensOfFailedRuns(t *testing.T) {
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	backend := NewScriptedMockBackend(
+		MockStep{Result: Result{Success: false, Error: "tests failed", Tokens: 300}},
+		MockSuccess("fixed", 200),
+	)
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) { return backend, nil },
+		Quota:      tracker,
+	}
+
+	tk := task.New("t-001", "Flaky")
+	tk.MaxRetries = 1
+	res, err := runner.Run(context.Background(), RunRequest{Task: tk, Backend: "claude"})
+	if err != nil {
+		t.Fatalf("expected retry to succeed, got: %v", err)
+	}
+
+	// The failed attempt's tokens count against the quota and the task
+	if usage, _ := tracker.GetUsage("claude"); usage == nil || usage.Tokens != 500 {
+		t.Errorf("expected 500 tokens recorded against claude, got %+v", usage)
+	}
+	if tk.Tokens != 500 {
+		t.Errorf("expected the task to have used 500 tokens, got %d", tk.Tokens)
+	}
+	if len(res.Attempts) != 2 || res.Attempts[0].Tokens != 300 || res.Attempts[1].Tokens != 200 {
+		t.Errorf("expected tokens per attempt, got %+v", res.Attempts)
+	}
+}
+
+// fakeQuota is a QuotaTracker driven by a fake clock.
+type fakeQuota struct {
+	now     time.Time
+	reopens map[string]time.Time
+	slept   time.Duration
+}
+
+func (q *fakeQuota) IsExhausted(backend string) bool {
+	at, ok := q.reopens[backend]
+	return ok && q.now.Before(at)
+}
+
+func (q *fakeQuota) RetryAfter(backend string) (time.Time, bool) {
+	if !q.IsExhausted(backend) {
+		return time.Time{}, false
+	}
+	return q.reopens[backend], true
+}
+
+func (q *fakeQuota) Record(backend string, tokens int) error { return nil }
+
+func (q *fakeQuota) RecordError(backend string, retryAfter time.Duration) error {
+	q.reopens[backend] = q.now.Add(retryAfter)
+	return nil
+}
+
+func (q *fakeQuota) sleep(ctx context.Context, d time.Duration) error {
+	q.slept += d
+	q.now = q.now.Add(d)
+	return ctx.Err()
+}
+
+func TestRunnerWaitsForQuota(t *testing.T) {
+	tests := []struct {
+		name        string
+		reopenIn    time.Duration
+		wantBackend string
+		wantSlept   time.Duration
+	}{
+		{"short retry-after retries primary", 30 * time.Second, "claude", 30 * time.Second},
+		{"long retry-after falls back", 2 * time.Hour, "copilot", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			q := &fakeQuota{now: start, reopens: map[string]time.Time{"claude": start.Add(tt.reopenIn)}}
+
+			runner := &Runner{
+				NewBackend: func(name, model string) (Backend, error) {
+					return NewScriptedMockBackend(MockSuccess(name, 10)), nil
+				},
+				Quota:        q,
+				MaxQuotaWait: time.Minute,
+				Now:          func() time.Time { return q.now },
+				Sleep:        q.sleep,
+			}
+
+			res, err := runner.Run(context.Background(), RunRequest{
+				Task:      task.New("t-001", "Wait"),
+				Backend:   "claude",
+				Fallbacks: []string{"copilot/gpt-4.1"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if res.Backend != tt.wantBackend {
+				t.Errorf("expected run on %s, got %s", tt.wantBackend, res.Backend)
+			}
+			if q.slept != tt.wantSlept {
+				t.Errorf("expected to wait %s, waited %s", tt.wantSlept, q.slept)
+			}
+		})
+	}
+}
+
+// quotaErrBackend is a backend whose Start always fails with err.
+type quotaErrBackend struct {
+	MockBackend
+	err error
+}
+
+func (b *quotaErrBackend) Start(ctx context.Context) error { return b.err }
+
+func TestRunnerQuotaCooldown(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		backoff time.Duration
+		want    time.Duration
+	}{
+		{"retry-after in message", &QuotaError{Backend: "claude", Status: 429, Message: "rate limited, retry after 30s"}, 0, 30 * time.Second},
+		{"retry-after field", &QuotaError{Backend: "claude", Status: 429, RetryAfter: 2 * time.Minute}, 0, 2 * time.Minute},
+		{"retry-after in plain error", errors.New("429 Too Many Requests: try again in 45s"), 0, 45 * time.Second},
+		{"no retry-after", &QuotaError{Backend: "claude", Status: 429, Message: "rate limited"}, 0, DefaultQuotaBackoff},
+		{"no retry-after with configured backoff", &QuotaError{Backend: "claude", Status: 429}, 10 * time.Minute, 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+			q := &fakeQuota{now: start, reopens: map[string]time.Time{}}
+
+			runner := &Runner{
+				NewBackend: func(name, model string) (Backend, error) {
+					if name == "claude" {
+						return &quotaErrBackend{err: tt.err}, nil
+					}
+					return NewScriptedMockBackend(MockSuccess(name, 10)), nil
+				},
+				Quota:        q,
+				QuotaBackoff: tt.backoff,
+				Now:          func() time.Time { return q.now },
+			}
+
+			if _, err := runner.Run(context.Background(), RunRequest{
+				Task:      task.New("t-001", "Cooldown"),
+				Backend:   "claude",
+				Fallbacks: []string{"copilot/gpt-4.1"},
+			}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := q.reopens["claude"].Sub(start); got != tt.want {
+				t.Errorf("expected claude cooldown of %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunnerWaitsForRetryAfterWithoutTracker(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	now := start
+	var slept time.Duration
+	calls := 0
+
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			calls++
+			if calls == 1 {
+				return &quotaErrBackend{err: &QuotaError{Backend: name, RetryAfter: 20 * time.Second}}, nil
+			}
+			return NewScriptedMockBackend(MockSuccess(name, 10)), nil
+		},
+		MaxQuotaWait: time.Minute,
+		Now:          func() time.Time { return now },
+		Sleep: func(ctx context.Context, d time.Duration) error {
+			slept += d
+			now = now.Add(d)
+			return nil
+		},
+	}
+
+	res, err := runner.Run(context.Background(), RunRequest{
+		Task:      task.New("t-001", "Wait"),
+		Backend:   "claude",
+		Fallbacks: []string{"copilot/gpt-4.1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if res.Backend != "claude" {
+		t.Errorf("expected the primary to be retried, got %s", res.Backend)
+	}
+	if slept != 20*time.Second {
+		t.Errorf("expected to wait 20s, waited %s", slept)
+	}
+}
+
+func TestRunnerQuotaWaitCancelled(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	q := &fakeQuota{now: start, reopens: map[string]time.Time{"claude": start.Add(30 * time.Second)}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return NewScriptedMockBackend(MockSuccess(name, 10)), nil
+		},
+		Quota:        q,
+		MaxQuotaWait: time.Minute,
+		Now:          func() time.Time { return q.now },
+		Sleep:        q.sleep,
+	}
+
+	res, err := runner.Run(ctx, RunRequest{
+		Task:      task.New("t-001", "Cancelled"),
+		Backend:   "claude",
+		Fallbacks: []string{"copilot/gpt-4.1"},
+	})
+	if !IsQuotaExhausted(err) {
+		t.Fatalf("expected the primary's quota error, got %v", err)
+	}
+	if len(res.Attempts) != 1 {
+		t.Errorf("expected no retry or failover after cancellation, got %+v", res.Attempts)
+	}
+}
+
+func TestRunnerLogsFailover(t *testing.T) {
+	var buf bytes.Buffer
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			if name == "claude" {
+				return &quotaBackend{}, nil
+			}
+			return NewMockBackend(), nil
+		},
+		Log: logging.New(&buf, slog.LevelDebug, logging.FormatJSON),
+	}
+
+	_, err := runner.Run(context.Background(), RunRequest{
+		Task:      task.New("t-001", "Logged"),
+		Backend:   "claude",
+		Model:     "opus",
+		Fallbacks: []string{"copilot/gpt-4.1"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var failover map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("expected JSON log line, got %q: %v", line, err)
+		}
+		if record["msg"] == "failing over" {
+			failover = record
+		}
+	}
+	if failover == nil {
+		t.Fatalf("expected a failover record, got:\n%s", buf.String())
+	}
+
+	want := map[string]any{
+		"level":        "INFO",
+		"task":         "t-001",
+		"from_backend": "claude",
+		"from_model":   "opus",
+		"to_backend":   "copilot",
+		"to_model":     "gpt-4.1",
+	}
+	for key, value := range want {
+		if failover[key] != value {
+			t.Errorf("expected %s=%v, got %v", key, value, failover[key])
+		}
+	}
+}
+
+func TestRunnerResumesConversation(t *testing.T) {
+	backend := NewScriptedMockBackend(
+		MockConversation("started", "conv-1"),
+		MockConversation("continued", "conv-1"),
+		MockSuccess("fresh", 0),
+	)
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) { return backend, nil },
+	}
+	tk := task.New("t-001", "Resumable")
+
+	// The first run records the conversation on the task
+	if _, err := runner.Run(context.Background(), RunRequest{Task: tk, Prompt: "Implement it", Backend: "mock", Resume: true}); err != nil {
+		t.Fatalf("first run failed: %v", err)
+	}
+	if tk.ConversationID != "conv-1" || tk.ConversationBackend != "mock" {
+		t.Fatalf("expected conversation mock/conv-1 recorded, got %s/%s", tk.ConversationBackend, tk.ConversationID)
+	}
+
+	// A resumed run continues it with the follow-up prompt
+	req := RunRequest{Task: tk, Prompt: "Implement it", ResumePrompt: "Carry on", Backend: "mock", Resume: true}
+	if _, err := runner.Run(context.Background(), req); err != nil {
+		t.Fatalf("resumed run failed: %v", err)
+	}
+
+	// A conversation owned by another backend is not replayed
+	tk.ConversationBackend = "claude"
+	if _, err := runner.Run(context.Background(), req); err != nil {
+		t.Fatalf("fresh run failed: %v", err)
+	}
+
+	calls := backend.GetCalls()
+	if len(calls) != 3 {
+		t.Fatalf("expected 3 calls, got %d", len(calls))
+	}
+	want := []Call{
+		{TaskID: "t-001", Prompt: "Implement it"},
+		{TaskID: "t-001", Prompt: "Carry on", ConversationID: "conv-1"},
+		{TaskID: "t-001", Prompt: "Implement it"},
+	}
+	for i := range want {
+		if calls[i] != want[i] {
+			t.Errorf("call %d = %+v, want %+v", i, calls[i], want[i])
+		}
+	}
+}
+
+func TestRunnerRecordsConversationMidRun(t *testing.T) {
+	// The session reports its conversation, then the run fails
+	backend := NewScriptedMockBackend(MockStep{
+		Err:    errors.New("agent crashed"),
+		Events: []Event{{Type: "session", Content: "conv-9"}},
+	})
+	tk := task.New("t-001", "Resumable")
+	var saved []string
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) { return backend, nil },
+		OnConversation: func(backend, conversationID string) {
+			// The task already holds the ID when the hook saves it
+			saved = append(saved, backend+"/"+conversationID+"="+tk.ConversationID)
+		},
+	}
+
+	if _, err := runner.Run(context.Background(), RunRequest{Task: tk, Prompt: "Implement it", Backend: "mock"}); err == nil {
+		t.Fatal("expected the run to fail")
+	}
+	if len(saved) != 1 || saved[0] != "mock/conv-9=conv-9" {
+		t.Errorf("expected one OnConversation call for mock/conv-9, got %v", saved)
+	}
+	if tk.ConversationID != "conv-9" || tk.ConversationBackend != "mock" {
+		t.Errorf("expected conversation mock/conv-9 recorded, got %s/%s", tk.ConversationBackend, tk.ConversationID)
+	}
+}
+
+// freshOnlySession is a mock session without conversation support.
+type freshOnlySession struct {
+	*MockSession
+}
+
+func (s freshOnlySession) Resume(ctx context.Context, conversationID, prompt string) (*Result, error) {
+	return nil, ErrResumeUnsupported
+}
+
+type freshOnlyBackend struct {
+	*MockBackend
+}
+
+func (b freshOnlyBackend) CreateSession(ctx context.Context, t *task.Task, worktree string) (Session, error) {
+	session, err := b.MockBackend.CreateSession(ctx, t, worktree)
+	if err != nil {
+		return nil, err
+	}
+	return freshOnlySession{session.(*MockSession)}, nil
+}
+
+func TestRunnerResumeUnsupportedStartsOver(t *testing.T) {
+	backend := freshOnlyBackend{NewScriptedMockBackend(MockSuccess("fresh", 0))}
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) { return backend, nil },
+	}
+	tk := task.New("t-001", "Resumable")
+	tk.RecordConversation("mock", "conv-1")
+
+	res, err := runner.Run(context.Background(), RunRequest{Task: tk, Prompt: "Implement it", ResumePrompt: "Carry on", Backend: "mock", Resume: true})
+	if err != nil || !res.Result.Success {
+		t.Fatalf("expected fresh run to succeed, got %v", err)
+	}
+	if prompts := backend.Prompts(); len(prompts) != 1 || prompts[0] != "Implement it" {
+		t.Errorf("expected a single fresh run with the full prompt, got %q", prompts)
+	}
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"sync"
+)
+
+// EventSink receives the streaming events of a run, in order.
+type EventSink interface {
+	Handle(event Event)
+}
+
+// EventSinkFunc adapts a function to an EventSink.
+type EventSinkFunc func(Event)
+
+// Handle calls f(event).
+func (f EventSinkFunc) Handle(event Event) {
+	f(event)
+}
+
+// MultiSink returns a sink that passes each event to every one of sinks in
+// order, skipping nil sinks.
+func MultiSink(sinks ...EventSink) EventSink {
+	var kept multiSink
+	for _, sink := range sinks {
+		if sink != nil {
+			kept = append(kept, sink)
+		}
+	}
+	return kept
+}
+
+type multiSink []EventSink
+
+func (m multiSink) Handle(event Event) {
+	for _, sink := range m {
+		sink.Handle(event)
+	}
+}
+
+// textSink renders events as readable text.
+type textSink struct {
+	w io.Writer
+}
+
+// NewTextSink returns a sink that writes events to w as the text flo shows
+// while a task runs: messages as they stream, and a line for each tool
+// call, usage report, completion and error. Write errors are ignored.
+func NewTextSink(w io.Writer) EventSink {
+	return &textSink{w: w}
+}
+
+func (s *textSink) Handle(event Event) {
+	switch event.Type {
+	case "message":
+		fmt.Fprint(s.w, event.Content)
+	case "tool_call":
+		fmt.Fprintf(s.w, "\n🔧 %s\n", event.Content)
+	case "usage":
+		if event.Usage != nil {
+			fmt.Fprintf(s.w, "\n📊 Tokens: %d in / %d out (%d total)\n",
+				event.Usage.InputTokens, event.Usage.OutputTokens, event.Usage.Total())
+		}
+	case "complete":
+		fmt.Fprintln(s.w, "\n✅ Complete")
+	case "error":
+		fmt.Fprintf(s.w, "\n❌ Error: %s\n", event.Content)
+	}
+}
+
+// EventBuffer is a sink that keeps every event it receives, for tests and
+// callers that inspect a run's events afterwards. It is safe for
+// concurrent use.
+type EventBuffer struct {
+	mu     sync.Mutex
+	events []Event
+}
+
+// Handle appends event to the buffer.
+func (b *EventBuffer) Handle(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.events = append(b.events, event)
+}
+
+// Events returns a copy of the events received so far.
+func (b *EventBuffer) Events() []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Event(nil), b.events...)
+}
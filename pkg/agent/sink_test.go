@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestRunnerFansEventsToSinks(t *testing.T) {
+	events := []Event{
+		{Type: "message", Content: "Reading the spec"},
+		{Type: "tool_call", Content: "eas_run_tests"},
+		{Type: "usage", Usage: &Usage{InputTokens: 120, OutputTokens: 30}},
+		{Type: "complete", Content: "done"},
+	}
+	backend := NewMockBackend()
+	backend.SetEvents(events)
+
+	path := filepath.Join(t.TempDir(), "run.log")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var captured EventBuffer
+	var text bytes.Buffer
+	runner := &Runner{
+		NewBackend: func(name, model string) (Backend, error) {
+			return backend, nil
+		},
+		Sink: MultiSink(&captured, nil, NewTextSink(&text), NewTextSink(f)),
+	}
+	if _, err := runner.Run(context.Background(), RunRequest{Task: task.New("t-001", "Sinks"), Backend: "mock"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f.Close()
+
+	if got := captured.Events(); !reflect.DeepEqual(got, events) {
+		t.Errorf("expected the buffer to capture %+v, got %+v", events, got)
+	}
+
+	logged, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Reading the spec\n🔧 eas_run_tests\n\n📊 Tokens: 120 in / 30 out (150 total)\n\n✅ Complete\n"
+	if text.String() != want {
+		t.Errorf("unexpected text output:\n%q\nwant:\n%q", text.String(), want)
+	}
+	if string(logged) != text.String() {
+		t.Errorf("expected the log file to match the text output, got:\n%q", logged)
+	}
+}
@@ -0,0 +1,253 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/richgo/flo/pkg/logging"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// DefaultMaxLineBytes is the longest stream-json line accepted when a
+// backend config leaves MaxLineBytes unset. Tool results can make single
+// lines far longer than bufio.Scanner's 64KB default.
+const DefaultMaxLineBytes = 16 << 20
+
+// DefaultMaxOutputBytes is the most output a session reads when a backend
+// config leaves MaxOutputBytes unset. A runaway agent past it is stopped.
+const DefaultMaxOutputBytes = 64 << 20
+
+// errOutputLimit reports a run stopped at the output limit.
+var errOutputLimit = errors.New("output limit exceeded")
+
+// outputLimitError describes a run stopped at limit, for its error event
+// and result.
+func outputLimitError(limit int) error {
+	return fmt.Errorf("%w: agent wrote more than %d bytes", errOutputLimit, limit)
+}
+
+// outputLimit returns max, or DefaultMaxOutputBytes if it is not positive.
+func outputLimit(max int) int {
+	if max <= 0 {
+		return DefaultMaxOutputBytes
+	}
+	return max
+}
+
+// Limits on the unparsed output kept for diagnostics.
+const (
+	maxUnparsedLines = 50
+	maxUnparsedBytes = 1024 // Per line
+)
+
+// streamOutput is what parseStream collected from a stream.
+type streamOutput struct {
+	LastMessage string
+	Usage       Usage
+	Failure     *ErrorPayload // Error payload of a failed result event
+	SessionID   string        // Conversation ID reported by the stream, if any
+	Unparsed    []string      // Non-JSON lines, truncated, at most maxUnparsedLines
+	Dropped     int           // Unparsed lines beyond maxUnparsedLines
+	ReadErr     error         // Error reading the stream, e.g. a line over the limit
+	Truncated   bool          // Parsing stopped at OutputLimit bytes
+	OutputLimit int           // Output limit in effect
+}
+
+// Diagnostics describes output that could not be parsed, for Result.Diagnostics.
+func (o streamOutput) Diagnostics() []string {
+	var diags []string
+	for _, line := range o.Unparsed {
+		diags = append(diags, "unparsed output: "+line)
+	}
+	if o.Dropped > 0 {
+		diags = append(diags, fmt.Sprintf("%d more unparsed lines omitted", o.Dropped))
+	}
+	if o.ReadErr != nil {
+		diags = append(diags, "stream read error: "+o.ReadErr.Error())
+	}
+	return diags
+}
+
+// truncatedResult is the failed result of a run stopped at the output
+// limit. Its output is the last message read before the limit.
+func (o streamOutput) truncatedResult() *Result {
+	return &Result{
+		Success:     false,
+		Output:      o.LastMessage,
+		Error:       outputLimitError(o.OutputLimit).Error(),
+		Tokens:      o.Usage.Total(),
+		Diagnostics: o.Diagnostics(),
+		Terminated:  TerminatedTruncated,
+	}
+}
+
+// ErrorText describes a failed run: waitErr followed by the last unparsed
+// line or the read error, which usually explain what went wrong.
+func (o streamOutput) ErrorText(waitErr error) string {
+	msg := waitErr.Error()
+	if o.ReadErr != nil {
+		msg += ": " + o.ReadErr.Error()
+	} else if len(o.Unparsed) > 0 {
+		msg += ": " + o.Unparsed[len(o.Unparsed)-1]
+	}
+	return msg
+}
+
+// parseStream reads stream-json events from r, forwarding messages, running
+// token usage and completion to events. The output holds the last assistant
+// message, the final usage totals, and the error payload of a failed result
+// event (nil if the result succeeded or never arrived). Usage reported
+// incrementally on assistant messages produces one usage event per report;
+// usage reported only on the final result produces a single usage event
+// before complete.
+//
+// Text deltas (content_block_delta events, bare or wrapped in a
+// stream_event) are forwarded as one message event each, and the assistant
+// message that follows them is not forwarded again. The deltas of a message
+// accumulate into the returned last message until a full message replaces
+// them. Tool calls in assistant messages are forwarded as tool_call events
+// naming the tool and its arguments.
+//
+// The session_id carried by events is kept as SessionID for resuming the
+// conversation. Lines that are not JSON are collected in Unparsed. Lines longer than
+// maxLine bytes (DefaultMaxLineBytes if maxLine <= 0) stop parsing with
+// ReadErr set; the rest of r is drained so the writer does not block.
+//
+// Once more than maxOutput bytes (DefaultMaxOutputBytes if maxOutput <= 0)
+// have been read, parsing stops with Truncated set and an error event is
+// sent. The rest of r is not drained: the caller should stop the writer.
+func parseStream(r io.Reader, events chan<- Event, maxLine, maxOutput int) streamOutput {
+	out := streamOutput{OutputLimit: outputLimit(maxOutput)}
+	read := 0
+	reported := false
+	var partial strings.Builder // Text streamed as deltas for the current message
+	streamed := false
+
+	emitUsage := func() {
+		u := out.Usage
+		events <- Event{
+			Type:    "usage",
+			Content: fmt.Sprintf("%d in / %d out", u.InputTokens, u.OutputTokens),
+			Usage:   &u,
+		}
+		reported = true
+	}
+
+	if maxLine <= 0 {
+		maxLine = DefaultMaxLineBytes
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLine)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if read += len(line) + 1; read > out.OutputLimit {
+			out.Truncated = true
+			events <- Event{Type: "error", Content: outputLimitError(out.OutputLimit).Error()}
+			return out
+		}
+
+		var event streamEvent
+		if err := json.Unmarshal(line, &event); err != nil {
+			out.addUnparsed(string(line))
+			continue
+		}
+
+		if event.SessionID != "" && event.SessionID != out.SessionID {
+			out.SessionID = event.SessionID
+			events <- Event{Type: "session", Content: event.SessionID}
+		}
+
+		kind, delta := event.Type, event.Delta
+		if event.Type == "stream_event" && event.Event != nil {
+			kind, delta = event.Event.Type, event.Event.Delta
+		}
+
+		switch kind {
+		case "message_start":
+			partial.Reset()
+		case "content_block_delta":
+			if delta == nil || delta.Type != "text_delta" || delta.Text == "" {
+				continue
+			}
+			partial.WriteString(delta.Text)
+			out.LastMessage = partial.String()
+			streamed = true
+			events <- Event{Type: "message", Content: delta.Text}
+		case "assistant":
+			if event.Message == nil {
+				continue
+			}
+			for _, block := range event.Message.Content {
+				switch block.Type {
+				case "text":
+					out.LastMessage = block.Text
+					if !streamed {
+						events <- Event{Type: "message", Content: block.Text}
+					}
+				case "tool_use":
+					events <- Event{Type: "tool_call", Content: block.toolCall()}
+				}
+			}
+			partial.Reset()
+			streamed = false
+			if u := event.Message.Usage; u != nil {
+				out.Usage.InputTokens += u.InputTokens
+				out.Usage.OutputTokens += u.OutputTokens
+				emitUsage()
+			}
+		case "result":
+			// The result carries authoritative totals when present
+			if u := event.Usage; u != nil {
+				final := Usage{InputTokens: u.InputTokens, OutputTokens: u.OutputTokens}
+				if !reported || final != out.Usage {
+					out.Usage = final
+					emitUsage()
+				}
+			}
+			if event.Error != nil {
+				out.Failure = event.Error
+			} else if event.IsError {
+				out.Failure = &ErrorPayload{Message: event.Result}
+			}
+			events <- Event{Type: "complete", Content: "done"}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		out.ReadErr = err
+		io.Copy(io.Discard, r)
+	}
+
+	return out
+}
+
+// addUnparsed records a non-JSON line. Blank lines are ignored.
+func (o *streamOutput) addUnparsed(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	if len(o.Unparsed) >= maxUnparsedLines {
+		o.Dropped++
+		return
+	}
+	if len(line) > maxUnparsedBytes {
+		line = line[:maxUnparsedBytes] + "..."
+	}
+	o.Unparsed = append(o.Unparsed, line)
+}
+
+// logUnparsed warns about output a backend produced that parseStream could
+// not read, so malformed output is visible in diagnostic logs.
+func logUnparsed(backend string, t *task.Task, output streamOutput) {
+	if len(output.Unparsed) == 0 && output.ReadErr == nil {
+		return
+	}
+	logging.L().Warn("unparsed backend output",
+		"backend", backend, "task", taskID(t),
+		"lines", len(output.Unparsed)+output.Dropped, "read_error", errString(output.ReadErr))
+}
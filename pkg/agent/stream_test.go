@@ -0,0 +1,297 @@
+package agent
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func collectStream(t *testing.T, stream string) ([]Event, string, Usage) {
+	t.Helper()
+	events := make(chan Event, 100)
+	output := parseStream(strings.NewReader(stream), events, 0, 0)
+	close(events)
+
+	var got []Event
+	for e := range events {
+		got = append(got, e)
+	}
+	return got, output.LastMessage, output.Usage
+}
+
+func TestParseStreamIncrementalUsage(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Reading files"}],"usage":{"input_tokens":100,"output_tokens":20}}}`,
+		`not json`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Done"}],"usage":{"input_tokens":50,"output_tokens":30}}}`,
+		`{"type":"result","usage":{"input_tokens":150,"output_tokens":50}}`,
+	}, "\n")
+
+	events, lastMessage, usage := collectStream(t, stream)
+
+	var types []string
+	var usages []Usage
+	for _, e := range events {
+		types = append(types, e.Type)
+		if e.Type == "usage" {
+			if e.Usage == nil {
+				t.Fatal("usage event missing Usage")
+			}
+			usages = append(usages, *e.Usage)
+		}
+	}
+
+	// Final totals match the running total, so no extra usage event
+	wantTypes := "message usage message usage complete"
+	if strings.Join(types, " ") != wantTypes {
+		t.Errorf("expected events %q, got %q", wantTypes, strings.Join(types, " "))
+	}
+	if len(usages) != 2 {
+		t.Fatalf("expected 2 usage events, got %d", len(usages))
+	}
+	if usages[0] != (Usage{100, 20}) || usages[1] != (Usage{150, 50}) {
+		t.Errorf("expected running totals 100/20 then 150/50, got %+v", usages)
+	}
+	if lastMessage != "Done" {
+		t.Errorf("expected last message 'Done', got %q", lastMessage)
+	}
+	if usage.Total() != 200 {
+		t.Errorf("expected total 200 tokens, got %d", usage.Total())
+	}
+}
+
+func TestParseStreamFinalUsageOnly(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Hello"}]}}`,
+		`{"type":"result","usage":{"input_tokens":10,"output_tokens":5}}`,
+	}, "\n")
+
+	events, _, usage := collectStream(t, stream)
+
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %+v", events)
+	}
+	if events[1].Type != "usage" || events[2].Type != "complete" {
+		t.Errorf("expected a single usage event before complete, got %+v", events)
+	}
+	if usage != (Usage{10, 5}) {
+		t.Errorf("expected final usage 10/5, got %+v", usage)
+	}
+}
+
+func TestParseStreamNoUsage(t *testing.T) {
+	events, _, usage := collectStream(t, `{"type":"result"}`)
+
+	if len(events) != 1 || events[0].Type != "complete" {
+		t.Errorf("expected only complete event, got %+v", events)
+	}
+	if usage.Total() != 0 {
+		t.Errorf("expected no usage, got %+v", usage)
+	}
+}
+
+func TestParseStreamDeltas(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"stream_event","event":{"type":"message_start"}}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Implementing "}}}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"pa"}}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"the login "}}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"flow."}}}`,
+		`{"type":"result"}`,
+	}, "\n")
+
+	events, lastMessage, _ := collectStream(t, stream)
+
+	var messages []string
+	for _, e := range events {
+		if e.Type == "message" {
+			messages = append(messages, e.Content)
+		}
+	}
+	if strings.Join(messages, "|") != "Implementing |the login |flow." {
+		t.Errorf("expected one message event per text delta, got %q", messages)
+	}
+	if lastMessage != "Implementing the login flow." {
+		t.Errorf("expected combined output, got %q", lastMessage)
+	}
+}
+
+func TestParseStreamDeltasThenFullMessage(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"Hel"}}}`,
+		`{"type":"stream_event","event":{"type":"content_block_delta","delta":{"type":"text_delta","text":"lo"}}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Hello"}]}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Unstreamed"}]}}`,
+		`{"type":"result"}`,
+	}, "\n")
+
+	events, lastMessage, _ := collectStream(t, stream)
+
+	var messages []string
+	for _, e := range events {
+		if e.Type == "message" {
+			messages = append(messages, e.Content)
+		}
+	}
+	// The streamed message is not repeated; a message without deltas still prints
+	if strings.Join(messages, "|") != "Hel|lo|Unstreamed" {
+		t.Errorf("unexpected message events %q", messages)
+	}
+	if lastMessage != "Unstreamed" {
+		t.Errorf("expected last message 'Unstreamed', got %q", lastMessage)
+	}
+}
+
+func TestParseStreamLongLine(t *testing.T) {
+	// A tool result far beyond bufio.Scanner's 64KB default
+	big := strings.Repeat("x", 200*1024)
+	stream := strings.Join([]string{
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"` + big + `"}]}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"After"}]}}`,
+	}, "\n")
+
+	events := make(chan Event, 10)
+	output := parseStream(strings.NewReader(stream), events, 0, 0)
+	close(events)
+
+	if output.ReadErr != nil {
+		t.Fatalf("unexpected read error: %v", output.ReadErr)
+	}
+	if output.LastMessage != "After" {
+		t.Errorf("expected parsing to continue past the long line, got last message of %d bytes", len(output.LastMessage))
+	}
+	if first := <-events; len(first.Content) != len(big) {
+		t.Errorf("expected the long message to be forwarded intact, got %d bytes", len(first.Content))
+	}
+
+	// The same line over a configured limit is reported, not silently lost
+	events = make(chan Event, 10)
+	output = parseStream(strings.NewReader(stream), events, 64*1024, 0)
+	close(events)
+	if output.ReadErr == nil {
+		t.Fatal("expected a read error for a line over the limit")
+	}
+	if diags := output.Diagnostics(); len(diags) != 1 || !strings.HasPrefix(diags[0], "stream read error") {
+		t.Errorf("expected the read error in diagnostics, got %v", diags)
+	}
+}
+
+func TestParseStreamUnparsedLines(t *testing.T) {
+	stream := strings.Join([]string{
+		`Warning: config file not found, using defaults`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Working"}]}}`,
+		``,
+		`{"type":"assistant","message":{"content":[{"type":"text"`,
+		`Error: not authenticated`,
+	}, "\n")
+
+	events := make(chan Event, 10)
+	output := parseStream(strings.NewReader(stream), events, 0, 0)
+	close(events)
+
+	want := []string{
+		`Warning: config file not found, using defaults`,
+		`{"type":"assistant","message":{"content":[{"type":"text"`,
+		`Error: not authenticated`,
+	}
+	if strings.Join(output.Unparsed, "\n") != strings.Join(want, "\n") {
+		t.Errorf("expected unparsed lines %q, got %q", want, output.Unparsed)
+	}
+	if output.LastMessage != "Working" {
+		t.Errorf("expected JSON lines still parsed, got last message %q", output.LastMessage)
+	}
+	if got := output.ErrorText(errors.New("exit status 1")); got != "exit status 1: Error: not authenticated" {
+		t.Errorf("expected error text to include the last unparsed line, got %q", got)
+	}
+}
+
+func TestParseStreamUnparsedLimit(t *testing.T) {
+	lines := make([]string, maxUnparsedLines+5)
+	for i := range lines {
+		lines[i] = "noise"
+	}
+	lines[0] = strings.Repeat("y", maxUnparsedBytes+100)
+
+	events := make(chan Event, 1)
+	output := parseStream(strings.NewReader(strings.Join(lines, "\n")), events, 0, 0)
+	close(events)
+
+	if len(output.Unparsed) != maxUnparsedLines || output.Dropped != 5 {
+		t.Errorf("expected %d kept and 5 dropped, got %d and %d", maxUnparsedLines, len(output.Unparsed), output.Dropped)
+	}
+	if len(output.Unparsed[0]) != maxUnparsedBytes+len("...") {
+		t.Errorf("expected long unparsed line truncated, got %d bytes", len(output.Unparsed[0]))
+	}
+}
+
+func TestParseStreamSessionID(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"system","subtype":"init","session_id":"b6f1c2"}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Done"}]},"session_id":"b6f1c2"}`,
+		`{"type":"result","session_id":"b6f1c2"}`,
+	}, "\n")
+
+	events := make(chan Event, 10)
+	output := parseStream(strings.NewReader(stream), events, 0, 0)
+	close(events)
+
+	if output.SessionID != "b6f1c2" {
+		t.Errorf("expected session ID b6f1c2, got %q", output.SessionID)
+	}
+
+	// The ID is reported once, as soon as it is seen
+	var sessions []string
+	for e := range events {
+		if e.Type == "session" {
+			sessions = append(sessions, e.Content)
+		}
+	}
+	if len(sessions) != 1 || sessions[0] != "b6f1c2" {
+		t.Errorf("expected one session event for b6f1c2, got %v", sessions)
+	}
+}
+
+func TestParseStreamToolUseAndResult(t *testing.T) {
+	stream := strings.Join([]string{
+		`{"type":"system","subtype":"init","session_id":"s-1","tools":["Bash","mcp__eas__eas_task_get"]}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"Checking the task"},{"type":"tool_use","id":"tu_1","name":"mcp__eas__eas_task_get","input":{"task_id":"t-001"}}],"usage":{"input_tokens":40,"output_tokens":12}}}`,
+		`{"type":"user","message":{"content":[{"type":"tool_result","tool_use_id":"tu_1","content":"{\"id\":\"t-001\"}"}]}}`,
+		`{"type":"assistant","message":{"content":[{"type":"tool_use","id":"tu_2","name":"Bash","input":{}}]}}`,
+		`{"type":"assistant","message":{"content":[{"type":"text","text":"All tests pass"}],"usage":{"input_tokens":60,"output_tokens":8}}}`,
+		`{"type":"result","subtype":"success","is_error":false,"result":"All tests pass","session_id":"s-1","usage":{"input_tokens":100,"output_tokens":20}}`,
+	}, "\n")
+
+	events, lastMessage, usage := collectStream(t, stream)
+
+	var got []string
+	for _, e := range events {
+		if e.Type == "usage" {
+			continue
+		}
+		got = append(got, e.Type+":"+e.Content)
+	}
+	want := []string{
+		"session:s-1",
+		"message:Checking the task",
+		`tool_call:mcp__eas__eas_task_get {"task_id":"t-001"}`,
+		"tool_call:Bash",
+		"message:All tests pass",
+		"complete:done",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("unexpected events:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if lastMessage != "All tests pass" {
+		t.Errorf("expected last message 'All tests pass', got %q", lastMessage)
+	}
+	if usage != (Usage{100, 20}) {
+		t.Errorf("expected result usage 100/20, got %+v", usage)
+	}
+}
+
+func TestToolCallTruncatesInput(t *testing.T) {
+	block := contentBlock{Type: "tool_use", Name: "Write", Input: []byte(`{"content":"` + strings.Repeat("x", 500) + `"}`)}
+	if got := block.toolCall(); len(got) != len("Write ")+maxToolCallInput+len("...") {
+		t.Errorf("expected truncated tool call, got %d bytes", len(got))
+	}
+}
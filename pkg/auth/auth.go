@@ -4,7 +4,12 @@ package auth
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
 )
 
 // Role represents a user role in the system.
@@ -33,26 +38,10 @@ type Authorizer interface {
 	HasPermission(role Role, permission Permission) bool
 }
 
-// basicRole implements the Role interface.
-type basicRole struct {
-	name        string
-	permissions []Permission
-}
-
-func (r *basicRole) Name() string {
-	return r.name
-}
-
-func (r *basicRole) Permissions() []Permission {
-	return r.permissions
-}
-
-// NewRole creates a new role with the given name and permissions.
+// NewRole creates a new role with the given name and permissions. The role
+// is compiled once, so authorizing against it does not scan its permissions.
 func NewRole(name string, permissions []Permission) Role {
-	return &basicRole{
-		name:        name,
-		permissions: permissions,
-	}
+	return compile(name, permissions)
 }
 
 // basicPermission implements the Permission interface.
@@ -81,6 +70,98 @@ func NewPermission(resource, action string) Permission {
 	}
 }
 
+// ParsePermission parses a permission in "resource:action" form, the inverse
+// of Permission.String. It splits on the first colon; either part may be "*".
+func ParsePermission(s string) (Permission, error) {
+	resource, action, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid permission '%s': expected resource:action", s)
+	}
+	if resource == "" || action == "" {
+		return nil, fmt.Errorf("invalid permission '%s': resource and action must be non-empty", s)
+	}
+	return NewPermission(resource, action), nil
+}
+
+// ParsePermissions parses a list of "resource:action" strings, failing on
+// the first malformed entry.
+func ParsePermissions(ss []string) ([]Permission, error) {
+	perms := make([]Permission, 0, len(ss))
+	for _, s := range ss {
+		perm, err := ParsePermission(s)
+		if err != nil {
+			return nil, err
+		}
+		perms = append(perms, perm)
+	}
+	return perms, nil
+}
+
+// Union returns the permissions granted by any of roles, deduplicated by
+// resource:action and in first-seen order. Like Intersect and Subtract it
+// compares permissions literally: "task:*" and "task:read" stay distinct.
+func Union(roles ...Role) []Permission {
+	var perms []Permission
+	seen := make(map[string]bool)
+	for _, role := range roles {
+		for _, perm := range role.Permissions() {
+			if key := perm.String(); !seen[key] {
+				seen[key] = true
+				perms = append(perms, perm)
+			}
+		}
+	}
+	return perms
+}
+
+// Intersect returns the permissions granted by every one of roles, in the
+// order of the first role. It returns nil for no roles.
+func Intersect(roles ...Role) []Permission {
+	if len(roles) == 0 {
+		return nil
+	}
+	var perms []Permission
+	for _, perm := range Union(roles[0]) {
+		inAll := true
+		for _, role := range roles[1:] {
+			if !grants(role.Permissions(), perm) {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// Subtract returns perms without any of remove, deduplicated and in order.
+// Removing "task:read" leaves a "task:*" in perms in place.
+func Subtract(perms []Permission, remove ...Permission) []Permission {
+	var kept []Permission
+	seen := make(map[string]bool)
+	for _, perm := range perms {
+		key := perm.String()
+		if seen[key] || grants(remove, perm) {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, perm)
+	}
+	return kept
+}
+
+// grants reports whether perms holds perm, compared as resource:action.
+func grants(perms []Permission, perm Permission) bool {
+	for _, p := range perms {
+		if p.Resource() == perm.Resource() && p.Action() == perm.Action() {
+			return true
+		}
+	}
+	return false
+}
+
 // NoOpAuthorizer is a stub authorizer that allows all operations.
 // This is for v1 development; production systems should use a real authorizer.
 type NoOpAuthorizer struct{}
@@ -110,34 +191,124 @@ func NewDefaultAuthorizer() *DefaultAuthorizer {
 
 // Authorize checks if the role has the required permission.
 func (a *DefaultAuthorizer) Authorize(ctx context.Context, role Role, resource, action string) error {
-	for _, perm := range role.Permissions() {
-		// Exact match
-		if perm.Resource() == resource && perm.Action() == action {
-			return nil
-		}
-		// Wildcard support - both must match
-		resourceMatch := perm.Resource() == resource || perm.Resource() == "*"
-		actionMatch := perm.Action() == action || perm.Action() == "*"
-		if resourceMatch && actionMatch {
-			return nil
-		}
+	if !allows(role, resource, action) {
+		return fmt.Errorf("unauthorized: role '%s' lacks permission %s:%s", role.Name(), resource, action)
 	}
-	return fmt.Errorf("unauthorized: role '%s' lacks permission %s:%s", role.Name(), resource, action)
+	return nil
 }
 
 // HasPermission checks if the role has a specific permission.
 func (a *DefaultAuthorizer) HasPermission(role Role, permission Permission) bool {
-	for _, perm := range role.Permissions() {
+	return allows(role, permission.Resource(), permission.Action())
+}
+
+// allows reports whether role grants action on resource, using the role's
+// index if it is compiled and scanning its permissions otherwise.
+func allows(role Role, resource, action string) bool {
+	if compiled, ok := role.(*CompiledRole); ok {
+		return compiled.Allows(resource, action)
+	}
+	return scanPermissions(role.Permissions(), resource, action)
+}
+
+// scanPermissions reports whether any of perms grants action on resource.
+// A permission matches when its resource covers resource (see
+// matchResource) and its action is action or "*".
+func scanPermissions(perms []Permission, resource, action string) bool {
+	for _, perm := range perms {
 		// Exact match
-		if perm.Resource() == permission.Resource() && perm.Action() == permission.Action() {
+		if perm.Resource() == resource && perm.Action() == action {
 			return true
 		}
+
 		// Wildcard support - both must match
-		resourceMatch := perm.Resource() == permission.Resource() || perm.Resource() == "*"
-		actionMatch := perm.Action() == permission.Action() || perm.Action() == "*"
+		resourceMatch := matchResource(perm.Resource(), resource)
+		actionMatch := perm.Action() == action || perm.Action() == "*"
 		if resourceMatch && actionMatch {
 			return true
 		}
 	}
 	return false
 }
+
+// matchResource reports whether a permission resource covers resource.
+// "*" matches everything, and a pattern ending in ".*" matches any resource
+// under that prefix (e.g. "task.android.*" matches "task.android.ua-001").
+func matchResource(pattern, resource string) bool {
+	if pattern == resource || pattern == "*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok && strings.HasSuffix(prefix, ".") {
+		return strings.HasPrefix(resource, prefix)
+	}
+	return false
+}
+
+// Decision values recorded by AuditAuthorizer.
+const (
+	DecisionAllow = "allow"
+	DecisionDeny  = "deny"
+)
+
+// AuditRecord is a single authorization decision written by AuditAuthorizer.
+type AuditRecord struct {
+	Timestamp time.Time `json:"timestamp"`
+	Role      string    `json:"role"`
+	Resource  string    `json:"resource"`
+	Action    string    `json:"action"`
+	Decision  string    `json:"decision"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// AuditAuthorizer wraps an Authorizer and records every Authorize call
+// as a JSON line on the given writer. Decisions are forwarded unchanged.
+type AuditAuthorizer struct {
+	inner Authorizer
+	mu    sync.Mutex
+	w     io.Writer
+}
+
+// NewAuditAuthorizer creates an authorizer that logs decisions made by inner to w.
+func NewAuditAuthorizer(inner Authorizer, w io.Writer) *AuditAuthorizer {
+	return &AuditAuthorizer{
+		inner: inner,
+		w:     w,
+	}
+}
+
+// Authorize delegates to the inner authorizer and logs the decision.
+func (a *AuditAuthorizer) Authorize(ctx context.Context, role Role, resource, action string) error {
+	err := a.inner.Authorize(ctx, role, resource, action)
+
+	record := AuditRecord{
+		Timestamp: time.Now(),
+		Role:      role.Name(),
+		Resource:  resource,
+		Action:    action,
+		Decision:  DecisionAllow,
+	}
+	if err != nil {
+		record.Decision = DecisionDeny
+		record.Error = err.Error()
+	}
+	a.write(record)
+
+	return err
+}
+
+// HasPermission delegates to the inner authorizer.
+func (a *AuditAuthorizer) HasPermission(role Role, permission Permission) bool {
+	return a.inner.HasPermission(role, permission)
+}
+
+func (a *AuditAuthorizer) write(record AuditRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		// Logging must never change the authorization outcome
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.w.Write(append(data, '\n'))
+}
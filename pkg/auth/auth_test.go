@@ -1,7 +1,10 @@
 package auth
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -38,6 +41,105 @@ func TestBasicPermission(t *testing.T) {
 	}
 }
 
+func TestParsePermission(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantResource string
+		wantAction   string
+		wantErr      bool
+	}{
+		{"task:read", "task", "read", false},
+		{"*:*", "*", "*", false},
+		{"task.android.*:write", "task.android.*", "write", false},
+		{"config:write:extra", "config", "write:extra", false},
+		{"task", "", "", true},
+		{":read", "", "", true},
+		{"task:", "", "", true},
+		{"", "", "", true},
+	}
+
+	for _, tt := range tests {
+		perm, err := ParsePermission(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePermission(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			continue
+		}
+		if perm.Resource() != tt.wantResource || perm.Action() != tt.wantAction {
+			t.Errorf("ParsePermission(%q) = %s:%s, want %s:%s",
+				tt.input, perm.Resource(), perm.Action(), tt.wantResource, tt.wantAction)
+		}
+		// Round-trips through String
+		if perm.String() != tt.input {
+			t.Errorf("ParsePermission(%q).String() = %q", tt.input, perm.String())
+		}
+	}
+}
+
+func TestParsePermissions(t *testing.T) {
+	perms, err := ParsePermissions([]string{"task:read", "workspace:*"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(perms) != 2 || perms[1].String() != "workspace:*" {
+		t.Errorf("unexpected permissions: %v", perms)
+	}
+
+	if _, err := ParsePermissions([]string{"task:read", "bogus"}); err == nil {
+		t.Error("expected error for malformed entry")
+	}
+}
+
+// permStrings formats perms for comparison.
+func permStrings(perms []Permission) string {
+	var ss []string
+	for _, perm := range perms {
+		ss = append(ss, perm.String())
+	}
+	return strings.Join(ss, " ")
+}
+
+func mustRole(t *testing.T, name string, perms ...string) Role {
+	t.Helper()
+	parsed, err := ParsePermissions(perms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewRole(name, parsed)
+}
+
+func TestPermissionSetOperations(t *testing.T) {
+	developer := mustRole(t, "developer", "task:read", "task:write", "workspace:execute")
+	reviewer := mustRole(t, "reviewer", "task:read", "task:*", "spec:read")
+
+	tests := []struct {
+		name string
+		got  []Permission
+		want string
+	}{
+		{"union dedups", Union(developer, reviewer), "task:read task:write workspace:execute task:* spec:read"},
+		{"union of none", Union(), ""},
+		{"intersect", Intersect(developer, reviewer), "task:read"},
+		{"intersect of none", Intersect(), ""},
+		{
+			"lead is developer and reviewer minus one action",
+			Subtract(Union(developer, reviewer), NewPermission("workspace", "execute")),
+			"task:read task:write task:* spec:read",
+		},
+		{"subtract keeps wildcards", Subtract(reviewer.Permissions(), NewPermission("task", "write")), "task:read task:* spec:read"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := permStrings(tt.got); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestNoOpAuthorizer(t *testing.T) {
 	auth := NewNoOpAuthorizer()
 	ctx := context.Background()
@@ -140,6 +242,44 @@ func TestDefaultAuthorizerResourceWildcard(t *testing.T) {
 	}
 }
 
+func TestDefaultAuthorizerPrefixWildcard(t *testing.T) {
+	auth := NewDefaultAuthorizer()
+	ctx := context.Background()
+
+	role := NewRole("android-dev", []Permission{
+		NewPermission("task.android.*", "write"),
+	})
+
+	if err := auth.Authorize(ctx, role, "task.android.ua-001", "write"); err != nil {
+		t.Errorf("task.android.* should allow task.android.ua-001: %v", err)
+	}
+	if err := auth.Authorize(ctx, role, "task.android.sub.ua-003", "write"); err != nil {
+		t.Errorf("task.android.* should allow nested resources: %v", err)
+	}
+	if err := auth.Authorize(ctx, role, "task.ios.ua-002", "write"); err == nil {
+		t.Error("task.android.* should deny task.ios.ua-002")
+	}
+	if err := auth.Authorize(ctx, role, "task.androidx.ua-004", "write"); err == nil {
+		t.Error("task.android.* should deny task.androidx.ua-004")
+	}
+	if err := auth.Authorize(ctx, role, "task.android", "write"); err == nil {
+		t.Error("task.android.* should deny the bare prefix")
+	}
+	if err := auth.Authorize(ctx, role, "task.android.ua-001", "read"); err == nil {
+		t.Error("task.android.* with write action should deny read")
+	}
+
+	if !auth.HasPermission(role, NewPermission("task.android.ua-001", "write")) {
+		t.Error("HasPermission should honor prefix wildcards")
+	}
+
+	// A bare * still allows everything
+	admin := NewRole("admin", []Permission{NewPermission("*", "*")})
+	if err := auth.Authorize(ctx, admin, "task.ios.ua-002", "delete"); err != nil {
+		t.Errorf("* should allow everything: %v", err)
+	}
+}
+
 func TestDefaultAuthorizerActionWildcard(t *testing.T) {
 	auth := NewDefaultAuthorizer()
 	ctx := context.Background()
@@ -219,3 +359,80 @@ func TestEmptyRole(t *testing.T) {
 		t.Error("empty role should not have any permissions")
 	}
 }
+
+func TestAuditAuthorizerLogsDecisions(t *testing.T) {
+	var buf bytes.Buffer
+	inner := NewDefaultAuthorizer()
+	auth := NewAuditAuthorizer(inner, &buf)
+	ctx := context.Background()
+
+	role := NewRole("developer", []Permission{
+		NewPermission("task", "read"),
+	})
+
+	if err := auth.Authorize(ctx, role, "task", "read"); err != nil {
+		t.Errorf("expected allow, got: %v", err)
+	}
+
+	err := auth.Authorize(ctx, role, "task", "delete")
+	innerErr := inner.Authorize(ctx, role, "task", "delete")
+	if err == nil || innerErr == nil || err.Error() != innerErr.Error() {
+		t.Errorf("expected deny matching inner authorizer, got %v (inner %v)", err, innerErr)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %d: %q", len(lines), buf.String())
+	}
+
+	tests := []struct {
+		line     string
+		action   string
+		decision string
+		hasError bool
+	}{
+		{lines[0], "read", DecisionAllow, false},
+		{lines[1], "delete", DecisionDeny, true},
+	}
+
+	for _, tt := range tests {
+		var record AuditRecord
+		if err := json.Unmarshal([]byte(tt.line), &record); err != nil {
+			t.Fatalf("failed to parse log line %q: %v", tt.line, err)
+		}
+		if record.Role != "developer" {
+			t.Errorf("expected role 'developer', got '%s'", record.Role)
+		}
+		if record.Resource != "task" {
+			t.Errorf("expected resource 'task', got '%s'", record.Resource)
+		}
+		if record.Action != tt.action {
+			t.Errorf("expected action '%s', got '%s'", tt.action, record.Action)
+		}
+		if record.Decision != tt.decision {
+			t.Errorf("expected decision '%s', got '%s'", tt.decision, record.Decision)
+		}
+		if record.Timestamp.IsZero() {
+			t.Error("expected timestamp to be set")
+		}
+		if (record.Error != "") != tt.hasError {
+			t.Errorf("unexpected error field: %q", record.Error)
+		}
+	}
+}
+
+func TestAuditAuthorizerForwardsNoOp(t *testing.T) {
+	var buf bytes.Buffer
+	auth := NewAuditAuthorizer(NewNoOpAuthorizer(), &buf)
+
+	role := NewRole("guest", []Permission{})
+	if err := auth.Authorize(context.Background(), role, "config", "write"); err != nil {
+		t.Errorf("expected NoOp decision to be forwarded, got: %v", err)
+	}
+	if !auth.HasPermission(role, NewPermission("config", "write")) {
+		t.Error("expected HasPermission to be forwarded")
+	}
+	if !strings.Contains(buf.String(), `"decision":"allow"`) {
+		t.Errorf("expected allow decision in log, got %q", buf.String())
+	}
+}
@@ -0,0 +1,100 @@
+package auth
+
+import "strings"
+
+// CompiledRole is a role with its permissions indexed for authorization.
+// Checks look up the resource and its ".*" prefixes in maps rather than
+// scanning every permission, which matters for roles with many grants.
+// It is built once by NewRole or CompileRole and never changes.
+type CompiledRole struct {
+	name        string
+	permissions []Permission
+
+	exact    map[string]*actionSet // Literal resources
+	prefixes map[string]*actionSet // "task.android.*" indexed as "task.android."
+	global   actionSet             // Resource "*"
+}
+
+// actionSet holds the actions granted on one resource pattern.
+type actionSet struct {
+	all     bool // Action "*"
+	actions map[string]bool
+}
+
+func (s *actionSet) add(action string) {
+	if action == "*" {
+		s.all = true
+		return
+	}
+	if s.actions == nil {
+		s.actions = make(map[string]bool)
+	}
+	s.actions[action] = true
+}
+
+func (s *actionSet) allows(action string) bool {
+	return s != nil && (s.all || s.actions[action])
+}
+
+// CompileRole indexes role's permissions. The result grants exactly what
+// role does under DefaultAuthorizer. A role that is already compiled is
+// returned as is.
+func CompileRole(role Role) *CompiledRole {
+	if compiled, ok := role.(*CompiledRole); ok {
+		return compiled
+	}
+	return compile(role.Name(), role.Permissions())
+}
+
+func compile(name string, permissions []Permission) *CompiledRole {
+	r := &CompiledRole{
+		name:        name,
+		permissions: permissions,
+		exact:       make(map[string]*actionSet),
+		prefixes:    make(map[string]*actionSet),
+	}
+	for _, perm := range permissions {
+		resource := perm.Resource()
+		if resource == "*" {
+			r.global.add(perm.Action())
+			continue
+		}
+		bucket := r.exact
+		if prefix, ok := strings.CutSuffix(resource, "*"); ok && strings.HasSuffix(prefix, ".") {
+			// A literal "task.*" resource also has the prefix "task.", so
+			// the prefix bucket covers the exact match too
+			bucket, resource = r.prefixes, prefix
+		}
+		set, ok := bucket[resource]
+		if !ok {
+			set = &actionSet{}
+			bucket[resource] = set
+		}
+		set.add(perm.Action())
+	}
+	return r
+}
+
+// Name returns the role name.
+func (r *CompiledRole) Name() string {
+	return r.name
+}
+
+// Permissions returns the permissions the role was compiled from.
+func (r *CompiledRole) Permissions() []Permission {
+	return r.permissions
+}
+
+// Allows reports whether the role grants action on resource, with the same
+// wildcard rules as DefaultAuthorizer.
+func (r *CompiledRole) Allows(resource, action string) bool {
+	if r.global.allows(action) || r.exact[resource].allows(action) {
+		return true
+	}
+	for i := 0; i < len(resource); i++ {
+		if resource[i] == '.' && r.prefixes[resource[:i+1]].allows(action) {
+			return true
+		}
+	}
+	return false
+}
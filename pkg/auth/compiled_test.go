@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// scannedRole hides a role's concrete type so DefaultAuthorizer scans its
+// permissions instead of using a compiled index.
+type scannedRole struct{ Role }
+
+func TestCompiledRoleMatchesScan(t *testing.T) {
+	resources := []string{
+		"*", "task", "task.", "task.*", "task.android", "task.android.*",
+		"task.android.ua-001", "task.ios.ua-002", "task*", "workspace", "config:x", "",
+	}
+	actions := []string{"*", "read", "write", "delete", ""}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		var perms []Permission
+		for n := rng.Intn(8); n > 0; n-- {
+			perms = append(perms, NewPermission(resources[rng.Intn(len(resources))], actions[rng.Intn(len(actions))]))
+		}
+		compiled := CompileRole(NewRole("generated", perms))
+
+		for _, resource := range resources {
+			for _, action := range actions {
+				want := scanPermissions(perms, resource, action)
+				if got := compiled.Allows(resource, action); got != want {
+					t.Fatalf("permissions %s: Allows(%q, %q) = %v, scan says %v",
+						permStrings(perms), resource, action, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestCompileRole(t *testing.T) {
+	role := mustRole(t, "developer", "task:read")
+	compiled := CompileRole(role)
+	if CompileRole(compiled) != compiled {
+		t.Error("expected a compiled role to be returned as is")
+	}
+
+	recompiled := CompileRole(scannedRole{role})
+	if recompiled.Name() != "developer" || permStrings(recompiled.Permissions()) != "task:read" {
+		t.Errorf("unexpected compiled role %s: %s", recompiled.Name(), permStrings(recompiled.Permissions()))
+	}
+	if !recompiled.Allows("task", "read") || recompiled.Allows("task", "write") {
+		t.Error("expected the compiled role to grant only task:read")
+	}
+}
+
+func BenchmarkAuthorize(b *testing.B) {
+	var perms []Permission
+	for i := 0; i < 500; i++ {
+		perms = append(perms, NewPermission(fmt.Sprintf("task.repo%d.*", i), "write"))
+	}
+	perms = append(perms, NewPermission("config", "read"))
+
+	auth := NewDefaultAuthorizer()
+	ctx := context.Background()
+	roles := map[string]Role{
+		"scan":     scannedRole{NewRole("admin", perms)},
+		"compiled": NewRole("admin", perms),
+	}
+	for name, role := range roles {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if err := auth.Authorize(ctx, role, "config", "read"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
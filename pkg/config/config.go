@@ -3,29 +3,78 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/fileutil"
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/task"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the feature configuration.
 type Config struct {
-	Feature   string                `yaml:"feature"`
-	Version   int                   `yaml:"version"`
-	Backend   string                `yaml:"backend"`
-	Claude    *ClaudeConfig         `yaml:"claude,omitempty"`
-	Copilot   *CopilotConfig        `yaml:"copilot,omitempty"`
-	TDD       TDDConfig             `yaml:"tdd"`
-	Repos     map[string]Repo       `yaml:"repos,omitempty"`
-	TaskTypes map[string]TaskType   `yaml:"taskTypes,omitempty"`
+	Include   []string                  `yaml:"include,omitempty"`
+	Feature   string                    `yaml:"feature"`
+	Version   int                       `yaml:"version,omitempty"`
+	Backend   string                    `yaml:"backend,omitempty"`
+	Claude    *ClaudeConfig             `yaml:"claude,omitempty"`
+	Copilot   *CopilotConfig            `yaml:"copilot,omitempty"`
+	OpenAI    *OpenAIConfig             `yaml:"openai,omitempty"`
+	TDD       TDDConfig                 `yaml:"tdd,omitempty"`
+	Retry     *RetryConfig              `yaml:"retry,omitempty"`
+	Repos     map[string]Repo           `yaml:"repos,omitempty"`
+	TaskTypes map[string]TaskType       `yaml:"taskTypes,omitempty"`
+	Profiles  map[string]ConfigOverride `yaml:"profiles,omitempty"`
+
+	Notifications *NotificationsConfig `yaml:"notifications,omitempty"`
+	Pricing       agent.PricingConfig  `yaml:"pricing,omitempty"` // Keyed by "backend/model"
+	Quota         *QuotaConfig         `yaml:"quota,omitempty"`
+	MCP           *MCPConfig           `yaml:"mcp,omitempty"`
+	SpecInclusion string               `yaml:"spec_inclusion,omitempty"`  // How much of SPEC.md prompts include (default: section when a task has a spec ref, else full)
+	TaskIDPattern string               `yaml:"task_id_pattern,omitempty"` // Regexp new task IDs must match in full (default: any ID)
+
+	DefaultTaskTimeout time.Duration `yaml:"default_task_timeout,omitempty"` // Per-task run limit when --timeout is not given (0 = no timeout)
+
+	// base is the unmerged config when loaded via includes or LoadProfile,
+	// so that saving never writes included or profile values into the file.
+	base *Config
+}
+
+// ConfigOverride holds the fields a named profile may override.
+// Only fields that are explicitly set replace the base config values.
+type ConfigOverride struct {
+	Backend   string              `yaml:"backend,omitempty"`
+	Claude    *ClaudeConfig       `yaml:"claude,omitempty"`
+	Copilot   *CopilotConfig      `yaml:"copilot,omitempty"`
+	OpenAI    *OpenAIConfig       `yaml:"openai,omitempty"`
+	TDD       *TDDOverride        `yaml:"tdd,omitempty"`
+	Repos     map[string]Repo     `yaml:"repos,omitempty"`
+	TaskTypes map[string]TaskType `yaml:"taskTypes,omitempty"`
+}
+
+// TDDOverride holds TDD settings a profile may override.
+// Pointers distinguish "not set" from explicit false/zero values.
+type TDDOverride struct {
+	Enforce           *bool  `yaml:"enforce,omitempty"`
+	TestCommand       string `yaml:"test_command,omitempty"`
+	CoverageThreshold *int   `yaml:"coverage_threshold,omitempty"`
 }
 
 // ClaudeConfig holds Claude-specific settings.
 type ClaudeConfig struct {
-	CLIPath   string   `yaml:"cli_path,omitempty"`
-	Model     string   `yaml:"model,omitempty"`
-	ExtraArgs []string `yaml:"extra_args,omitempty"`
+	CLIPath        string       `yaml:"cli_path,omitempty"`
+	Model          string       `yaml:"model,omitempty"`
+	ExtraArgs      []string     `yaml:"extra_args,omitempty"`
+	Retry          *RetryConfig `yaml:"retry,omitempty"`
+	MaxOutputBytes int          `yaml:"max_output_bytes,omitempty"` // Output read before a runaway session is stopped (0 = agent.DefaultMaxOutputBytes)
+	MaxLineBytes   int          `yaml:"max_line_bytes,omitempty"`   // Longest stream-json line accepted (0 = agent.DefaultMaxLineBytes)
 }
 
 // CopilotConfig holds Copilot-specific settings.
@@ -33,6 +82,57 @@ type CopilotConfig struct {
 	CLIPath  string          `yaml:"cli_path,omitempty"`
 	Model    string          `yaml:"model,omitempty"`
 	Provider *ProviderConfig `yaml:"provider,omitempty"`
+	Retry    *RetryConfig    `yaml:"retry,omitempty"`
+}
+
+// OpenAIConfig holds settings for the OpenAI-compatible backend, which
+// talks to Provider.BaseURL directly rather than through a CLI.
+type OpenAIConfig struct {
+	Model          string          `yaml:"model,omitempty"`
+	Provider       *ProviderConfig `yaml:"provider,omitempty"`
+	Retry          *RetryConfig    `yaml:"retry,omitempty"`
+	MaxOutputBytes int             `yaml:"max_output_bytes,omitempty"` // Stream read before a runaway run is stopped (0 = agent.DefaultMaxOutputBytes)
+	MaxLineBytes   int             `yaml:"max_line_bytes,omitempty"`   // Longest SSE line accepted (0 = agent.DefaultMaxLineBytes)
+}
+
+// RetryConfig holds retry/backoff settings for backend calls.
+// A backend-level section replaces the workspace-level one.
+type RetryConfig struct {
+	MaxAttempts int           `yaml:"max_attempts"`
+	BaseDelay   time.Duration `yaml:"base_delay,omitempty"`
+	MaxDelay    time.Duration `yaml:"max_delay,omitempty"`
+}
+
+// DefaultRetry returns the retry settings used when none are configured.
+func DefaultRetry() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 4,
+		BaseDelay:   time.Second,
+		MaxDelay:    30 * time.Second,
+	}
+}
+
+// Validate checks the retry settings are usable.
+func (r *RetryConfig) Validate() error {
+	if r.MaxAttempts < 1 {
+		return fmt.Errorf("max_attempts must be at least 1, got %d", r.MaxAttempts)
+	}
+	if r.BaseDelay < 0 {
+		return fmt.Errorf("base_delay must be non-negative, got %s", r.BaseDelay)
+	}
+	if r.MaxDelay < 0 {
+		return fmt.Errorf("max_delay must be non-negative, got %s", r.MaxDelay)
+	}
+	return nil
+}
+
+// AgentConfig converts the settings for use with agent.NewRetryableBackend.
+func (r RetryConfig) AgentConfig() agent.RetryConfig {
+	cfg := agent.DefaultRetryConfig()
+	cfg.MaxRetries = r.MaxAttempts - 1
+	cfg.InitialBackoff = r.BaseDelay
+	cfg.MaxBackoff = r.MaxDelay
+	return cfg
 }
 
 // ProviderConfig holds BYOK provider settings.
@@ -42,6 +142,30 @@ type ProviderConfig struct {
 	APIKeyEnv string `yaml:"api_key_env,omitempty"`
 }
 
+// Validate checks the provider can be used before a backend is launched:
+// base_url must be an http(s) URL and the variable named by api_key_env,
+// if any, must be set. Neither value appears in the error, since both are
+// masked by Redacted.
+func (p *ProviderConfig) Validate() error {
+	u, err := url.Parse(p.BaseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base_url must be an http(s) URL")
+	}
+	if p.APIKeyEnv != "" && os.Getenv(p.APIKeyEnv) == "" {
+		return fmt.Errorf("api_key_env %s is not set", p.APIKeyEnv)
+	}
+	return nil
+}
+
+// AgentConfig converts the settings for use with the agent backends.
+func (p ProviderConfig) AgentConfig() *agent.ProviderConfig {
+	return &agent.ProviderConfig{
+		Type:      p.Type,
+		BaseURL:   p.BaseURL,
+		APIKeyEnv: p.APIKeyEnv,
+	}
+}
+
 // TDDConfig holds TDD enforcement settings.
 type TDDConfig struct {
 	Enforce           bool   `yaml:"enforce"`
@@ -49,17 +173,347 @@ type TDDConfig struct {
 	CoverageThreshold int    `yaml:"coverage_threshold,omitempty"`
 }
 
+// MCPConfig sets how backends launch the EAS MCP server. Unset fields keep
+// the defaults: an eas binary in the current directory or on PATH, run as
+// "eas mcp serve".
+type MCPConfig struct {
+	Name      string   `yaml:"name,omitempty"`       // Server name in the generated config
+	Command   string   `yaml:"command,omitempty"`    // Binary path or name on PATH
+	ExtraArgs []string `yaml:"extra_args,omitempty"` // Appended to "mcp serve"
+
+	// ConcurrentTaskCalls lets tool calls that change the same task run
+	// concurrently. By default they run one at a time per task.
+	ConcurrentTaskCalls bool `yaml:"concurrent_task_calls,omitempty"`
+
+	// Servers are additional MCP servers offered to backends, keyed by name.
+	Servers map[string]MCPServerSpec `yaml:"servers,omitempty"`
+}
+
+// MCPServerSpec is an additional MCP server. An entry named like the
+// built-in server replaces it only when Override is set.
+type MCPServerSpec struct {
+	Command  string            `yaml:"command"`
+	Args     []string          `yaml:"args,omitempty"`
+	Env      map[string]string `yaml:"env,omitempty"`
+	Cwd      string            `yaml:"cwd,omitempty"`
+	Override bool              `yaml:"override,omitempty"`
+}
+
+// Validate checks every additional server has a command.
+func (m *MCPConfig) Validate() error {
+	names := make([]string, 0, len(m.Servers))
+	for name := range m.Servers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("server name is required")
+		}
+		if m.Servers[name].Command == "" {
+			return fmt.Errorf("server '%s': command is required", name)
+		}
+	}
+	return nil
+}
+
+// QuotaConfig holds usage limits enforced by the quota tracker.
+type QuotaConfig struct {
+	Window  time.Duration         `yaml:"window,omitempty"`  // Limit window (0 = quota.DefaultWindow)
+	Backoff time.Duration         `yaml:"backoff,omitempty"` // Cooldown after a quota error without a retry-after (0 = agent.DefaultQuotaBackoff)
+	Limits  map[string]QuotaLimit `yaml:"limits,omitempty"`  // Keyed by backend or "backend/model"
+	Pools   map[string]QuotaPool  `yaml:"pools,omitempty"`   // Shared limits, keyed by pool name
+}
+
+// QuotaPool is a limit shared by several backends or models, such as a
+// "premium" pool of the expensive models. Each member still has its own
+// limits; it is exhausted when either they or the pool's run out.
+type QuotaPool struct {
+	Members  []string `yaml:"members"` // Backends or "backend/model" refs
+	Requests int      `yaml:"requests,omitempty"`
+	Tokens   int      `yaml:"tokens,omitempty"`
+}
+
+// QuotaLimit caps usage of a backend or model within the quota window.
+// Rate and Burst additionally space out session starts: at most Rate
+// starts per second, and at most Burst (default 1) sessions at once.
+// A zero field is not limited.
+type QuotaLimit struct {
+	Requests int     `yaml:"requests,omitempty"`
+	Tokens   int     `yaml:"tokens,omitempty"`
+	Rate     float64 `yaml:"rate,omitempty"`
+	Burst    int     `yaml:"burst,omitempty"`
+}
+
+// DefaultQuotaLimits returns the limits used for backends the config does
+// not limit.
+func DefaultQuotaLimits() map[string]QuotaLimit {
+	return map[string]QuotaLimit{
+		"claude":  {Requests: 50},
+		"copilot": {Requests: 100},
+	}
+}
+
+// Validate checks the window, backoff and limits are usable.
+func (q *QuotaConfig) Validate() error {
+	if q.Window < 0 {
+		return fmt.Errorf("window must be non-negative, got %s", q.Window)
+	}
+	if q.Backoff < 0 {
+		return fmt.Errorf("backoff must be non-negative, got %s", q.Backoff)
+	}
+
+	keys := make([]string, 0, len(q.Limits))
+	for key := range q.Limits {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		backend := key
+		if strings.Contains(key, "/") {
+			if err := ValidateModelRef(key); err != nil {
+				return err
+			}
+			backend, _, _ = agent.ParseModelRef(key)
+		}
+		if !agent.IsRegistered(backend) {
+			return fmt.Errorf("limit '%s' uses unknown backend '%s' (available: %s)",
+				key, backend, strings.Join(agent.ListBackends(), ", "))
+		}
+
+		limit := q.Limits[key]
+		if limit.Requests < 0 || limit.Tokens < 0 || limit.Rate < 0 || limit.Burst < 0 {
+			return fmt.Errorf("limit '%s': values must be non-negative", key)
+		}
+		if limit.Requests == 0 && limit.Tokens == 0 && limit.Rate == 0 {
+			return fmt.Errorf("limit '%s': requests, tokens or rate must be positive", key)
+		}
+		if limit.Burst > 0 && limit.Rate == 0 {
+			return fmt.Errorf("limit '%s': burst requires a rate", key)
+		}
+	}
+	return q.validatePools()
+}
+
+// validatePools checks each pool has a limit and members, and that no key
+// is in two pools. A pool may not hold a backend and one of its models, as
+// a run counts against both keys and so would count twice.
+func (q *QuotaConfig) validatePools() error {
+	names := make([]string, 0, len(q.Pools))
+	for name := range q.Pools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	poolOf := make(map[string]string)
+	for _, name := range names {
+		pool := q.Pools[name]
+		if pool.Requests < 0 || pool.Tokens < 0 {
+			return fmt.Errorf("pool '%s': values must be non-negative", name)
+		}
+		if pool.Requests == 0 && pool.Tokens == 0 {
+			return fmt.Errorf("pool '%s': requests or tokens must be positive", name)
+		}
+		if len(pool.Members) == 0 {
+			return fmt.Errorf("pool '%s' has no members", name)
+		}
+
+		for _, key := range pool.Members {
+			backend := key
+			if strings.Contains(key, "/") {
+				if err := ValidateModelRef(key); err != nil {
+					return fmt.Errorf("pool '%s': %w", name, err)
+				}
+				backend, _, _ = agent.ParseModelRef(key)
+			}
+			if !agent.IsRegistered(backend) {
+				return fmt.Errorf("pool '%s' member '%s' uses unknown backend '%s' (available: %s)",
+					name, key, backend, strings.Join(agent.ListBackends(), ", "))
+			}
+			if other, ok := poolOf[key]; ok {
+				return fmt.Errorf("'%s' is in pools '%s' and '%s'", key, other, name)
+			}
+			poolOf[key] = name
+		}
+	}
+
+	for key, name := range poolOf {
+		backend, _, err := agent.ParseModelRef(key)
+		if err != nil {
+			continue // A backend, not a model
+		}
+		if poolOf[backend] == name {
+			return fmt.Errorf("pool '%s' has both '%s' and its model '%s'", name, backend, key)
+		}
+	}
+	return nil
+}
+
+// QuotaLimits returns the configured limits, with DefaultQuotaLimits filling
+// in any backend that has no limit of its own. Fields a configured limit
+// leaves at zero keep the default, so a rate-only limit keeps the default
+// request cap.
+func (c *Config) QuotaLimits() map[string]QuotaLimit {
+	limits := DefaultQuotaLimits()
+	if c.Quota == nil {
+		return limits
+	}
+	for key, limit := range c.Quota.Limits {
+		merged := limits[key]
+		if limit.Requests != 0 {
+			merged.Requests = limit.Requests
+		}
+		if limit.Tokens != 0 {
+			merged.Tokens = limit.Tokens
+		}
+		if limit.Rate != 0 {
+			merged.Rate = limit.Rate
+		}
+		if limit.Burst != 0 {
+			merged.Burst = limit.Burst
+		}
+		limits[key] = merged
+	}
+	return limits
+}
+
+// QuotaBackoff returns how long a backend is marked exhausted after a quota
+// error that gives no retry-after, or 0 for agent.DefaultQuotaBackoff.
+func (c *Config) QuotaBackoff() time.Duration {
+	if c.Quota == nil {
+		return 0
+	}
+	return c.Quota.Backoff
+}
+
+// ApplyQuota sets the tracker's window, limits and pools from the config.
+func (c *Config) ApplyQuota(t *quota.Tracker) {
+	if c.Quota != nil && c.Quota.Window > 0 {
+		t.SetWindow(c.Quota.Window)
+	}
+	for key, limit := range c.QuotaLimits() {
+		if limit.Requests > 0 {
+			t.SetLimit(key, limit.Requests)
+		}
+		if limit.Tokens > 0 {
+			t.SetTokenLimit(key, limit.Tokens)
+		}
+	}
+	if c.Quota == nil {
+		return
+	}
+	for name, pool := range c.Quota.Pools {
+		for _, key := range pool.Members {
+			t.SetPool(key, name)
+		}
+		if pool.Requests > 0 {
+			t.SetLimit(quota.PoolKey(name), pool.Requests)
+		}
+		if pool.Tokens > 0 {
+			t.SetTokenLimit(quota.PoolKey(name), pool.Tokens)
+		}
+	}
+}
+
+// ApplyRateLimits sets the limiter's per-backend start limits from the
+// quota limits that have a rate.
+func (c *Config) ApplyRateLimits(l *agent.RateLimiter) {
+	for key, limit := range c.QuotaLimits() {
+		if limit.Rate > 0 {
+			l.SetLimit(key, limit.Rate, limit.Burst)
+		}
+	}
+}
+
+// Notification events a webhook can subscribe to.
+const (
+	EventCompleted = "completed"
+	EventFailed    = "failed"
+)
+
+// NotificationsConfig holds outbound notification settings.
+type NotificationsConfig struct {
+	Webhook *WebhookConfig `yaml:"webhook,omitempty"`
+	Slack   *SlackConfig   `yaml:"slack,omitempty"`
+}
+
+// SlackConfig holds the Slack incoming webhook that status summaries are posted to.
+type SlackConfig struct {
+	URL     string        `yaml:"url"`
+	Timeout time.Duration `yaml:"timeout,omitempty"` // Per-attempt timeout (0 = default)
+}
+
+// Webhook returns the Slack settings as a plain webhook subscribed to all events.
+func (s *SlackConfig) Webhook() WebhookConfig {
+	return WebhookConfig{URL: s.URL, Timeout: s.Timeout}
+}
+
+// WebhookConfig holds settings for POSTing task events to a URL.
+type WebhookConfig struct {
+	URL     string        `yaml:"url"`
+	Events  []string      `yaml:"events,omitempty"`  // completed, failed (empty = all)
+	Timeout time.Duration `yaml:"timeout,omitempty"` // Per-attempt timeout (0 = default)
+}
+
+// Validate checks the webhook settings are usable.
+func (w *WebhookConfig) Validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL, got '%s'", w.URL)
+	}
+	for _, event := range w.Events {
+		if event != EventCompleted && event != EventFailed {
+			return fmt.Errorf("unknown event '%s' (must be %s or %s)", event, EventCompleted, EventFailed)
+		}
+	}
+	if w.Timeout < 0 {
+		return fmt.Errorf("timeout must be non-negative, got %s", w.Timeout)
+	}
+	return nil
+}
+
+// Subscribed reports whether the webhook wants event.
+func (w *WebhookConfig) Subscribed(event string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
 // Repo represents a linked repository.
+// Backend, Model and TestCommand, when set, override the workspace defaults for tasks in this repo.
 type Repo struct {
-	URL    string `yaml:"url"`
-	Branch string `yaml:"branch,omitempty"`
-	Path   string `yaml:"path,omitempty"`
+	URL         string `yaml:"url"`
+	Branch      string `yaml:"branch,omitempty"`
+	Path        string `yaml:"path,omitempty"`
+	Backend     string `yaml:"backend,omitempty"`
+	Model       string `yaml:"model,omitempty"`
+	TestCommand string `yaml:"test_command,omitempty"`
 }
 
 // TaskType represents configuration for a task type.
 type TaskType struct {
-	Model    string `yaml:"model"`
-	Thinking string `yaml:"thinking,omitempty"`
+	Model            string   `yaml:"model"`
+	Fallback         string   `yaml:"fallback,omitempty"`
+	Fallbacks        []string `yaml:"fallbacks,omitempty"` // Tried after Fallback, in order
+	Thinking         string   `yaml:"thinking,omitempty"`
+	TestCommand      string   `yaml:"test_command,omitempty"`
+	CompletionChecks []string `yaml:"completion_checks,omitempty"` // Shell commands run after the tests, in order
+}
+
+// FallbackChain returns the task type's fallback references in order:
+// Fallback, if set, followed by Fallbacks.
+func (tt TaskType) FallbackChain() []string {
+	var chain []string
+	if tt.Fallback != "" {
+		chain = append(chain, tt.Fallback)
+	}
+	return append(chain, tt.Fallbacks...)
 }
 
 // New creates a new Config with default values.
@@ -150,18 +604,302 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("feature name is required")
 	}
 
-	if c.Backend != "claude" && c.Backend != "copilot" {
-		return fmt.Errorf("backend must be 'claude' or 'copilot', got '%s'", c.Backend)
+	if !agent.IsRegistered(c.Backend) {
+		return fmt.Errorf("backend must be one of %s, got '%s'", strings.Join(agent.ListBackends(), ", "), c.Backend)
+	}
+
+	// Check retry settings
+	if c.Retry != nil {
+		if err := c.Retry.Validate(); err != nil {
+			return fmt.Errorf("retry: %w", err)
+		}
+	}
+	if c.Claude != nil && c.Claude.Retry != nil {
+		if err := c.Claude.Retry.Validate(); err != nil {
+			return fmt.Errorf("claude retry: %w", err)
+		}
+	}
+	if c.Claude != nil {
+		if err := agent.CheckExtraArgs(c.Claude.ExtraArgs); err != nil {
+			return fmt.Errorf("claude: %w", err)
+		}
+		if c.Claude.MaxOutputBytes < 0 {
+			return fmt.Errorf("claude: max_output_bytes must be non-negative, got %d", c.Claude.MaxOutputBytes)
+		}
+		if c.Claude.MaxLineBytes < 0 {
+			return fmt.Errorf("claude: max_line_bytes must be non-negative, got %d", c.Claude.MaxLineBytes)
+		}
+	}
+	if c.Copilot != nil && c.Copilot.Retry != nil {
+		if err := c.Copilot.Retry.Validate(); err != nil {
+			return fmt.Errorf("copilot retry: %w", err)
+		}
+	}
+	if c.OpenAI != nil {
+		if c.OpenAI.Retry != nil {
+			if err := c.OpenAI.Retry.Validate(); err != nil {
+				return fmt.Errorf("openai retry: %w", err)
+			}
+		}
+		if c.OpenAI.MaxOutputBytes < 0 {
+			return fmt.Errorf("openai: max_output_bytes must be non-negative, got %d", c.OpenAI.MaxOutputBytes)
+		}
+		if c.OpenAI.MaxLineBytes < 0 {
+			return fmt.Errorf("openai: max_line_bytes must be non-negative, got %d", c.OpenAI.MaxLineBytes)
+		}
+	}
+
+	if c.Notifications != nil && c.Notifications.Webhook != nil {
+		if err := c.Notifications.Webhook.Validate(); err != nil {
+			return fmt.Errorf("notifications webhook: %w", err)
+		}
+	}
+	if c.Notifications != nil && c.Notifications.Slack != nil {
+		webhook := c.Notifications.Slack.Webhook()
+		if err := webhook.Validate(); err != nil {
+			return fmt.Errorf("notifications slack: %w", err)
+		}
+	}
+
+	if c.Quota != nil {
+		if err := c.Quota.Validate(); err != nil {
+			return fmt.Errorf("quota: %w", err)
+		}
+	}
+	if c.MCP != nil {
+		if err := c.MCP.Validate(); err != nil {
+			return fmt.Errorf("mcp: %w", err)
+		}
+	}
+	if _, err := task.CompileIDPattern(c.TaskIDPattern); err != nil {
+		return fmt.Errorf("task_id_pattern: %w", err)
+	}
+	if c.DefaultTaskTimeout < 0 {
+		return fmt.Errorf("default_task_timeout must be non-negative, got %s", c.DefaultTaskTimeout)
+	}
+	switch c.SpecInclusion {
+	case "", SpecFull, SpecSection, SpecNone:
+	default:
+		return fmt.Errorf("spec_inclusion must be %s, %s or %s, got '%s'", SpecFull, SpecSection, SpecNone, c.SpecInclusion)
+	}
+
+	// Check pricing keys are model references with sane prices
+	refs := make([]string, 0, len(c.Pricing))
+	for ref := range c.Pricing {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	for _, ref := range refs {
+		if err := ValidateModelRef(ref); err != nil {
+			return fmt.Errorf("pricing: %w", err)
+		}
+		if price := c.Pricing[ref]; price.Input < 0 || price.Output < 0 {
+			return fmt.Errorf("pricing '%s': prices must be non-negative", ref)
+		}
+	}
+
+	// Check task type models reference registered backends
+	names := make([]string, 0, len(c.TaskTypes))
+	for name := range c.TaskTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		tt := c.TaskTypes[name]
+		if err := ValidateModelRef(tt.Model); err != nil {
+			return fmt.Errorf("task type '%s' model: %w", name, err)
+		}
+		for _, ref := range tt.FallbackChain() {
+			if err := ValidateModelRef(ref); err != nil {
+				return fmt.Errorf("task type '%s' fallback: %w", name, err)
+			}
+		}
+		if err := agent.ValidateThinking(tt.Thinking); err != nil {
+			return fmt.Errorf("task type '%s': %w", name, err)
+		}
+	}
+
+	// Check repo overrides reference registered backends
+	repoNames := make([]string, 0, len(c.Repos))
+	for name := range c.Repos {
+		repoNames = append(repoNames, name)
+	}
+	sort.Strings(repoNames)
+	for _, name := range repoNames {
+		backend := c.Repos[name].Backend
+		if backend != "" && !agent.IsRegistered(backend) {
+			return fmt.Errorf("repo '%s' uses unknown backend '%s' (available: %s)",
+				name, backend, strings.Join(agent.ListBackends(), ", "))
+		}
+	}
+
+	return nil
+}
+
+// RetryFor returns the effective retry settings for a backend:
+// the backend's own section, then the workspace section, then defaults.
+func (c *Config) RetryFor(backend string) RetryConfig {
+	switch {
+	case backend == "claude" && c.Claude != nil && c.Claude.Retry != nil:
+		return *c.Claude.Retry
+	case backend == "copilot" && c.Copilot != nil && c.Copilot.Retry != nil:
+		return *c.Copilot.Retry
+	case backend == "openai" && c.OpenAI != nil && c.OpenAI.Retry != nil:
+		return *c.OpenAI.Retry
+	case c.Retry != nil:
+		return *c.Retry
+	default:
+		return DefaultRetry()
+	}
+}
+
+// Sources of the model ResolveModel picks, as reported by ModelSource.
+const (
+	ModelSourceTask     = "task"
+	ModelSourceTaskType = "task type"
+	ModelSourceRepo     = "repo"
+	ModelSourceDefault  = "workspace default"
+)
+
+// ResolveModel determines the backend, model and fallback chain to run a
+// task with. Precedence: the task's own "backend/model", then its task
+// type's model, then the task repo's override, then the workspace default
+// backend. The fallback chain is the task's own, else its task type's.
+func (c *Config) ResolveModel(t *task.Task) (backend, model string, fallbacks []string) {
+	backend, model, fallbacks, _ = c.resolveModel(t)
+	return backend, model, fallbacks
+}
+
+// ModelSource reports where ResolveModel takes t's backend and model from:
+// one of the ModelSource constants.
+func (c *Config) ModelSource(t *task.Task) string {
+	_, _, _, source := c.resolveModel(t)
+	return source
+}
+
+func (c *Config) resolveModel(t *task.Task) (backend, model string, fallbacks []string, source string) {
+	backend = c.Backend
+	fallbacks = t.FallbackChain()
+
+	var tt TaskType
+	if t.Type != "" {
+		tt = c.TaskTypes[t.Type]
+	}
+	if len(fallbacks) == 0 {
+		fallbacks = tt.FallbackChain()
+	}
+
+	if backend, model, err := agent.ParseModelRef(t.Model); err == nil {
+		return backend, model, fallbacks, ModelSourceTask
+	}
+	if backend, model, err := agent.ParseModelRef(tt.Model); err == nil {
+		return backend, model, fallbacks, ModelSourceTaskType
+	}
+
+	if repo, ok := c.Repos[t.Repo]; ok && t.Repo != "" && (repo.Backend != "" || repo.Model != "") {
+		if repo.Backend != "" {
+			backend = repo.Backend
+		}
+		return backend, repo.Model, fallbacks, ModelSourceRepo
+	}
+
+	return backend, model, fallbacks, ModelSourceDefault
+}
+
+// TestCommandFor returns the test command for a task.
+// Precedence: the task type's command, then the task repo's command,
+// then the workspace TDD test command.
+func (c *Config) TestCommandFor(t *task.Task) string {
+	if tt, ok := c.TaskTypes[t.Type]; ok && t.Type != "" && tt.TestCommand != "" {
+		return tt.TestCommand
+	}
+	if repo, ok := c.Repos[t.Repo]; ok && t.Repo != "" && repo.TestCommand != "" {
+		return repo.TestCommand
+	}
+	return c.TDD.TestCommand
+}
+
+// CompletionChecksFor returns the commands that must pass, after the tests,
+// before a task of t's type is completed.
+func (c *Config) CompletionChecksFor(t *task.Task) []string {
+	if t.Type == "" {
+		return nil
+	}
+	return c.TaskTypes[t.Type].CompletionChecks
+}
+
+// TaskTimeout returns how long one task run may take, or 0 for no limit.
+// A non-nil override, as given with --timeout, wins over
+// DefaultTaskTimeout, so an explicit 0 lifts a configured limit.
+func (c *Config) TaskTimeout(override *time.Duration) time.Duration {
+	if override != nil {
+		return *override
+	}
+	return c.DefaultTaskTimeout
+}
+
+// Spec inclusion modes for Config.SpecInclusion.
+const (
+	SpecFull    = "full"    // The whole spec
+	SpecSection = "section" // The section the task's SpecRef points at
+	SpecNone    = "none"    // No spec; agents can still call eas_spec_read
+)
+
+// SpecInclusionFor returns how much of the spec a task's prompt includes.
+// Section mode applies only to tasks with a SpecRef; others get the full
+// spec.
+func (c *Config) SpecInclusionFor(t *task.Task) string {
+	switch c.SpecInclusion {
+	case SpecFull, SpecNone:
+		return c.SpecInclusion
+	}
+	if t.SpecRef != "" {
+		return SpecSection
+	}
+	return SpecFull
+}
+
+// ThinkingFor returns the thinking mode configured for a task's type,
+// or "" when the type is unset or has no thinking mode.
+func (c *Config) ThinkingFor(t *task.Task) string {
+	if tt, ok := c.TaskTypes[t.Type]; ok && t.Type != "" {
+		return tt.Thinking
+	}
+	return ""
+}
+
+// ValidateModelRef checks that a "backend/model" reference names a registered
+// backend. An empty reference is valid and means "not set".
+func ValidateModelRef(ref string) error {
+	if ref == "" {
+		return nil
+	}
+
+	backend, _, err := agent.ParseModelRef(ref)
+	if err != nil {
+		return err
+	}
+
+	if !agent.IsRegistered(backend) {
+		return fmt.Errorf("model '%s' uses unknown backend '%s' (available: %s)",
+			ref, backend, strings.Join(agent.ListBackends(), ", "))
 	}
 
 	return nil
 }
 
 // Load reads a config from a YAML file.
+// Files listed under include are resolved relative to the including file
+// and merged first, in order; the local file is then applied on top.
 func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
+	merged, err := loadIncludes(path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config: %w", err)
+		return nil, err
+	}
+
+	data, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config: %w", err)
 	}
 
 	var cfg Config
@@ -169,32 +907,331 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
 
+	// Keep the local file on its own so saving doesn't flatten includes into it
+	if len(cfg.Include) > 0 {
+		local, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		var base Config
+		if err := yaml.Unmarshal(local, &base); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+		cfg.Include = base.Include
+		cfg.base = &base
+	}
+
 	// Apply defaults
 	cfg.applyDefaults()
 
 	return &cfg, nil
 }
 
-// Save writes the config to a YAML file.
+// loadIncludes reads a config file as a raw map with its includes merged in.
+// stack holds the absolute paths currently being loaded, for cycle detection.
+func loadIncludes(path string, stack []string) (map[string]any, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	for _, p := range stack {
+		if p == absPath {
+			return nil, fmt.Errorf("config include cycle: %s -> %s", strings.Join(stack, " -> "), absPath)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	var raw map[string]any
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	var directive struct {
+		Include []string `yaml:"include"`
+	}
+	if err := yaml.Unmarshal(data, &directive); err != nil {
+		return nil, fmt.Errorf("failed to parse includes in %s: %w", path, err)
+	}
+
+	merged := make(map[string]any)
+	stack = append(stack, absPath)
+	for _, inc := range directive.Include {
+		incPath := inc
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(absPath), incPath)
+		}
+		included, err := loadIncludes(incPath, stack)
+		if err != nil {
+			return nil, fmt.Errorf("include %s: %w", inc, err)
+		}
+		mergeMaps(merged, included)
+	}
+	delete(merged, "include")
+	mergeMaps(merged, raw)
+
+	return merged, nil
+}
+
+// mergeMaps deep-merges src into dst. Nested maps are merged key by key;
+// any other value in src replaces the one in dst.
+func mergeMaps(dst, src map[string]any) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]any)
+		dstMap, dstIsMap := dst[key].(map[string]any)
+		if srcIsMap && dstIsMap {
+			mergeMaps(dstMap, srcMap)
+			continue
+		}
+		dst[key] = value
+	}
+}
+
+// LoadProfile reads a config and merges the named profile over it.
+// An empty profile name behaves like Load.
+func LoadProfile(path, profile string) (*Config, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	if profile == "" {
+		return cfg, nil
+	}
+
+	override, ok := cfg.Profiles[profile]
+	if !ok {
+		names := make([]string, 0, len(cfg.Profiles))
+		for name := range cfg.Profiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("profile '%s' not found (available: %s)", profile, strings.Join(names, ", "))
+	}
+
+	merged := cfg.merge(override)
+	merged.applyDefaults()
+	return merged, nil
+}
+
+// merge returns a copy of c with the override applied. c is left untouched.
+func (c *Config) merge(o ConfigOverride) *Config {
+	merged := *c
+	merged.base = c
+
+	if o.Backend != "" {
+		merged.Backend = o.Backend
+	}
+
+	if o.Claude != nil {
+		claude := ClaudeConfig{}
+		if c.Claude != nil {
+			claude = *c.Claude
+		}
+		if o.Claude.CLIPath != "" {
+			claude.CLIPath = o.Claude.CLIPath
+		}
+		if o.Claude.Model != "" {
+			claude.Model = o.Claude.Model
+		}
+		if o.Claude.ExtraArgs != nil {
+			claude.ExtraArgs = o.Claude.ExtraArgs
+		}
+		if o.Claude.Retry != nil {
+			claude.Retry = o.Claude.Retry
+		}
+		if o.Claude.MaxOutputBytes != 0 {
+			claude.MaxOutputBytes = o.Claude.MaxOutputBytes
+		}
+		if o.Claude.MaxLineBytes != 0 {
+			claude.MaxLineBytes = o.Claude.MaxLineBytes
+		}
+		merged.Claude = &claude
+	}
+
+	if o.Copilot != nil {
+		copilot := CopilotConfig{}
+		if c.Copilot != nil {
+			copilot = *c.Copilot
+		}
+		if o.Copilot.CLIPath != "" {
+			copilot.CLIPath = o.Copilot.CLIPath
+		}
+		if o.Copilot.Model != "" {
+			copilot.Model = o.Copilot.Model
+		}
+		if o.Copilot.Provider != nil {
+			copilot.Provider = o.Copilot.Provider
+		}
+		if o.Copilot.Retry != nil {
+			copilot.Retry = o.Copilot.Retry
+		}
+		merged.Copilot = &copilot
+	}
+
+	if o.OpenAI != nil {
+		openai := OpenAIConfig{}
+		if c.OpenAI != nil {
+			openai = *c.OpenAI
+		}
+		if o.OpenAI.Model != "" {
+			openai.Model = o.OpenAI.Model
+		}
+		if o.OpenAI.Provider != nil {
+			openai.Provider = o.OpenAI.Provider
+		}
+		if o.OpenAI.Retry != nil {
+			openai.Retry = o.OpenAI.Retry
+		}
+		if o.OpenAI.MaxOutputBytes != 0 {
+			openai.MaxOutputBytes = o.OpenAI.MaxOutputBytes
+		}
+		if o.OpenAI.MaxLineBytes != 0 {
+			openai.MaxLineBytes = o.OpenAI.MaxLineBytes
+		}
+		merged.OpenAI = &openai
+	}
+
+	if o.TDD != nil {
+		if o.TDD.Enforce != nil {
+			merged.TDD.Enforce = *o.TDD.Enforce
+		}
+		if o.TDD.TestCommand != "" {
+			merged.TDD.TestCommand = o.TDD.TestCommand
+		}
+		if o.TDD.CoverageThreshold != nil {
+			merged.TDD.CoverageThreshold = *o.TDD.CoverageThreshold
+		}
+	}
+
+	if len(o.Repos) > 0 {
+		merged.Repos = make(map[string]Repo, len(c.Repos)+len(o.Repos))
+		for name, repo := range c.Repos {
+			merged.Repos[name] = repo
+		}
+		for name, repo := range o.Repos {
+			merged.Repos[name] = repo
+		}
+	}
+
+	if len(o.TaskTypes) > 0 {
+		merged.TaskTypes = make(map[string]TaskType, len(c.TaskTypes)+len(o.TaskTypes))
+		for name, tt := range c.TaskTypes {
+			merged.TaskTypes[name] = tt
+		}
+		for name, tt := range o.TaskTypes {
+			merged.TaskTypes[name] = tt
+		}
+	}
+
+	return &merged
+}
+
+// Save writes the config to a YAML file. Configs loaded with a profile
+// save their base config instead. An existing file is updated in place:
+// its comments, key order and profiles are kept, and only the settings
+// that changed are rewritten.
 func (c *Config) Save(path string) error {
+	if c.base != nil {
+		return c.base.Save(path)
+	}
+
 	// Create directory if needed
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(c)
+	var doc yaml.Node
+	if err := doc.Encode(c); err != nil {
+		return fmt.Errorf("failed to serialize config: %w", err)
+	}
+	if existing, err := os.ReadFile(path); err == nil {
+		var current yaml.Node
+		if yaml.Unmarshal(existing, &current) == nil && len(current.Content) == 1 &&
+			current.Content[0].Kind == yaml.MappingNode {
+			mergeNode(current.Content[0], &doc, true)
+			doc = current
+		}
+	}
+
+	data, err := yaml.Marshal(&doc)
 	if err != nil {
 		return fmt.Errorf("failed to serialize config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := fileutil.WriteFileAtomic(path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write config: %w", err)
 	}
 
 	return nil
 }
 
+// mergeNode updates the YAML mapping dst to hold the values of src,
+// keeping dst's comments and key order. Keys missing from src are removed,
+// except unknown keys at the top level, which Config does not read and so
+// cannot have cleared.
+func mergeNode(dst, src *yaml.Node, top bool) {
+	values := make(map[string]*yaml.Node, len(src.Content)/2)
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		values[src.Content[i].Value] = src.Content[i+1]
+	}
+
+	kept := dst.Content[:0]
+	seen := make(map[string]bool, len(values))
+	for i := 0; i+1 < len(dst.Content); i += 2 {
+		key, value := dst.Content[i], dst.Content[i+1]
+		next, ok := values[key.Value]
+		if !ok {
+			if top && !configKeys[key.Value] {
+				kept = append(kept, key, value)
+			}
+			continue
+		}
+		seen[key.Value] = true
+		if value.Kind == yaml.MappingNode && next.Kind == yaml.MappingNode {
+			mergeNode(value, next, false)
+		} else if !sameNode(value, next) {
+			next.HeadComment, next.LineComment, next.FootComment = value.HeadComment, value.LineComment, value.FootComment
+			value = next
+		}
+		kept = append(kept, key, value)
+	}
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		if !seen[src.Content[i].Value] {
+			kept = append(kept, src.Content[i], src.Content[i+1])
+		}
+	}
+	dst.Content = kept
+}
+
+// sameNode reports whether two YAML nodes hold the same value, so an
+// unchanged value keeps the style it was written in.
+func sameNode(a, b *yaml.Node) bool {
+	var av, bv any
+	if a.Decode(&av) != nil || b.Decode(&bv) != nil {
+		return false
+	}
+	ad, _ := yaml.Marshal(av)
+	bd, _ := yaml.Marshal(bv)
+	return string(ad) == string(bd)
+}
+
+// configKeys are the top-level keys Config reads.
+var configKeys = func() map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(Config{})
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		if name != "" && name != "-" {
+			keys[name] = true
+		}
+	}
+	return keys
+}()
+
 // applyDefaults sets default values for optional fields.
 func (c *Config) applyDefaults() {
 	if c.Version == 0 {
@@ -215,6 +1252,287 @@ func (c *Config) applyDefaults() {
 	// For loaded configs, we trust the file value.
 }
 
+// redactedValue replaces sensitive values in redacted configs.
+const redactedValue = "***"
+
+// Redacted returns a deep copy of the config that is safe to print.
+// Provider base URLs are masked, as is any value containing the secret
+// referenced by a provider's APIKeyEnv. The receiver is left untouched.
+func (c *Config) Redacted() *Config {
+	r := c.clone()
+
+	var secretValues []string
+	collect := func(provider *ProviderConfig) {
+		if provider != nil && provider.APIKeyEnv != "" {
+			if v := os.Getenv(provider.APIKeyEnv); v != "" {
+				secretValues = append(secretValues, v)
+			}
+		}
+	}
+	if r.Copilot != nil {
+		collect(r.Copilot.Provider)
+	}
+	if r.OpenAI != nil {
+		collect(r.OpenAI.Provider)
+	}
+	for _, p := range r.Profiles {
+		if p.Copilot != nil {
+			collect(p.Copilot.Provider)
+		}
+		if p.OpenAI != nil {
+			collect(p.OpenAI.Provider)
+		}
+	}
+
+	mask := func(v string) string {
+		for _, secret := range secretValues {
+			if strings.Contains(v, secret) {
+				return redactedValue
+			}
+		}
+		return v
+	}
+	redactClaude := func(cc *ClaudeConfig) {
+		if cc == nil {
+			return
+		}
+		cc.CLIPath = mask(cc.CLIPath)
+		cc.Model = mask(cc.Model)
+		for i, arg := range cc.ExtraArgs {
+			cc.ExtraArgs[i] = mask(arg)
+		}
+	}
+	redactCopilot := func(cp *CopilotConfig) {
+		if cp == nil {
+			return
+		}
+		cp.CLIPath = mask(cp.CLIPath)
+		cp.Model = mask(cp.Model)
+		if cp.Provider != nil && cp.Provider.BaseURL != "" {
+			cp.Provider.BaseURL = redactedValue
+		}
+	}
+	redactOpenAI := func(oc *OpenAIConfig) {
+		if oc == nil {
+			return
+		}
+		oc.Model = mask(oc.Model)
+		if oc.Provider != nil && oc.Provider.BaseURL != "" {
+			oc.Provider.BaseURL = redactedValue
+		}
+	}
+
+	redactClaude(r.Claude)
+	redactCopilot(r.Copilot)
+	redactOpenAI(r.OpenAI)
+	r.TDD.TestCommand = mask(r.TDD.TestCommand)
+	for name, repo := range r.Repos {
+		repo.URL = mask(repo.URL)
+		repo.TestCommand = mask(repo.TestCommand)
+		r.Repos[name] = repo
+	}
+	for name, tt := range r.TaskTypes {
+		tt.TestCommand = mask(tt.TestCommand)
+		for i, check := range tt.CompletionChecks {
+			tt.CompletionChecks[i] = mask(check)
+		}
+		r.TaskTypes[name] = tt
+	}
+	// Webhook URLs commonly embed tokens
+	if r.Notifications != nil && r.Notifications.Webhook != nil && r.Notifications.Webhook.URL != "" {
+		r.Notifications.Webhook.URL = redactedValue
+	}
+	if r.Notifications != nil && r.Notifications.Slack != nil && r.Notifications.Slack.URL != "" {
+		r.Notifications.Slack.URL = redactedValue
+	}
+	for name, p := range r.Profiles {
+		redactClaude(p.Claude)
+		redactCopilot(p.Copilot)
+		redactOpenAI(p.OpenAI)
+		if p.TDD != nil {
+			p.TDD.TestCommand = mask(p.TDD.TestCommand)
+		}
+		for repoName, repo := range p.Repos {
+			repo.URL = mask(repo.URL)
+			repo.TestCommand = mask(repo.TestCommand)
+			p.Repos[repoName] = repo
+		}
+		r.Profiles[name] = p
+	}
+
+	return r
+}
+
+// clone returns a deep copy of the config.
+func (c *Config) clone() *Config {
+	cp := *c
+	cp.base = nil
+	cp.Claude = c.Claude.clone()
+	cp.Copilot = c.Copilot.clone()
+	cp.OpenAI = c.OpenAI.clone()
+	cp.Retry = c.Retry.clone()
+	cp.Repos = cloneRepos(c.Repos)
+	cp.TaskTypes = cloneTaskTypes(c.TaskTypes)
+	cp.Notifications = c.Notifications.clone()
+	cp.Quota = c.Quota.clone()
+	cp.MCP = c.MCP.clone()
+	if c.Pricing != nil {
+		cp.Pricing = make(agent.PricingConfig, len(c.Pricing))
+		for ref, price := range c.Pricing {
+			cp.Pricing[ref] = price
+		}
+	}
+
+	if c.Profiles != nil {
+		cp.Profiles = make(map[string]ConfigOverride, len(c.Profiles))
+		for name, p := range c.Profiles {
+			p.Claude = p.Claude.clone()
+			p.Copilot = p.Copilot.clone()
+			p.OpenAI = p.OpenAI.clone()
+			if p.TDD != nil {
+				tdd := *p.TDD
+				p.TDD = &tdd
+			}
+			p.Repos = cloneRepos(p.Repos)
+			p.TaskTypes = cloneTaskTypes(p.TaskTypes)
+			cp.Profiles[name] = p
+		}
+	}
+
+	return &cp
+}
+
+func (c *ClaudeConfig) clone() *ClaudeConfig {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	if c.ExtraArgs != nil {
+		cp.ExtraArgs = append([]string{}, c.ExtraArgs...)
+	}
+	cp.Retry = c.Retry.clone()
+	return &cp
+}
+
+func (c *CopilotConfig) clone() *CopilotConfig {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	if c.Provider != nil {
+		provider := *c.Provider
+		cp.Provider = &provider
+	}
+	cp.Retry = c.Retry.clone()
+	return &cp
+}
+
+func (c *OpenAIConfig) clone() *OpenAIConfig {
+	if c == nil {
+		return nil
+	}
+	cp := *c
+	if c.Provider != nil {
+		provider := *c.Provider
+		cp.Provider = &provider
+	}
+	cp.Retry = c.Retry.clone()
+	return &cp
+}
+
+func (m *MCPConfig) clone() *MCPConfig {
+	if m == nil {
+		return nil
+	}
+	cp := *m
+	cp.ExtraArgs = append([]string(nil), m.ExtraArgs...)
+	if m.Servers != nil {
+		cp.Servers = make(map[string]MCPServerSpec, len(m.Servers))
+		for name, spec := range m.Servers {
+			spec.Args = append([]string(nil), spec.Args...)
+			if spec.Env != nil {
+				env := make(map[string]string, len(spec.Env))
+				for k, v := range spec.Env {
+					env[k] = v
+				}
+				spec.Env = env
+			}
+			cp.Servers[name] = spec
+		}
+	}
+	return &cp
+}
+
+func (q *QuotaConfig) clone() *QuotaConfig {
+	if q == nil {
+		return nil
+	}
+	cp := *q
+	if q.Limits != nil {
+		cp.Limits = make(map[string]QuotaLimit, len(q.Limits))
+		for key, limit := range q.Limits {
+			cp.Limits[key] = limit
+		}
+	}
+	if q.Pools != nil {
+		cp.Pools = make(map[string]QuotaPool, len(q.Pools))
+		for name, pool := range q.Pools {
+			pool.Members = append([]string(nil), pool.Members...)
+			cp.Pools[name] = pool
+		}
+	}
+	return &cp
+}
+
+func (n *NotificationsConfig) clone() *NotificationsConfig {
+	if n == nil {
+		return nil
+	}
+	cp := *n
+	if n.Webhook != nil {
+		webhook := *n.Webhook
+		webhook.Events = append([]string(nil), n.Webhook.Events...)
+		cp.Webhook = &webhook
+	}
+	if n.Slack != nil {
+		slack := *n.Slack
+		cp.Slack = &slack
+	}
+	return &cp
+}
+
+func (r *RetryConfig) clone() *RetryConfig {
+	if r == nil {
+		return nil
+	}
+	cp := *r
+	return &cp
+}
+
+func cloneRepos(repos map[string]Repo) map[string]Repo {
+	if repos == nil {
+		return nil
+	}
+	cp := make(map[string]Repo, len(repos))
+	for name, repo := range repos {
+		cp[name] = repo
+	}
+	return cp
+}
+
+func cloneTaskTypes(types map[string]TaskType) map[string]TaskType {
+	if types == nil {
+		return nil
+	}
+	cp := make(map[string]TaskType, len(types))
+	for name, tt := range types {
+		tt.Fallbacks = append([]string(nil), tt.Fallbacks...)
+		tt.CompletionChecks = append([]string(nil), tt.CompletionChecks...)
+		cp[name] = tt
+	}
+	return cp
+}
+
 // GetBackendConfig returns the backend-specific config.
 func (c *Config) GetBackendConfig() any {
 	switch c.Backend {
@@ -222,6 +1540,8 @@ func (c *Config) GetBackendConfig() any {
 		return c.Claude
 	case "copilot":
 		return c.Copilot
+	case "openai":
+		return c.OpenAI
 	default:
 		return nil
 	}
@@ -3,7 +3,13 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/task"
 )
 
 func TestNewConfig(t *testing.T) {
@@ -144,6 +150,50 @@ func TestConfigLoadInvalidYAML(t *testing.T) {
 	}
 }
 
+func TestConfigLoadMCP(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	os.WriteFile(configPath, []byte(`feature: mcp
+mcp:
+  name: flo
+  command: /opt/flo/bin/flo-mcp
+  extra_args: ["--profile", "ci"]
+  concurrent_task_calls: true
+`), 0644)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if cfg.MCP == nil || cfg.MCP.Name != "flo" || cfg.MCP.Command != "/opt/flo/bin/flo-mcp" {
+		t.Fatalf("unexpected mcp config: %+v", cfg.MCP)
+	}
+	if strings.Join(cfg.MCP.ExtraArgs, " ") != "--profile ci" {
+		t.Errorf("unexpected extra args: %v", cfg.MCP.ExtraArgs)
+	}
+	if !cfg.MCP.ConcurrentTaskCalls {
+		t.Error("expected concurrent_task_calls to be set")
+	}
+
+	redacted := cfg.Redacted()
+	redacted.MCP.ExtraArgs[0] = "changed"
+	if cfg.MCP.ExtraArgs[0] != "--profile" {
+		t.Error("expected copies not to share mcp extra args")
+	}
+}
+
+func TestConfigMCPServersValidate(t *testing.T) {
+	cfg := New("test")
+	cfg.MCP = &MCPConfig{Servers: map[string]MCPServerSpec{"git": {Command: "mcp-server-git"}}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.MCP.Servers["broken"] = MCPServerSpec{Args: []string{"serve"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("expected missing command error, got %v", err)
+	}
+}
+
 func TestConfigDefaults(t *testing.T) {
 	tmpDir := t.TempDir()
 	configPath := filepath.Join(tmpDir, "config.yaml")
@@ -299,3 +349,1001 @@ func TestConfigTaskTypesPersistence(t *testing.T) {
 		t.Errorf("custom type thinking mismatch: got %q", customType.Thinking)
 	}
 }
+
+func TestConfigValidateTaskTypeModels(t *testing.T) {
+	tests := []struct {
+		name     string
+		taskType TaskType
+		wantErr  bool
+	}{
+		{"registered backend", TaskType{Model: "claude/opus"}, false},
+		{"registered fallback", TaskType{Model: "claude/opus", Fallback: "copilot/gpt-4"}, false},
+		{"unknown backend prefix", TaskType{Model: "cluade/opus"}, true},
+		{"unknown fallback prefix", TaskType{Model: "claude/opus", Fallback: "copilt/gpt-4"}, true},
+		{"registered fallback chain", TaskType{Model: "claude/opus", Fallbacks: []string{"claude/sonnet", "copilot/gpt-4"}}, false},
+		{"unknown prefix in fallback chain", TaskType{Model: "claude/opus", Fallbacks: []string{"claude/sonnet", "copilt/gpt-4"}}, true},
+		{"missing model part", TaskType{Model: "claude"}, true},
+		{"extended thinking", TaskType{Model: "claude/opus", Thinking: "extended"}, false},
+		{"invalid thinking", TaskType{Model: "claude/opus", Thinking: "deep"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New("test")
+			cfg.TaskTypes["custom"] = tt.taskType
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigThinkingFor(t *testing.T) {
+	cfg := New("test")
+
+	if got := cfg.ThinkingFor(&task.Task{Type: "research"}); got != "extended" {
+		t.Errorf("expected extended for research, got %q", got)
+	}
+	if got := cfg.ThinkingFor(&task.Task{Type: "unknown"}); got != "" {
+		t.Errorf("expected empty for unknown type, got %q", got)
+	}
+	if got := cfg.ThinkingFor(&task.Task{}); got != "" {
+		t.Errorf("expected empty for untyped task, got %q", got)
+	}
+}
+
+func TestValidateModelRef(t *testing.T) {
+	if err := ValidateModelRef(""); err != nil {
+		t.Errorf("empty ref should be valid, got: %v", err)
+	}
+	if err := ValidateModelRef("gemini/pro"); err != nil {
+		t.Errorf("gemini/pro should be valid, got: %v", err)
+	}
+	err := ValidateModelRef("cluade/opus")
+	if err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+	if !strings.Contains(err.Error(), "cluade") {
+		t.Errorf("error should name the bad backend, got: %v", err)
+	}
+	for _, ref := range []string{"claude", "claude/", "claude/opus/4"} {
+		if err := ValidateModelRef(ref); err == nil {
+			t.Errorf("expected %q to be rejected", ref)
+		}
+	}
+}
+
+func TestConfigRepoOverridePersistence(t *testing.T) {
+	cfg := New("test")
+	cfg.Repos = map[string]Repo{
+		"android": {
+			URL:     "git@github.com:org/android.git",
+			Backend: "copilot",
+			Model:   "gpt-4",
+		},
+	}
+
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	loaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	repo := loaded.Repos["android"]
+	if repo.Backend != "copilot" {
+		t.Errorf("repo backend mismatch: got %q", repo.Backend)
+	}
+	if repo.Model != "gpt-4" {
+		t.Errorf("repo model mismatch: got %q", repo.Model)
+	}
+}
+
+func TestConfigResolveModelPrecedence(t *testing.T) {
+	cfg := New("test")
+	cfg.Repos = map[string]Repo{
+		"android": {URL: "git@github.com:org/android.git", Backend: "copilot", Model: "gpt-4"},
+		"ios":     {URL: "git@github.com:org/ios.git"},
+	}
+	cfg.TaskTypes = map[string]TaskType{
+		"build": {Model: "claude/sonnet", Fallback: "copilot/gpt-4"},
+		"docs":  {Fallback: "gemini/flash"},
+		"arch":  {Model: "claude/opus", Fallback: "claude/sonnet", Fallbacks: []string{"copilot/gpt-4"}},
+	}
+
+	tests := []struct {
+		name         string
+		model        string
+		fallback     string
+		taskType     string
+		repo         string
+		wantBackend  string
+		wantModel    string
+		wantFallback string
+	}{
+		{"task model wins over type", "gemini/pro", "", "build", "", "gemini", "pro", "copilot/gpt-4"},
+		{"task model wins over repo", "gemini/pro", "", "", "android", "gemini", "pro", ""},
+		{"type model", "", "", "build", "", "claude", "sonnet", "copilot/gpt-4"},
+		{"type model wins over repo", "", "", "build", "android", "claude", "sonnet", "copilot/gpt-4"},
+		{"task fallback wins over type", "", "claude/haiku", "build", "", "claude", "sonnet", "claude/haiku"},
+		{"type without model", "", "", "docs", "", "claude", "", "gemini/flash"},
+		{"type fallback chain", "", "", "arch", "", "claude", "opus", "claude/sonnet,copilot/gpt-4"},
+		{"task fallback replaces type chain", "", "gemini/pro", "arch", "", "claude", "opus", "gemini/pro"},
+		{"unknown type", "", "", "bogus", "", "claude", "", ""},
+		{"repo override", "", "", "", "android", "copilot", "gpt-4", ""},
+		{"repo without override", "", "", "", "ios", "claude", "", ""},
+		{"unknown repo", "", "", "", "web", "claude", "", ""},
+		{"workspace default", "", "", "", "", "claude", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk := task.New("t-001", "Test")
+			tk.Model = tt.model
+			tk.Fallback = tt.fallback
+			tk.Type = tt.taskType
+			tk.Repo = tt.repo
+
+			backend, model, fallbacks := cfg.ResolveModel(tk)
+			fallback := strings.Join(fallbacks, ",")
+			if backend != tt.wantBackend || model != tt.wantModel || fallback != tt.wantFallback {
+				t.Errorf("ResolveModel() = %s/%s (fallback %q), want %s/%s (fallback %q)",
+					backend, model, fallback, tt.wantBackend, tt.wantModel, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestConfigResolveModelDefaultTaskType(t *testing.T) {
+	cfg := New("test")
+	tk := task.New("t-001", "Design the API")
+	tk.Type = "architecture"
+
+	backend, model, _ := cfg.ResolveModel(tk)
+	if backend != "claude" || model != "opus" {
+		t.Errorf("expected claude/opus from the default task types, got %s/%s", backend, model)
+	}
+	if thinking := cfg.ThinkingFor(tk); thinking != "extended" {
+		t.Errorf("expected extended thinking, got %q", thinking)
+	}
+	if source := cfg.ModelSource(tk); source != ModelSourceTaskType {
+		t.Errorf("expected model from the task type, got %q", source)
+	}
+}
+
+func TestConfigModelSource(t *testing.T) {
+	cfg := New("test")
+	cfg.Repos = map[string]Repo{
+		"android": {URL: "git@github.com:org/android.git", Backend: "copilot"},
+		"ios":     {URL: "git@github.com:org/ios.git"},
+	}
+
+	tests := []struct {
+		model, taskType, repo string
+		want                  string
+	}{
+		{"gemini/pro", "architecture", "android", ModelSourceTask},
+		{"", "architecture", "android", ModelSourceTaskType},
+		{"", "bogus", "android", ModelSourceRepo},
+		{"", "", "ios", ModelSourceDefault},
+		{"", "", "", ModelSourceDefault},
+	}
+	for _, tt := range tests {
+		tk := task.New("t-001", "Test")
+		tk.Model, tk.Type, tk.Repo = tt.model, tt.taskType, tt.repo
+		if got := cfg.ModelSource(tk); got != tt.want {
+			t.Errorf("ModelSource(%+v) = %q, want %q", tt, got, tt.want)
+		}
+	}
+}
+
+func TestConfigTestCommandForPrecedence(t *testing.T) {
+	cfg := New("test")
+	cfg.TDD.TestCommand = "go test ./..."
+	cfg.Repos = map[string]Repo{
+		"android": {URL: "git@github.com:org/android.git", TestCommand: "./gradlew test"},
+		"ios":     {URL: "git@github.com:org/ios.git"},
+	}
+	cfg.TaskTypes = map[string]TaskType{
+		"e2e":   {Model: "claude/opus", TestCommand: "make e2e"},
+		"build": {Model: "claude/sonnet"},
+	}
+
+	tests := []struct {
+		name     string
+		taskType string
+		repo     string
+		want     string
+	}{
+		{"task type wins over repo", "e2e", "android", "make e2e"},
+		{"repo override", "build", "android", "./gradlew test"},
+		{"repo without override", "", "ios", "go test ./..."},
+		{"workspace default", "", "", "go test ./..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk := task.New("t-001", "Test")
+			tk.Type = tt.taskType
+			tk.Repo = tt.repo
+
+			if got := cfg.TestCommandFor(tk); got != tt.want {
+				t.Errorf("TestCommandFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidateRepoBackend(t *testing.T) {
+	cfg := New("test")
+	cfg.Repos = map[string]Repo{
+		"android": {URL: "git@github.com:org/android.git", Backend: "cluade"},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown repo backend")
+	}
+}
+
+func TestConfigValidateClaudeExtraArgs(t *testing.T) {
+	cfg := New("test")
+	cfg.Claude = &ClaudeConfig{ExtraArgs: []string{"--verbose", "--output-format", "text"}}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "--output-format") {
+		t.Errorf("expected error for a reserved extra arg, got %v", err)
+	}
+
+	cfg.Claude.ExtraArgs = []string{"--verbose"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestConfigValidateClaudeMaxOutputBytes(t *testing.T) {
+	cfg := New("test")
+	cfg.Claude = &ClaudeConfig{MaxOutputBytes: 1 << 20}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.Claude.MaxOutputBytes = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "max_output_bytes") {
+		t.Errorf("expected max_output_bytes error, got %v", err)
+	}
+}
+
+func TestConfigClaudeMaxLineBytes(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	content := "feature: test\nclaude:\n  max_line_bytes: 67108864\n"
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if cfg.Claude == nil || cfg.Claude.MaxLineBytes != 64<<20 {
+		t.Fatalf("expected max_line_bytes 64MiB, got %+v", cfg.Claude)
+	}
+
+	cfg.Claude.MaxLineBytes = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "max_line_bytes") {
+		t.Errorf("expected max_line_bytes error, got %v", err)
+	}
+}
+
+func TestConfigValidateSpecInclusion(t *testing.T) {
+	cfg := New("test")
+	for _, mode := range []string{"", SpecFull, SpecSection, SpecNone} {
+		cfg.SpecInclusion = mode
+		if err := cfg.Validate(); err != nil {
+			t.Errorf("unexpected error for %q: %v", mode, err)
+		}
+	}
+
+	cfg.SpecInclusion = "sections"
+	if err := cfg.Validate(); err == nil {
+		t.Error("expected error for unknown spec_inclusion")
+	}
+}
+
+func TestConfigValidateTaskIDPattern(t *testing.T) {
+	cfg := New("test")
+	cfg.TaskIDPattern = `ua-\d{3}`
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.TaskIDPattern = "ua-("
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "task_id_pattern") {
+		t.Errorf("expected task_id_pattern error, got %v", err)
+	}
+}
+
+func TestConfigValidateDefaultTaskTimeout(t *testing.T) {
+	cfg := New("test")
+	cfg.DefaultTaskTimeout = 30 * time.Minute
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.DefaultTaskTimeout = -time.Minute
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "default_task_timeout") {
+		t.Errorf("expected default_task_timeout error, got %v", err)
+	}
+}
+
+func TestProviderConfigValidate(t *testing.T) {
+	t.Setenv("FLO_TEST_PROVIDER_KEY", "sk-test-secret")
+	t.Setenv("FLO_TEST_PROVIDER_EMPTY", "")
+
+	tests := []struct {
+		name     string
+		provider ProviderConfig
+		wantErr  string
+	}{
+		{"key set", ProviderConfig{Type: "openai", BaseURL: "https://api.example.com/v1", APIKeyEnv: "FLO_TEST_PROVIDER_KEY"}, ""},
+		{"no key needed", ProviderConfig{Type: "openai", BaseURL: "http://localhost:8000/v1"}, ""},
+		{"key unset", ProviderConfig{BaseURL: "https://api.example.com/v1", APIKeyEnv: "FLO_TEST_PROVIDER_MISSING"}, "FLO_TEST_PROVIDER_MISSING is not set"},
+		{"key empty", ProviderConfig{BaseURL: "https://api.example.com/v1", APIKeyEnv: "FLO_TEST_PROVIDER_EMPTY"}, "FLO_TEST_PROVIDER_EMPTY is not set"},
+		{"missing base url", ProviderConfig{APIKeyEnv: "FLO_TEST_PROVIDER_KEY"}, "base_url"},
+		{"malformed base url", ProviderConfig{BaseURL: "://api.example.com", APIKeyEnv: "FLO_TEST_PROVIDER_KEY"}, "base_url"},
+		{"base url without scheme", ProviderConfig{BaseURL: "api.example.com/v1"}, "base_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.provider.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			if strings.Contains(err.Error(), "sk-test-secret") {
+				t.Errorf("error leaks the API key: %v", err)
+			}
+		})
+	}
+}
+
+func TestConfigTaskTimeout(t *testing.T) {
+	dur := func(d time.Duration) *time.Duration { return &d }
+	tests := []struct {
+		name     string
+		config   time.Duration
+		override *time.Duration
+		want     time.Duration
+	}{
+		{"no timeout by default", 0, nil, 0},
+		{"config default", 30 * time.Minute, nil, 30 * time.Minute},
+		{"flag overrides config", 30 * time.Minute, dur(5 * time.Minute), 5 * time.Minute},
+		{"flag without config", 0, dur(time.Hour), time.Hour},
+		{"zero flag lifts config limit", 30 * time.Minute, dur(0), 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New("test")
+			cfg.DefaultTaskTimeout = tt.config
+			if got := cfg.TaskTimeout(tt.override); got != tt.want {
+				t.Errorf("TaskTimeout() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigDefaultTaskTimeoutYAML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("feature: test\ndefault_task_timeout: 45m\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.DefaultTaskTimeout != 45*time.Minute {
+		t.Errorf("expected 45m, got %s", cfg.DefaultTaskTimeout)
+	}
+}
+
+func TestConfigOpenAI(t *testing.T) {
+	t.Setenv("TEST_FLO_OPENAI_KEY", "sk-openai-secret")
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	yamlConfig := `feature: test
+backend: openai
+openai:
+  model: qwen-coder
+  provider:
+    type: openai
+    base_url: http://localhost:8000/v1
+    api_key_env: TEST_FLO_OPENAI_KEY
+  retry:
+    max_attempts: 2
+profiles:
+  remote:
+    openai:
+      model: gpt-4.1
+`
+	if err := os.WriteFile(path, []byte(yamlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if oc, ok := cfg.GetBackendConfig().(*OpenAIConfig); !ok || oc.Model != "qwen-coder" || oc.Provider.BaseURL != "http://localhost:8000/v1" {
+		t.Errorf("expected the openai section as backend config, got %+v", cfg.GetBackendConfig())
+	}
+	if got := cfg.RetryFor("openai").MaxAttempts; got != 2 {
+		t.Errorf("expected openai retry max_attempts 2, got %d", got)
+	}
+	if redacted := cfg.Redacted(); redacted.OpenAI.Provider.BaseURL != "***" || cfg.OpenAI.Provider.BaseURL == "***" {
+		t.Errorf("expected only the redacted copy to mask the base URL, got %q", redacted.OpenAI.Provider.BaseURL)
+	}
+
+	profiled, err := LoadProfile(path, "remote")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+	if profiled.OpenAI.Model != "gpt-4.1" || profiled.OpenAI.Provider == nil {
+		t.Errorf("expected the profile to override only the model, got %+v", profiled.OpenAI)
+	}
+
+	cfg.OpenAI.MaxOutputBytes = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "openai") {
+		t.Errorf("expected openai max_output_bytes error, got %v", err)
+	}
+}
+
+func TestConfigLoadProfile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	cfg := New("test")
+	cfg.Claude = &ClaudeConfig{
+		CLIPath:   "/usr/local/bin/claude",
+		Model:     "sonnet",
+		ExtraArgs: []string{"--verbose"},
+	}
+	cfg.Profiles = map[string]ConfigOverride{
+		"prod": {
+			Backend: "copilot",
+			Claude:  &ClaudeConfig{Model: "opus"},
+		},
+	}
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	merged, err := LoadProfile(configPath, "prod")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+
+	if merged.Backend != "copilot" {
+		t.Errorf("expected backend 'copilot', got '%s'", merged.Backend)
+	}
+	if merged.Claude.Model != "opus" {
+		t.Errorf("expected claude model 'opus', got '%s'", merged.Claude.Model)
+	}
+	if merged.Claude.CLIPath != "/usr/local/bin/claude" {
+		t.Errorf("claude CLI path should be untouched, got '%s'", merged.Claude.CLIPath)
+	}
+	if len(merged.Claude.ExtraArgs) != 1 {
+		t.Errorf("claude extra args should be untouched, got %v", merged.Claude.ExtraArgs)
+	}
+	if merged.Feature != "test" || !merged.TDD.Enforce {
+		t.Error("fields not in the profile should be untouched")
+	}
+
+	// Saving a profiled config must not leak profile values into the file
+	if err := merged.Save(configPath); err != nil {
+		t.Fatalf("failed to save merged: %v", err)
+	}
+	base, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if base.Backend != "claude" || base.Claude.Model != "sonnet" {
+		t.Errorf("base config modified by profile: backend=%s model=%s", base.Backend, base.Claude.Model)
+	}
+}
+
+func TestConfigSaveKeepsExistingFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	content := `# project settings
+feature: test
+backend: claude # default backend
+custom_key: keep me
+claude:
+  model: sonnet
+profiles:
+  prod:
+    backend: copilot
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadProfile(configPath, "prod")
+	if err != nil {
+		t.Fatalf("LoadProfile failed: %v", err)
+	}
+	cfg.base.Claude.Model = "opus"
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := string(data)
+	for _, want := range []string{"# project settings", "# default backend", "custom_key: keep me", "model: opus"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved config missing %q:\n%s", want, saved)
+		}
+	}
+
+	reloaded, err := LoadProfile(configPath, "prod")
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if reloaded.Backend != "copilot" || reloaded.Claude.Model != "opus" {
+		t.Errorf("unexpected reload: backend=%s model=%s", reloaded.Backend, reloaded.Claude.Model)
+	}
+}
+
+func TestConfigLoadProfileNotFound(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+
+	if err := New("test").Save(configPath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	if _, err := LoadProfile(configPath, "staging"); err == nil {
+		t.Error("expected error for unknown profile")
+	}
+
+	cfg, err := LoadProfile(configPath, "")
+	if err != nil {
+		t.Fatalf("empty profile should load base config: %v", err)
+	}
+	if cfg.Backend != "claude" {
+		t.Errorf("expected base backend, got '%s'", cfg.Backend)
+	}
+}
+
+func TestConfigRedacted(t *testing.T) {
+	t.Setenv("TEST_FLO_API_KEY", "sk-secret-value")
+
+	cfg := New("test")
+	cfg.Claude = &ClaudeConfig{
+		Model:     "sonnet",
+		ExtraArgs: []string{"--api-key=sk-secret-value", "--verbose"},
+	}
+	cfg.Copilot = &CopilotConfig{
+		Model: "gpt-4.1",
+		Provider: &ProviderConfig{
+			Type:      "azure",
+			BaseURL:   "https://mycompany.openai.azure.com/openai/v1/",
+			APIKeyEnv: "TEST_FLO_API_KEY",
+		},
+	}
+
+	redacted := cfg.Redacted()
+
+	// Redacted copy masks sensitive fields
+	if redacted.Copilot.Provider.BaseURL != "***" {
+		t.Errorf("expected base URL masked, got %q", redacted.Copilot.Provider.BaseURL)
+	}
+	if redacted.Claude.ExtraArgs[0] != "***" {
+		t.Errorf("expected secret arg masked, got %q", redacted.Claude.ExtraArgs[0])
+	}
+	if redacted.Claude.ExtraArgs[1] != "--verbose" {
+		t.Errorf("non-secret arg should be kept, got %q", redacted.Claude.ExtraArgs[1])
+	}
+	if redacted.Copilot.Provider.APIKeyEnv != "TEST_FLO_API_KEY" {
+		t.Errorf("env var name should be kept, got %q", redacted.Copilot.Provider.APIKeyEnv)
+	}
+	if redacted.Copilot.Model != "gpt-4.1" {
+		t.Errorf("model should be kept, got %q", redacted.Copilot.Model)
+	}
+
+	// Original is untouched
+	if cfg.Copilot.Provider.BaseURL != "https://mycompany.openai.azure.com/openai/v1/" {
+		t.Errorf("original base URL modified: %q", cfg.Copilot.Provider.BaseURL)
+	}
+	if cfg.Claude.ExtraArgs[0] != "--api-key=sk-secret-value" {
+		t.Errorf("original extra args modified: %q", cfg.Claude.ExtraArgs[0])
+	}
+}
+
+func TestConfigNotificationsWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		webhook WebhookConfig
+		wantErr bool
+	}{
+		{"all events", WebhookConfig{URL: "https://hooks.example.com/flo"}, false},
+		{"subscribed events", WebhookConfig{URL: "http://localhost:8080/", Events: []string{"completed", "failed"}}, false},
+		{"unknown event", WebhookConfig{URL: "https://hooks.example.com/flo", Events: []string{"started"}}, true},
+		{"missing url", WebhookConfig{}, true},
+		{"non-http url", WebhookConfig{URL: "ftp://hooks.example.com"}, true},
+		{"negative timeout", WebhookConfig{URL: "https://hooks.example.com/flo", Timeout: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New("test")
+			webhook := tt.webhook
+			cfg.Notifications = &NotificationsConfig{Webhook: &webhook}
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	webhook := &WebhookConfig{Events: []string{"failed"}}
+	if webhook.Subscribed("completed") || !webhook.Subscribed("failed") {
+		t.Error("expected only failed to be subscribed")
+	}
+	if !(&WebhookConfig{}).Subscribed("completed") {
+		t.Error("expected no events to mean all events")
+	}
+}
+
+func TestConfigPricing(t *testing.T) {
+	tests := []struct {
+		name    string
+		pricing agent.PricingConfig
+		wantErr bool
+	}{
+		{"valid", agent.PricingConfig{"claude/opus": {Input: 15, Output: 75}}, false},
+		{"bare model", agent.PricingConfig{"opus": {Input: 15, Output: 75}}, true},
+		{"unknown backend", agent.PricingConfig{"nope/opus": {Input: 15, Output: 75}}, true},
+		{"negative price", agent.PricingConfig{"claude/opus": {Input: -1, Output: 75}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New("test")
+			cfg.Pricing = tt.pricing
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigQuotaValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		quota   QuotaConfig
+		wantErr bool
+	}{
+		{"backend limit", QuotaConfig{Limits: map[string]QuotaLimit{"claude": {Requests: 10}}}, false},
+		{"model limit", QuotaConfig{Window: 30 * time.Minute, Limits: map[string]QuotaLimit{"claude/opus": {Tokens: 100000}}}, false},
+		{"unknown backend", QuotaConfig{Limits: map[string]QuotaLimit{"nope": {Requests: 10}}}, true},
+		{"unknown model backend", QuotaConfig{Limits: map[string]QuotaLimit{"nope/opus": {Requests: 10}}}, true},
+		{"zero limit", QuotaConfig{Limits: map[string]QuotaLimit{"claude": {}}}, true},
+		{"negative requests", QuotaConfig{Limits: map[string]QuotaLimit{"claude": {Requests: -1, Tokens: 10}}}, true},
+		{"rate limit", QuotaConfig{Limits: map[string]QuotaLimit{"claude": {Rate: 0.5, Burst: 2}}}, false},
+		{"negative rate", QuotaConfig{Limits: map[string]QuotaLimit{"claude": {Requests: 10, Rate: -1}}}, true},
+		{"burst without rate", QuotaConfig{Limits: map[string]QuotaLimit{"claude": {Requests: 10, Burst: 2}}}, true},
+		{"negative window", QuotaConfig{Window: -time.Minute}, true},
+		{"backoff", QuotaConfig{Backoff: 5 * time.Minute}, false},
+		{"negative backoff", QuotaConfig{Backoff: -time.Minute}, true},
+		{"pool", QuotaConfig{Pools: map[string]QuotaPool{"premium": {Members: []string{"claude/opus", "copilot/gpt-5"}, Requests: 20}}}, false},
+		{"pool without limit", QuotaConfig{Pools: map[string]QuotaPool{"premium": {Members: []string{"claude/opus"}}}}, true},
+		{"pool without members", QuotaConfig{Pools: map[string]QuotaPool{"premium": {Tokens: 1000}}}, true},
+		{"pool unknown backend", QuotaConfig{Pools: map[string]QuotaPool{"premium": {Members: []string{"nope/opus"}, Requests: 20}}}, true},
+		{"key in two pools", QuotaConfig{Pools: map[string]QuotaPool{
+			"premium": {Members: []string{"claude/opus"}, Requests: 20},
+			"shared":  {Members: []string{"claude/opus", "codex"}, Requests: 50},
+		}}, true},
+		{"pool with backend and its model", QuotaConfig{Pools: map[string]QuotaPool{"premium": {Members: []string{"claude", "claude/opus"}, Requests: 20}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New("test")
+			q := tt.quota
+			cfg.Quota = &q
+
+			err := cfg.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigQuotaLimits(t *testing.T) {
+	cfg := New("test")
+	if got := cfg.QuotaLimits(); got["claude"].Requests != 50 || got["copilot"].Requests != 100 {
+		t.Errorf("expected default limits, got %v", got)
+	}
+
+	cfg.Quota = &QuotaConfig{Limits: map[string]QuotaLimit{"claude": {Requests: 2}}}
+	limits := cfg.QuotaLimits()
+	if limits["claude"].Requests != 2 {
+		t.Errorf("expected configured claude limit 2, got %d", limits["claude"].Requests)
+	}
+	if limits["copilot"].Requests != 100 {
+		t.Errorf("expected default copilot limit 100, got %d", limits["copilot"].Requests)
+	}
+
+	cfg.Quota.Limits["copilot"] = QuotaLimit{Rate: 0.5, Burst: 2}
+	limits = cfg.QuotaLimits()
+	if got := limits["copilot"]; got.Requests != 100 || got.Rate != 0.5 || got.Burst != 2 {
+		t.Errorf("expected rate-only limit to keep the default request cap, got %+v", got)
+	}
+}
+
+func TestConfigApplyQuota(t *testing.T) {
+	cfg := New("test")
+	cfg.Quota = &QuotaConfig{
+		Window: 10 * time.Minute,
+		Limits: map[string]QuotaLimit{"claude": {Requests: 2}},
+	}
+
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	cfg.ApplyQuota(tracker)
+
+	tracker.Record("claude", 100)
+	if tracker.IsExhausted("claude") {
+		t.Fatal("expected claude available after 1 of 2 requests")
+	}
+	tracker.Record("claude", 100)
+	if !tracker.IsExhausted("claude") {
+		t.Fatal("expected claude exhausted at the configured 2 requests")
+	}
+
+	usage, _ := tracker.GetUsage("claude")
+	if got := usage.RetryAfter.Sub(usage.WindowStart); got != 10*time.Minute {
+		t.Errorf("expected retry after the configured 10m window, got %s", got)
+	}
+}
+
+func TestConfigApplyQuotaPools(t *testing.T) {
+	cfg := New("test")
+	cfg.Quota = &QuotaConfig{
+		Limits: map[string]QuotaLimit{"claude/opus": {Requests: 5}, "copilot/gpt-5": {Requests: 5}},
+		Pools:  map[string]QuotaPool{"premium": {Members: []string{"claude/opus", "copilot/gpt-5"}, Requests: 3}},
+	}
+
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	cfg.ApplyQuota(tracker)
+
+	tracker.Record("claude/opus", 100)
+	tracker.Record("copilot/gpt-5", 100)
+	if tracker.IsExhausted("copilot/gpt-5") {
+		t.Fatal("expected the premium pool available after 2 of 3 requests")
+	}
+	tracker.Record("claude/opus", 100)
+	if !tracker.IsExhausted("copilot/gpt-5") {
+		t.Error("expected copilot/gpt-5 exhausted by the premium pool")
+	}
+}
+
+func TestConfigRedactedWebhookURL(t *testing.T) {
+	cfg := New("test")
+	cfg.Notifications = &NotificationsConfig{
+		Webhook: &WebhookConfig{URL: "https://hooks.example.com/T000/secret-token"},
+		Slack:   &SlackConfig{URL: "https://hooks.slack.com/services/T000/B000/secret"},
+	}
+
+	redacted := cfg.Redacted()
+	if redacted.Notifications.Webhook.URL != "***" {
+		t.Errorf("expected webhook URL masked, got %q", redacted.Notifications.Webhook.URL)
+	}
+	if redacted.Notifications.Slack.URL != "***" {
+		t.Errorf("expected slack URL masked, got %q", redacted.Notifications.Slack.URL)
+	}
+	if cfg.Notifications.Slack.URL != "https://hooks.slack.com/services/T000/B000/secret" {
+		t.Errorf("original slack URL modified: %q", cfg.Notifications.Slack.URL)
+	}
+	if cfg.Notifications.Webhook.URL != "https://hooks.example.com/T000/secret-token" {
+		t.Errorf("original webhook URL modified: %q", cfg.Notifications.Webhook.URL)
+	}
+}
+
+func TestConfigLoadIncludeChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	sharedDir := filepath.Join(tmpDir, "shared")
+	os.MkdirAll(sharedDir, 0755)
+
+	// org.yaml <- team.yaml <- config.yaml
+	os.WriteFile(filepath.Join(sharedDir, "org.yaml"), []byte(`backend: copilot
+tdd:
+  enforce: true
+  test_command: make test
+copilot:
+  model: gpt-4.1
+`), 0644)
+	os.WriteFile(filepath.Join(sharedDir, "team.yaml"), []byte(`include:
+  - org.yaml
+tdd:
+  coverage_threshold: 80
+`), 0644)
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(configPath, []byte(`include:
+  - shared/team.yaml
+feature: local
+tdd:
+  test_command: go test ./...
+`), 0644)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	if cfg.Feature != "local" {
+		t.Errorf("expected feature 'local', got '%s'", cfg.Feature)
+	}
+	if cfg.Backend != "copilot" {
+		t.Errorf("expected backend from org include, got '%s'", cfg.Backend)
+	}
+	if cfg.Copilot == nil || cfg.Copilot.Model != "gpt-4.1" {
+		t.Error("expected copilot model from org include")
+	}
+	if !cfg.TDD.Enforce {
+		t.Error("expected TDD.Enforce from org include")
+	}
+	if cfg.TDD.CoverageThreshold != 80 {
+		t.Errorf("expected coverage threshold from team include, got %d", cfg.TDD.CoverageThreshold)
+	}
+	if cfg.TDD.TestCommand != "go test ./..." {
+		t.Errorf("expected local test command to win, got '%s'", cfg.TDD.TestCommand)
+	}
+
+	// Saving must not flatten included values into the local file
+	if err := cfg.Save(configPath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	data, _ := os.ReadFile(configPath)
+	if strings.Contains(string(data), "copilot") {
+		t.Errorf("included values written to local file:\n%s", data)
+	}
+	reloaded, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to reload: %v", err)
+	}
+	if reloaded.Backend != "copilot" || reloaded.TDD.CoverageThreshold != 80 {
+		t.Error("includes not preserved after save")
+	}
+}
+
+func TestConfigLoadIncludeCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(configPath, []byte("include:\n  - config.yaml\nfeature: loop\n"), 0644)
+
+	_, err := Load(configPath)
+	if err == nil {
+		t.Fatal("expected error for include cycle")
+	}
+	if !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("expected cycle error, got: %v", err)
+	}
+}
+
+func TestConfigLoadIncludeMissing(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(configPath, []byte("include:\n  - missing.yaml\nfeature: test\n"), 0644)
+
+	if _, err := Load(configPath); err == nil {
+		t.Error("expected error for missing include")
+	}
+}
+
+func TestConfigRetryDefaults(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(configPath, []byte("feature: minimal\n"), 0644)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+
+	retry := cfg.RetryFor("claude")
+	if retry.MaxAttempts < 1 {
+		t.Errorf("expected default max attempts >= 1, got %d", retry.MaxAttempts)
+	}
+	if retry.BaseDelay <= 0 || retry.MaxDelay < retry.BaseDelay {
+		t.Errorf("unexpected default delays: base=%s max=%s", retry.BaseDelay, retry.MaxDelay)
+	}
+
+	agentCfg := retry.AgentConfig()
+	if agentCfg.MaxRetries != retry.MaxAttempts-1 {
+		t.Errorf("expected %d retries, got %d", retry.MaxAttempts-1, agentCfg.MaxRetries)
+	}
+}
+
+func TestConfigRetryOverrides(t *testing.T) {
+	tmpDir := t.TempDir()
+	configPath := filepath.Join(tmpDir, "config.yaml")
+	os.WriteFile(configPath, []byte(`feature: test
+retry:
+  max_attempts: 2
+  base_delay: 500ms
+  max_delay: 5s
+claude:
+  retry:
+    max_attempts: 6
+    base_delay: 2s
+    max_delay: 1m
+`), 0644)
+
+	cfg, err := Load(configPath)
+	if err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	claude := cfg.RetryFor("claude")
+	if claude.MaxAttempts != 6 || claude.BaseDelay != 2*time.Second || claude.MaxDelay != time.Minute {
+		t.Errorf("claude override not applied: %+v", claude)
+	}
+	copilot := cfg.RetryFor("copilot")
+	if copilot.MaxAttempts != 2 || copilot.BaseDelay != 500*time.Millisecond || copilot.MaxDelay != 5*time.Second {
+		t.Errorf("workspace retry not applied: %+v", copilot)
+	}
+}
+
+func TestConfigRetryValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   *RetryConfig
+		wantErr bool
+	}{
+		{"valid", &RetryConfig{MaxAttempts: 1}, false},
+		{"zero max attempts", &RetryConfig{MaxAttempts: 0}, true},
+		{"negative base delay", &RetryConfig{MaxAttempts: 3, BaseDelay: -time.Second}, true},
+		{"negative max delay", &RetryConfig{MaxAttempts: 3, MaxDelay: -time.Second}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := New("test")
+			cfg.Retry = tt.retry
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			cfg = New("test")
+			cfg.Claude = &ClaudeConfig{Retry: tt.retry}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() with claude retry error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+// Package fileutil provides crash-safe file writing helpers.
+package fileutil
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// writeTemp writes data to the temp file. It is a variable so tests can
+// simulate a failed write.
+var writeTemp = func(f *os.File, data []byte) error {
+	_, err := f.Write(data)
+	return err
+}
+
+// WriteFileAtomic writes data to path by writing a temp file in the same
+// directory and renaming it into place, so readers and crashes never see a
+// partially written file. An existing file's mode is preserved; perm is used
+// for new files.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	tmp, err := os.CreateTemp(dir, "."+base+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	// Leave the original untouched on any failure
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := writeTemp(tmp, data); err != nil {
+		return fail(fmt.Errorf("failed to write temp file: %w", err))
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(fmt.Errorf("failed to sync temp file: %w", err))
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fail(fmt.Errorf("failed to set file mode: %w", err))
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package fileutil
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+
+	if err := WriteFileAtomic(path, []byte("first"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+	if err := WriteFileAtomic(path, []byte("second"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "second" {
+		t.Errorf("expected 'second', got %q", data)
+	}
+
+	// No temp files are left behind
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("expected only the target file, got %d entries", len(entries))
+	}
+}
+
+func TestWriteFileAtomicPreservesMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret.yaml")
+	os.WriteFile(path, []byte("old"), 0600)
+
+	if err := WriteFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("WriteFileAtomic failed: %v", err)
+	}
+
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600 preserved, got %o", info.Mode().Perm())
+	}
+}
+
+func TestWriteFileAtomicFailedWriteKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tasks.json")
+	os.WriteFile(path, []byte("original"), 0644)
+
+	// Simulate the process failing part-way through writing
+	orig := writeTemp
+	writeTemp = func(f *os.File, data []byte) error {
+		f.Write(data[:len(data)/2])
+		return errors.New("disk full")
+	}
+	defer func() { writeTemp = orig }()
+
+	if err := WriteFileAtomic(path, []byte("replacement"), 0644); err == nil {
+		t.Fatal("expected error from failed write")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "original" {
+		t.Errorf("expected original contents intact, got %q", data)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("expected temp file cleaned up, got %d entries", len(entries))
+	}
+}
@@ -0,0 +1,84 @@
+// Package github publishes task outcomes to GitHub issues.
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultBaseURL is the public GitHub REST API.
+const DefaultBaseURL = "https://api.github.com"
+
+// HTTPClient is the subset of *http.Client the client needs, so tests can
+// substitute a fake transport.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Client calls the GitHub issues API for a single repository.
+type Client struct {
+	BaseURL string     // API root (default DefaultBaseURL)
+	Repo    string     // owner/name
+	Token   string     // Bearer token; may be empty for dry runs
+	HTTP    HTTPClient // Default http.DefaultClient
+}
+
+// NewClient creates a client for repo ("owner/name").
+func NewClient(repo, token string) (*Client, error) {
+	owner, name, ok := strings.Cut(repo, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("repo must be owner/name, got '%s'", repo)
+	}
+	return &Client{
+		BaseURL: DefaultBaseURL,
+		Repo:    repo,
+		Token:   token,
+		HTTP:    http.DefaultClient,
+	}, nil
+}
+
+// Comment posts body as a comment on issue.
+func (c *Client) Comment(ctx context.Context, issue int, body string) error {
+	path := fmt.Sprintf("/repos/%s/issues/%d/comments", c.Repo, issue)
+	return c.do(ctx, http.MethodPost, path, map[string]string{"body": body})
+}
+
+// CloseIssue marks issue as closed.
+func (c *Client) CloseIssue(ctx context.Context, issue int) error {
+	path := fmt.Sprintf("/repos/%s/issues/%d", c.Repo, issue)
+	return c.do(ctx, http.MethodPatch, path, map[string]string{"state": "closed"})
+}
+
+func (c *Client) do(ctx context.Context, method, path string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to serialize request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, strings.TrimSuffix(c.BaseURL, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s %s returned %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// ExportOptions controls how task outcomes are published.
+type ExportOptions struct {
+	Close  bool      // Close the issues of complete tasks
+	DryRun bool      // Print what would be posted instead of calling the API
+	Out    io.Writer // Where dry-run output goes
+}
+
+// ExportSummary reports the outcome of Export.
+type ExportSummary struct {
+	Commented []string         // Task IDs whose issue was commented on
+	Closed    []string         // Task IDs whose issue was closed
+	Errors    map[string]error // Per-task failures
+}
+
+// Export comments on the GitHub issue of every finished task that records
+// one, and closes the issues of complete tasks when opts.Close is set.
+// A failure on one task does not stop the others.
+func Export(ctx context.Context, c *Client, tasks []*task.Task, opts ExportOptions) *ExportSummary {
+	summary := &ExportSummary{
+		Commented: []string{},
+		Closed:    []string{},
+		Errors:    make(map[string]error),
+	}
+
+	sorted := append([]*task.Task(nil), tasks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for _, t := range sorted {
+		if t.Issue <= 0 || !t.IsTerminal() {
+			continue
+		}
+		body := CompletionComment(t)
+		closeIssue := opts.Close && t.Status == task.StatusComplete
+
+		if opts.DryRun {
+			fmt.Fprintf(opts.Out, "--- %s#%d (task %s)\n%s\n", c.Repo, t.Issue, t.ID, body)
+			if closeIssue {
+				fmt.Fprintf(opts.Out, "(would close %s#%d)\n", c.Repo, t.Issue)
+			}
+			fmt.Fprintln(opts.Out)
+			summary.Commented = append(summary.Commented, t.ID)
+			if closeIssue {
+				summary.Closed = append(summary.Closed, t.ID)
+			}
+			continue
+		}
+
+		if err := c.Comment(ctx, t.Issue, body); err != nil {
+			summary.Errors[t.ID] = err
+			audit.Warn("github.export", "Failed to comment on issue", map[string]interface{}{
+				"task_id": t.ID,
+				"issue":   t.Issue,
+				"error":   err.Error(),
+			})
+			continue
+		}
+		summary.Commented = append(summary.Commented, t.ID)
+
+		if closeIssue {
+			if err := c.CloseIssue(ctx, t.Issue); err != nil {
+				summary.Errors[t.ID] = err
+				audit.Warn("github.export", "Failed to close issue", map[string]interface{}{
+					"task_id": t.ID,
+					"issue":   t.Issue,
+					"error":   err.Error(),
+				})
+				continue
+			}
+			summary.Closed = append(summary.Closed, t.ID)
+		}
+	}
+
+	audit.Info("github.export", "Exported task outcomes", map[string]interface{}{
+		"repo":      c.Repo,
+		"commented": len(summary.Commented),
+		"closed":    len(summary.Closed),
+		"failed":    len(summary.Errors),
+	})
+
+	return summary
+}
+
+// CompletionComment renders the issue comment summarizing a finished task.
+func CompletionComment(t *task.Task) string {
+	var b strings.Builder
+	if t.Status == task.StatusComplete {
+		fmt.Fprintf(&b, "✅ Task `%s` complete: %s\n", t.ID, t.Title)
+	} else {
+		fmt.Fprintf(&b, "❌ Task `%s` %s: %s\n", t.ID, t.Status, t.Title)
+	}
+	b.WriteString("\n")
+
+	backend := t.UsedBackend
+	if backend == "" {
+		backend = "unknown"
+	}
+	if t.UsedModel != "" {
+		backend += " (" + t.UsedModel + ")"
+	}
+	fmt.Fprintf(&b, "- Backend: %s\n", backend)
+	fmt.Fprintf(&b, "- Tokens: %d\n", t.Tokens)
+	if d := t.Duration(); d > 0 {
+		fmt.Fprintf(&b, "- Duration: %s\n", d.Round(time.Second))
+	}
+	return b.String()
+}
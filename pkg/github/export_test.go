@@ -0,0 +1,190 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// recordedRequest is a request captured by fakeHTTP.
+type recordedRequest struct {
+	Method string
+	Path   string
+	Body   map[string]string
+}
+
+// fakeHTTP records requests and answers with status.
+type fakeHTTP struct {
+	status   int
+	requests []recordedRequest
+}
+
+func (f *fakeHTTP) Do(req *http.Request) (*http.Response, error) {
+	var body map[string]string
+	json.NewDecoder(req.Body).Decode(&body)
+	f.requests = append(f.requests, recordedRequest{Method: req.Method, Path: req.URL.Path, Body: body})
+
+	status := f.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Body:       io.NopCloser(strings.NewReader("{}")),
+	}, nil
+}
+
+func newTestClient(t *testing.T, fake *fakeHTTP) *Client {
+	t.Helper()
+	c, err := NewClient("acme/shop", "token")
+	if err != nil {
+		t.Fatalf("NewClient failed: %v", err)
+	}
+	c.HTTP = fake
+	return c
+}
+
+func finishedTask(id string, issue int, status task.Status) *task.Task {
+	t := task.New(id, "Task "+id)
+	t.Issue = issue
+	t.SetStatus(task.StatusInProgress)
+	t.SetStatus(status)
+	return t
+}
+
+func TestNewClientRejectsBadRepo(t *testing.T) {
+	for _, repo := range []string{"", "acme", "/shop", "acme/", "acme/shop/extra"} {
+		if _, err := NewClient(repo, ""); err == nil {
+			t.Errorf("expected error for repo %q", repo)
+		}
+	}
+}
+
+func TestCompletionComment(t *testing.T) {
+	tk := task.New("t-001", "Add login")
+	tk.Issue = 12
+	tk.SetStatus(task.StatusInProgress)
+	tk.SetStatus(task.StatusComplete)
+	started := tk.CompletedAt.Add(-90 * time.Second)
+	tk.StartedAt = &started
+	tk.RecordRun("claude", "claude-sonnet-4", 1234)
+
+	body := CompletionComment(tk)
+	for _, want := range []string{
+		"✅ Task `t-001` complete: Add login",
+		"- Backend: claude (claude-sonnet-4)",
+		"- Tokens: 1234",
+		"- Duration: 1m30s",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("comment missing %q:\n%s", want, body)
+		}
+	}
+
+	failed := finishedTask("t-002", 13, task.StatusFailed)
+	if body := CompletionComment(failed); !strings.Contains(body, "❌ Task `t-002` failed") {
+		t.Errorf("unexpected failed comment:\n%s", body)
+	}
+}
+
+func TestExportClosesOnlyCompleteTasks(t *testing.T) {
+	fake := &fakeHTTP{}
+	c := newTestClient(t, fake)
+
+	tasks := []*task.Task{
+		finishedTask("t-002", 20, task.StatusFailed),
+		finishedTask("t-001", 10, task.StatusComplete),
+		task.New("t-003", "Pending with issue"),
+		finishedTask("t-004", 0, task.StatusComplete), // No issue recorded
+	}
+	tasks[2].Issue = 30
+
+	summary := Export(context.Background(), c, tasks, ExportOptions{Close: true})
+
+	if len(summary.Errors) != 0 {
+		t.Fatalf("unexpected errors: %v", summary.Errors)
+	}
+	if strings.Join(summary.Commented, ",") != "t-001,t-002" {
+		t.Errorf("expected comments on t-001,t-002, got %v", summary.Commented)
+	}
+	if strings.Join(summary.Closed, ",") != "t-001" {
+		t.Errorf("expected only t-001 closed, got %v", summary.Closed)
+	}
+
+	want := []recordedRequest{
+		{Method: http.MethodPost, Path: "/repos/acme/shop/issues/10/comments"},
+		{Method: http.MethodPatch, Path: "/repos/acme/shop/issues/10"},
+		{Method: http.MethodPost, Path: "/repos/acme/shop/issues/20/comments"},
+	}
+	if len(fake.requests) != len(want) {
+		t.Fatalf("expected %d requests, got %d: %+v", len(want), len(fake.requests), fake.requests)
+	}
+	for i, w := range want {
+		got := fake.requests[i]
+		if got.Method != w.Method || got.Path != w.Path {
+			t.Errorf("request %d: expected %s %s, got %s %s", i, w.Method, w.Path, got.Method, got.Path)
+		}
+	}
+	if fake.requests[1].Body["state"] != "closed" {
+		t.Errorf("expected close payload, got %v", fake.requests[1].Body)
+	}
+	if !strings.Contains(fake.requests[0].Body["body"], "Task `t-001` complete") {
+		t.Errorf("unexpected comment body: %q", fake.requests[0].Body["body"])
+	}
+}
+
+func TestExportWithoutCloseOnlyComments(t *testing.T) {
+	fake := &fakeHTTP{}
+	c := newTestClient(t, fake)
+
+	summary := Export(context.Background(), c, []*task.Task{finishedTask("t-001", 10, task.StatusComplete)}, ExportOptions{})
+
+	if len(summary.Closed) != 0 {
+		t.Errorf("expected no closes, got %v", summary.Closed)
+	}
+	if len(fake.requests) != 1 || fake.requests[0].Method != http.MethodPost {
+		t.Errorf("expected a single comment request, got %+v", fake.requests)
+	}
+}
+
+func TestExportDryRun(t *testing.T) {
+	fake := &fakeHTTP{}
+	c := newTestClient(t, fake)
+	var buf bytes.Buffer
+
+	summary := Export(context.Background(), c, []*task.Task{finishedTask("t-001", 10, task.StatusComplete)},
+		ExportOptions{Close: true, DryRun: true, Out: &buf})
+
+	if len(fake.requests) != 0 {
+		t.Errorf("dry run made %d requests", len(fake.requests))
+	}
+	output := buf.String()
+	if !strings.Contains(output, "acme/shop#10 (task t-001)") || !strings.Contains(output, "would close acme/shop#10") {
+		t.Errorf("unexpected dry-run output:\n%s", output)
+	}
+	if len(summary.Closed) != 1 {
+		t.Errorf("expected dry run to report 1 close, got %v", summary.Closed)
+	}
+}
+
+func TestExportRecordsAPIErrors(t *testing.T) {
+	fake := &fakeHTTP{status: http.StatusNotFound}
+	c := newTestClient(t, fake)
+
+	summary := Export(context.Background(), c, []*task.Task{finishedTask("t-001", 10, task.StatusComplete)}, ExportOptions{Close: true})
+
+	if summary.Errors["t-001"] == nil {
+		t.Fatal("expected error for t-001")
+	}
+	if len(fake.requests) != 1 {
+		t.Errorf("expected no close after failed comment, got %d requests", len(fake.requests))
+	}
+}
@@ -0,0 +1,77 @@
+// Package logging provides the leveled diagnostic logger shared by commands,
+// the agent runner, and tools. It is separate from the user-facing progress
+// output and from the audit trail.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Format is a log format selected with --log-format.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// DefaultLevel keeps diagnostics quiet unless something goes wrong.
+const DefaultLevel = slog.LevelWarn
+
+var current atomic.Pointer[slog.Logger]
+
+func init() {
+	current.Store(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: DefaultLevel})))
+}
+
+// ParseLevel validates a level name: debug, info, warn, or error.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(s) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "", "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return 0, fmt.Errorf("invalid log level '%s' (expected debug, info, warn, or error)", s)
+	}
+}
+
+// ParseFormat validates a format name. An empty name means text.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid log format '%s' (expected text or json)", s)
+	}
+}
+
+// New creates a logger writing records at level or above to w.
+func New(w io.Writer, level slog.Level, format Format) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Setup replaces the shared logger with one writing to w.
+func Setup(w io.Writer, level slog.Level, format Format) {
+	current.Store(New(w, level, format))
+}
+
+// L returns the shared logger.
+func L() *slog.Logger {
+	return current.Load()
+}
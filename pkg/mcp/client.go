@@ -0,0 +1,112 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Defaults for the generated client config.
+const (
+	DefaultServerName = "eas"
+	DefaultCommand    = "eas"
+)
+
+// LaunchOptions describe how a backend launches the EAS MCP server.
+type LaunchOptions struct {
+	Name      string   // Server name in the config (default DefaultServerName)
+	Command   string   // Binary path, or name on PATH (default: DefaultCommand in the current directory, else on PATH)
+	ExtraArgs []string // Appended to "mcp serve"
+	Dir       string   // Working directory of the server, normally the workspace root
+
+	// Servers are additional servers written alongside the built-in one.
+	Servers map[string]ServerSpec
+}
+
+// ServerSpec is an additional MCP server. A spec named like the built-in
+// server replaces it only when Override is set.
+type ServerSpec struct {
+	Command  string
+	Args     []string
+	Env      map[string]string
+	Cwd      string
+	Override bool
+}
+
+// ClientServer is one server entry in an MCP client config.
+type ClientServer struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env,omitempty"`
+	Cwd     string            `json:"cwd,omitempty"`
+}
+
+// ClientConfig is the mcpServers file read by MCP clients such as the
+// Claude CLI.
+type ClientConfig struct {
+	MCPServers map[string]ClientServer `json:"mcpServers"`
+}
+
+// NewClientConfig builds the client config for opts. An explicit command
+// path must exist; a bare command name is left for PATH lookup.
+func NewClientConfig(opts LaunchOptions) (*ClientConfig, error) {
+	name := opts.Name
+	if name == "" {
+		name = DefaultServerName
+	}
+
+	cfg := &ClientConfig{MCPServers: make(map[string]ClientServer, len(opts.Servers)+1)}
+	for serverName, spec := range opts.Servers {
+		if serverName == name && !spec.Override {
+			return nil, fmt.Errorf("mcp server '%s' conflicts with the built-in server (set override to replace it)", serverName)
+		}
+		if spec.Command == "" {
+			return nil, fmt.Errorf("mcp server '%s' has no command", serverName)
+		}
+		cfg.MCPServers[serverName] = ClientServer{
+			Command: spec.Command,
+			Args:    append([]string{}, spec.Args...),
+			Env:     spec.Env,
+			Cwd:     spec.Cwd,
+		}
+	}
+	if _, replaced := cfg.MCPServers[name]; replaced {
+		return cfg, nil
+	}
+
+	command := opts.Command
+	if command == "" {
+		// Prefer a binary in the current directory, otherwise use PATH
+		command = DefaultCommand
+		if cwd, err := os.Getwd(); err == nil {
+			local := filepath.Join(cwd, DefaultCommand)
+			if _, err := os.Stat(local); err == nil {
+				command = local
+			}
+		}
+	} else if strings.ContainsRune(command, '/') || strings.ContainsRune(command, filepath.Separator) {
+		if _, err := os.Stat(command); err != nil {
+			return nil, fmt.Errorf("mcp command '%s' not found: %w", command, err)
+		}
+	}
+
+	args := append([]string{"mcp", "serve"}, opts.ExtraArgs...)
+	cfg.MCPServers[name] = ClientServer{Command: command, Args: args, Cwd: opts.Dir}
+	return cfg, nil
+}
+
+// WriteClientConfig writes the client config for opts to path.
+func WriteClientConfig(path string, opts LaunchOptions) error {
+	cfg, err := NewClientConfig(opts)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to serialize MCP config: %w", err)
+	}
+	return os.WriteFile(path, data, 0644)
+}
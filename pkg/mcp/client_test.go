@@ -0,0 +1,146 @@
+package mcp
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteClientConfigDefaults(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.json")
+	if err := WriteClientConfig(path, LaunchOptions{Dir: "/work/feature"}); err != nil {
+		t.Fatalf("WriteClientConfig failed: %v", err)
+	}
+
+	cfg := readClientConfig(t, path)
+	server, ok := cfg.MCPServers[DefaultServerName]
+	if !ok {
+		t.Fatalf("expected %q server, got %v", DefaultServerName, cfg.MCPServers)
+	}
+	if filepath.Base(server.Command) != DefaultCommand {
+		t.Errorf("expected default command, got %q", server.Command)
+	}
+	if !reflect.DeepEqual(server.Args, []string{"mcp", "serve"}) {
+		t.Errorf("expected default args, got %v", server.Args)
+	}
+	if server.Cwd != "/work/feature" {
+		t.Errorf("expected cwd /work/feature, got %q", server.Cwd)
+	}
+}
+
+func TestWriteClientConfigOverrides(t *testing.T) {
+	dir := t.TempDir()
+	wrapper := filepath.Join(dir, "flo-wrapper.sh")
+	if err := os.WriteFile(wrapper, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "mcp.json")
+	err := WriteClientConfig(path, LaunchOptions{
+		Name:      "flo",
+		Command:   wrapper,
+		ExtraArgs: []string{"--profile", "ci"},
+		Dir:       dir,
+	})
+	if err != nil {
+		t.Fatalf("WriteClientConfig failed: %v", err)
+	}
+
+	cfg := readClientConfig(t, path)
+	want := ClientServer{Command: wrapper, Args: []string{"mcp", "serve", "--profile", "ci"}, Cwd: dir}
+	if len(cfg.MCPServers) != 1 || !reflect.DeepEqual(cfg.MCPServers["flo"], want) {
+		t.Errorf("expected only server flo = %+v, got %+v", want, cfg.MCPServers)
+	}
+}
+
+func TestNewClientConfigCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{"name on PATH", "flo", false},
+		{"missing explicit path", "/nonexistent/bin/flo", true},
+		{"missing relative path", "./bin/flo-missing", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClientConfig(LaunchOptions{Command: tt.command})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewClientConfig(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func readClientConfig(t *testing.T, path string) ClientConfig {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+	var cfg ClientConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("failed to parse config: %v", err)
+	}
+	return cfg
+}
+
+func TestWriteClientConfigExtraServers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.json")
+	err := WriteClientConfig(path, LaunchOptions{
+		Command: "eas",
+		Dir:     "/work/feature",
+		Servers: map[string]ServerSpec{
+			"filesystem": {
+				Command: "npx",
+				Args:    []string{"-y", "@modelcontextprotocol/server-filesystem", "/work"},
+				Env:     map[string]string{"LOG_LEVEL": "warn"},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteClientConfig failed: %v", err)
+	}
+
+	cfg := readClientConfig(t, path)
+	if len(cfg.MCPServers) != 2 {
+		t.Fatalf("expected built-in and filesystem servers, got %v", cfg.MCPServers)
+	}
+	if cfg.MCPServers[DefaultServerName].Command != "eas" {
+		t.Errorf("expected built-in server kept, got %+v", cfg.MCPServers[DefaultServerName])
+	}
+	fs := cfg.MCPServers["filesystem"]
+	if fs.Command != "npx" || len(fs.Args) != 3 || fs.Env["LOG_LEVEL"] != "warn" {
+		t.Errorf("unexpected filesystem server: %+v", fs)
+	}
+}
+
+func TestNewClientConfigBuiltinConflict(t *testing.T) {
+	custom := ServerSpec{Command: "my-eas", Args: []string{"serve"}}
+
+	if _, err := NewClientConfig(LaunchOptions{Servers: map[string]ServerSpec{"eas": custom}}); err == nil {
+		t.Error("expected an error for a server shadowing the built-in one")
+	}
+
+	custom.Override = true
+	cfg, err := NewClientConfig(LaunchOptions{Servers: map[string]ServerSpec{"eas": custom}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cfg.MCPServers["eas"]; got.Command != "my-eas" || !reflect.DeepEqual(got.Args, []string{"serve"}) {
+		t.Errorf("expected override to replace the built-in server, got %+v", got)
+	}
+
+	// Renaming the built-in server frees its default name
+	cfg, err = NewClientConfig(LaunchOptions{Name: "flo", Command: "eas", Servers: map[string]ServerSpec{"eas": {Command: "other"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MCPServers["flo"].Command != "eas" || cfg.MCPServers["eas"].Command != "other" {
+		t.Errorf("unexpected servers: %+v", cfg.MCPServers)
+	}
+}
@@ -3,7 +3,9 @@ package mcp
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 
@@ -54,6 +56,12 @@ func NewServer(toolReg *tools.Registry) *Server {
 // HandleRequest processes a single MCP request and returns a response.
 // Returns nil response for notifications (requests without ID).
 func (s *Server) HandleRequest(req Request) (*Response, error) {
+	return s.HandleRequestContext(context.Background(), req)
+}
+
+// HandleRequestContext is HandleRequest with a context, which tools/call
+// passes to the tool so a cancelled server stops long-running tools.
+func (s *Server) HandleRequestContext(ctx context.Context, req Request) (*Response, error) {
 	// Notifications don't get responses
 	if req.ID == nil {
 		// Handle known notifications silently
@@ -71,12 +79,9 @@ func (s *Server) HandleRequest(req Request) (*Response, error) {
 	case "tools/list":
 		resp.Result = s.handleToolsList()
 	case "tools/call":
-		result, err := s.handleToolsCall(req.Params)
+		result, err := s.handleToolsCall(ctx, req.Params)
 		if err != nil {
-			resp.Error = &ErrorResp{
-				Code:    -32000,
-				Message: err.Error(),
-			}
+			resp.Error = toolErrorResp(err)
 		} else {
 			resp.Result = result
 		}
@@ -90,6 +95,54 @@ func (s *Server) HandleRequest(req Request) (*Response, error) {
 	return resp, nil
 }
 
+// JSON-RPC error codes for tool failures. Codes in -32000..-32099 are
+// reserved for implementation-defined server errors.
+const (
+	codeInvalidParams = -32602
+	codeToolError     = -32000
+	codeNotFound      = -32001
+	codeUnauthorized  = -32003
+	codeConflict      = -32009
+)
+
+// toolErrorResp maps a tool error to a JSON-RPC error, carrying a
+// ToolError's code and details in the error data.
+func toolErrorResp(err error) *ErrorResp {
+	resp := &ErrorResp{
+		Code:    codeToolError,
+		Message: err.Error(),
+	}
+
+	var toolErr *tools.ToolError
+	if !errors.As(err, &toolErr) {
+		return resp
+	}
+
+	switch toolErr.Code {
+	case tools.CodeInvalidArgs:
+		resp.Code = codeInvalidParams
+	case tools.CodeNotFound:
+		resp.Code = codeNotFound
+	case tools.CodeUnauthorized:
+		resp.Code = codeUnauthorized
+	case tools.CodeConflict:
+		resp.Code = codeConflict
+	}
+
+	if toolErr.Code != "" || len(toolErr.Details) > 0 {
+		data := map[string]any{}
+		if toolErr.Code != "" {
+			data["code"] = toolErr.Code
+		}
+		if len(toolErr.Details) > 0 {
+			data["details"] = toolErr.Details
+		}
+		resp.Data = data
+	}
+
+	return resp
+}
+
 func (s *Server) handleInitialize(params map[string]any) map[string]any {
 	return map[string]any{
 		"protocolVersion": protocolVersion,
@@ -128,10 +181,10 @@ func (s *Server) handleToolsList() map[string]any {
 	}
 }
 
-func (s *Server) handleToolsCall(params map[string]any) (map[string]any, error) {
+func (s *Server) handleToolsCall(ctx context.Context, params map[string]any) (map[string]any, error) {
 	name, ok := params["name"].(string)
 	if !ok {
-		return nil, fmt.Errorf("missing tool name")
+		return nil, tools.ErrInvalidArgs("missing tool name")
 	}
 
 	args, _ := params["arguments"].(map[string]any)
@@ -139,7 +192,7 @@ func (s *Server) handleToolsCall(params map[string]any) (map[string]any, error)
 		args = make(map[string]any)
 	}
 
-	result, err := s.tools.Execute(name, tools.Args(args))
+	result, err := s.tools.ExecuteContext(ctx, name, tools.Args(args))
 	if err != nil {
 		return nil, err
 	}
@@ -205,6 +258,11 @@ func (s *Server) writeResponse(output io.Writer, resp *Response) error {
 
 // Serve runs the MCP server on stdio until EOF.
 func (s *Server) Serve(input io.Reader, output io.Writer) error {
+	return s.ServeContext(context.Background(), input, output)
+}
+
+// ServeContext is Serve with a context passed to each tool call.
+func (s *Server) ServeContext(ctx context.Context, input io.Reader, output io.Writer) error {
 	scanner := bufio.NewScanner(input)
 	for scanner.Scan() {
 		line := scanner.Bytes()
@@ -225,7 +283,7 @@ func (s *Server) Serve(input io.Reader, output io.Writer) error {
 			continue
 		}
 
-		resp, err := s.HandleRequest(req)
+		resp, err := s.HandleRequestContext(ctx, req)
 		if err != nil {
 			continue
 		}
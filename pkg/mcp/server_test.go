@@ -2,6 +2,7 @@ package mcp
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"testing"
 
@@ -129,6 +130,30 @@ func TestMCPToolsCall(t *testing.T) {
 	}
 }
 
+func TestMCPToolsCallContext(t *testing.T) {
+	toolReg := tools.NewRegistry()
+	toolReg.Register(tools.NewContext("wait", "Wait for cancellation", nil, func(ctx context.Context, args tools.Args) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}))
+	server := NewServer(toolReg)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp, err := server.HandleRequestContext(ctx, Request{
+		JSONRPC: "2.0",
+		ID:      3,
+		Method:  "tools/call",
+		Params:  map[string]any{"name": "wait"},
+	})
+	if err != nil {
+		t.Fatalf("tools/call failed: %v", err)
+	}
+	if resp.Error == nil || resp.Error.Message != context.Canceled.Error() {
+		t.Errorf("expected the tool to see the cancelled context, got %+v", resp.Error)
+	}
+}
+
 func TestMCPToolsCallNotFound(t *testing.T) {
 	server := NewServer(tools.NewRegistry())
 
@@ -148,7 +173,64 @@ func TestMCPToolsCallNotFound(t *testing.T) {
 	}
 
 	if resp.Error == nil {
-		t.Error("expected error response for nonexistent tool")
+		t.Fatal("expected error response for nonexistent tool")
+	}
+	if resp.Error.Code != codeNotFound {
+		t.Errorf("expected error code %d, got %d", codeNotFound, resp.Error.Code)
+	}
+	data, _ := resp.Error.Data.(map[string]any)
+	if data["code"] != tools.CodeNotFound {
+		t.Errorf("expected data code %q, got %v", tools.CodeNotFound, resp.Error.Data)
+	}
+}
+
+func TestMCPToolErrorCodes(t *testing.T) {
+	reg := tools.NewRegistry()
+	reg.Register(tools.New("denied", "Always denied", nil, func(args tools.Args) (string, error) {
+		return "", tools.ErrUnauthorized("not allowed").WithDetail("role", "viewer")
+	}))
+	reg.Register(tools.New("plain", "Plain error", nil, func(args tools.Args) (string, error) {
+		return "", bytes.ErrTooLarge
+	}))
+	reg.Register(tools.New("typed", "Typed args", map[string]any{
+		"type":     "object",
+		"required": []any{"id"},
+	}, func(args tools.Args) (string, error) {
+		return "", nil
+	}))
+	server := NewServer(reg)
+
+	tests := []struct {
+		tool     string
+		wantCode int
+		wantData string
+	}{
+		{"denied", codeUnauthorized, tools.CodeUnauthorized},
+		{"typed", codeInvalidParams, tools.CodeInvalidArgs},
+		{"plain", codeToolError, ""},
+	}
+
+	for _, tt := range tests {
+		resp, _ := server.HandleRequest(Request{
+			JSONRPC: "2.0",
+			ID:      1,
+			Method:  "tools/call",
+			Params:  map[string]any{"name": tt.tool},
+		})
+		if resp.Error == nil {
+			t.Errorf("%s: expected error response", tt.tool)
+			continue
+		}
+		if resp.Error.Code != tt.wantCode {
+			t.Errorf("%s: expected code %d, got %d", tt.tool, tt.wantCode, resp.Error.Code)
+		}
+		data, _ := resp.Error.Data.(map[string]any)
+		if tt.wantData == "" && resp.Error.Data != nil {
+			t.Errorf("%s: expected no data, got %v", tt.tool, resp.Error.Data)
+		}
+		if tt.wantData != "" && data["code"] != tt.wantData {
+			t.Errorf("%s: expected data code %q, got %v", tt.tool, tt.wantData, resp.Error.Data)
+		}
 	}
 }
 
@@ -0,0 +1,105 @@
+// Package metrics exposes quota and task state as Prometheus metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// Source supplies the current state on each scrape.
+type Source interface {
+	Usage() (map[string]*quota.Usage, error)
+	Tasks() ([]*task.Task, error)
+}
+
+var (
+	// Usage resets every quota window, so it is a gauge rather than a counter
+	requestsDesc = prometheus.NewDesc(
+		"flo_backend_window_requests",
+		"Requests made to a backend in the current quota window.",
+		[]string{"backend"}, nil,
+	)
+	tokensDesc = prometheus.NewDesc(
+		"flo_backend_window_tokens",
+		"Tokens consumed by a backend in the current quota window.",
+		[]string{"backend"}, nil,
+	)
+	exhaustedDesc = prometheus.NewDesc(
+		"flo_backend_exhausted",
+		"Whether a backend's quota is exhausted (1) or not (0).",
+		[]string{"backend"}, nil,
+	)
+	tasksDesc = prometheus.NewDesc(
+		"flo_tasks",
+		"Number of tasks by status.",
+		[]string{"status"}, nil,
+	)
+)
+
+// Collector is a prometheus.Collector that reads its values from a Source
+// at scrape time, so metrics always reflect the current workspace state.
+type Collector struct {
+	source Source
+}
+
+// NewCollector creates a collector reading from source.
+func NewCollector(source Source) *Collector {
+	return &Collector{source: source}
+}
+
+// Describe sends the metric descriptors.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- requestsDesc
+	ch <- tokensDesc
+	ch <- exhaustedDesc
+	ch <- tasksDesc
+}
+
+// Collect reads the source and sends the current metric values.
+// A failing source is logged and its metrics are skipped for this scrape.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	if usage, err := c.source.Usage(); err != nil {
+		audit.Warn("metrics.collect", "Failed to read quota usage", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		for backend, u := range usage {
+			exhausted := 0.0
+			if u.IsExhausted {
+				exhausted = 1
+			}
+			ch <- prometheus.MustNewConstMetric(requestsDesc, prometheus.GaugeValue, float64(u.Requests), backend)
+			ch <- prometheus.MustNewConstMetric(tokensDesc, prometheus.GaugeValue, float64(u.Tokens), backend)
+			ch <- prometheus.MustNewConstMetric(exhaustedDesc, prometheus.GaugeValue, exhausted, backend)
+		}
+	}
+
+	if tasks, err := c.source.Tasks(); err != nil {
+		audit.Warn("metrics.collect", "Failed to read tasks", map[string]interface{}{
+			"error": err.Error(),
+		})
+	} else {
+		counts := make(map[task.Status]int)
+		for _, t := range tasks {
+			counts[t.Status]++
+		}
+		// Every status is reported so that empty ones read as zero rather
+		// than disappearing
+		for _, status := range task.Statuses() {
+			ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(counts[status]), string(status))
+		}
+	}
+}
+
+// Handler returns an HTTP handler serving the source's metrics in the
+// Prometheus exposition format.
+func Handler(source Source) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewCollector(source))
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{})
+}
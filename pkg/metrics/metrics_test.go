@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/quota"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// staticSource is a Source with fixed state.
+type staticSource struct {
+	usage    map[string]*quota.Usage
+	tasks    []*task.Task
+	tasksErr error
+}
+
+func (s *staticSource) Usage() (map[string]*quota.Usage, error) { return s.usage, nil }
+func (s *staticSource) Tasks() ([]*task.Task, error)            { return s.tasks, s.tasksErr }
+
+func scrape(t *testing.T, source Source) string {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler(source))
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/metrics")
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	done := task.New("t-003", "Done")
+	done.Status = task.StatusComplete
+	source := &staticSource{
+		usage: map[string]*quota.Usage{
+			"claude":  {Backend: "claude", Requests: 12, Tokens: 3400, IsExhausted: true},
+			"copilot": {Backend: "copilot", Requests: 3, Tokens: 900},
+		},
+		tasks: []*task.Task{task.New("t-001", "One"), task.New("t-002", "Two"), done},
+	}
+
+	body := scrape(t, source)
+
+	for _, want := range []string{
+		`flo_backend_window_requests{backend="claude"} 12`,
+		`flo_backend_window_tokens{backend="copilot"} 900`,
+		`# TYPE flo_backend_window_requests gauge`,
+		`# TYPE flo_backend_window_tokens gauge`,
+		`flo_backend_exhausted{backend="claude"} 1`,
+		`flo_backend_exhausted{backend="copilot"} 0`,
+		`flo_tasks{status="pending"} 2`,
+		`flo_tasks{status="complete"} 1`,
+		`flo_tasks{status="failed"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics output:\n%s", want, body)
+		}
+	}
+}
+
+func TestMetricsRefreshOnScrape(t *testing.T) {
+	source := &staticSource{tasks: []*task.Task{task.New("t-001", "One")}}
+	handler := Handler(source)
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	source.tasks = append(source.tasks, task.New("t-002", "Two"))
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("scrape failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if !strings.Contains(string(body), `flo_tasks{status="pending"} 2`) {
+		t.Errorf("expected scrape to reflect current tasks:\n%s", body)
+	}
+}
+
+func TestMetricsSourceError(t *testing.T) {
+	source := &staticSource{
+		usage:    map[string]*quota.Usage{"claude": {Backend: "claude", Requests: 1}},
+		tasksErr: errors.New("manifest unreadable"),
+	}
+
+	body := scrape(t, source)
+
+	if !strings.Contains(body, `flo_backend_window_requests{backend="claude"} 1`) {
+		t.Errorf("expected quota metrics despite task error:\n%s", body)
+	}
+	if strings.Contains(body, "flo_tasks{") {
+		t.Errorf("expected no task metrics on error:\n%s", body)
+	}
+}
@@ -0,0 +1,96 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StatusSummary is a point-in-time view of workspace progress for chat.
+type StatusSummary struct {
+	Feature    string
+	Pending    int
+	InProgress int
+	Complete   int
+	Failed     int
+	Active     []SummaryTask // Tasks currently in progress
+	Recent     []SummaryTask // Recently completed tasks, newest first
+}
+
+// SummaryTask is a task line in a status summary.
+type SummaryTask struct {
+	ID       string
+	Title    string
+	Assignee string // Backend working the task, if known
+}
+
+// SlackMessage is a Slack incoming-webhook payload.
+type SlackMessage struct {
+	Text   string       `json:"text"` // Fallback for notifications
+	Blocks []SlackBlock `json:"blocks"`
+}
+
+// SlackBlock is a Block Kit layout block.
+type SlackBlock struct {
+	Type   string      `json:"type"`
+	Text   *SlackText  `json:"text,omitempty"`
+	Fields []SlackText `json:"fields,omitempty"`
+}
+
+// SlackText is a Block Kit text object.
+type SlackText struct {
+	Type string `json:"type"` // plain_text or mrkdwn
+	Text string `json:"text"`
+}
+
+// SlackStatus renders s as Slack blocks: a header, counts by status, the
+// in-progress tasks with their assignees, and recently completed tasks.
+func SlackStatus(s StatusSummary) SlackMessage {
+	title := "Task status"
+	if s.Feature != "" {
+		title = fmt.Sprintf("%s status", s.Feature)
+	}
+
+	blocks := []SlackBlock{
+		{Type: "header", Text: &SlackText{Type: "plain_text", Text: title}},
+		{Type: "section", Fields: []SlackText{
+			mrkdwn(fmt.Sprintf("*📋 Pending*\n%d", s.Pending)),
+			mrkdwn(fmt.Sprintf("*🔄 In Progress*\n%d", s.InProgress)),
+			mrkdwn(fmt.Sprintf("*✅ Complete*\n%d", s.Complete)),
+			mrkdwn(fmt.Sprintf("*❌ Failed*\n%d", s.Failed)),
+		}},
+		{Type: "divider"},
+		taskSection("In progress", s.Active),
+		taskSection("Recently completed", s.Recent),
+	}
+
+	return SlackMessage{
+		Text: fmt.Sprintf("%s: %d pending, %d in progress, %d complete, %d failed",
+			title, s.Pending, s.InProgress, s.Complete, s.Failed),
+		Blocks: blocks,
+	}
+}
+
+// taskSection renders a bulleted list of tasks under a bold heading.
+func taskSection(heading string, tasks []SummaryTask) SlackBlock {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%s*", heading)
+	if len(tasks) == 0 {
+		b.WriteString("\n_None_")
+	}
+	for _, t := range tasks {
+		fmt.Fprintf(&b, "\n• `%s` %s", escapeMrkdwn(t.ID), escapeMrkdwn(t.Title))
+		if t.Assignee != "" {
+			fmt.Fprintf(&b, " — _%s_", escapeMrkdwn(t.Assignee))
+		}
+	}
+	return SlackBlock{Type: "section", Text: &SlackText{Type: "mrkdwn", Text: b.String()}}
+}
+
+func mrkdwn(text string) SlackText {
+	return SlackText{Type: "mrkdwn", Text: text}
+}
+
+// escapeMrkdwn escapes the characters Slack treats as control sequences.
+func escapeMrkdwn(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
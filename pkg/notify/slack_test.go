@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSlackStatus(t *testing.T) {
+	msg := SlackStatus(StatusSummary{
+		Feature:    "checkout",
+		Pending:    3,
+		InProgress: 1,
+		Complete:   2,
+		Failed:     1,
+		Active: []SummaryTask{
+			{ID: "t-003", Title: "Add payment form", Assignee: "claude"},
+		},
+		Recent: []SummaryTask{
+			{ID: "t-002", Title: "Cart <totals> & tax"},
+			{ID: "t-001", Title: "Scaffold checkout"},
+		},
+	})
+
+	if len(msg.Blocks) != 5 {
+		t.Fatalf("expected 5 blocks, got %d", len(msg.Blocks))
+	}
+	if msg.Blocks[0].Type != "header" || msg.Blocks[0].Text.Text != "checkout status" {
+		t.Errorf("unexpected header: %+v", msg.Blocks[0])
+	}
+
+	counts := msg.Blocks[1].Fields
+	wantCounts := []string{"Pending*\n3", "In Progress*\n1", "Complete*\n2", "Failed*\n1"}
+	if len(counts) != len(wantCounts) {
+		t.Fatalf("expected %d count fields, got %d", len(wantCounts), len(counts))
+	}
+	for i, want := range wantCounts {
+		if counts[i].Type != "mrkdwn" || !strings.HasSuffix(counts[i].Text, want) {
+			t.Errorf("field %d: expected suffix %q, got %q", i, want, counts[i].Text)
+		}
+	}
+
+	active := msg.Blocks[3].Text.Text
+	if !strings.Contains(active, "`t-003` Add payment form — _claude_") {
+		t.Errorf("in-progress section missing task: %q", active)
+	}
+
+	recent := msg.Blocks[4].Text.Text
+	if !strings.Contains(recent, "Cart &lt;totals&gt; &amp; tax") {
+		t.Errorf("expected escaped title in recent section: %q", recent)
+	}
+	if strings.Index(recent, "t-002") > strings.Index(recent, "t-001") {
+		t.Errorf("expected recent tasks in given order: %q", recent)
+	}
+
+	if !strings.Contains(msg.Text, "3 pending, 1 in progress, 2 complete, 1 failed") {
+		t.Errorf("unexpected fallback text: %q", msg.Text)
+	}
+}
+
+func TestSlackStatusEmptySections(t *testing.T) {
+	msg := SlackStatus(StatusSummary{})
+
+	if msg.Blocks[0].Text.Text != "Task status" {
+		t.Errorf("expected default header, got %q", msg.Blocks[0].Text.Text)
+	}
+	for _, b := range msg.Blocks[3:] {
+		if !strings.Contains(b.Text.Text, "_None_") {
+			t.Errorf("expected empty section placeholder, got %q", b.Text.Text)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Package notify sends task lifecycle notifications to external services.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+)
+
+// DefaultTimeout bounds each webhook attempt when none is configured.
+const DefaultTimeout = 5 * time.Second
+
+// DefaultRetries is how many times a failed webhook POST is retried.
+const DefaultRetries = 2
+
+// DefaultDeadline bounds a webhook POST across all its attempts and the
+// waits between them, so a slow endpoint holds up the caller for at most
+// this long.
+const DefaultDeadline = 8 * time.Second
+
+// TaskEvent is the JSON payload POSTed when a task finishes.
+type TaskEvent struct {
+	Event    string    `json:"event"` // config.EventCompleted or config.EventFailed
+	TaskID   string    `json:"task_id"`
+	Title    string    `json:"title,omitempty"`
+	Status   string    `json:"status"`
+	Backend  string    `json:"backend,omitempty"`
+	Model    string    `json:"model,omitempty"`
+	Duration float64   `json:"duration_seconds"`
+	Tokens   int       `json:"tokens"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"timestamp"`
+}
+
+// Webhook POSTs task events to a configured URL.
+type Webhook struct {
+	config   config.WebhookConfig
+	client   *http.Client
+	retries  int
+	backoff  time.Duration // Delay before the first retry, doubled each time
+	deadline time.Duration // Bound on Post, including retries
+}
+
+// NewWebhook creates a webhook notifier from cfg.
+func NewWebhook(cfg config.WebhookConfig) *Webhook {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	return &Webhook{
+		config:   cfg,
+		client:   &http.Client{Timeout: timeout},
+		retries:  DefaultRetries,
+		backoff:  500 * time.Millisecond,
+		deadline: DefaultDeadline,
+	}
+}
+
+// Notify POSTs e if the webhook subscribes to its event. Events the webhook
+// does not subscribe to are skipped without error.
+func (w *Webhook) Notify(ctx context.Context, e TaskEvent) error {
+	if !w.config.Subscribed(e.Event) {
+		return nil
+	}
+	return w.Post(ctx, e)
+}
+
+// Post sends v as a JSON body, retrying network errors and 5xx responses
+// until DefaultDeadline has passed.
+func (w *Webhook) Post(ctx context.Context, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to serialize payload: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, w.deadline)
+	defer cancel()
+
+	backoff := w.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up retrying: %w (last error: %w)", ctx.Err(), err)
+		}
+		backoff *= 2
+	}
+}
+
+// Send notifies like Notify but only logs failures, so a broken webhook
+// never fails the task that triggered it.
+func (w *Webhook) Send(ctx context.Context, e TaskEvent) {
+	if err := w.Notify(ctx, e); err != nil {
+		audit.Warn("notify.webhook", "Webhook notification failed", map[string]interface{}{
+			"task_id": e.TaskID,
+			"event":   e.Event,
+			"error":   err.Error(),
+		})
+	}
+}
+
+// post makes one attempt, reporting whether a failure is worth retrying.
+func (w *Webhook) post(ctx context.Context, body []byte) (retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("webhook request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned %s", resp.Status)
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return false, nil
+}
@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/config"
+)
+
+func TestWebhookPostsPayload(t *testing.T) {
+	var got TaskEvent
+	var contentType string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&got)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(config.WebhookConfig{URL: server.URL})
+	err := webhook.Notify(context.Background(), TaskEvent{
+		Event:    config.EventCompleted,
+		TaskID:   "t-001",
+		Status:   "complete",
+		Backend:  "claude",
+		Duration: 42.5,
+		Tokens:   1200,
+	})
+	if err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("expected JSON content type, got %q", contentType)
+	}
+	if got.TaskID != "t-001" || got.Status != "complete" || got.Backend != "claude" {
+		t.Errorf("unexpected payload: %+v", got)
+	}
+	if got.Duration != 42.5 || got.Tokens != 1200 {
+		t.Errorf("expected duration 42.5 and 1200 tokens, got %+v", got)
+	}
+}
+
+func TestWebhookSkipsUnsubscribedEvents(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(config.WebhookConfig{URL: server.URL, Events: []string{config.EventFailed}})
+	if err := webhook.Notify(context.Background(), TaskEvent{Event: config.EventCompleted, TaskID: "t-001"}); err != nil {
+		t.Fatalf("Notify failed: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected no request for unsubscribed event, got %d", calls)
+	}
+}
+
+func TestWebhookServerErrorIsRetriedAndNonFatal(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(config.WebhookConfig{URL: server.URL})
+	webhook.backoff = time.Millisecond
+
+	event := TaskEvent{Event: config.EventFailed, TaskID: "t-001", Status: "failed"}
+	if err := webhook.Notify(context.Background(), event); err == nil {
+		t.Error("expected error from Notify on 500")
+	}
+	if calls != 1+DefaultRetries {
+		t.Errorf("expected %d attempts, got %d", 1+DefaultRetries, calls)
+	}
+
+	// Send swallows the failure
+	webhook.Send(context.Background(), event)
+}
+
+func TestWebhookClientErrorNotRetried(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(config.WebhookConfig{URL: server.URL})
+	webhook.backoff = time.Millisecond
+
+	if err := webhook.Notify(context.Background(), TaskEvent{Event: config.EventFailed, TaskID: "t-001"}); err == nil {
+		t.Error("expected error from Notify on 404")
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt, got %d", calls)
+	}
+}
+
+func TestWebhookRetriesStopAtDeadline(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(config.WebhookConfig{URL: server.URL})
+	webhook.backoff = time.Hour
+	webhook.deadline = 50 * time.Millisecond
+
+	start := time.Now()
+	err := webhook.Notify(context.Background(), TaskEvent{Event: config.EventFailed, TaskID: "t-001"})
+	if err == nil {
+		t.Fatal("expected error once the deadline passed")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected Notify to give up at the deadline, took %s", elapsed)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 attempt before the deadline, got %d", calls)
+	}
+}
@@ -0,0 +1,124 @@
+// Package output renders command results as human-readable text or JSON.
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Format is an output format selected with --output.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// ParseFormat validates a format name. An empty name means text.
+func ParseFormat(s string) (Format, error) {
+	switch Format(s) {
+	case "", FormatText:
+		return FormatText, nil
+	case FormatJSON:
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid output format '%s' (expected text or json)", s)
+	}
+}
+
+// Symbols are the status markers text output uses.
+type Symbols struct {
+	OK         string
+	Exhausted  string
+	Pending    string
+	InProgress string
+	Complete   string
+	Failed     string
+}
+
+// PrettySymbols are the emoji markers used on capable terminals.
+var PrettySymbols = Symbols{
+	OK:         "✓",
+	Exhausted:  "✗",
+	Pending:    "📋",
+	InProgress: "🔄",
+	Complete:   "✅",
+	Failed:     "❌",
+}
+
+// ASCIISymbols are plain markers for CI logs and non-UTF-8 terminals.
+var ASCIISymbols = Symbols{
+	OK:         "[ok]",
+	Exhausted:  "[x]",
+	Pending:    "[ ]",
+	InProgress: "[~]",
+	Complete:   "[+]",
+	Failed:     "[!]",
+}
+
+// WantASCII reports whether text output should be plain ASCII: when asked
+// for with a flag, when NO_COLOR is set to anything (see no-color.org), or
+// when TERM is "dumb". getenv is usually os.Getenv.
+func WantASCII(flag bool, getenv func(string) string) bool {
+	return flag || getenv("NO_COLOR") != "" || getenv("TERM") == "dumb"
+}
+
+// Printer writes command results in the selected format.
+type Printer struct {
+	format Format
+	w      io.Writer
+	errW   io.Writer
+	ascii  bool
+}
+
+// New creates a printer writing results to w.
+// Progress output goes to w in text mode and to stderr in JSON mode,
+// so that w only ever carries a single JSON document.
+func New(format Format, w io.Writer) *Printer {
+	return &Printer{
+		format: format,
+		w:      w,
+		errW:   os.Stderr,
+	}
+}
+
+// SetASCII switches text output to plain ASCII markers.
+func (p *Printer) SetASCII(ascii bool) {
+	p.ascii = ascii
+}
+
+// Symbols returns the status markers for text output.
+func (p *Printer) Symbols() Symbols {
+	if p.ascii {
+		return ASCIISymbols
+	}
+	return PrettySymbols
+}
+
+// JSON returns true if results should be emitted as JSON.
+func (p *Printer) JSON() bool {
+	return p.format == FormatJSON
+}
+
+// Print emits v as indented JSON in JSON mode, or calls text otherwise.
+func (p *Printer) Print(v any, text func(w io.Writer) error) error {
+	if p.JSON() {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to serialize output: %w", err)
+		}
+		_, err = fmt.Fprintln(p.w, string(data))
+		return err
+	}
+	return text(p.w)
+}
+
+// Progress returns the writer for incidental progress output.
+func (p *Printer) Progress() io.Writer {
+	if p.JSON() {
+		return p.errW
+	}
+	return p.w
+}
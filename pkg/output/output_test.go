@@ -0,0 +1,118 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestParseFormat(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    Format
+		wantErr bool
+	}{
+		{"", FormatText, false},
+		{"text", FormatText, false},
+		{"json", FormatJSON, false},
+		{"yaml", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseFormat(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseFormat(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFormat(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinterJSON(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(FormatJSON, &buf)
+
+	value := map[string]any{"feature": "auth", "total_tasks": 3}
+	textCalled := false
+	err := p.Print(value, func(w io.Writer) error {
+		textCalled = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if textCalled {
+		t.Error("text renderer should not be called in JSON mode")
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if got["feature"] != "auth" || got["total_tasks"] != float64(3) {
+		t.Errorf("unexpected JSON: %v", got)
+	}
+	if p.Progress() == &buf {
+		t.Error("progress should not share the JSON writer")
+	}
+}
+
+func TestPrinterText(t *testing.T) {
+	var buf bytes.Buffer
+	p := New(FormatText, &buf)
+
+	err := p.Print(map[string]any{"ignored": true}, func(w io.Writer) error {
+		_, err := fmt.Fprintln(w, "Feature: auth")
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Print failed: %v", err)
+	}
+	if buf.String() != "Feature: auth\n" {
+		t.Errorf("unexpected text output: %q", buf.String())
+	}
+	if p.Progress() != &buf {
+		t.Error("progress should go to the main writer in text mode")
+	}
+}
+
+func TestWantASCII(t *testing.T) {
+	tests := []struct {
+		name string
+		flag bool
+		env  map[string]string
+		want bool
+	}{
+		{"default", false, nil, false},
+		{"flag", true, nil, true},
+		{"NO_COLOR", false, map[string]string{"NO_COLOR": "1"}, true},
+		{"empty NO_COLOR", false, map[string]string{"NO_COLOR": ""}, false},
+		{"dumb terminal", false, map[string]string{"TERM": "dumb"}, true},
+		{"xterm", false, map[string]string{"TERM": "xterm-256color"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(key string) string { return tt.env[key] }
+			if got := WantASCII(tt.flag, getenv); got != tt.want {
+				t.Errorf("WantASCII(%v) = %v, want %v", tt.flag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinterSymbols(t *testing.T) {
+	p := New(FormatText, io.Discard)
+	if p.Symbols() != PrettySymbols {
+		t.Error("expected pretty symbols by default")
+	}
+	p.SetASCII(true)
+	if p.Symbols() != ASCIISymbols {
+		t.Error("expected ASCII symbols after SetASCII")
+	}
+}
@@ -0,0 +1,97 @@
+package quota
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable writes usage as the 'flo quota' table, showing each backend's
+// status as ok or exhausted, such as "OK" and "EXHAUSTED". Pools have rows
+// of their own, named by PoolKey, and each backend in a pool names it.
+// Times are shown relative to now.
+func WriteTable(w io.Writer, usage []*Usage, ok, exhausted string, now time.Time) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+
+	fmt.Fprintln(tw, "BACKEND\tPOOL\tREQUESTS\tTOKENS\tSTATUS\tLAST REQUEST\tWINDOW")
+	fmt.Fprintln(tw, "-------\t----\t--------\t------\t------\t------------\t------")
+
+	for _, u := range usage {
+		status := ok
+		if u.IsExhausted {
+			status = fmt.Sprintf("%s (retry after %s)",
+				exhausted, formatDuration(u.RetryAfter.Sub(now)))
+		}
+
+		lastReq := "never"
+		if !u.LastRequest.IsZero() {
+			lastReq = formatRelativeTime(now.Sub(u.LastRequest))
+		}
+
+		pool := u.Pool
+		if pool == "" {
+			pool = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n",
+			u.Backend,
+			pool,
+			u.Requests,
+			u.Tokens,
+			status,
+			lastReq,
+			formatDuration(now.Sub(u.WindowStart)),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// formatRelativeTime describes how long ago something happened, given the
+// time since.
+func formatRelativeTime(dur time.Duration) string {
+	if dur < time.Minute {
+		return "just now"
+	}
+	if dur < time.Hour {
+		mins := int(dur.Minutes())
+		if mins == 1 {
+			return "1 minute ago"
+		}
+		return fmt.Sprintf("%d minutes ago", mins)
+	}
+	if dur < 24*time.Hour {
+		hours := int(dur.Hours())
+		if hours == 1 {
+			return "1 hour ago"
+		}
+		return fmt.Sprintf("%d hours ago", hours)
+	}
+
+	days := int(dur.Hours() / 24)
+	if days == 1 {
+		return "1 day ago"
+	}
+	return fmt.Sprintf("%d days ago", days)
+}
+
+// formatDuration shortens d to its largest unit, e.g. "45s" or "1.5h".
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		return "expired"
+	}
+
+	if d < time.Minute {
+		return fmt.Sprintf("%.0fs", d.Seconds())
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%.0fm", d.Minutes())
+	}
+	if d < 24*time.Hour {
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+
+	days := d.Hours() / 24
+	return fmt.Sprintf("%.1fd", days)
+}
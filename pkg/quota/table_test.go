@@ -0,0 +1,49 @@
+package quota
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteTable(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	usage := []*Usage{
+		{Backend: "claude", Requests: 50, Tokens: 120000, LastRequest: now.Add(-5 * time.Minute), WindowStart: now.Add(-30 * time.Minute), IsExhausted: true, RetryAfter: now.Add(30 * time.Minute)},
+		{Backend: "copilot", Requests: 3, Tokens: 900, LastRequest: now.Add(-2 * time.Hour), WindowStart: now.Add(-3 * time.Hour), Pool: "premium"},
+		{Backend: PoolKey("premium"), Requests: 3, Tokens: 900, LastRequest: now.Add(-2 * time.Hour), WindowStart: now.Add(-3 * time.Hour)},
+	}
+
+	t.Run("ascii", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteTable(&buf, usage, "[ok] OK", "[x] EXHAUSTED", now); err != nil {
+			t.Fatal(err)
+		}
+		got := buf.String()
+
+		for i, r := range got {
+			if r > 0x7e && r != '\n' {
+				t.Fatalf("non-ASCII rune %q at %d in:\n%s", r, i, got)
+			}
+		}
+		if strings.Contains(got, "\x1b[") {
+			t.Errorf("unexpected ANSI escape in:\n%s", got)
+		}
+		for _, want := range []string{"[x] EXHAUSTED (retry after 30m)", "[ok] OK", "5 minutes ago", "2 hours ago", "premium", "pool:premium"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("expected %q in:\n%s", want, got)
+			}
+		}
+	})
+
+	t.Run("pretty", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteTable(&buf, usage, "✓ OK", "✗ EXHAUSTED", now); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(buf.String(), "✓ OK") {
+			t.Errorf("expected pretty markers in:\n%s", buf.String())
+		}
+	})
+}
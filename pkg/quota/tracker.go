@@ -2,44 +2,82 @@
 package quota
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
+
+	"github.com/richgo/flo/pkg/fileutil"
+	"github.com/richgo/flo/pkg/logging"
 )
 
 // Usage tracks usage metrics for a backend.
 type Usage struct {
-	Backend      string    `json:"backend"`
-	Requests     int       `json:"requests"`
-	Tokens       int       `json:"tokens"`
-	LastRequest  time.Time `json:"last_request"`
-	WindowStart  time.Time `json:"window_start"`
-	IsExhausted  bool      `json:"is_exhausted"`
-	RetryAfter   time.Time `json:"retry_after,omitempty"`
+	Backend     string    `json:"backend"`
+	Requests    int       `json:"requests"`
+	Tokens      int       `json:"tokens"`
+	LastRequest time.Time `json:"last_request"`
+	WindowStart time.Time `json:"window_start"`
+	IsExhausted bool      `json:"is_exhausted"`
+	RetryAfter  time.Time `json:"retry_after,omitempty"`
+	Pool        string    `json:"pool,omitempty"` // Pool the key shares limits with, as configured; set only on ListUsage copies, so never saved
 }
 
 // Tracker manages quota tracking for multiple backends.
 type Tracker struct {
-	mu      sync.RWMutex
-	usage   map[string]*Usage
-	path    string
-	limits  map[string]int // Backend -> requests per window
-	window  time.Duration  // Time window for limits
+	mu          sync.RWMutex
+	usage       map[string]*Usage
+	path        string
+	limits      map[string]int    // Backend -> requests per window
+	tokenLimits map[string]int    // Backend -> tokens per window
+	window      time.Duration     // Time window for limits
+	cost        CostFunc          // Optional cost estimate for exports
+	pools       map[string]string // Key -> pool whose aggregate limits it also counts against
 }
 
+// CostFunc estimates the USD cost of tokens used against a quota key
+// ("backend" or "backend/model"), reporting false when it has no price.
+type CostFunc func(key string, tokens int) (float64, bool)
+
+// DefaultWindow is the quota window used until SetWindow is called.
+const DefaultWindow = time.Hour
+
 // New creates a new quota tracker.
 func New(dataPath string) *Tracker {
 	return &Tracker{
-		usage:  make(map[string]*Usage),
-		path:   dataPath,
-		limits: make(map[string]int),
-		window: time.Hour, // Default 1 hour window
+		usage:       make(map[string]*Usage),
+		path:        dataPath,
+		limits:      make(map[string]int),
+		tokenLimits: make(map[string]int),
+		window:      DefaultWindow,
+		pools:       make(map[string]string),
 	}
 }
 
+// poolKeyPrefix marks a pool's usage among the per-key usage.
+const poolKeyPrefix = "pool:"
+
+// PoolKey returns the key a pool's aggregate usage is tracked under. Pool
+// limits are set on it with SetLimit and SetTokenLimit.
+func PoolKey(pool string) string {
+	return poolKeyPrefix + pool
+}
+
+// SetPool adds key to pool, so that its usage also counts against the
+// pool's limits and it is exhausted whenever the pool is. Assign a backend
+// or its models to a pool, not both, or a run is counted twice.
+func (t *Tracker) SetPool(key, pool string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pools[key] = pool
+}
+
 // SetLimit sets the request limit for a backend.
 func (t *Tracker) SetLimit(backend string, requests int) {
 	t.mu.Lock()
@@ -47,6 +85,13 @@ func (t *Tracker) SetLimit(backend string, requests int) {
 	t.limits[backend] = requests
 }
 
+// SetTokenLimit sets the token limit for a backend.
+func (t *Tracker) SetTokenLimit(backend string, tokens int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.tokenLimits[backend] = tokens
+}
+
 // SetWindow sets the time window for quota tracking.
 func (t *Tracker) SetWindow(d time.Duration) {
 	t.mu.Lock()
@@ -54,13 +99,30 @@ func (t *Tracker) SetWindow(d time.Duration) {
 	t.window = d
 }
 
-// Record records a request and token usage for a backend.
+// SetCostFunc sets how ExportCSV estimates each key's cost.
+func (t *Tracker) SetCostFunc(fn CostFunc) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.cost = fn
+}
+
+// Record records a request and token usage for a backend, and for its
+// pool if it has one.
 func (t *Tracker) Record(backend string, tokens int) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
 	now := time.Now()
-	
+	t.recordLocked(backend, tokens, now)
+	if pool, ok := t.pools[backend]; ok {
+		t.recordLocked(PoolKey(pool), tokens, now)
+	}
+
+	return t.save()
+}
+
+// recordLocked counts a request against key's usage.
+func (t *Tracker) recordLocked(backend string, tokens int, now time.Time) {
 	usage, ok := t.usage[backend]
 	if !ok {
 		usage = &Usage{
@@ -83,14 +145,12 @@ func (t *Tracker) Record(backend string, tokens int) error {
 	usage.LastRequest = now
 
 	// Check if exhausted
-	if limit, ok := t.limits[backend]; ok {
-		if usage.Requests >= limit {
-			usage.IsExhausted = true
-			usage.RetryAfter = usage.WindowStart.Add(t.window)
-		}
+	limit, hasLimit := t.limits[backend]
+	tokenLimit, hasTokenLimit := t.tokenLimits[backend]
+	if (hasLimit && usage.Requests >= limit) || (hasTokenLimit && usage.Tokens >= tokenLimit) {
+		usage.IsExhausted = true
+		usage.RetryAfter = usage.WindowStart.Add(t.window)
 	}
-
-	return t.save()
 }
 
 // RecordError records a rate limit error for a backend.
@@ -134,8 +194,27 @@ func (t *Tracker) GetUsage(backend string) (*Usage, bool) {
 	return &copy, true
 }
 
-// IsExhausted returns true if the backend has exhausted its quota.
+// IsExhausted returns true if the backend, or the pool it belongs to, has
+// exhausted its quota.
 func (t *Tracker) IsExhausted(backend string) bool {
+	if t.isExhausted(backend) {
+		return true
+	}
+	pool, ok := t.poolOf(backend)
+	return ok && t.isExhausted(PoolKey(pool))
+}
+
+// poolOf returns the pool key belongs to.
+func (t *Tracker) poolOf(key string) (string, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	pool, ok := t.pools[key]
+	return pool, ok
+}
+
+// isExhausted reports whether key's own usage is exhausted, clearing it
+// once its retry time has passed.
+func (t *Tracker) isExhausted(backend string) bool {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
@@ -162,7 +241,29 @@ func (t *Tracker) IsExhausted(backend string) bool {
 	return usage.IsExhausted
 }
 
-// ListUsage returns usage for all backends.
+// RetryAfter returns when an exhausted backend's quota reopens, which is
+// the later of its own and its pool's. It reports false when neither is
+// exhausted or the time is unknown.
+func (t *Tracker) RetryAfter(backend string) (time.Time, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	keys := []string{backend}
+	if pool, ok := t.pools[backend]; ok {
+		keys = append(keys, PoolKey(pool))
+	}
+	var at time.Time
+	for _, key := range keys {
+		usage, ok := t.usage[key]
+		if ok && usage.IsExhausted && usage.RetryAfter.After(at) {
+			at = usage.RetryAfter
+		}
+	}
+	return at, !at.IsZero()
+}
+
+// ListUsage returns usage for all backends and pools, with each backend's
+// Pool filled in.
 func (t *Tracker) ListUsage() map[string]*Usage {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
@@ -170,11 +271,52 @@ func (t *Tracker) ListUsage() map[string]*Usage {
 	result := make(map[string]*Usage)
 	for k, v := range t.usage {
 		copy := *v
+		copy.Pool = t.pools[k]
 		result[k] = &copy
 	}
 	return result
 }
 
+// ExportCSV writes usage as CSV with a header row and one row per quota
+// key, sorted by key. The estimated cost is left empty for keys the cost
+// func cannot price, and window starts are RFC 3339 in UTC.
+func (t *Tracker) ExportCSV(w io.Writer) error {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	keys := make([]string, 0, len(t.usage))
+	for key := range t.usage {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true // RFC 4180 line endings
+	cw.Write([]string{"backend", "requests", "tokens", "estimated_cost_usd", "window_start", "exhausted"})
+	for _, key := range keys {
+		usage := t.usage[key]
+		cost := ""
+		if t.cost != nil {
+			if usd, ok := t.cost(key, usage.Tokens); ok {
+				cost = strconv.FormatFloat(usd, 'f', 4, 64)
+			}
+		}
+		cw.Write([]string{
+			key,
+			strconv.Itoa(usage.Requests),
+			strconv.Itoa(usage.Tokens),
+			cost,
+			usage.WindowStart.UTC().Format(time.RFC3339),
+			strconv.FormatBool(usage.IsExhausted),
+		})
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to write usage CSV: %w", err)
+	}
+	return nil
+}
+
 // Reset clears usage for a backend.
 func (t *Tracker) Reset(backend string) error {
 	t.mu.Lock()
@@ -196,7 +338,14 @@ func (t *Tracker) ResetAll() error {
 	return t.save()
 }
 
-// Load loads usage data from disk.
+// corruptSuffix is appended to a quota file that Load cannot parse when it
+// is moved aside.
+const corruptSuffix = ".corrupt"
+
+// Load loads usage data from disk. A file that is not valid JSON, such as
+// one truncated by a crash, is moved aside to quota.json.corrupt and usage
+// starts fresh, since losing the counts is better than blocking every run.
+// An empty file also starts fresh but is left in place.
 func (t *Tracker) Load() error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -208,17 +357,32 @@ func (t *Tracker) Load() error {
 		}
 		return fmt.Errorf("failed to read quota file: %w", err)
 	}
+	if len(data) == 0 {
+		t.usage = make(map[string]*Usage)
+		return nil
+	}
 
 	var usage map[string]*Usage
 	if err := json.Unmarshal(data, &usage); err != nil {
-		return fmt.Errorf("failed to parse quota file: %w", err)
+		backup := t.path + corruptSuffix
+		if rerr := os.Rename(t.path, backup); rerr != nil {
+			return fmt.Errorf("failed to parse quota file: %w (and could not move it aside: %v)", err, rerr)
+		}
+		logging.L().Warn("quota file is corrupt, starting with empty usage",
+			"path", t.path, "backup", backup, "error", err.Error())
+		usage = nil
+	}
+	if usage == nil {
+		usage = make(map[string]*Usage)
 	}
 
 	t.usage = usage
 	return nil
 }
 
-// save persists usage data to disk (must be called with lock held).
+// save persists usage data to disk (must be called with lock held). The
+// file is replaced atomically so concurrent readers never see it partly
+// written.
 func (t *Tracker) save() error {
 	// Create directory if needed
 	dir := filepath.Dir(t.path)
@@ -231,7 +395,7 @@ func (t *Tracker) save() error {
 		return fmt.Errorf("failed to serialize usage: %w", err)
 	}
 
-	if err := os.WriteFile(t.path, data, 0644); err != nil {
+	if err := fileutil.WriteFileAtomic(t.path, data, 0644); err != nil {
 		return fmt.Errorf("failed to write quota file: %w", err)
 	}
 
@@ -1,8 +1,10 @@
 package quota
 
 import (
+	"encoding/csv"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -93,6 +95,21 @@ func TestIsExhausted(t *testing.T) {
 	}
 }
 
+func TestTokenLimitExhausts(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetTokenLimit("claude", 1000)
+
+	tracker.Record("claude", 600)
+	if tracker.IsExhausted("claude") {
+		t.Error("Should not be exhausted at 600/1000 tokens")
+	}
+
+	tracker.Record("claude", 400)
+	if !tracker.IsExhausted("claude") {
+		t.Error("Should be exhausted at 1000/1000 tokens")
+	}
+}
+
 func TestRecordError(t *testing.T) {
 	tmpDir := t.TempDir()
 	path := filepath.Join(tmpDir, "quota.json")
@@ -270,15 +287,176 @@ func TestLoadNonExistentFile(t *testing.T) {
 	}
 }
 
-func TestLoadInvalidJSON(t *testing.T) {
-	tmpDir := t.TempDir()
-	path := filepath.Join(tmpDir, "invalid.json")
-	
-	// Write invalid JSON
-	os.WriteFile(path, []byte("not json"), 0644)
-	
+func TestLoadCorruptFile(t *testing.T) {
+	for name, content := range map[string]string{
+		"invalid":   "not json",
+		"truncated": `{"claude": {"backend": "claude", "requests": 3, "tok`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "quota.json")
+			os.WriteFile(path, []byte(content), 0644)
+
+			tracker := New(path)
+			if err := tracker.Load(); err != nil {
+				t.Fatalf("Load should recover from a corrupt file: %v", err)
+			}
+			if len(tracker.ListUsage()) != 0 {
+				t.Errorf("expected empty usage after recovery, got %v", tracker.ListUsage())
+			}
+
+			backup, err := os.ReadFile(path + ".corrupt")
+			if err != nil {
+				t.Fatalf("expected the corrupt file to be moved aside: %v", err)
+			}
+			if string(backup) != content {
+				t.Errorf("expected backup to hold the original content, got %q", backup)
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("expected quota file to be moved, stat err = %v", err)
+			}
+
+			// Usage recorded after recovery is saved normally
+			if err := tracker.Record("claude", 100); err != nil {
+				t.Fatalf("Record failed: %v", err)
+			}
+			reloaded := New(path)
+			if err := reloaded.Load(); err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if usage, ok := reloaded.GetUsage("claude"); !ok || usage.Tokens != 100 {
+				t.Errorf("expected 100 tokens after recovery, got %+v", usage)
+			}
+		})
+	}
+}
+
+func TestLoadEmptyFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	os.WriteFile(path, nil, 0644)
+
+	tracker := New(path)
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("Load should treat an empty file as fresh: %v", err)
+	}
+	if len(tracker.ListUsage()) != 0 {
+		t.Errorf("expected empty usage, got %v", tracker.ListUsage())
+	}
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup for an empty file, stat err = %v", err)
+	}
+}
+
+func TestLoadValidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "quota.json")
+	os.WriteFile(path, []byte(`{"claude": {"backend": "claude", "requests": 3, "tokens": 1200}}`), 0644)
+
 	tracker := New(path)
-	if err := tracker.Load(); err == nil {
-		t.Error("Load should fail for invalid JSON")
+	if err := tracker.Load(); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	usage, ok := tracker.GetUsage("claude")
+	if !ok || usage.Requests != 3 || usage.Tokens != 1200 {
+		t.Errorf("expected 3 requests and 1200 tokens, got %+v", usage)
+	}
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup for a valid file, stat err = %v", err)
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.Record("claude/opus", 1500)
+	tracker.Record("claude/opus", 500)
+	tracker.Record("copilot", 300)
+	tracker.RecordError("copilot", time.Minute)
+	tracker.SetCostFunc(func(key string, tokens int) (float64, bool) {
+		if key != "claude/opus" {
+			return 0, false
+		}
+		return float64(tokens) * 0.00005, true
+	})
+
+	var b strings.Builder
+	if err := tracker.ExportCSV(&b); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	if !strings.HasSuffix(b.String(), "\r\n") {
+		t.Errorf("expected CRLF line endings, got %q", b.String())
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(b.String())).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header and 2 rows, got %q", rows)
+	}
+	if got := strings.Join(rows[0], ","); got != "backend,requests,tokens,estimated_cost_usd,window_start,exhausted" {
+		t.Errorf("unexpected header: %s", got)
+	}
+
+	usage, _ := tracker.GetUsage("claude/opus")
+	want := []string{"claude/opus", "2", "2000", "0.1000", usage.WindowStart.UTC().Format(time.RFC3339), "false"}
+	if strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected row:\n got %q\nwant %q", rows[1], want)
+	}
+	if rows[2][0] != "copilot" || rows[2][3] != "" || rows[2][5] != "true" {
+		t.Errorf("expected unpriced, exhausted copilot row, got %q", rows[2])
+	}
+}
+
+func TestPoolExhaustsJointly(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetLimit("claude/opus", 3)
+	tracker.SetLimit("copilot/gpt-5", 3)
+	tracker.SetPool("claude/opus", "premium")
+	tracker.SetPool("copilot/gpt-5", "premium")
+	tracker.SetLimit(PoolKey("premium"), 4)
+
+	tracker.Record("claude/opus", 100)
+	tracker.Record("claude/opus", 100)
+	tracker.Record("copilot/gpt-5", 100)
+	if tracker.IsExhausted("claude/opus") || tracker.IsExhausted("copilot/gpt-5") {
+		t.Fatal("expected the pool to have room after 3 of 4 requests")
+	}
+
+	tracker.Record("copilot/gpt-5", 100)
+	for _, key := range []string{"claude/opus", "copilot/gpt-5"} {
+		if usage, _ := tracker.GetUsage(key); usage.IsExhausted {
+			t.Errorf("expected %s to be under its own limit, got %+v", key, usage)
+		}
+		if !tracker.IsExhausted(key) {
+			t.Errorf("expected %s to be exhausted by its pool", key)
+		}
+		if _, ok := tracker.RetryAfter(key); !ok {
+			t.Errorf("expected a retry time for %s from its pool", key)
+		}
+	}
+	if tracker.IsExhausted("claude/sonnet") {
+		t.Error("expected a model outside the pool to be unaffected")
+	}
+
+	usage := tracker.ListUsage()
+	if pool := usage[PoolKey("premium")]; pool == nil || pool.Requests != 4 || pool.Tokens != 400 {
+		t.Errorf("expected pool usage of 4 requests and 400 tokens, got %+v", pool)
+	}
+	if usage["claude/opus"].Pool != "premium" {
+		t.Errorf("expected claude/opus to list its pool, got %q", usage["claude/opus"].Pool)
+	}
+}
+
+func TestPoolTokenLimit(t *testing.T) {
+	tracker := New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.SetPool("claude", "shared")
+	tracker.SetPool("codex", "shared")
+	tracker.SetTokenLimit(PoolKey("shared"), 1000)
+
+	tracker.Record("claude", 600)
+	if tracker.IsExhausted("codex") {
+		t.Fatal("expected the pool to have tokens left")
+	}
+	tracker.Record("codex", 500)
+	if !tracker.IsExhausted("claude") || !tracker.IsExhausted("codex") {
+		t.Error("expected both pool members to be exhausted once the pool's tokens ran out")
 	}
 }
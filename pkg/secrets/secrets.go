@@ -4,6 +4,7 @@ package secrets
 import (
 	"bufio"
 	"fmt"
+	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
@@ -116,6 +117,44 @@ func Mask(value string) string {
 	return value[:4] + "****" + value[len(value)-4:]
 }
 
+// secretKeyMarkers are substrings of variable names that usually hold secrets.
+var secretKeyMarkers = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "AUTH"}
+
+// IsSecretKey reports whether an environment variable name looks like it
+// holds a secret, e.g. GITHUB_TOKEN or DB_PASSWORD.
+func IsSecretKey(key string) bool {
+	upper := strings.ToUpper(key)
+	for _, marker := range secretKeyMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactEnv returns a copy of env that is safe to log. Values of secret-looking
+// keys are masked, and passwords embedded in URLs are replaced.
+func RedactEnv(env map[string]string) map[string]string {
+	if env == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(env))
+	for key, value := range env {
+		switch {
+		case IsSecretKey(key):
+			value = Mask(value)
+		case strings.Contains(value, "://"):
+			if u, err := url.Parse(value); err == nil && u.User != nil {
+				if _, hasPassword := u.User.Password(); hasPassword {
+					value = u.Redacted()
+				}
+			}
+		}
+		redacted[key] = value
+	}
+	return redacted
+}
+
 // LoadDefault loads .env from the current directory or specified locations.
 func LoadDefault() (*Manager, error) {
 	m := NewManager()
@@ -138,6 +177,7 @@ func LoadDefault() (*Manager, error) {
 var WellKnownKeys = []string{
 	"CLAUDE_API_KEY",
 	"COPILOT_TOKEN",
+	"GITHUB_TOKEN",
 	"FLO_BACKEND",
 	"FLO_MODEL",
 }
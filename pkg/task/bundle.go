@@ -0,0 +1,136 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// ParseTaskBundle reads several tasks from one markdown file. Each task is a
+// frontmatter block between "---" lines followed by its body, which runs
+// until the next block's opening "---"; bodies therefore cannot contain a
+// bare "---" line. Every block needs an id.
+//
+// Tasks are returned with deps on other tasks in the bundle ordered before
+// their dependents, so they can be added one by one. Deps on tasks outside
+// the bundle are kept as is. Failures are *ParseErrors naming the block.
+func ParseTaskBundle(path string) ([]*Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read task bundle: %w", err)
+	}
+
+	lines := strings.Split(string(data), "\n")
+	var fences []int // Indexes of the "---" lines
+	for i, line := range lines {
+		if strings.TrimRight(line, "\r") == "---" {
+			fences = append(fences, i)
+		}
+	}
+
+	// Anything before the first block must be blank
+	first := len(lines)
+	if len(fences) > 0 {
+		first = fences[0]
+	}
+	if strings.TrimSpace(strings.Join(lines[:first], "\n")) != "" {
+		return nil, &ParseError{Path: path, Line: 1, Block: 1, Err: ErrMissingFrontmatter}
+	}
+	if len(fences) == 0 {
+		return nil, &ParseError{Path: path, Line: 1, Err: fmt.Errorf("%w: no task blocks", ErrMissingFrontmatter)}
+	}
+
+	now := time.Now()
+	var tasks []*Task
+	blocks := make(map[string]int) // Task ID to block number
+	for i := 0; i < len(fences); i += 2 {
+		block := i/2 + 1
+		open := fences[i] + 1
+		if i+1 == len(fences) {
+			return nil, &ParseError{Path: path, Line: open, Block: block, Err: fmt.Errorf("%w: no closing '---'", ErrInvalidFrontmatter)}
+		}
+
+		end := len(lines)
+		if i+2 < len(fences) {
+			end = fences[i+2]
+		}
+		frontmatter := strings.Join(lines[fences[i]+1:fences[i+1]], "\n")
+		body := strings.Join(lines[fences[i+1]+1:end], "\n")
+
+		t, err := parseSection(path, open, frontmatter, body)
+		if err != nil {
+			var pe *ParseError
+			if errors.As(err, &pe) {
+				pe.Block = block
+				if pe.Line == 0 {
+					pe.Line = open
+				}
+			}
+			return nil, err
+		}
+		if t.ID == "" {
+			return nil, &ParseError{Path: path, Line: open, Block: block, Err: ErrMissingID}
+		}
+		if prev, dup := blocks[t.ID]; dup {
+			return nil, &ParseError{Path: path, Line: open, Block: block, Err: fmt.Errorf("duplicate id '%s' (also task %d)", t.ID, prev)}
+		}
+		if err := t.Validate(); err != nil {
+			return nil, &ParseError{Path: path, Line: open, Block: block, Err: err}
+		}
+
+		if t.Status == "" {
+			t.Status = StatusPending
+		}
+		if t.CreatedAt.IsZero() {
+			t.CreatedAt = now
+		}
+		if t.UpdatedAt.IsZero() {
+			t.UpdatedAt = now
+		}
+		blocks[t.ID] = block
+		tasks = append(tasks, t)
+	}
+
+	return orderByDeps(path, tasks, blocks)
+}
+
+// orderByDeps sorts tasks so each comes after the bundle tasks it depends
+// on, optionally or not, keeping file order otherwise. It fails on a dependency cycle.
+func orderByDeps(path string, tasks []*Task, blocks map[string]int) ([]*Task, error) {
+	byID := make(map[string]*Task, len(tasks))
+	for _, t := range tasks {
+		byID[t.ID] = t
+	}
+
+	ordered := make([]*Task, 0, len(tasks))
+	state := make(map[string]int) // 1 visiting, 2 done
+	var visit func(t *Task) error
+	visit = func(t *Task) error {
+		switch state[t.ID] {
+		case 1:
+			return &ParseError{Path: path, Block: blocks[t.ID], Err: fmt.Errorf("circular dependency involving '%s'", t.ID)}
+		case 2:
+			return nil
+		}
+		state[t.ID] = 1
+		for _, depID := range t.AllDeps() {
+			if dep, ok := byID[depID]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		state[t.ID] = 2
+		ordered = append(ordered, t)
+		return nil
+	}
+
+	for _, t := range tasks {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
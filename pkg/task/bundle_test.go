@@ -0,0 +1,181 @@
+package task
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeBundle(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "plan.md")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseTaskBundle(t *testing.T) {
+	path := writeBundle(t, `---
+id: t-003
+deps: [t-002]
+type: test
+---
+# Test the migration
+
+Run it against a copy of production.
+
+---
+id: t-001
+priority: 1
+---
+# Add schema
+
+---
+id: t-002
+deps: [t-001, x-100]
+related: [t-003]
+---
+# Migrate data
+`)
+
+	tasks, err := ParseTaskBundle(path)
+	if err != nil {
+		t.Fatalf("ParseTaskBundle failed: %v", err)
+	}
+
+	var ids []string
+	for _, tk := range tasks {
+		ids = append(ids, tk.ID)
+	}
+	// Deps within the bundle come first; x-100 is outside it and ignored
+	if got := strings.Join(ids, " "); got != "t-001 t-002 t-003" {
+		t.Fatalf("expected t-001 t-002 t-003, got %s", got)
+	}
+
+	first, second, third := tasks[0], tasks[1], tasks[2]
+	if first.Title != "Add schema" || first.Priority != 1 || first.Status != StatusPending || first.CreatedAt.IsZero() {
+		t.Errorf("unexpected first task: %+v", first)
+	}
+	if len(second.Deps) != 2 || len(second.Related) != 1 {
+		t.Errorf("expected deps and related on t-002, got %v / %v", second.Deps, second.Related)
+	}
+	if third.Title != "Test the migration" || third.Description != "Run it against a copy of production." || third.Type != "test" {
+		t.Errorf("unexpected third task: %+v", third)
+	}
+}
+
+func TestParseTaskBundleErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		wantKind  error
+		wantBlock int
+		wantLine  int
+	}{
+		{
+			name: "malformed middle block",
+			content: `---
+id: t-001
+---
+# First
+
+---
+id: t-002
+status: pending
+  model: claude
+---
+# Second
+
+---
+id: t-003
+---
+# Third
+`,
+			wantKind:  ErrInvalidFrontmatter,
+			wantBlock: 2,
+			wantLine:  9,
+		},
+		{
+			name:      "text before the first block",
+			content:   "Planning notes\n---\nid: t-001\n---\n# First\n",
+			wantKind:  ErrMissingFrontmatter,
+			wantBlock: 1,
+			wantLine:  1,
+		},
+		{
+			name:      "unclosed last block",
+			content:   "---\nid: t-001\n---\n# First\n---\nid: t-002\n",
+			wantKind:  ErrInvalidFrontmatter,
+			wantBlock: 2,
+			wantLine:  5,
+		},
+		{
+			name:      "missing id",
+			content:   "---\nid: t-001\n---\n# First\n---\npriority: 1\n---\n# Second\n",
+			wantKind:  ErrMissingID,
+			wantBlock: 2,
+			wantLine:  5,
+		},
+		{
+			name:      "missing title",
+			content:   "---\nid: t-001\n---\nNo heading\n",
+			wantKind:  ErrMissingTitle,
+			wantBlock: 1,
+			wantLine:  1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeBundle(t, tt.content)
+
+			_, err := ParseTaskBundle(path)
+			var pe *ParseError
+			if !errors.As(err, &pe) || !errors.Is(err, tt.wantKind) {
+				t.Fatalf("expected %v ParseError, got %v", tt.wantKind, err)
+			}
+			if pe.Block != tt.wantBlock || pe.Line != tt.wantLine {
+				t.Errorf("expected task %d at line %d, got task %d at line %d", tt.wantBlock, tt.wantLine, pe.Block, pe.Line)
+			}
+			if !strings.Contains(err.Error(), path) {
+				t.Errorf("expected error to name %s, got %v", path, err)
+			}
+		})
+	}
+}
+
+func TestParseTaskBundleOrdersOptionalDeps(t *testing.T) {
+	// t-001 optionally depends on t-002, which comes later in the file
+	path := writeBundle(t, "---\nid: t-001\noptional_deps: [t-002]\n---\n# Release\n---\nid: t-002\n---\n# Changelog\n")
+
+	tasks, err := ParseTaskBundle(path)
+	if err != nil {
+		t.Fatalf("ParseTaskBundle failed: %v", err)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "t-002" || tasks[1].ID != "t-001" {
+		t.Fatalf("expected t-002 before t-001, got %v", tasks)
+	}
+	if len(tasks[1].OptionalDeps) != 1 || len(tasks[1].Deps) != 0 {
+		t.Errorf("expected one optional dep on t-001, got deps %v optional %v", tasks[1].Deps, tasks[1].OptionalDeps)
+	}
+
+	cycle := writeBundle(t, "---\nid: a\noptional_deps: [b]\n---\n# A\n---\nid: b\ndeps: [a]\n---\n# B\n")
+	if _, err := ParseTaskBundle(cycle); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("expected circular dependency error, got %v", err)
+	}
+}
+
+func TestParseTaskBundleRejectsCycleAndDuplicates(t *testing.T) {
+	cycle := writeBundle(t, "---\nid: a\ndeps: [b]\n---\n# A\n---\nid: b\ndeps: [a]\n---\n# B\n")
+	if _, err := ParseTaskBundle(cycle); err == nil || !strings.Contains(err.Error(), "circular") {
+		t.Errorf("expected circular dependency error, got %v", err)
+	}
+
+	dup := writeBundle(t, "---\nid: a\n---\n# A\n---\nid: a\n---\n# Again\n")
+	if _, err := ParseTaskBundle(dup); err == nil || !strings.Contains(err.Error(), "task 2: duplicate id 'a'") {
+		t.Errorf("expected duplicate id error naming task 2, got %v", err)
+	}
+}
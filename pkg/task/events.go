@@ -0,0 +1,73 @@
+package task
+
+import "sync"
+
+// RegistryEventKind is the mutation a RegistryEvent reports.
+type RegistryEventKind string
+
+const (
+	EventAdded   RegistryEventKind = "added"
+	EventUpdated RegistryEventKind = "updated"
+	EventDeleted RegistryEventKind = "deleted"
+)
+
+// RegistryEvent reports a committed registry mutation. Status is the task's
+// status after the change, and empty for a delete.
+type RegistryEvent struct {
+	Kind   RegistryEventKind `json:"kind"`
+	TaskID string            `json:"task_id"`
+	Status Status            `json:"status,omitempty"`
+}
+
+// subscriberBuffer is how many events a subscriber can fall behind before
+// further events are dropped for it.
+const subscriberBuffer = 64
+
+// subscribers fans registry events out to Subscribe channels.
+type subscribers struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]chan RegistryEvent
+}
+
+// Subscribe returns a channel that receives an event after each successful
+// Add, Update and Delete, and a func that stops delivery and closes the
+// channel. Mutations never wait on a subscriber: events for one that has
+// fallen subscriberBuffer events behind are dropped.
+func (r *Registry) Subscribe() (<-chan RegistryEvent, func()) {
+	s := &r.subscribers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.subs == nil {
+		s.subs = make(map[int]chan RegistryEvent)
+	}
+	id := s.next
+	s.next++
+	ch := make(chan RegistryEvent, subscriberBuffer)
+	s.subs[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.subs, id)
+			close(ch)
+		})
+	}
+}
+
+// publish delivers ev to every subscriber with room for it.
+func (r *Registry) publish(ev RegistryEvent) {
+	s := &r.subscribers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ch := range s.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
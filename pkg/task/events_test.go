@@ -0,0 +1,117 @@
+package task
+
+import (
+	"errors"
+	"testing"
+)
+
+// drain returns the events buffered on ch without blocking.
+func drain(ch <-chan RegistryEvent) []RegistryEvent {
+	var events []RegistryEvent
+	for {
+		select {
+		case ev, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, ev)
+		default:
+			return events
+		}
+	}
+}
+
+func TestRegistrySubscribe(t *testing.T) {
+	reg := NewRegistry()
+	events, unsubscribe := reg.Subscribe()
+	defer unsubscribe()
+
+	tk := New("t-001", "Watched")
+	if err := reg.Add(tk); err != nil {
+		t.Fatal(err)
+	}
+	got := drain(events)
+	if len(got) != 1 || got[0] != (RegistryEvent{Kind: EventAdded, TaskID: "t-001", Status: StatusPending}) {
+		t.Fatalf("expected one added event, got %+v", got)
+	}
+
+	tk.SetStatus(StatusInProgress)
+	if err := reg.Update(tk); err != nil {
+		t.Fatal(err)
+	}
+	got = drain(events)
+	if len(got) != 1 || got[0] != (RegistryEvent{Kind: EventUpdated, TaskID: "t-001", Status: StatusInProgress}) {
+		t.Fatalf("expected one updated event, got %+v", got)
+	}
+
+	if err := reg.Add(New("t-001", "Duplicate")); err == nil {
+		t.Fatal("expected duplicate add to fail")
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Errorf("expected no event for a failed add, got %+v", got)
+	}
+
+	if err := reg.Delete("t-001"); err != nil {
+		t.Fatal(err)
+	}
+	got = drain(events)
+	if len(got) != 1 || got[0] != (RegistryEvent{Kind: EventDeleted, TaskID: "t-001"}) {
+		t.Fatalf("expected one deleted event, got %+v", got)
+	}
+}
+
+func TestRegistryUnsubscribe(t *testing.T) {
+	reg := NewRegistry()
+	events, unsubscribe := reg.Subscribe()
+	unsubscribe()
+	unsubscribe() // Safe to call twice
+
+	if err := reg.Add(New("t-001", "Unwatched")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("expected the channel to be closed with no events after unsubscribe")
+	}
+}
+
+func TestRegistrySubscribeDropsWhenFull(t *testing.T) {
+	reg := NewRegistry()
+	events, unsubscribe := reg.Subscribe()
+	defer unsubscribe()
+
+	tk := New("t-001", "Busy")
+	if err := reg.Add(tk); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < subscriberBuffer*2; i++ {
+		tk.Description = string(rune('a' + i%26))
+		if err := reg.Update(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(drain(events)); got != subscriberBuffer {
+		t.Errorf("expected %d buffered events, got %d", subscriberBuffer, got)
+	}
+}
+
+func TestRegistrySubscribeSkipsRolledBackUpdate(t *testing.T) {
+	reg := NewRegistry()
+	tk := New("t-001", "Guarded")
+	if err := reg.Add(tk); err != nil {
+		t.Fatal(err)
+	}
+	reg.RegisterBlockingHook("", StatusInProgress, func(*Task) error {
+		return errors.New("not yet")
+	})
+
+	events, unsubscribe := reg.Subscribe()
+	defer unsubscribe()
+
+	tk.SetStatus(StatusInProgress)
+	if err := reg.Update(tk); err == nil {
+		t.Fatal("expected the blocking hook to fail the update")
+	}
+	if got := drain(events); len(got) != 0 {
+		t.Errorf("expected no event for a rolled back update, got %+v", got)
+	}
+}
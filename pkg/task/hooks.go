@@ -0,0 +1,93 @@
+package task
+
+import (
+	"fmt"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/logging"
+)
+
+// HookFunc is called with a task after its status changes.
+type HookFunc func(t *Task) error
+
+// statusHook is a hook registered for one transition.
+type statusHook struct {
+	from, to Status
+	fn       HookFunc
+	blocking bool
+}
+
+// matches reports whether the hook applies to from -> to. An empty status
+// in the hook matches any status.
+func (h statusHook) matches(from, to Status) bool {
+	return (h.from == "" || h.from == from) && (h.to == "" || h.to == to)
+}
+
+// RegisterHook registers fn to run after Update commits a from -> to
+// transition. An empty from or to matches any status. A failing hook is
+// logged and does not undo the transition.
+func (r *Registry) RegisterHook(from, to Status, fn HookFunc) {
+	r.addHook(statusHook{from: from, to: to, fn: fn})
+}
+
+// RegisterBlockingHook is like RegisterHook, but a failing hook rolls the
+// task back to its previous status and Update returns the hook's error.
+// Blocking hooks run before non-blocking ones.
+func (r *Registry) RegisterBlockingHook(from, to Status, fn HookFunc) {
+	r.addHook(statusHook{from: from, to: to, fn: fn, blocking: true})
+}
+
+func (r *Registry) addHook(h statusHook) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hooks = append(r.hooks, h)
+}
+
+// hooksLocked returns the hooks matching from -> to, blocking hooks first.
+func (r *Registry) hooksLocked(from, to Status) []statusHook {
+	var blocking, others []statusHook
+	for _, h := range r.hooks {
+		if !h.matches(from, to) {
+			continue
+		}
+		if h.blocking {
+			blocking = append(blocking, h)
+		} else {
+			others = append(others, h)
+		}
+	}
+	return append(blocking, others...)
+}
+
+// runHooks runs the hooks for t's transition from prev. Hooks run without
+// the registry lock held, so they may call back into the registry. A
+// blocking hook failure restores prev and is returned; other failures are
+// logged.
+func (r *Registry) runHooks(t *Task, prev Status, hooks []statusHook) error {
+	to := t.Status
+	for _, h := range hooks {
+		err := h.fn(t)
+		if err == nil {
+			continue
+		}
+
+		details := map[string]interface{}{
+			"task_id":  t.ID,
+			"from":     string(prev),
+			"to":       string(to),
+			"blocking": h.blocking,
+			"error":    err.Error(),
+		}
+		if h.blocking {
+			r.mu.Lock()
+			t.Status = prev
+			r.statuses[t.ID] = prev
+			r.mu.Unlock()
+			audit.Error("task.registry.hook", "Blocking status hook failed; transition rolled back", details)
+			return fmt.Errorf("status hook for %s -> %s failed: %w", prev, to, err)
+		}
+		audit.Warn("task.registry.hook", "Status hook failed", details)
+		logging.L().Warn("status hook failed", "task", t.ID, "from", prev, "to", to, "error", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package task
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRegistryHookFiresOnMatchingTransition(t *testing.T) {
+	reg := NewRegistry()
+	tk := New("t-001", "Hooked")
+	reg.Add(tk)
+
+	var fired []string
+	reg.RegisterHook(StatusInProgress, StatusComplete, func(t *Task) error {
+		fired = append(fired, t.ID)
+		return nil
+	})
+
+	// pending -> in_progress does not match
+	tk.SetStatus(StatusInProgress)
+	if err := reg.Update(tk); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if len(fired) != 0 {
+		t.Fatalf("expected no hook on pending -> in_progress, got %v", fired)
+	}
+
+	// in_progress -> complete matches
+	tk.SetStatus(StatusComplete)
+	if err := reg.Update(tk); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// An update without a status change does not fire again
+	tk.Description = "edited"
+	if err := reg.Update(tk); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	if len(fired) != 1 || fired[0] != "t-001" {
+		t.Errorf("expected hook to fire exactly once for t-001, got %v", fired)
+	}
+}
+
+func TestRegistryHookWildcard(t *testing.T) {
+	reg := NewRegistry()
+	tk := New("t-001", "Hooked")
+	reg.Add(tk)
+
+	count := 0
+	reg.RegisterHook("", StatusFailed, func(t *Task) error {
+		count++
+		return nil
+	})
+
+	tk.SetStatus(StatusInProgress)
+	reg.Update(tk)
+	tk.SetStatus(StatusFailed)
+	reg.Update(tk)
+
+	if count != 1 {
+		t.Errorf("expected wildcard hook to fire once on failure, got %d", count)
+	}
+}
+
+func TestRegistryHookErrors(t *testing.T) {
+	hookErr := errors.New("tagging failed")
+
+	t.Run("non-blocking keeps transition", func(t *testing.T) {
+		reg := NewRegistry()
+		tk := New("t-001", "Hooked")
+		reg.Add(tk)
+		reg.RegisterHook(StatusPending, StatusInProgress, func(t *Task) error {
+			return hookErr
+		})
+
+		tk.SetStatus(StatusInProgress)
+		if err := reg.Update(tk); err != nil {
+			t.Fatalf("expected non-blocking hook error to be swallowed, got %v", err)
+		}
+		if got, _ := reg.Get("t-001"); got.Status != StatusInProgress {
+			t.Errorf("expected transition kept, got %s", got.Status)
+		}
+	})
+
+	t.Run("blocking rolls back", func(t *testing.T) {
+		reg := NewRegistry()
+		tk := New("t-001", "Hooked")
+		reg.Add(tk)
+
+		later := false
+		reg.RegisterHook(StatusPending, StatusInProgress, func(t *Task) error {
+			later = true
+			return nil
+		})
+		reg.RegisterBlockingHook(StatusPending, StatusInProgress, func(t *Task) error {
+			return hookErr
+		})
+
+		tk.SetStatus(StatusInProgress)
+		if err := reg.Update(tk); !errors.Is(err, hookErr) {
+			t.Fatalf("expected blocking hook error, got %v", err)
+		}
+		if got, _ := reg.Get("t-001"); got.Status != StatusPending {
+			t.Errorf("expected rollback to pending, got %s", got.Status)
+		}
+		if later {
+			t.Error("expected non-blocking hooks to be skipped after a blocking failure")
+		}
+
+		// The rolled-back transition can be retried and fires the hooks again
+		reg.hooks = reg.hooks[:1]
+		tk.SetStatus(StatusInProgress)
+		if err := reg.Update(tk); err != nil || !later {
+			t.Errorf("expected retried transition to run hooks, got err=%v later=%v", err, later)
+		}
+	})
+}
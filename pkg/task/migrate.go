@@ -0,0 +1,113 @@
+package task
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/fileutil"
+)
+
+// TaskMigration transforms a task loaded from an older tasks file, for
+// example to fill in a field added since it was written. raw holds the
+// task's fields as stored in the file, so a migration can tell a field
+// that is absent from one set to its zero value.
+type TaskMigration func(t *Task, raw map[string]json.RawMessage) error
+
+// DefaultPriority returns a migration that sets Priority to priority on
+// tasks whose stored entry has no priority field. An explicit priority,
+// including 0, is kept.
+func DefaultPriority(priority int) TaskMigration {
+	return func(t *Task, raw map[string]json.RawMessage) error {
+		if _, ok := raw["priority"]; !ok {
+			t.Priority = priority
+		}
+		return nil
+	}
+}
+
+// SplitFallback moves a legacy single Fallback to the front of Fallbacks,
+// leaving the task's fallback chain unchanged.
+func SplitFallback(t *Task, raw map[string]json.RawMessage) error {
+	if t.Fallback != "" {
+		t.Fallbacks = append([]string{t.Fallback}, t.Fallbacks...)
+		t.Fallback = ""
+	}
+	return nil
+}
+
+// UsedBackendKey reads the backend that completed a task from the legacy
+// "backend" key, which tasks files used before it was stored as
+// "used_backend".
+func UsedBackendKey(t *Task, raw map[string]json.RawMessage) error {
+	legacy, ok := raw["backend"]
+	if !ok || t.UsedBackend != "" {
+		return nil
+	}
+	if err := json.Unmarshal(legacy, &t.UsedBackend); err != nil {
+		return fmt.Errorf("invalid backend: %w", err)
+	}
+	return nil
+}
+
+// MigrateFile loads the tasks file at path, applies migrations to every
+// task in ID order, saves it back and replaces the registry's tasks with
+// the migrated ones. The original file is first copied to path + ".bak".
+// The migrations run on a separate copy of the tasks, so if one fails or
+// leaves a task invalid neither the file nor the registry is changed.
+func (r *Registry) MigrateFile(path string, migrations ...TaskMigration) error {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read tasks file: %w", err)
+	}
+	var stored struct {
+		Tasks []map[string]json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(original, &stored); err != nil {
+		return fmt.Errorf("failed to unmarshal: %w", err)
+	}
+	raw := make(map[string]map[string]json.RawMessage, len(stored.Tasks))
+	for _, fields := range stored.Tasks {
+		var id string
+		json.Unmarshal(fields["id"], &id)
+		raw[id] = fields
+	}
+
+	migrated := NewRegistry()
+	if err := migrated.Load(path); err != nil {
+		return err
+	}
+
+	tasks := migrated.List()
+	sort.Slice(tasks, func(i, j int) bool { return tasks[i].ID < tasks[j].ID })
+	for _, t := range tasks {
+		for _, migrate := range migrations {
+			if err := migrate(t, raw[t.ID]); err != nil {
+				return fmt.Errorf("migrating task '%s': %w", t.ID, err)
+			}
+		}
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("migrating task '%s': %w", t.ID, err)
+		}
+	}
+
+	if err := fileutil.WriteFileAtomic(path+".bak", original, 0644); err != nil {
+		return fmt.Errorf("failed to back up tasks file: %w", err)
+	}
+	if err := migrated.Save(path); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.tasks, r.statuses, r.version = migrated.tasks, migrated.statuses, migrated.version
+	r.mu.Unlock()
+
+	audit.Info("task.registry.migrate", "Tasks file migrated", map[string]interface{}{
+		"path":       path,
+		"task_count": len(tasks),
+		"migrations": len(migrations),
+	})
+	return nil
+}
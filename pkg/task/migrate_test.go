@@ -0,0 +1,83 @@
+package task
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistryMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	legacy := `{
+  "version": 3,
+  "tasks": [
+    {"id": "t-001", "title": "Setup", "status": "complete", "fallback": "copilot/gpt-4", "backend": "claude"},
+    {"id": "t-002", "title": "Build", "status": "pending", "priority": 1, "deps": ["t-001"]},
+    {"id": "t-003", "title": "Hotfix", "status": "pending", "priority": 0}
+  ]
+}`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reg := NewRegistry()
+	if err := reg.MigrateFile(path, DefaultPriority(5), SplitFallback, UsedBackendKey); err != nil {
+		t.Fatalf("MigrateFile failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	if err != nil || string(backup) != legacy {
+		t.Errorf("expected the original file backed up, got %q (%v)", backup, err)
+	}
+
+	migrated := NewRegistry()
+	if err := migrated.Load(path); err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	setup, _ := migrated.Get("t-001")
+	build, _ := migrated.Get("t-002")
+	hotfix, _ := migrated.Get("t-003")
+	if setup.Priority != 5 || build.Priority != 1 || hotfix.Priority != 0 {
+		t.Errorf("expected default priority only where absent, got %d, %d and %d", setup.Priority, build.Priority, hotfix.Priority)
+	}
+	if got, _ := reg.Get("t-001"); got == nil || got.Priority != 5 {
+		t.Errorf("expected the registry to hold the migrated tasks, got %+v", got)
+	}
+	if setup.Fallback != "" || strings.Join(setup.FallbackChain(), ",") != "copilot/gpt-4" {
+		t.Errorf("expected fallback moved to the chain, got %q %v", setup.Fallback, setup.Fallbacks)
+	}
+	if setup.UsedBackend != "claude" {
+		t.Errorf("expected the legacy backend key read into UsedBackend, got %q", setup.UsedBackend)
+	}
+}
+
+func TestRegistryMigrateFileFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tasks.json")
+	original := `{"version": 1, "tasks": [{"id": "t-001", "title": "Setup", "status": "pending"}]}`
+	os.WriteFile(path, []byte(original), 0644)
+
+	reg := NewRegistry()
+	reg.Add(New("t-009", "Unsaved"))
+	failing := func(t *Task, raw map[string]json.RawMessage) error { return errors.New("cannot migrate") }
+	if err := reg.MigrateFile(path, DefaultPriority(2), failing); err == nil {
+		t.Fatal("expected migration error")
+	}
+
+	// The registry keeps its tasks, untouched by the migrations that ran
+	if _, err := reg.Get("t-001"); err == nil {
+		t.Error("expected the registry not to load the failed migration's tasks")
+	}
+	if _, err := reg.Get("t-009"); err != nil {
+		t.Errorf("expected the registry's own tasks kept: %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != original {
+		t.Errorf("expected tasks file untouched, got %s", data)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Errorf("expected no backup after a failed migration, got %v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package task
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrNoSelection is returned by PickTask when input ends before a task is
+// chosen.
+var ErrNoSelection = errors.New("no task selected")
+
+// WriteReadyList writes ready as a numbered list with each task's priority
+// and title, numbered from 1 in the order given.
+func WriteReadyList(w io.Writer, ready []*Task) {
+	for i, t := range ready {
+		fmt.Fprintf(w, "  %d) %s [P%d] %s\n", i+1, t.ID, t.Priority, t.Title)
+	}
+}
+
+// PickTask lists ready on out and reads a choice from in: either a list
+// number or a task ID. Invalid choices are reported and asked again until
+// in runs out, which returns ErrNoSelection.
+func PickTask(in io.Reader, out io.Writer, ready []*Task) (*Task, error) {
+	if len(ready) == 0 {
+		return nil, errors.New("no tasks are ready")
+	}
+
+	fmt.Fprintln(out, "Ready tasks:")
+	WriteReadyList(out, ready)
+
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprintf(out, "Select a task [1-%d]: ", len(ready))
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return nil, err
+			}
+			return nil, ErrNoSelection
+		}
+		if t := selectTask(strings.TrimSpace(scanner.Text()), ready); t != nil {
+			return t, nil
+		}
+		fmt.Fprintln(out, "Enter a number from the list or a task ID.")
+	}
+}
+
+// selectTask returns the task choice names in ready, or nil.
+func selectTask(choice string, ready []*Task) *Task {
+	if n, err := strconv.Atoi(choice); err == nil && n >= 1 && n <= len(ready) {
+		return ready[n-1]
+	}
+	for _, t := range ready {
+		if t.ID == choice {
+			return t
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package task
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPickTask(t *testing.T) {
+	schema := New("t-002", "Schema")
+	schema.Priority = 1
+	ready := []*Task{schema, New("t-001", "Setup")}
+
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{"by number", "2\n", "t-001", nil},
+		{"by ID", "t-002\n", "t-002", nil},
+		{"asks again after an invalid choice", "9\nnope\n1\n", "t-002", nil},
+		{"input ends", "", "", ErrNoSelection},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			got, err := PickTask(strings.NewReader(tt.input), &out, ready)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.want != "" && (got == nil || got.ID != tt.want) {
+				t.Errorf("expected %s, got %+v", tt.want, got)
+			}
+			if !strings.Contains(out.String(), "1) t-002 [P1] Schema\n  2) t-001 [P0] Setup") {
+				t.Errorf("expected a numbered ready list, got:\n%s", out.String())
+			}
+		})
+	}
+
+	if _, err := PickTask(strings.NewReader("1\n"), &strings.Builder{}, nil); err == nil {
+		t.Error("expected error with no ready tasks")
+	}
+}
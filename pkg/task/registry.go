@@ -4,23 +4,79 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 
 	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/fileutil"
 )
 
 // Registry manages a collection of tasks with dependency tracking.
 type Registry struct {
-	tasks   map[string]*Task
-	mu      sync.RWMutex
-	version int // Optimistic concurrency control version
+	tasks    map[string]*Task
+	statuses map[string]Status // Status as of the last Add/Update/Load, to detect transitions
+	hooks    []statusHook
+	mu       sync.RWMutex
+	version  int // Optimistic concurrency control version
+
+	idPattern   string         // As configured, for error messages
+	idPatternRe *regexp.Regexp // Task IDs must match when set
+
+	subscribers subscribers // Receivers of mutation events
+}
+
+// CompileIDPattern compiles a task ID pattern so that it must match the
+// whole ID. An empty pattern compiles to nil, allowing any ID.
+func CompileIDPattern(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid task ID pattern '%s': %w", pattern, err)
+	}
+	return re, nil
+}
+
+// SetIDPattern makes Add reject tasks whose ID does not match pattern in
+// full. Tasks already in the registry, or loaded from disk, keep their IDs
+// and can still be updated. An empty pattern allows any ID.
+func (r *Registry) SetIDPattern(pattern string) error {
+	re, err := CompileIDPattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.idPattern, r.idPatternRe = pattern, re
+	return nil
+}
+
+// CheckID returns an error if id does not match the ID pattern.
+func (r *Registry) CheckID(id string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.checkIDLocked(id)
+}
+
+// checkIDLocked returns an error if id does not match the ID pattern.
+func (r *Registry) checkIDLocked(id string) error {
+	if r.idPatternRe == nil || r.idPatternRe.MatchString(id) {
+		return nil
+	}
+	return fmt.Errorf("invalid task: ID '%s' does not match the task ID pattern '%s'", id, r.idPattern)
 }
 
 // NewRegistry creates an empty task registry.
 func NewRegistry() *Registry {
 	return &Registry{
-		tasks: make(map[string]*Task),
+		tasks:    make(map[string]*Task),
+		statuses: make(map[string]Status),
 	}
 }
 
@@ -38,6 +94,14 @@ func (r *Registry) Add(task *Task) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	if err := r.checkIDLocked(task.ID); err != nil {
+		audit.Error("task.registry.add", "Task validation failed", map[string]interface{}{
+			"task_id": task.ID,
+			"error":   err.Error(),
+		})
+		return err
+	}
+
 	if _, exists := r.tasks[task.ID]; exists {
 		audit.Warn("task.registry.add", "Task already exists", map[string]interface{}{
 			"task_id": task.ID,
@@ -55,10 +119,12 @@ func (r *Registry) Add(task *Task) error {
 	}
 
 	r.tasks[task.ID] = task
+	r.statuses[task.ID] = task.Status
 	audit.Info("task.registry.add", "Task added to registry", map[string]interface{}{
 		"task_id": task.ID,
 		"title":   task.Title,
 	})
+	r.publish(RegistryEvent{Kind: EventAdded, TaskID: task.ID, Status: task.Status})
 	return nil
 }
 
@@ -74,14 +140,30 @@ func (r *Registry) Get(id string) (*Task, error) {
 	return task, nil
 }
 
-// Update updates an existing task.
+// Update updates an existing task. If its status changed since the last
+// update, the matching status hooks run after the change is committed.
+// Subscribers hear of the update once the hooks have not rolled it back.
 func (r *Registry) Update(task *Task) error {
+	prev, hooks, err := r.update(task)
+	if err != nil {
+		return err
+	}
+	if err := r.runHooks(task, prev, hooks); err != nil {
+		return err
+	}
+	r.publish(RegistryEvent{Kind: EventUpdated, TaskID: task.ID, Status: task.Status})
+	return nil
+}
+
+// update commits task and returns its previous status and the hooks for
+// the transition, if any.
+func (r *Registry) update(task *Task) (Status, []statusHook, error) {
 	if err := task.Validate(); err != nil {
 		audit.Error("task.registry.update", "Task validation failed", map[string]interface{}{
 			"task_id": task.ID,
 			"error":   err.Error(),
 		})
-		return fmt.Errorf("invalid task: %w", err)
+		return "", nil, fmt.Errorf("invalid task: %w", err)
 	}
 
 	r.mu.Lock()
@@ -91,7 +173,7 @@ func (r *Registry) Update(task *Task) error {
 		audit.Error("task.registry.update", "Task not found", map[string]interface{}{
 			"task_id": task.ID,
 		})
-		return fmt.Errorf("task '%s' not found", task.ID)
+		return "", nil, fmt.Errorf("task '%s' not found", task.ID)
 	}
 
 	if err := r.validateDepsLocked(task); err != nil {
@@ -99,24 +181,30 @@ func (r *Registry) Update(task *Task) error {
 			"task_id": task.ID,
 			"error":   err.Error(),
 		})
-		return err
+		return "", nil, err
 	}
 
 	// Check for circular dependencies
-	if err := r.checkCircularLocked(task.ID, task.Deps, make(map[string]bool)); err != nil {
+	if err := r.checkCircularLocked(task.ID, task.AllDeps(), make(map[string]bool)); err != nil {
 		audit.Error("task.registry.update", "Circular dependency detected", map[string]interface{}{
 			"task_id": task.ID,
 			"error":   err.Error(),
 		})
-		return err
+		return "", nil, err
 	}
 
+	prev := r.statuses[task.ID]
 	r.tasks[task.ID] = task
+	r.statuses[task.ID] = task.Status
 	audit.Info("task.registry.update", "Task updated", map[string]interface{}{
 		"task_id": task.ID,
 		"title":   task.Title,
 	})
-	return nil
+
+	if prev == task.Status {
+		return prev, nil, nil
+	}
+	return prev, r.hooksLocked(prev, task.Status), nil
 }
 
 // Delete removes a task by ID.
@@ -134,7 +222,7 @@ func (r *Registry) Delete(id string) error {
 
 	// Check for dependents
 	for _, task := range r.tasks {
-		for _, dep := range task.Deps {
+		for _, dep := range task.AllDeps() {
 			if dep == id {
 				audit.Warn("task.registry.delete", "Cannot delete task with dependents", map[string]interface{}{
 					"task_id":   id,
@@ -145,13 +233,108 @@ func (r *Registry) Delete(id string) error {
 		}
 	}
 
-	delete(r.tasks, id)
+	r.removeLocked(id)
 	audit.Info("task.registry.delete", "Task deleted", map[string]interface{}{
 		"task_id": id,
 	})
+	r.publish(RegistryEvent{Kind: EventDeleted, TaskID: id})
 	return nil
 }
 
+// DeleteCascade removes a task along with every task that depends on it,
+// directly or transitively, and returns the deleted IDs in the order
+// CascadeOrder gives.
+func (r *Registry) DeleteCascade(id string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[id]; !exists {
+		audit.Error("task.registry.delete", "Task not found", map[string]interface{}{
+			"task_id": id,
+		})
+		return nil, fmt.Errorf("task '%s' not found", id)
+	}
+
+	ids := r.cascadeOrderLocked(id)
+	for _, del := range ids {
+		r.removeLocked(del)
+	}
+	audit.Info("task.registry.delete", "Task deleted with dependents", map[string]interface{}{
+		"task_id": id,
+		"deleted": ids,
+	})
+	for _, del := range ids {
+		r.publish(RegistryEvent{Kind: EventDeleted, TaskID: del})
+	}
+	return ids, nil
+}
+
+// CascadeOrder returns the IDs DeleteCascade would remove for id: the task
+// and all its transitive dependents, each listed before the tasks it
+// depends on.
+func (r *Registry) CascadeOrder(id string) ([]string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.tasks[id]; !exists {
+		return nil, fmt.Errorf("task '%s' not found", id)
+	}
+	return r.cascadeOrderLocked(id), nil
+}
+
+// cascadeOrderLocked lists id after its dependents, visiting dependents in
+// ID order so the result is deterministic.
+func (r *Registry) cascadeOrderLocked(id string) []string {
+	dependents := make(map[string][]string)
+	for _, task := range r.tasks {
+		for _, dep := range task.AllDeps() {
+			dependents[dep] = append(dependents[dep], task.ID)
+		}
+	}
+
+	var order []string
+	seen := make(map[string]bool)
+	var visit func(id string)
+	visit = func(id string) {
+		if seen[id] {
+			return
+		}
+		seen[id] = true
+		next := dependents[id]
+		sort.Strings(next)
+		for _, d := range next {
+			visit(d)
+		}
+		order = append(order, id)
+	}
+	visit(id)
+	return order
+}
+
+// removeLocked deletes id and drops related links to it. Related links are
+// informational, so they never block a delete.
+func (r *Registry) removeLocked(id string) {
+	for _, task := range r.tasks {
+		task.Related = removeID(task.Related, id)
+	}
+	delete(r.tasks, id)
+	delete(r.statuses, id)
+}
+
+// removeID returns ids without id, reusing its backing array.
+func removeID(ids []string, id string) []string {
+	kept := ids[:0]
+	for _, other := range ids {
+		if other != id {
+			kept = append(kept, other)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
 // List returns all tasks.
 func (r *Registry) List() []*Task {
 	r.mu.RLock()
@@ -164,6 +347,49 @@ func (r *Registry) List() []*Task {
 	return tasks
 }
 
+// DuplicateTitles returns the titles shared by more than one task, mapped
+// to the sorted IDs of the tasks sharing them. Titles are compared after
+// trimming surrounding whitespace.
+func (r *Registry) DuplicateTitles() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byTitle := make(map[string][]string)
+	for _, t := range r.tasks {
+		title := strings.TrimSpace(t.Title)
+		byTitle[title] = append(byTitle[title], t.ID)
+	}
+
+	dups := make(map[string][]string)
+	for title, ids := range byTitle {
+		if len(ids) > 1 {
+			sort.Strings(ids)
+			dups[title] = ids
+		}
+	}
+	return dups
+}
+
+// DefaultIDPrefix is the prefix of generated task IDs (t-001, t-002, ...).
+const DefaultIDPrefix = "t-"
+
+// NextID returns the ID after the highest numbered ID in reg with the given
+// prefix, zero-padded to three digits. IDs whose suffix after the prefix is
+// not a plain number are ignored, so "ua-ios-1" never affects "ua-".
+func NextID(reg *Registry, prefix string) string {
+	max := 0
+	for _, t := range reg.List() {
+		suffix, ok := strings.CutPrefix(t.ID, prefix)
+		if !ok || suffix == "" || strings.TrimLeft(suffix, "0123456789") != "" {
+			continue
+		}
+		if n, err := strconv.Atoi(suffix); err == nil && n > max {
+			max = n
+		}
+	}
+	return fmt.Sprintf("%s%03d", prefix, max+1)
+}
+
 // ListByStatus returns tasks with the given status.
 func (r *Registry) ListByStatus(status Status) []*Task {
 	r.mu.RLock()
@@ -192,8 +418,35 @@ func (r *Registry) ListByRepo(repo string) []*Task {
 	return tasks
 }
 
+// ListByGroup returns tasks in the given group.
+func (r *Registry) ListByGroup(group string) []*Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*Task
+	for _, task := range r.tasks {
+		if task.Group == group {
+			tasks = append(tasks, task)
+		}
+	}
+	return tasks
+}
+
+// GroupProgress returns how many tasks in group are complete, out of the
+// group's total.
+func (r *Registry) GroupProgress(group string) (complete, total int) {
+	for _, task := range r.ListByGroup(group) {
+		total++
+		if task.IsComplete() {
+			complete++
+		}
+	}
+	return complete, total
+}
+
 // GetReady returns tasks that are ready to start.
-// A task is ready if it's pending and all its dependencies are complete.
+// A task is ready if it's pending, all its dependencies are complete and
+// all its optional dependencies have finished, whether complete or failed.
 func (r *Registry) GetReady() []*Task {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -210,7 +463,78 @@ func (r *Registry) GetReady() []*Task {
 	return ready
 }
 
-// GetDeps returns the tasks that the given task depends on.
+// ReadyFilter narrows GetReadyFiltered to ready tasks matching every field
+// that is set.
+type ReadyFilter struct {
+	Repo  string
+	Label string
+	Group string
+}
+
+// GetReadyFiltered returns the ready tasks matching filter, highest
+// priority (lowest Priority value) first, with ties broken by ID.
+func (r *Registry) GetReadyFiltered(filter ReadyFilter) []*Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ready []*Task
+	for _, task := range r.tasks {
+		if task.Status != StatusPending || !r.allDepsCompleteLocked(task) {
+			continue
+		}
+		if filter.Repo != "" && task.Repo != filter.Repo {
+			continue
+		}
+		if filter.Label != "" && !task.HasLabel(filter.Label) {
+			continue
+		}
+		if filter.Group != "" && task.Group != filter.Group {
+			continue
+		}
+		ready = append(ready, task)
+	}
+
+	sort.Slice(ready, func(i, j int) bool {
+		if ready[i].Priority != ready[j].Priority {
+			return ready[i].Priority < ready[j].Priority
+		}
+		return ready[i].ID < ready[j].ID
+	})
+	return ready
+}
+
+// BlockedBy returns, for every pending task, the IDs of its dependencies
+// that are not yet complete and its optional dependencies that have not yet
+// finished. Ready tasks map to an empty slice.
+func (r *Registry) BlockedBy() map[string][]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	blocked := make(map[string][]string)
+	for _, task := range r.tasks {
+		if task.Status != StatusPending {
+			continue
+		}
+		incomplete := []string{}
+		for _, depID := range task.Deps {
+			dep, exists := r.tasks[depID]
+			if !exists || dep.Status != StatusComplete {
+				incomplete = append(incomplete, depID)
+			}
+		}
+		for _, depID := range task.OptionalDeps {
+			dep, exists := r.tasks[depID]
+			if !exists || !dep.IsTerminal() {
+				incomplete = append(incomplete, depID)
+			}
+		}
+		blocked[task.ID] = incomplete
+	}
+	return blocked
+}
+
+// GetDeps returns the tasks that the given task depends on, required
+// dependencies first, then optional ones.
 func (r *Registry) GetDeps(id string) ([]*Task, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -220,8 +544,9 @@ func (r *Registry) GetDeps(id string) ([]*Task, error) {
 		return nil, fmt.Errorf("task '%s' not found", id)
 	}
 
-	deps := make([]*Task, 0, len(task.Deps))
-	for _, depID := range task.Deps {
+	allDeps := task.AllDeps()
+	deps := make([]*Task, 0, len(allDeps))
+	for _, depID := range allDeps {
 		if dep, exists := r.tasks[depID]; exists {
 			deps = append(deps, dep)
 		}
@@ -240,7 +565,7 @@ func (r *Registry) GetDependents(id string) ([]*Task, error) {
 
 	var dependents []*Task
 	for _, task := range r.tasks {
-		for _, dep := range task.Deps {
+		for _, dep := range task.AllDeps() {
 			if dep == id {
 				dependents = append(dependents, task)
 				break
@@ -250,24 +575,70 @@ func (r *Registry) GetDependents(id string) ([]*Task, error) {
 	return dependents, nil
 }
 
-// ValidateDeps checks if all dependencies exist.
+// GetRelated returns the tasks linked to the given task as related, either
+// listed in its Related or listing it in theirs. Related tasks do not affect
+// readiness.
+func (r *Registry) GetRelated(id string) ([]*Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, exists := r.tasks[id]
+	if !exists {
+		return nil, fmt.Errorf("task '%s' not found", id)
+	}
+
+	seen := make(map[string]bool)
+	var related []*Task
+	for _, relID := range task.Related {
+		if rel, exists := r.tasks[relID]; exists && !seen[relID] {
+			seen[relID] = true
+			related = append(related, rel)
+		}
+	}
+
+	var backlinks []*Task
+	for _, other := range r.tasks {
+		if seen[other.ID] {
+			continue
+		}
+		for _, relID := range other.Related {
+			if relID == id {
+				backlinks = append(backlinks, other)
+				break
+			}
+		}
+	}
+	sort.Slice(backlinks, func(i, j int) bool { return backlinks[i].ID < backlinks[j].ID })
+	return append(related, backlinks...), nil
+}
+
+// ValidateDeps checks if all dependencies and related tasks exist.
 func (r *Registry) ValidateDeps(task *Task) error {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	return r.validateDepsLocked(task)
 }
 
-// validateDepsLocked checks deps without acquiring lock.
+// validateDepsLocked checks deps and related links without acquiring lock.
 func (r *Registry) validateDepsLocked(task *Task) error {
-	for _, depID := range task.Deps {
+	for _, depID := range task.AllDeps() {
 		if _, exists := r.tasks[depID]; !exists {
 			return fmt.Errorf("dependency '%s' not found", depID)
 		}
 	}
+	for _, relID := range task.Related {
+		if relID == task.ID {
+			return fmt.Errorf("task '%s' cannot be related to itself", relID)
+		}
+		if _, exists := r.tasks[relID]; !exists {
+			return fmt.Errorf("related task '%s' not found", relID)
+		}
+	}
 	return nil
 }
 
-// allDepsCompleteLocked checks if all deps are complete without acquiring lock.
+// allDepsCompleteLocked checks if all deps are complete and all optional
+// deps are complete or failed without acquiring lock.
 func (r *Registry) allDepsCompleteLocked(task *Task) bool {
 	for _, depID := range task.Deps {
 		dep, exists := r.tasks[depID]
@@ -275,6 +646,12 @@ func (r *Registry) allDepsCompleteLocked(task *Task) bool {
 			return false
 		}
 	}
+	for _, depID := range task.OptionalDeps {
+		dep, exists := r.tasks[depID]
+		if !exists || !dep.IsTerminal() {
+			return false
+		}
+	}
 	return true
 }
 
@@ -293,7 +670,7 @@ func (r *Registry) checkCircularLocked(startID string, deps []string, visited ma
 		if !exists {
 			continue
 		}
-		if err := r.checkCircularLocked(startID, dep.Deps, visited); err != nil {
+		if err := r.checkCircularLocked(startID, dep.AllDeps(), visited); err != nil {
 			return err
 		}
 	}
@@ -317,34 +694,36 @@ func unlockFile(file *os.File) error {
 }
 
 // Save writes the registry to a JSON file with file locking and optimistic concurrency.
+// The file is replaced atomically, so a crash mid-save leaves the previous
+// contents intact. Tasks are written sorted by ID so that saving the same
+// tasks produces the same file. Writers serialize on a sidecar lock file
+// because the data file itself is replaced on every save.
 func (r *Registry) Save(path string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	// Open file for read-write, create if doesn't exist
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	lock, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0644)
 	if err != nil {
-		return fmt.Errorf("failed to open: %w", err)
+		return fmt.Errorf("failed to open lock: %w", err)
 	}
-	defer file.Close()
+	defer lock.Close()
 
 	// Acquire exclusive lock
-	if err := lockFile(file); err != nil {
+	if err := lockFile(lock); err != nil {
 		return fmt.Errorf("failed to lock file: %w", err)
 	}
-	defer unlockFile(file)
+	defer unlockFile(lock)
 
 	// Read current version for optimistic concurrency check
-	stat, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to stat: %w", err)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read current version: %w", err)
 	}
 
-	if stat.Size() > 0 {
+	if len(existing) > 0 {
 		// File exists, check version
 		var currentData registryData
-		decoder := json.NewDecoder(file)
-		if err := decoder.Decode(&currentData); err != nil {
+		if err := json.Unmarshal(existing, &currentData); err != nil {
 			return fmt.Errorf("failed to read current version: %w", err)
 		}
 
@@ -354,33 +733,30 @@ func (r *Registry) Save(path string) error {
 		}
 	}
 
-	// Increment version for this save
-	r.version++
-
 	data := registryData{
-		Version: r.version,
+		Version: r.version + 1,
 		Tasks:   make([]*Task, 0, len(r.tasks)),
 	}
 	for _, task := range r.tasks {
 		data.Tasks = append(data.Tasks, task)
 	}
+	// Sort so unchanged tasks save identically and diffs stay small
+	sort.Slice(data.Tasks, func(i, j int) bool {
+		return data.Tasks[i].ID < data.Tasks[j].ID
+	})
 
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal: %w", err)
 	}
 
-	// Truncate and write from beginning
-	if err := file.Truncate(0); err != nil {
-		return fmt.Errorf("failed to truncate: %w", err)
-	}
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek: %w", err)
-	}
-	if _, err := file.Write(jsonData); err != nil {
+	if err := fileutil.WriteFileAtomic(path, jsonData, 0644); err != nil {
 		return fmt.Errorf("failed to write: %w", err)
 	}
 
+	// Only advance the version once the save has landed
+	r.version = data.Version
+
 	return nil
 }
 
@@ -410,6 +786,7 @@ func (r *Registry) Load(path string) error {
 
 	// Clear existing and add all tasks
 	r.tasks = make(map[string]*Task)
+	r.statuses = make(map[string]Status)
 	r.version = data.Version
 
 	// First pass: add all tasks without dep validation
@@ -418,6 +795,7 @@ func (r *Registry) Load(path string) error {
 			return fmt.Errorf("invalid task '%s': %w", task.ID, err)
 		}
 		r.tasks[task.ID] = task
+		r.statuses[task.ID] = task.Status
 	}
 
 	// Second pass: validate all deps
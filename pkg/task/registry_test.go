@@ -1,6 +1,8 @@
 package task
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -88,6 +90,53 @@ func TestRegistryGetNotFound(t *testing.T) {
 	}
 }
 
+func TestRegistryIDPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		id      string
+		wantErr bool
+	}{
+		{`ua-\d{3}`, "ua-001", false},
+		{`ua-\d{3}`, "bad id", true},
+		{`ua-\d{3}`, "xua-0012", true}, // The whole ID must match
+		{`[A-Z]+-\d+`, "JIRA-123", false},
+		{"", "bad id", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.id, func(t *testing.T) {
+			reg := NewRegistry()
+			if err := reg.SetIDPattern(tt.pattern); err != nil {
+				t.Fatalf("SetIDPattern failed: %v", err)
+			}
+			err := reg.Add(New(tt.id, "Task"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Add(%q) error = %v, wantErr %v", tt.id, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.pattern) {
+				t.Errorf("expected the pattern in the error, got %v", err)
+			}
+		})
+	}
+
+	// Tasks added before the pattern was set can still be updated
+	reg := NewRegistry()
+	tk := New("legacy", "Old ID")
+	reg.Add(tk)
+	reg.SetIDPattern(`ua-\d{3}`)
+	tk.Title = "Renamed"
+	if err := reg.Update(tk); err != nil {
+		t.Errorf("expected Update to accept an existing task outside the pattern, got %v", err)
+	}
+	if got, _ := reg.Get("legacy"); got.Title != "Renamed" {
+		t.Errorf("expected the update to apply, got %q", got.Title)
+	}
+
+	if err := reg.SetIDPattern("ua-("); err == nil {
+		t.Error("expected error for an invalid pattern")
+	}
+}
+
 func TestRegistryUpdate(t *testing.T) {
 	reg := NewRegistry()
 
@@ -151,6 +200,52 @@ func TestRegistryDeleteWithDependents(t *testing.T) {
 	}
 }
 
+func TestRegistryDeleteCascade(t *testing.T) {
+	reg := NewRegistry()
+	add := func(id string, deps ...string) {
+		tk := New(id, "Task "+id)
+		tk.Deps = deps
+		if err := reg.Add(tk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("ua-001")
+	add("ua-002", "ua-001")
+	add("ua-004", "ua-001")
+	add("ua-003", "ua-002", "ua-004") // Reached twice
+	add("ua-005")
+
+	related, _ := reg.Get("ua-005")
+	related.Related = []string{"ua-003"}
+
+	order, err := reg.CascadeOrder("ua-001")
+	if err != nil {
+		t.Fatalf("CascadeOrder failed: %v", err)
+	}
+	// Each task comes before the tasks it depends on
+	if fmt.Sprint(order) != "[ua-003 ua-002 ua-004 ua-001]" {
+		t.Errorf("unexpected cascade order: %v", order)
+	}
+
+	deleted, err := reg.DeleteCascade("ua-001")
+	if err != nil {
+		t.Fatalf("DeleteCascade failed: %v", err)
+	}
+	if fmt.Sprint(deleted) != fmt.Sprint(order) {
+		t.Errorf("expected %v deleted, got %v", order, deleted)
+	}
+	if got := reg.List(); len(got) != 1 || got[0].ID != "ua-005" {
+		t.Errorf("expected only ua-005 left, got %v", got)
+	}
+	if len(related.Related) != 0 {
+		t.Errorf("expected related link to a deleted task dropped, got %v", related.Related)
+	}
+
+	if _, err := reg.DeleteCascade("ua-001"); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
 func TestRegistryList(t *testing.T) {
 	reg := NewRegistry()
 
@@ -246,6 +341,94 @@ func TestRegistryGetReady(t *testing.T) {
 	}
 }
 
+func TestRegistryGetReadyFiltered(t *testing.T) {
+	reg := NewRegistry()
+	add := func(id, repo, group string, priority int, labels []string, deps ...string) *Task {
+		tk := New(id, "Task "+id)
+		tk.Repo, tk.Group, tk.Priority, tk.Labels, tk.Deps = repo, group, priority, labels, deps
+		if err := reg.Add(tk); err != nil {
+			t.Fatal(err)
+		}
+		return tk
+	}
+	security := []string{"security"}
+	base := add("ua-001", "android", "", 0, nil)
+	add("ua-002", "android", "auth", 2, security)
+	add("ua-003", "android", "auth", 1, security)
+	add("ua-004", "ios", "auth", 0, security)
+	add("ua-005", "android", "", 0, []string{"ui"})
+	add("ua-006", "android", "auth", 0, security, "ua-001") // Blocked until ua-001 completes
+
+	ids := func(tasks []*Task) string {
+		var got []string
+		for _, tk := range tasks {
+			got = append(got, tk.ID)
+		}
+		return strings.Join(got, " ")
+	}
+
+	tests := []struct {
+		name   string
+		filter ReadyFilter
+		want   string
+	}{
+		{"repo and label", ReadyFilter{Repo: "android", Label: "security"}, "ua-003 ua-002"},
+		{"label only", ReadyFilter{Label: "security"}, "ua-004 ua-003 ua-002"},
+		{"repo label and group", ReadyFilter{Repo: "ios", Label: "security", Group: "auth"}, "ua-004"},
+		{"no match", ReadyFilter{Repo: "ios", Label: "ui"}, ""},
+		{"no filter", ReadyFilter{}, "ua-001 ua-004 ua-005 ua-003 ua-002"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ids(reg.GetReadyFiltered(tt.filter)); got != tt.want {
+				t.Errorf("GetReadyFiltered(%+v) = %q, want %q", tt.filter, got, tt.want)
+			}
+		})
+	}
+
+	base.SetStatus(StatusInProgress)
+	base.SetStatus(StatusComplete)
+	reg.Update(base)
+	if got := ids(reg.GetReadyFiltered(ReadyFilter{Repo: "android", Label: "security"})); got != "ua-006 ua-003 ua-002" {
+		t.Errorf("expected unblocked ua-006 first, got %q", got)
+	}
+}
+
+func TestRegistryBlockedBy(t *testing.T) {
+	reg := NewRegistry()
+
+	// a (complete) <- c, b (pending) <- c, c <- d
+	a := New("ua-001", "Complete")
+	reg.Add(a)
+	b := New("ua-002", "Pending")
+	reg.Add(b)
+	c := New("ua-003", "Blocked by b")
+	c.Deps = []string{"ua-001", "ua-002"}
+	reg.Add(c)
+	d := New("ua-004", "Blocked by c")
+	d.Deps = []string{"ua-003"}
+	reg.Add(d)
+
+	a.SetStatus(StatusInProgress)
+	a.SetStatus(StatusComplete)
+	reg.Update(a)
+
+	blocked := reg.BlockedBy()
+
+	if _, ok := blocked["ua-001"]; ok {
+		t.Error("complete task should not be listed")
+	}
+	if len(blocked["ua-002"]) != 0 {
+		t.Errorf("ua-002 should be ready, got blocked by %v", blocked["ua-002"])
+	}
+	if got := blocked["ua-003"]; len(got) != 1 || got[0] != "ua-002" {
+		t.Errorf("ua-003 should be blocked by [ua-002], got %v", got)
+	}
+	if got := blocked["ua-004"]; len(got) != 1 || got[0] != "ua-003" {
+		t.Errorf("ua-004 should be blocked by [ua-003], got %v", got)
+	}
+}
+
 func TestRegistryGetDeps(t *testing.T) {
 	reg := NewRegistry()
 
@@ -355,6 +538,84 @@ func TestRegistrySaveLoad(t *testing.T) {
 	}
 }
 
+func TestRegistrySaveStableOrder(t *testing.T) {
+	var tasks []*Task
+	for _, id := range []string{"t-003", "t-010", "t-001", "t-002"} {
+		tk := New(id, "Task "+id)
+		tk.Env = map[string]string{"B": "2", "A": "1"}
+		tasks = append(tasks, tk)
+	}
+
+	save := func(order []int) []byte {
+		reg := NewRegistry()
+		for _, i := range order {
+			reg.Add(tasks[i])
+		}
+		path := filepath.Join(t.TempDir(), "tasks.json")
+		if err := reg.Save(path); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+		data, _ := os.ReadFile(path)
+		return data
+	}
+
+	first := save([]int{0, 1, 2, 3})
+	for i := 0; i < 10; i++ {
+		if again := save([]int{3, 2, 1, 0}); !bytes.Equal(first, again) {
+			t.Fatalf("saves differ:\n%s\n---\n%s", first, again)
+		}
+	}
+
+	var data registryData
+	if err := json.Unmarshal(first, &data); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, tk := range data.Tasks {
+		ids = append(ids, tk.ID)
+	}
+	if got := strings.Join(ids, " "); got != "t-001 t-002 t-003 t-010" {
+		t.Errorf("expected tasks sorted by ID, got %s", got)
+	}
+}
+
+func TestRegistrySaveAtomic(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "tasks.json")
+
+	reg := NewRegistry()
+	reg.Add(New("ua-001", "First"))
+	if err := reg.Save(filePath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+	os.Chmod(filePath, 0600)
+
+	reg.Add(New("ua-002", "Second"))
+	if err := reg.Save(filePath); err != nil {
+		t.Fatalf("failed to save: %v", err)
+	}
+
+	// The file was replaced, not rewritten, and kept its mode
+	info, _ := os.Stat(filePath)
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600 preserved, got %o", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(tmpDir)
+	for _, e := range entries {
+		if strings.Contains(e.Name(), ".tmp") {
+			t.Errorf("temp file left behind: %s", e.Name())
+		}
+	}
+
+	reg2 := NewRegistry()
+	if err := reg2.Load(filePath); err != nil {
+		t.Fatalf("failed to load: %v", err)
+	}
+	if len(reg2.List()) != 2 {
+		t.Errorf("expected 2 tasks, got %d", len(reg2.List()))
+	}
+}
+
 func TestRegistryConcurrentReads(t *testing.T) {
 	tmpDir := t.TempDir()
 	filePath := filepath.Join(tmpDir, "tasks.json")
@@ -498,3 +759,250 @@ func TestRegistryVersionConflict(t *testing.T) {
 		t.Errorf("expected version conflict error, got: %v", err)
 	}
 }
+
+func TestNextID(t *testing.T) {
+	tests := []struct {
+		name   string
+		ids    []string
+		prefix string
+		want   string
+	}{
+		{"empty registry", nil, "ua-", "ua-001"},
+		{"sequential", []string{"t-001", "t-002"}, "t-", "t-003"},
+		{"gap in numbering", []string{"ua-001", "ua-007", "ua-003"}, "ua-", "ua-008"},
+		{"mixed prefixes", []string{"ua-002", "ios-009", "t-004"}, "ua-", "ua-003"},
+		{"longer prefix sharing a stem", []string{"ua-ios-005", "ua-001"}, "ua-", "ua-002"},
+		{"non-numeric suffixes ignored", []string{"ua-abc", "ua-12b", "ua-+9"}, "ua-", "ua-001"},
+		{"past three digits", []string{"t-999"}, "t-", "t-1000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := NewRegistry()
+			for _, id := range tt.ids {
+				if err := reg.Add(New(id, "Task "+id)); err != nil {
+					t.Fatalf("Add(%s) failed: %v", id, err)
+				}
+			}
+
+			if got := NextID(reg, tt.prefix); got != tt.want {
+				t.Errorf("NextID(%q) = %q, want %q", tt.prefix, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryDuplicateTitles(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(New("t-002", "Add login"))
+	reg.Add(New("t-001", "Add login "))
+	reg.Add(New("t-003", "Add logout"))
+
+	dups := reg.DuplicateTitles()
+	if len(dups) != 1 {
+		t.Fatalf("expected one duplicated title, got %v", dups)
+	}
+	if ids := dups["Add login"]; strings.Join(ids, ",") != "t-001,t-002" {
+		t.Errorf("expected t-001,t-002 for 'Add login', got %v", ids)
+	}
+
+	if dups := NewRegistry().DuplicateTitles(); len(dups) != 0 {
+		t.Errorf("expected no duplicates in an empty registry, got %v", dups)
+	}
+}
+
+func TestRegistryGroups(t *testing.T) {
+	reg := NewRegistry()
+
+	add := func(id, group string, status Status) {
+		tk := New(id, "Task "+id)
+		tk.Group = group
+		reg.Add(tk)
+		if status != StatusPending {
+			tk.SetStatus(StatusInProgress)
+			if status == StatusComplete {
+				tk.SetStatus(StatusComplete)
+			}
+			reg.Update(tk)
+		}
+	}
+	add("t-001", "auth", StatusComplete)
+	add("t-002", "auth", StatusPending)
+	add("t-003", "auth", StatusComplete)
+	add("t-004", "billing", StatusPending)
+	add("t-005", "", StatusComplete)
+
+	if got := len(reg.ListByGroup("auth")); got != 3 {
+		t.Errorf("expected 3 auth tasks, got %d", got)
+	}
+	if got := len(reg.ListByGroup("")); got != 1 {
+		t.Errorf("expected 1 ungrouped task, got %d", got)
+	}
+
+	tests := []struct {
+		group           string
+		complete, total int
+	}{
+		{"auth", 2, 3},
+		{"billing", 0, 1},
+		{"missing", 0, 0},
+	}
+	for _, tt := range tests {
+		complete, total := reg.GroupProgress(tt.group)
+		if complete != tt.complete || total != tt.total {
+			t.Errorf("GroupProgress(%q) = %d/%d, want %d/%d", tt.group, complete, total, tt.complete, tt.total)
+		}
+	}
+}
+
+func TestRegistryRelatedDoesNotBlock(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(New("t-001", "Schema"))
+
+	related := New("t-002", "Docs")
+	related.Related = []string{"t-001"}
+	if err := reg.Add(related); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	blocked := New("t-003", "Migration")
+	blocked.Deps = []string{"t-001"}
+	reg.Add(blocked)
+
+	ready := make(map[string]bool)
+	for _, tk := range reg.GetReady() {
+		ready[tk.ID] = true
+	}
+	if !ready["t-002"] {
+		t.Error("expected related-only task to be ready")
+	}
+	if ready["t-003"] {
+		t.Error("expected task with an incomplete dep to be blocked")
+	}
+
+	// Links are visible from both ends
+	for id, want := range map[string]string{"t-002": "t-001", "t-001": "t-002"} {
+		got, err := reg.GetRelated(id)
+		if err != nil {
+			t.Fatalf("GetRelated(%s) failed: %v", id, err)
+		}
+		if len(got) != 1 || got[0].ID != want {
+			t.Errorf("GetRelated(%s) = %v, want [%s]", id, got, want)
+		}
+	}
+}
+
+func TestRegistryRelatedValidation(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(New("t-001", "Schema"))
+
+	tests := []struct {
+		name    string
+		related []string
+	}{
+		{"unknown task", []string{"t-999"}},
+		{"itself", []string{"t-002"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tk := New("t-002", "Docs")
+			tk.Related = tt.related
+			if err := reg.Add(tk); err == nil {
+				t.Errorf("expected error for related %v", tt.related)
+			}
+		})
+	}
+}
+
+func TestRegistryDeleteDropsRelatedLinks(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(New("t-001", "Schema"))
+	tk := New("t-002", "Docs")
+	tk.Related = []string{"t-001"}
+	reg.Add(tk)
+
+	if err := reg.Delete("t-001"); err != nil {
+		t.Fatalf("expected related task to be deletable, got %v", err)
+	}
+	if len(tk.Related) != 0 {
+		t.Errorf("expected link to be dropped, got %v", tk.Related)
+	}
+}
+
+func TestRegistryOptionalDeps(t *testing.T) {
+	tests := []struct {
+		name      string
+		depStatus []Status // Transitions applied to the dependency
+		optional  bool
+		wantReady bool
+	}{
+		{"optional pending", nil, true, false},
+		{"optional in progress", []Status{StatusInProgress}, true, false},
+		{"optional complete", []Status{StatusInProgress, StatusComplete}, true, true},
+		{"optional failed", []Status{StatusInProgress, StatusFailed}, true, true},
+		{"required complete", []Status{StatusInProgress, StatusComplete}, false, true},
+		{"required failed", []Status{StatusInProgress, StatusFailed}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := NewRegistry()
+			dep := New("t-001", "Integration")
+			reg.Add(dep)
+			tk := New("t-002", "Feature")
+			if tt.optional {
+				tk.OptionalDeps = []string{"t-001"}
+			} else {
+				tk.Deps = []string{"t-001"}
+			}
+			if err := reg.Add(tk); err != nil {
+				t.Fatalf("Add failed: %v", err)
+			}
+			for _, s := range tt.depStatus {
+				if err := dep.SetStatus(s); err != nil {
+					t.Fatal(err)
+				}
+				if err := reg.Update(dep); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ready := false
+			for _, r := range reg.GetReady() {
+				if r.ID == "t-002" {
+					ready = true
+				}
+			}
+			if ready != tt.wantReady {
+				t.Errorf("GetReady: t-002 ready = %v, want %v", ready, tt.wantReady)
+			}
+			if blocked := reg.BlockedBy()["t-002"]; (len(blocked) == 0) != tt.wantReady {
+				t.Errorf("BlockedBy(t-002) = %v, want ready %v", blocked, tt.wantReady)
+			}
+		})
+	}
+}
+
+func TestRegistryOptionalDepsValidation(t *testing.T) {
+	reg := NewRegistry()
+	reg.Add(New("t-001", "Integration"))
+
+	tk := New("t-002", "Feature")
+	tk.OptionalDeps = []string{"t-999"}
+	if err := reg.Add(tk); err == nil {
+		t.Error("expected error for an unknown optional dependency")
+	}
+
+	tk.OptionalDeps = []string{"t-001"}
+	if err := reg.Add(tk); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if err := reg.Delete("t-001"); err == nil {
+		t.Error("expected delete of an optional dependency to fail")
+	}
+
+	dep, _ := reg.Get("t-001")
+	dep.OptionalDeps = []string{"t-002"}
+	if err := reg.Update(dep); err == nil {
+		t.Error("expected circular optional dependency to be rejected")
+	}
+}
@@ -2,8 +2,11 @@
 package task
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,6 +24,11 @@ const (
 	StatusFailed     Status = "failed"
 )
 
+// Statuses returns every valid status, in lifecycle order.
+func Statuses() []Status {
+	return []Status{StatusPending, StatusInProgress, StatusComplete, StatusFailed}
+}
+
 // IsValid returns true if the status is a known valid status.
 func (s Status) IsValid() bool {
 	switch s {
@@ -33,19 +41,58 @@ func (s Status) IsValid() bool {
 
 // Task represents a unit of work within a feature.
 type Task struct {
-	ID          string    `json:"id" yaml:"id"`
-	Title       string    `json:"title" yaml:"title"`
-	Description string    `json:"description,omitempty" yaml:"description,omitempty"`
-	Status      Status    `json:"status" yaml:"status"`
-	Priority    int       `json:"priority,omitempty" yaml:"priority,omitempty"`
-	Repo        string    `json:"repo,omitempty" yaml:"repo,omitempty"`
-	Deps        []string  `json:"deps,omitempty" yaml:"deps,omitempty"`
-	SpecRef     string    `json:"spec_ref,omitempty" yaml:"spec_ref,omitempty"`
-	Model       string    `json:"model,omitempty" yaml:"model,omitempty"`
-	Fallback    string    `json:"fallback,omitempty" yaml:"fallback,omitempty"`
-	Type        string    `json:"type,omitempty" yaml:"type,omitempty"`
-	CreatedAt   time.Time `json:"created_at" yaml:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at" yaml:"updated_at"`
+	ID                  string            `json:"id" yaml:"id"`
+	Title               string            `json:"title" yaml:"title"`
+	Description         string            `json:"description,omitempty" yaml:"description,omitempty"`
+	Status              Status            `json:"status" yaml:"status"`
+	Priority            int               `json:"priority,omitempty" yaml:"priority,omitempty"`
+	Repo                string            `json:"repo,omitempty" yaml:"repo,omitempty"`
+	Group               string            `json:"group,omitempty" yaml:"group,omitempty"`   // Epic the task belongs to
+	Labels              []string          `json:"labels,omitempty" yaml:"labels,omitempty"` // Free-form tags, e.g. "security"
+	Deps                []string          `json:"deps,omitempty" yaml:"deps,omitempty"`
+	OptionalDeps        []string          `json:"optional_deps,omitempty" yaml:"optional_deps,omitempty"` // Like Deps, but a failed one does not block
+	Related             []string          `json:"related,omitempty" yaml:"related,omitempty"`             // Informational links; unlike Deps they never block
+	SpecRef             string            `json:"spec_ref,omitempty" yaml:"spec_ref,omitempty"`
+	SpecHashAtCreate    string            `json:"spec_hash_at_create,omitempty" yaml:"spec_hash_at_create,omitempty"`
+	Model               string            `json:"model,omitempty" yaml:"model,omitempty"`
+	Fallback            string            `json:"fallback,omitempty" yaml:"fallback,omitempty"`
+	Fallbacks           []string          `json:"fallbacks,omitempty" yaml:"fallbacks,omitempty"` // Tried after Fallback, in order
+	Type                string            `json:"type,omitempty" yaml:"type,omitempty"`
+	EstimatedMinutes    int               `json:"estimated_minutes,omitempty" yaml:"estimated_minutes,omitempty"`
+	MaxRetries          int               `json:"max_retries,omitempty" yaml:"max_retries,omitempty"`                   // Reruns after a failure that is not a quota error
+	Issue               int               `json:"issue,omitempty" yaml:"issue,omitempty"`                               // GitHub issue number
+	Env                 map[string]string `json:"env,omitempty" yaml:"env,omitempty"`                                   // Extra environment for the backend process
+	UsedBackend         string            `json:"used_backend,omitempty" yaml:"used_backend,omitempty"`                 // Backend that completed the task
+	UsedModel           string            `json:"used_model,omitempty" yaml:"used_model,omitempty"`                     // Model that completed the task
+	Tokens              int               `json:"tokens,omitempty" yaml:"tokens,omitempty"`                             // Tokens used across runs
+	ConversationID      string            `json:"conversation_id,omitempty" yaml:"conversation_id,omitempty"`           // Last backend conversation, for resuming
+	ConversationBackend string            `json:"conversation_backend,omitempty" yaml:"conversation_backend,omitempty"` // Backend that owns ConversationID
+	FailureCategory     FailureCategory   `json:"failure_category,omitempty" yaml:"failure_category,omitempty"`         // Why the last run failed
+	FailureReason       string            `json:"failure_reason,omitempty" yaml:"failure_reason,omitempty"`             // Error from the last failed run
+	History             []Note            `json:"history,omitempty" yaml:"history,omitempty"`
+	Notes               []Note            `json:"notes,omitempty" yaml:"notes,omitempty"` // Comments left by agents and people; append-only
+	CreatedAt           time.Time         `json:"created_at" yaml:"created_at"`
+	UpdatedAt           time.Time         `json:"updated_at" yaml:"updated_at"`
+	StartedAt           *time.Time        `json:"started_at,omitempty" yaml:"started_at,omitempty"`
+	CompletedAt         *time.Time        `json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+}
+
+// FailureCategory says why a task's run failed, to help triage.
+type FailureCategory string
+
+// Failure categories.
+const (
+	FailureQuota   FailureCategory = "quota"   // Every backend tried was out of quota
+	FailureTests   FailureCategory = "tests"   // The task's tests did not pass
+	FailureTimeout FailureCategory = "timeout" // The run took too long
+	FailureAgent   FailureCategory = "agent"   // The agent could not do the task
+)
+
+// Note is a timestamped entry in a task's history or notes.
+type Note struct {
+	Time    time.Time `json:"time" yaml:"time"`
+	Author  string    `json:"author,omitempty" yaml:"author,omitempty"` // Who left the note; unset for history entries
+	Message string    `json:"message" yaml:"message"`
 }
 
 // New creates a new Task with the given ID and title.
@@ -61,6 +108,24 @@ func New(id, title string) *Task {
 	}
 }
 
+// HasLabel reports whether the task has label.
+func (t *Task) HasLabel(label string) bool {
+	for _, l := range t.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// AllDeps returns the task's required and optional dependencies.
+func (t *Task) AllDeps() []string {
+	if len(t.OptionalDeps) == 0 {
+		return t.Deps
+	}
+	return append(append([]string(nil), t.Deps...), t.OptionalDeps...)
+}
+
 // Validate checks if the task has valid required fields.
 func (t *Task) Validate() error {
 	if t.ID == "" {
@@ -121,8 +186,18 @@ func (t *Task) SetStatus(newStatus Status) error {
 	}
 
 	oldStatus := t.Status
+	now := time.Now()
 	t.Status = newStatus
-	t.UpdatedAt = time.Now()
+	t.UpdatedAt = now
+
+	// Track actual duration; a retried task restarts its clock
+	switch newStatus {
+	case StatusInProgress:
+		t.StartedAt = &now
+		t.CompletedAt = nil
+	case StatusComplete:
+		t.CompletedAt = &now
+	}
 	
 	audit.Info("task.set_status", "Task status changed", map[string]interface{}{
 		"task_id":    t.ID,
@@ -134,6 +209,96 @@ func (t *Task) SetStatus(newStatus Status) error {
 	return nil
 }
 
+// Duration returns how long the task took from starting to completion,
+// or zero if it has not both started and completed.
+func (t *Task) Duration() time.Duration {
+	if t.StartedAt == nil || t.CompletedAt == nil {
+		return 0
+	}
+	return t.CompletedAt.Sub(*t.StartedAt)
+}
+
+// FallbackChain returns the "backend/model" references to fail over to, in
+// order: Fallback, if set, followed by Fallbacks.
+func (t *Task) FallbackChain() []string {
+	var chain []string
+	if t.Fallback != "" {
+		chain = append(chain, t.Fallback)
+	}
+	return append(chain, t.Fallbacks...)
+}
+
+// RecordRun records the backend and model that completed a successful run,
+// which may be a fallback after failover. Tokens accumulate across runs,
+// and any failure recorded by an earlier run is cleared.
+func (t *Task) RecordRun(backend, model string, tokens int) {
+	t.UsedBackend = backend
+	t.UsedModel = model
+	t.Tokens += tokens
+	t.FailureCategory = ""
+	t.FailureReason = ""
+	t.UpdatedAt = time.Now()
+}
+
+// RecordTokens adds tokens used by a run that did not complete the task.
+func (t *Task) RecordTokens(tokens int) {
+	t.Tokens += tokens
+	t.UpdatedAt = time.Now()
+}
+
+// RecordFailure stores why the task's last run failed. A later successful
+// run clears it.
+func (t *Task) RecordFailure(category FailureCategory, reason string) {
+	t.FailureCategory = category
+	t.FailureReason = reason
+	t.UpdatedAt = time.Now()
+}
+
+// RecordConversation stores the conversation a backend reported for the
+// task's last run, so a later run on the same backend can continue it.
+func (t *Task) RecordConversation(backend, conversationID string) {
+	t.ConversationBackend = backend
+	t.ConversationID = conversationID
+	t.UpdatedAt = time.Now()
+}
+
+// AddHistory appends a timestamped message to the task history.
+func (t *Task) AddHistory(message string) {
+	now := time.Now()
+	t.History = append(t.History, Note{Time: now, Message: message})
+	t.UpdatedAt = now
+}
+
+// AddNote appends a timestamped note from author to the task's notes.
+// Notes are never edited or removed.
+func (t *Task) AddNote(author, text string) {
+	now := time.Now()
+	t.Notes = append(t.Notes, Note{Time: now, Author: author, Message: text})
+	t.UpdatedAt = now
+}
+
+// Reset moves an interrupted in_progress task back to pending so it can be
+// claimed again. This bypasses the normal transition table, which only lets
+// in_progress tasks finish as complete or failed. The interrupted run's
+// timestamps are cleared so it does not count towards the task's duration.
+func (t *Task) Reset() error {
+	if t.Status != StatusInProgress {
+		return fmt.Errorf("cannot reset task %s: status is %s, not %s", t.ID, t.Status, StatusInProgress)
+	}
+
+	t.Status = StatusPending
+	t.StartedAt = nil
+	t.CompletedAt = nil
+	t.UpdatedAt = time.Now()
+
+	audit.Info("task.reset", "Task reset to pending", map[string]interface{}{
+		"task_id":    t.ID,
+		"task_title": t.Title,
+	})
+
+	return nil
+}
+
 // IsReady returns true if the task is pending and could be started.
 // Note: This doesn't check dependencies - use Registry.IsReady() for that.
 func (t *Task) IsReady() bool {
@@ -150,7 +315,44 @@ func (t *Task) IsTerminal() bool {
 	return t.Status == StatusComplete || t.Status == StatusFailed
 }
 
+// Task file parse failures, distinguished so callers can report them.
+var (
+	ErrMissingFrontmatter = errors.New("missing YAML frontmatter")
+	ErrInvalidFrontmatter = errors.New("invalid YAML frontmatter")
+	ErrMissingTitle       = errors.New("missing title (add a '# Title' heading or a title field)")
+	ErrMissingID          = errors.New("missing id")
+)
+
+// ParseError reports a task file that could not be parsed. Err wraps one
+// of the Err* parse failures above.
+type ParseError struct {
+	Path  string
+	Line  int // Line in the file, 1-based; 0 when unknown
+	Block int // Task block in a bundle, 1-based; 0 for single-task files
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	loc := e.Path
+	if e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", e.Path, e.Line)
+	}
+	if e.Block > 0 {
+		return fmt.Sprintf("%s: task %d: %v", loc, e.Block, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", loc, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// yamlLine matches the line number in yaml.v3 error messages.
+var yamlLine = regexp.MustCompile(`line (\d+): `)
+
 // ParseTaskFile reads a task from a task.md file with YAML frontmatter.
+// Malformed files return a *ParseError with the path and, for bad YAML,
+// the line where parsing failed.
 func ParseTaskFile(path string) (*Task, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -158,30 +360,37 @@ func ParseTaskFile(path string) (*Task, error) {
 	}
 
 	content := string(data)
-	
+
 	// Check for YAML frontmatter (--- ... ---)
 	if !strings.HasPrefix(content, "---\n") {
-		return nil, fmt.Errorf("task file missing YAML frontmatter")
+		return nil, &ParseError{Path: path, Line: 1, Err: ErrMissingFrontmatter}
 	}
 
-	// Find end of frontmatter
-	endIdx := strings.Index(content[4:], "\n---\n")
-	if endIdx == -1 {
-		return nil, fmt.Errorf("task file has invalid frontmatter")
+	// Split frontmatter from body; the closing --- may end the file
+	rest := content[4:]
+	var frontmatter, body string
+	if endIdx := strings.Index(rest, "\n---\n"); endIdx != -1 {
+		frontmatter = rest[:endIdx]
+		body = rest[endIdx+5:]
+	} else if strings.HasSuffix(rest, "\n---") {
+		frontmatter = strings.TrimSuffix(rest, "\n---")
+	} else {
+		return nil, &ParseError{Path: path, Line: 1, Err: fmt.Errorf("%w: no closing '---'", ErrInvalidFrontmatter)}
 	}
-	endIdx += 4 // Adjust for the offset
 
-	// Extract frontmatter and body
-	frontmatter := content[4:endIdx]
-	body := strings.TrimSpace(content[endIdx+5:])
+	return parseSection(path, 1, frontmatter, body)
+}
 
-	// Parse YAML frontmatter
+// parseSection parses one frontmatter block and its markdown body. open is
+// the file line of the block's opening ---.
+func parseSection(path string, open int, frontmatter, body string) (*Task, error) {
 	var task Task
 	if err := yaml.Unmarshal([]byte(frontmatter), &task); err != nil {
-		return nil, fmt.Errorf("failed to parse frontmatter: %w", err)
+		return nil, frontmatterError(path, open, err)
 	}
 
 	// Extract title and description from body
+	body = strings.TrimSpace(body)
 	if body != "" {
 		lines := strings.Split(body, "\n")
 		for i, line := range lines {
@@ -196,6 +405,29 @@ func ParseTaskFile(path string) (*Task, error) {
 			}
 		}
 	}
+	if task.Title == "" {
+		return nil, &ParseError{Path: path, Err: ErrMissingTitle}
+	}
 
 	return &task, nil
 }
+
+// frontmatterError converts a YAML error into a ParseError, moving its line
+// number from the frontmatter to the file, where the frontmatter starts on
+// the line after open.
+func frontmatterError(path string, open int, err error) *ParseError {
+	msg := err.Error()
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) && len(typeErr.Errors) > 0 {
+		msg = typeErr.Errors[0]
+	}
+	msg = strings.TrimPrefix(msg, "yaml: ")
+
+	line := 0
+	if m := yamlLine.FindStringSubmatchIndex(msg); m != nil {
+		line, _ = strconv.Atoi(msg[m[2]:m[3]])
+		line += open
+		msg = msg[:m[0]] + msg[m[1]:]
+	}
+	return &ParseError{Path: path, Line: line, Err: fmt.Errorf("%w: %s", ErrInvalidFrontmatter, msg)}
+}
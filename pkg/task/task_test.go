@@ -2,7 +2,10 @@ package task
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -208,6 +211,56 @@ func TestTaskUpdateTimestamp(t *testing.T) {
 	}
 }
 
+func TestTaskDuration(t *testing.T) {
+	task := New("du-001", "Test")
+	if task.Duration() != 0 {
+		t.Errorf("expected zero duration before start, got %s", task.Duration())
+	}
+
+	task.SetStatus(StatusInProgress)
+	if task.StartedAt == nil {
+		t.Fatal("expected StartedAt to be set")
+	}
+	if task.Duration() != 0 {
+		t.Errorf("expected zero duration before completion, got %s", task.Duration())
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	task.SetStatus(StatusComplete)
+	if task.CompletedAt == nil {
+		t.Fatal("expected CompletedAt to be set")
+	}
+	if task.Duration() <= 0 {
+		t.Errorf("expected non-zero duration, got %s", task.Duration())
+	}
+}
+
+func TestTaskTimestampsJSON(t *testing.T) {
+	task := New("du-002", "Test")
+	task.EstimatedMinutes = 30
+	task.SetStatus(StatusInProgress)
+	task.SetStatus(StatusComplete)
+
+	data, err := json.Marshal(task)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	var decoded Task
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("failed to unmarshal: %v", err)
+	}
+	if decoded.EstimatedMinutes != 30 {
+		t.Errorf("expected estimate 30, got %d", decoded.EstimatedMinutes)
+	}
+	if decoded.StartedAt == nil || !decoded.StartedAt.Equal(*task.StartedAt) {
+		t.Errorf("expected StartedAt %v, got %v", task.StartedAt, decoded.StartedAt)
+	}
+	if decoded.CompletedAt == nil || !decoded.CompletedAt.Equal(*task.CompletedAt) {
+		t.Errorf("expected CompletedAt %v, got %v", task.CompletedAt, decoded.CompletedAt)
+	}
+}
+
 // Helper
 func contains(s, substr string) bool {
 	return len(s) >= len(substr) && (s == substr || len(s) > 0 && containsHelper(s, substr))
@@ -331,3 +384,206 @@ invalid yaml: [unclosed
 	}
 }
 
+func TestParseTaskFileErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		wantKind error
+		wantLine int
+		wantMsg  string
+	}{
+		{
+			name:     "missing frontmatter",
+			content:  "# Just a title\n",
+			wantKind: ErrMissingFrontmatter,
+			wantLine: 1,
+		},
+		{
+			name:     "unclosed frontmatter",
+			content:  "---\nid: t-001\n\n# Title\n",
+			wantKind: ErrInvalidFrontmatter,
+			wantLine: 1,
+			wantMsg:  "no closing",
+		},
+		{
+			name:     "bad yaml syntax",
+			content:  "---\nid: t-001\nstatus: pending\n  model: claude\n---\n\n# Title\n",
+			wantKind: ErrInvalidFrontmatter,
+			wantLine: 4,
+			wantMsg:  "mapping values are not allowed",
+		},
+		{
+			name:     "wrong yaml type",
+			content:  "---\nid: t-001\npriority: high\n---\n\n# Title\n",
+			wantKind: ErrInvalidFrontmatter,
+			wantLine: 3,
+			wantMsg:  "cannot unmarshal",
+		},
+		{
+			name:     "missing title",
+			content:  "---\nid: t-001\n---\n\nNo heading here.\n",
+			wantKind: ErrMissingTitle,
+		},
+	}
+
+	kinds := []error{ErrMissingFrontmatter, ErrInvalidFrontmatter, ErrMissingTitle}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "TASK-t-001.md")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			_, err := ParseTaskFile(path)
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("expected *ParseError, got %v", err)
+			}
+			for _, kind := range kinds {
+				if errors.Is(err, kind) != (kind == tt.wantKind) {
+					t.Errorf("errors.Is(%v) = %v, want %v", kind, !(kind == tt.wantKind), kind == tt.wantKind)
+				}
+			}
+			if parseErr.Line != tt.wantLine {
+				t.Errorf("expected line %d, got %d (%v)", tt.wantLine, parseErr.Line, err)
+			}
+			if !strings.Contains(err.Error(), path) || !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Errorf("expected error naming %s and %q, got %v", path, tt.wantMsg, err)
+			}
+		})
+	}
+}
+
+func TestParseTaskFileClosingAtEOF(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TASK-t-001.md")
+	os.WriteFile(path, []byte("---\nid: t-001\ntitle: From frontmatter\n---"), 0644)
+
+	task, err := ParseTaskFile(path)
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if task.Title != "From frontmatter" {
+		t.Errorf("expected frontmatter title, got %q", task.Title)
+	}
+}
+
+func TestTaskReset(t *testing.T) {
+	task := New("t-001", "Interrupted")
+
+	if err := task.Reset(); err == nil {
+		t.Error("expected error resetting a pending task")
+	}
+
+	task.SetStatus(StatusInProgress)
+	if err := task.Reset(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if task.Status != StatusPending {
+		t.Errorf("expected pending after reset, got %s", task.Status)
+	}
+	if task.StartedAt != nil || task.CompletedAt != nil {
+		t.Errorf("expected run timestamps cleared, got started=%v completed=%v", task.StartedAt, task.CompletedAt)
+	}
+
+	task.AddHistory("reset by flo resume")
+	if len(task.History) != 1 || task.History[0].Message != "reset by flo resume" {
+		t.Errorf("expected history note, got %+v", task.History)
+	}
+	if task.History[0].Time.IsZero() {
+		t.Error("expected note timestamp to be set")
+	}
+}
+
+func TestTaskRecordRun(t *testing.T) {
+	task := New("rr-001", "Test")
+	task.RecordRun("claude", "opus", 1000)
+	task.RecordRun("copilot", "gpt-4", 500)
+
+	if task.UsedBackend != "copilot" || task.UsedModel != "gpt-4" {
+		t.Errorf("expected last run copilot/gpt-4, got %s/%s", task.UsedBackend, task.UsedModel)
+	}
+	if task.Tokens != 1500 {
+		t.Errorf("expected 1500 tokens, got %d", task.Tokens)
+	}
+
+	data, _ := json.Marshal(task)
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	if fields["used_backend"] != "copilot" || fields["used_model"] != "gpt-4" {
+		t.Errorf("expected used_backend and used_model keys, got %s", data)
+	}
+}
+
+func TestTaskFallbackChain(t *testing.T) {
+	tests := []struct {
+		name      string
+		fallback  string
+		fallbacks []string
+		want      string
+	}{
+		{"none", "", nil, ""},
+		{"single string", "copilot/gpt-4", nil, "copilot/gpt-4"},
+		{"list only", "", []string{"claude/sonnet", "copilot/gpt-4"}, "claude/sonnet,copilot/gpt-4"},
+		{"string then list", "claude/sonnet", []string{"copilot/gpt-4"}, "claude/sonnet,copilot/gpt-4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := New("fb-001", "Test")
+			task.Fallback = tt.fallback
+			task.Fallbacks = tt.fallbacks
+			if got := strings.Join(task.FallbackChain(), ","); got != tt.want {
+				t.Errorf("FallbackChain() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTaskFileFallbacks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TASK-t-001.md")
+	content := `---
+id: t-001
+status: pending
+model: claude/opus
+fallbacks:
+  - claude/sonnet
+  - copilot/gpt-4
+---
+
+# Chain`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := ParseTaskFile(path)
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if got := strings.Join(task.FallbackChain(), ","); got != "claude/sonnet,copilot/gpt-4" {
+		t.Errorf("expected fallback chain from frontmatter, got %q", got)
+	}
+}
+
+func TestParseTaskFileEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "TASK-t-001.md")
+	content := `---
+id: t-001
+status: pending
+env:
+  DATABASE_URL: "postgres://localhost:5432/test"
+  LOG_LEVEL: debug
+---
+
+# Needs a database`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	task, err := ParseTaskFile(path)
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if len(task.Env) != 2 || task.Env["DATABASE_URL"] != "postgres://localhost:5432/test" || task.Env["LOG_LEVEL"] != "debug" {
+		t.Errorf("unexpected env from frontmatter: %v", task.Env)
+	}
+}
@@ -0,0 +1,111 @@
+package task
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DefaultTreeDepth is the dependency tree depth used when none is given.
+const DefaultTreeDepth = 10
+
+// DepNode is a task in a dependency tree, with the tasks it depends on as
+// children.
+type DepNode struct {
+	ID        string     `json:"id"`
+	Title     string     `json:"title,omitempty"`
+	Status    Status     `json:"status,omitempty"`
+	Deps      []*DepNode `json:"deps,omitempty"`
+	Optional  bool       `json:"optional,omitempty"`  // An optional dep of the task above
+	Missing   bool       `json:"missing,omitempty"`   // No task with this ID exists
+	Cycle     bool       `json:"cycle,omitempty"`     // Already on the path above; not expanded
+	Truncated bool       `json:"truncated,omitempty"` // Depth limit reached with deps left out
+}
+
+// DepTree returns the transitive dependencies of the task id as a tree, at
+// most maxDepth levels below it (DefaultTreeDepth if maxDepth <= 0). A task
+// reached again through its own deps is marked Cycle rather than expanded,
+// though Update rejects such cycles.
+func (r *Registry) DepTree(id string, maxDepth int) (*DepNode, error) {
+	if maxDepth <= 0 {
+		maxDepth = DefaultTreeDepth
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.tasks[id]; !exists {
+		return nil, fmt.Errorf("task '%s' not found", id)
+	}
+	return r.depNodeLocked(id, maxDepth, make(map[string]bool)), nil
+}
+
+// depNodeLocked builds the node for id, with path holding the IDs above it.
+func (r *Registry) depNodeLocked(id string, depth int, path map[string]bool) *DepNode {
+	t, exists := r.tasks[id]
+	if !exists {
+		return &DepNode{ID: id, Missing: true}
+	}
+
+	node := &DepNode{ID: t.ID, Title: t.Title, Status: t.Status}
+	switch {
+	case path[id]:
+		node.Cycle = true
+		return node
+	case len(t.AllDeps()) == 0:
+		return node
+	case depth == 0:
+		node.Truncated = true
+		return node
+	}
+
+	path[id] = true
+	for _, depID := range t.Deps {
+		node.Deps = append(node.Deps, r.depNodeLocked(depID, depth-1, path))
+	}
+	for _, depID := range t.OptionalDeps {
+		dep := r.depNodeLocked(depID, depth-1, path)
+		dep.Optional = true
+		node.Deps = append(node.Deps, dep)
+	}
+	delete(path, id)
+	return node
+}
+
+// Render writes the tree to w, one task per line, with each level of
+// dependencies indented below the task that needs them.
+func (n *DepNode) Render(w io.Writer) {
+	fmt.Fprintln(w, n.label())
+	n.renderDeps(w, "")
+}
+
+func (n *DepNode) renderDeps(w io.Writer, indent string) {
+	for i, dep := range n.Deps {
+		branch, next := "├── ", "│   "
+		if i == len(n.Deps)-1 {
+			branch, next = "└── ", "    "
+		}
+		fmt.Fprintln(w, indent+branch+dep.label())
+		dep.renderDeps(w, indent+next)
+	}
+}
+
+// label describes the node on one line.
+func (n *DepNode) label() string {
+	var b strings.Builder
+	if n.Missing {
+		b.WriteString(n.ID + " (missing)")
+	} else {
+		fmt.Fprintf(&b, "%s %s [%s]", n.ID, n.Title, n.Status)
+	}
+	if n.Optional {
+		b.WriteString(" (optional)")
+	}
+	if n.Cycle {
+		b.WriteString(" (cycle)")
+	}
+	if n.Truncated {
+		b.WriteString(" ...")
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package task
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDepTreeRender(t *testing.T) {
+	reg := NewRegistry()
+	add := func(id, title string, deps ...string) *Task {
+		tk := New(id, title)
+		tk.Deps = deps
+		if err := reg.Add(tk); err != nil {
+			t.Fatal(err)
+		}
+		return tk
+	}
+	setup := add("t-001", "Setup")
+	add("t-002", "Schema", "t-001")
+	add("t-003", "Fixtures")
+	api := add("t-004", "API", "t-002", "t-003")
+	add("t-005", "Docs")
+	api.OptionalDeps = []string{"t-005"}
+
+	setup.SetStatus(StatusInProgress)
+	setup.SetStatus(StatusComplete)
+
+	tree, err := reg.DepTree("t-004", 0)
+	if err != nil {
+		t.Fatalf("DepTree failed: %v", err)
+	}
+
+	var b strings.Builder
+	tree.Render(&b)
+	want := `t-004 API [pending]
+├── t-002 Schema [pending]
+│   └── t-001 Setup [complete]
+├── t-003 Fixtures [pending]
+└── t-005 Docs [pending] (optional)
+`
+	if b.String() != want {
+		t.Errorf("unexpected tree:\n%s\nwant:\n%s", b.String(), want)
+	}
+
+	// A depth of one stops below the direct deps
+	shallow, _ := reg.DepTree("t-004", 1)
+	if !shallow.Deps[0].Truncated || len(shallow.Deps[0].Deps) != 0 || shallow.Deps[1].Truncated {
+		t.Errorf("expected only t-002 truncated, got %+v %+v", shallow.Deps[0], shallow.Deps[1])
+	}
+
+	if _, err := reg.DepTree("t-999", 0); err == nil {
+		t.Error("expected error for unknown task")
+	}
+}
+
+func TestDepTreeCycle(t *testing.T) {
+	// Load does not reject cycles, so build one directly
+	reg := NewRegistry()
+	a, b := New("a", "A"), New("b", "B")
+	a.Deps, b.Deps = []string{"b"}, []string{"a"}
+	reg.tasks["a"], reg.tasks["b"] = a, b
+
+	tree, err := reg.DepTree("a", 0)
+	if err != nil {
+		t.Fatalf("DepTree failed: %v", err)
+	}
+	if got := tree.Deps[0].Deps[0]; got.ID != "a" || !got.Cycle || len(got.Deps) != 0 {
+		t.Errorf("expected a cycle back to a, got %+v", got)
+	}
+}
@@ -0,0 +1,55 @@
+package tools
+
+import "math"
+
+// Int returns the integer argument key. JSON numbers arrive as float64, so
+// whole floats are converted; fractional or out-of-range ones are not.
+func (a Args) Int(key string) (int, bool) {
+	switch v := a[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		if v < math.MinInt || v > math.MaxInt {
+			return 0, false
+		}
+		return int(v), true
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt || v >= math.MaxInt {
+			return 0, false
+		}
+		return int(v), true
+	}
+	return 0, false
+}
+
+// String returns the string argument key.
+func (a Args) String(key string) (string, bool) {
+	s, ok := a[key].(string)
+	return s, ok
+}
+
+// Bool returns the boolean argument key.
+func (a Args) Bool(key string) (bool, bool) {
+	b, ok := a[key].(bool)
+	return b, ok
+}
+
+// StringSlice returns the string array argument key. JSON arrays arrive as
+// []any; they convert only if every element is a string.
+func (a Args) StringSlice(key string) ([]string, bool) {
+	switch v := a[key].(type) {
+	case []string:
+		return v, true
+	case []any:
+		out := make([]string, len(v))
+		for i, elem := range v {
+			s, ok := elem.(string)
+			if !ok {
+				return nil, false
+			}
+			out[i] = s
+		}
+		return out, true
+	}
+	return nil, false
+}
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestArgsInt(t *testing.T) {
+	var args Args
+	if err := json.Unmarshal([]byte(`{"limit": 5, "ratio": 2.5, "name": "5", "big": 1e300}`), &args); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := args.Int("limit"); !ok || got != 5 {
+		t.Errorf("Int(limit) = %d, %v; want 5, true", got, ok)
+	}
+	for _, key := range []string{"ratio", "name", "big", "missing"} {
+		if got, ok := args.Int(key); ok {
+			t.Errorf("Int(%s) = %d, expected no integer", key, got)
+		}
+	}
+
+	if got, ok := (Args{"n": 7}).Int("n"); !ok || got != 7 {
+		t.Errorf("Int(n) = %d, %v; want 7, true", got, ok)
+	}
+}
+
+func TestArgsStringBoolSlice(t *testing.T) {
+	var args Args
+	if err := json.Unmarshal([]byte(`{"id": "t-001", "force": true, "ids": ["a", "b"], "mixed": ["a", 1]}`), &args); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, ok := args.String("id"); !ok || got != "t-001" {
+		t.Errorf("String(id) = %q, %v", got, ok)
+	}
+	if _, ok := args.String("force"); ok {
+		t.Error("String(force) should not convert a bool")
+	}
+	if got, ok := args.Bool("force"); !ok || !got {
+		t.Errorf("Bool(force) = %v, %v", got, ok)
+	}
+	if got, ok := args.StringSlice("ids"); !ok || !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("StringSlice(ids) = %v, %v", got, ok)
+	}
+	if got, ok := args.StringSlice("mixed"); ok {
+		t.Errorf("StringSlice(mixed) = %v, expected no slice", got)
+	}
+}
@@ -1,30 +1,74 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/richgo/flo/pkg/quota"
 	"github.com/richgo/flo/pkg/task"
 )
 
-// TestRunner is the interface for running tests.
+// TestRunner is the interface for running tests. Run should stop and return
+// ctx's error when ctx is cancelled.
 type TestRunner interface {
-	Run(taskID string) (pass bool, output string, err error)
+	Run(ctx context.Context, taskID string) (pass bool, output string, err error)
+}
+
+// CompletionChecker runs checks beyond the tests that must pass before a
+// task is completed. When the TestRunner given to NewEASTools also
+// implements it, eas_task_complete runs the checks after the tests pass.
+// CheckCompletion returns the failing check and its output, or an empty
+// failed if every check passed.
+type CompletionChecker interface {
+	CheckCompletion(ctx context.Context, taskID string) (failed, output string, err error)
+}
+
+// QuotaChecker reports backend quota state. It is satisfied by *quota.Tracker.
+type QuotaChecker interface {
+	IsExhausted(backend string) bool
+	GetUsage(backend string) (*quota.Usage, bool)
+}
+
+// QuotaGuard stops eas_task_claim from claiming a task whose backend has no
+// quota left, since the run would fail immediately after the claim.
+type QuotaGuard struct {
+	Tracker QuotaChecker
+	Backend func(t *task.Task) string // Resolves the backend a task runs on
 }
 
 // EASToolsConfig holds the configuration for EAS tools.
 type EASToolsConfig struct {
 	SpecPath string // Path to SPEC.md
+
+	// ConcurrentTaskCalls lets calls that change the same task run
+	// concurrently, for callers that serialize them already. By default
+	// they run one at a time per task.
+	ConcurrentTaskCalls bool
+}
+
+// NewEASTools creates a tool registry with all EAS tools registered and
+// the default configuration. A nil quotaGuard disables the quota check on
+// claim. Tools that change a task run one at a time per task.
+func NewEASTools(taskReg *task.Registry, testRunner TestRunner, quotaGuard *QuotaGuard) *Registry {
+	return NewEASToolsWithConfig(taskReg, testRunner, quotaGuard, EASToolsConfig{})
 }
 
-// NewEASTools creates a tool registry with all EAS tools registered.
-func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
+// NewEASToolsWithConfig is NewEASTools with the given configuration.
+func NewEASToolsWithConfig(taskReg *task.Registry, testRunner TestRunner, quotaGuard *QuotaGuard, cfg EASToolsConfig) *Registry {
 	reg := NewRegistry()
+	locks := newTaskLocks()
+	if cfg.ConcurrentTaskCalls {
+		locks = nil
+	}
 
 	// eas_task_list
-	reg.Register(New(
+	reg.Register(NewContext(
 		"eas_task_list",
-		"List tasks with optional filters. Returns JSON array of tasks.",
+		"List tasks with optional filters and pagination. Returns JSON object with tasks, total and offset.",
 		map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -36,15 +80,31 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 					"type":        "string",
 					"description": "Filter by repository name",
 				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": "Maximum number of tasks to return (default: all)",
+				},
+				"offset": map[string]any{
+					"type":        "integer",
+					"description": "Number of tasks to skip (default: 0)",
+				},
+				"sort": map[string]any{
+					"type":        "string",
+					"description": "Sort key: priority, created, updated, id (default: priority)",
+				},
+				"desc": map[string]any{
+					"type":        "boolean",
+					"description": "Sort descending; for priority, descending puts the most urgent first (default: true for priority, false otherwise)",
+				},
 			},
 		},
-		func(args Args) (string, error) {
+		func(ctx context.Context, args Args) (string, error) {
 			return handleTaskList(taskReg, args)
 		},
 	))
 
 	// eas_task_get
-	reg.Register(New(
+	reg.Register(NewContext(
 		"eas_task_get",
 		"Get detailed information about a specific task.",
 		map[string]any{
@@ -57,15 +117,15 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
-		func(args Args) (string, error) {
+		func(ctx context.Context, args Args) (string, error) {
 			return handleTaskGet(taskReg, args)
 		},
 	))
 
 	// eas_task_claim
-	reg.Register(New(
+	reg.Register(NewContext(
 		"eas_task_claim",
-		"Claim a task (sets status to in_progress). Task must be pending with all deps complete.",
+		"Claim a task (sets status to in_progress). Task must be pending with all deps complete and all optional deps complete or failed.",
 		map[string]any{
 			"type": "object",
 			"properties": map[string]any{
@@ -76,13 +136,14 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
-		func(args Args) (string, error) {
-			return handleTaskClaim(taskReg, args)
+		func(ctx context.Context, args Args) (string, error) {
+			defer locks.guard(args)()
+			return handleTaskClaim(taskReg, quotaGuard, args)
 		},
 	))
 
 	// eas_task_complete
-	reg.Register(New(
+	reg.Register(NewContext(
 		"eas_task_complete",
 		"Mark task as complete. Runs tests first - will fail if tests don't pass.",
 		map[string]any{
@@ -95,13 +156,42 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
+		func(ctx context.Context, args Args) (string, error) {
+			defer locks.guard(args)()
+			return handleTaskComplete(ctx, taskReg, testRunner, args)
+		},
+	))
+
+	// eas_task_note
+	reg.Register(New(
+		"eas_task_note",
+		"Leave a note on a task, e.g. why it was reopened or a gotcha found while working on it. Notes cannot be edited or removed.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"task_id": map[string]any{
+					"type":        "string",
+					"description": "Task ID to add the note to",
+				},
+				"text": map[string]any{
+					"type":        "string",
+					"description": "Note text",
+				},
+				"author": map[string]any{
+					"type":        "string",
+					"description": "Who is leaving the note (default: agent)",
+				},
+			},
+			"required": []any{"task_id", "text"},
+		},
 		func(args Args) (string, error) {
-			return handleTaskComplete(taskReg, testRunner, args)
+			defer locks.guard(args)()
+			return handleTaskNote(taskReg, args)
 		},
 	))
 
 	// eas_run_tests
-	reg.Register(New(
+	reg.Register(NewContext(
 		"eas_run_tests",
 		"Run tests for a task. Returns test output and pass/fail status.",
 		map[string]any{
@@ -114,20 +204,109 @@ func NewEASTools(taskReg *task.Registry, testRunner TestRunner) *Registry {
 			},
 			"required": []any{"task_id"},
 		},
-		func(args Args) (string, error) {
-			return handleRunTests(testRunner, args)
+		func(ctx context.Context, args Args) (string, error) {
+			return handleRunTests(ctx, testRunner, args)
+		},
+	))
+
+	// eas_help
+	reg.Register(NewContext(
+		"eas_help",
+		"List available tools with their descriptions. Returns JSON object with tools.",
+		map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"schemas": map[string]any{
+					"type":        "boolean",
+					"description": "Include each tool's input schema",
+				},
+			},
+		},
+		func(ctx context.Context, args Args) (string, error) {
+			return handleHelp(reg, args)
 		},
 	))
 
 	return reg
 }
 
+// handleHelp describes the registry's tools at call time, so tools
+// registered after NewEASTools are included.
+func handleHelp(reg *Registry, args Args) (string, error) {
+	schemas, _ := args.Bool("schemas")
+
+	result := map[string]any{
+		"tools": reg.Describe(schemas),
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to serialize tools: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// taskListResult is the eas_task_list response: one page of tasks plus the
+// total number of matching tasks.
+type taskListResult struct {
+	Tasks  []*task.Task `json:"tasks"`
+	Total  int          `json:"total"`
+	Offset int          `json:"offset"`
+}
+
+// taskListSortKeys are the sort keys eas_task_list accepts.
+var taskListSortKeys = []string{"priority", "created", "updated", "id"}
+
+// taskListLess orders tasks ascending by each sort key. Priority ascends
+// from least to most urgent, so a descending sort puts the lowest Priority
+// value first, matching GetReadyFiltered.
+var taskListLess = map[string]func(a, b *task.Task) bool{
+	"priority": func(a, b *task.Task) bool { return a.Priority > b.Priority },
+	"created":  func(a, b *task.Task) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"updated":  func(a, b *task.Task) bool { return a.UpdatedAt.Before(b.UpdatedAt) },
+	"id":       func(a, b *task.Task) bool { return a.ID < b.ID },
+}
+
 func handleTaskList(taskReg *task.Registry, args Args) (string, error) {
+	limit, hasLimit, err := intArg(args, "limit")
+	if err != nil {
+		return "", err
+	}
+	offset, _, err := intArg(args, "offset")
+	if err != nil {
+		return "", err
+	}
+
 	var tasks []*task.Task
 
 	// Apply filters
-	statusFilter, hasStatus := args["status"].(string)
-	repoFilter, hasRepo := args["repo"].(string)
+	statusFilter, hasStatus := args.String("status")
+	repoFilter, hasRepo := args.String("repo")
+
+	if hasStatus && !task.Status(statusFilter).IsValid() {
+		valid := make([]string, 0, len(task.Statuses()))
+		for _, s := range task.Statuses() {
+			valid = append(valid, string(s))
+		}
+		return "", ErrInvalidArgs("invalid status '%s' (valid: %s)", statusFilter, strings.Join(valid, ", ")).
+			WithDetail("status", statusFilter).
+			WithDetail("valid", valid)
+	}
+
+	sortKey, hasSort := args.String("sort")
+	if !hasSort {
+		sortKey = "priority"
+	}
+	if _, ok := taskListLess[sortKey]; !ok {
+		return "", ErrInvalidArgs("invalid sort '%s' (valid: %s)", sortKey, strings.Join(taskListSortKeys, ", ")).
+			WithDetail("sort", sortKey).
+			WithDetail("valid", taskListSortKeys)
+	}
+	desc, hasDesc := args.Bool("desc")
+	if !hasDesc {
+		desc = sortKey == "priority"
+	}
 
 	if hasStatus && hasRepo {
 		// Both filters
@@ -145,12 +324,32 @@ func handleTaskList(taskReg *task.Registry, args Args) (string, error) {
 		tasks = taskReg.List()
 	}
 
-	// Handle nil slice
-	if tasks == nil {
-		tasks = []*task.Task{}
+	// Sort so pages are stable across calls
+	less := taskListLess[sortKey]
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := tasks[i], tasks[j]
+		if desc {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	result := taskListResult{Tasks: []*task.Task{}, Total: len(tasks), Offset: offset}
+	if offset < len(tasks) {
+		end := len(tasks)
+		if hasLimit && offset+limit < end {
+			end = offset + limit
+		}
+		result.Tasks = tasks[offset:end]
 	}
 
-	data, err := json.MarshalIndent(tasks, "", "  ")
+	data, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize tasks: %w", err)
 	}
@@ -158,18 +357,44 @@ func handleTaskList(taskReg *task.Registry, args Args) (string, error) {
 	return string(data), nil
 }
 
+// intArg reads an optional non-negative integer argument.
+func intArg(args Args, name string) (value int, ok bool, err error) {
+	if args[name] == nil {
+		return 0, false, nil
+	}
+	value, ok = args.Int(name)
+	if !ok {
+		return 0, false, ErrInvalidArgs("%s must be an integer", name)
+	}
+	if value < 0 {
+		return 0, false, ErrInvalidArgs("%s must be non-negative, got %d", name, value)
+	}
+	return value, true, nil
+}
+
 func handleTaskGet(taskReg *task.Registry, args Args) (string, error) {
-	taskID, ok := args["task_id"].(string)
+	taskID, ok := args.String("task_id")
 	if !ok {
-		return "", fmt.Errorf("task_id is required")
+		return "", ErrInvalidArgs("task_id is required")
 	}
 
 	t, err := taskReg.Get(taskID)
 	if err != nil {
-		return "", err
+		return "", ErrNotFound("%v", err).WithDetail("task_id", taskID)
 	}
 
-	data, err := json.MarshalIndent(t, "", "  ")
+	// Include the related tasks' titles and statuses so callers need not
+	// look each one up
+	view := struct {
+		*task.Task
+		RelatedTasks []relatedTask `json:"related_tasks,omitempty"`
+	}{Task: t}
+	related, _ := taskReg.GetRelated(taskID)
+	for _, rel := range related {
+		view.RelatedTasks = append(view.RelatedTasks, relatedTask{ID: rel.ID, Title: rel.Title, Status: rel.Status})
+	}
+
+	data, err := json.MarshalIndent(view, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize task: %w", err)
 	}
@@ -177,30 +402,52 @@ func handleTaskGet(taskReg *task.Registry, args Args) (string, error) {
 	return string(data), nil
 }
 
-func handleTaskClaim(taskReg *task.Registry, args Args) (string, error) {
-	taskID, ok := args["task_id"].(string)
+// relatedTask summarizes a related task in eas_task_get output.
+type relatedTask struct {
+	ID     string      `json:"id"`
+	Title  string      `json:"title"`
+	Status task.Status `json:"status"`
+}
+
+func handleTaskClaim(taskReg *task.Registry, quotaGuard *QuotaGuard, args Args) (string, error) {
+	taskID, ok := args.String("task_id")
 	if !ok {
-		return "", fmt.Errorf("task_id is required")
+		return "", ErrInvalidArgs("task_id is required")
 	}
 
 	t, err := taskReg.Get(taskID)
 	if err != nil {
-		return "", err
+		return "", ErrNotFound("%v", err).WithDetail("task_id", taskID)
 	}
 
 	// Check if task is pending
 	if t.Status != task.StatusPending {
-		return "", fmt.Errorf("task '%s' is not pending (status: %s)", taskID, t.Status)
+		return "", ErrConflict("task '%s' is not pending (status: %s)", taskID, t.Status).
+			WithDetail("task_id", taskID).
+			WithDetail("status", string(t.Status))
 	}
 
-	// Check if all deps are complete
+	// Check if all deps are complete and all optional deps have finished
+	optional := make(map[string]bool, len(t.OptionalDeps))
+	for _, id := range t.OptionalDeps {
+		optional[id] = true
+	}
 	deps, _ := taskReg.GetDeps(taskID)
 	for _, dep := range deps {
+		if optional[dep.ID] && dep.IsTerminal() {
+			continue
+		}
 		if dep.Status != task.StatusComplete {
-			return "", fmt.Errorf("dependency '%s' is not complete (status: %s)", dep.ID, dep.Status)
+			return "", ErrConflict("dependency '%s' is not complete (status: %s)", dep.ID, dep.Status).
+				WithDetail("task_id", taskID).
+				WithDetail("dependency", dep.ID)
 		}
 	}
 
+	if err := quotaGuard.check(t); err != nil {
+		return "", err
+	}
+
 	// Claim the task
 	if err := t.SetStatus(task.StatusInProgress); err != nil {
 		return "", err
@@ -212,30 +459,70 @@ func handleTaskClaim(taskReg *task.Registry, args Args) (string, error) {
 	return fmt.Sprintf("Task '%s' claimed successfully", taskID), nil
 }
 
-func handleTaskComplete(taskReg *task.Registry, testRunner TestRunner, args Args) (string, error) {
-	taskID, ok := args["task_id"].(string)
+// check returns a CodeQuotaExhausted error if t's backend is exhausted.
+func (g *QuotaGuard) check(t *task.Task) error {
+	if g == nil || g.Tracker == nil || g.Backend == nil {
+		return nil
+	}
+
+	backend := g.Backend(t)
+	if !g.Tracker.IsExhausted(backend) {
+		return nil
+	}
+
+	err := &ToolError{
+		Code:    CodeQuotaExhausted,
+		Message: fmt.Sprintf("quota exhausted for backend '%s'", backend),
+	}
+	err.WithDetail("task_id", t.ID).WithDetail("backend", backend)
+	if usage, ok := g.Tracker.GetUsage(backend); ok && !usage.RetryAfter.IsZero() {
+		err.Message += fmt.Sprintf(" - retry after %s (in %s)",
+			usage.RetryAfter.Format(time.RFC3339), time.Until(usage.RetryAfter).Round(time.Minute))
+		err.WithDetail("retry_after", usage.RetryAfter)
+	}
+	return err
+}
+
+func handleTaskComplete(ctx context.Context, taskReg *task.Registry, testRunner TestRunner, args Args) (string, error) {
+	taskID, ok := args.String("task_id")
 	if !ok {
-		return "", fmt.Errorf("task_id is required")
+		return "", ErrInvalidArgs("task_id is required")
 	}
 
 	t, err := taskReg.Get(taskID)
 	if err != nil {
-		return "", err
+		return "", ErrNotFound("%v", err).WithDetail("task_id", taskID)
 	}
 
 	// Check if task is in progress
 	if t.Status != task.StatusInProgress {
-		return "", fmt.Errorf("task '%s' is not in progress (status: %s)", taskID, t.Status)
+		return "", ErrConflict("task '%s' is not in progress (status: %s)", taskID, t.Status).
+			WithDetail("task_id", taskID).
+			WithDetail("status", string(t.Status))
 	}
 
 	// Run tests if test runner is configured
 	if testRunner != nil {
-		pass, output, err := testRunner.Run(taskID)
+		pass, output, err := testRunner.Run(ctx, taskID)
 		if err != nil {
 			return "", fmt.Errorf("failed to run tests: %w", err)
 		}
 		if !pass {
-			return "", fmt.Errorf("tests failed - cannot complete task:\n%s", output)
+			return "", ErrConflict("tests failed - cannot complete task:\n%s", output).
+				WithDetail("task_id", taskID)
+		}
+	}
+
+	// Run completion checks after the tests
+	if checker, ok := testRunner.(CompletionChecker); ok {
+		failed, output, err := checker.CheckCompletion(ctx, taskID)
+		if err != nil {
+			return "", fmt.Errorf("failed to run completion checks: %w", err)
+		}
+		if failed != "" {
+			return "", ErrConflict("completion check %q failed - cannot complete task:\n%s", failed, output).
+				WithDetail("task_id", taskID).
+				WithDetail("check", failed)
 		}
 	}
 
@@ -250,17 +537,47 @@ func handleTaskComplete(taskReg *task.Registry, testRunner TestRunner, args Args
 	return fmt.Sprintf("Task '%s' completed successfully", taskID), nil
 }
 
-func handleRunTests(testRunner TestRunner, args Args) (string, error) {
-	taskID, ok := args["task_id"].(string)
+// defaultNoteAuthor is the author of notes left through eas_task_note
+// without one.
+const defaultNoteAuthor = "agent"
+
+func handleTaskNote(taskReg *task.Registry, args Args) (string, error) {
+	taskID, ok := args.String("task_id")
+	if !ok {
+		return "", ErrInvalidArgs("task_id is required")
+	}
+	text, _ := args.String("text")
+	if strings.TrimSpace(text) == "" {
+		return "", ErrInvalidArgs("text is required")
+	}
+	author, _ := args.String("author")
+	if author == "" {
+		author = defaultNoteAuthor
+	}
+
+	t, err := taskReg.Get(taskID)
+	if err != nil {
+		return "", ErrNotFound("%v", err).WithDetail("task_id", taskID)
+	}
+	t.AddNote(author, text)
+	if err := taskReg.Update(t); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Note added to task '%s'", taskID), nil
+}
+
+func handleRunTests(ctx context.Context, testRunner TestRunner, args Args) (string, error) {
+	taskID, ok := args.String("task_id")
 	if !ok {
-		return "", fmt.Errorf("task_id is required")
+		return "", ErrInvalidArgs("task_id is required")
 	}
 
 	if testRunner == nil {
 		return "No test runner configured", nil
 	}
 
-	pass, output, err := testRunner.Run(taskID)
+	pass, output, err := testRunner.Run(ctx, taskID)
 	if err != nil {
 		return "", fmt.Errorf("failed to run tests: %w", err)
 	}
@@ -1,10 +1,15 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/richgo/flo/pkg/quota"
 	"github.com/richgo/flo/pkg/task"
 )
 
@@ -29,7 +34,7 @@ func setupTestRegistry() *task.Registry {
 
 func TestEASTaskList(t *testing.T) {
 	taskReg := setupTestRegistry()
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 
 	// List all
 	result, err := tools.Get("eas_task_list")
@@ -42,13 +47,66 @@ func TestEASTaskList(t *testing.T) {
 		t.Fatalf("execution failed: %v", err)
 	}
 
-	var tasks []map[string]any
-	if err := json.Unmarshal([]byte(output), &tasks); err != nil {
+	var list taskListResult
+	if err := json.Unmarshal([]byte(output), &list); err != nil {
 		t.Fatalf("failed to parse output: %v", err)
 	}
 
-	if len(tasks) != 3 {
-		t.Errorf("expected 3 tasks, got %d", len(tasks))
+	if len(list.Tasks) != 3 || list.Total != 3 || list.Offset != 0 {
+		t.Errorf("expected all 3 tasks, got %d (total %d, offset %d)", len(list.Tasks), list.Total, list.Offset)
+	}
+}
+
+func TestEASTaskListPage(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tools := NewEASTools(taskReg, nil, nil)
+	tool, _ := tools.Get("eas_task_list")
+
+	// JSON numbers arrive as float64
+	output, err := tool.Execute(Args{"limit": float64(2), "offset": float64(1)})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+
+	var result taskListResult
+	json.Unmarshal([]byte(output), &result)
+
+	if result.Total != 3 || result.Offset != 1 {
+		t.Errorf("expected total 3 offset 1, got total %d offset %d", result.Total, result.Offset)
+	}
+	if len(result.Tasks) != 2 || result.Tasks[0].ID != "ua-002" || result.Tasks[1].ID != "ua-003" {
+		t.Errorf("expected ua-002 and ua-003, got %+v", result.Tasks)
+	}
+}
+
+func TestEASTaskListOffsetBeyondEnd(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tools := NewEASTools(taskReg, nil, nil)
+	tool, _ := tools.Get("eas_task_list")
+
+	output, err := tool.Execute(Args{"offset": 10})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+
+	var result taskListResult
+	json.Unmarshal([]byte(output), &result)
+
+	if len(result.Tasks) != 0 || result.Total != 3 || result.Offset != 10 {
+		t.Errorf("expected empty page with total 3, got %d tasks (total %d, offset %d)", len(result.Tasks), result.Total, result.Offset)
+	}
+}
+
+func TestEASTaskListNegativePagination(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tools := NewEASTools(taskReg, nil, nil)
+	tool, _ := tools.Get("eas_task_list")
+
+	if _, err := tool.Execute(Args{"limit": -1}); err == nil {
+		t.Error("expected error for negative limit")
+	}
+	if _, err := tool.Execute(Args{"offset": -1}); err == nil {
+		t.Error("expected error for negative offset")
 	}
 }
 
@@ -60,37 +118,141 @@ func TestEASTaskListFilterByStatus(t *testing.T) {
 	task1.SetStatus(task.StatusInProgress)
 	taskReg.Update(task1)
 
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_list")
 
 	output, _ := tool.Execute(Args{"status": "pending"})
 
-	var tasks []map[string]any
-	json.Unmarshal([]byte(output), &tasks)
+	var result taskListResult
+	json.Unmarshal([]byte(output), &result)
+	tasks := result.Tasks
 
 	if len(tasks) != 2 {
 		t.Errorf("expected 2 pending tasks, got %d", len(tasks))
 	}
 }
 
+func TestEASTaskListInvalidStatus(t *testing.T) {
+	tools := NewEASTools(setupTestRegistry(), nil, nil)
+
+	_, err := tools.Execute("eas_task_list", Args{"status": "done"})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != CodeInvalidArgs {
+		t.Fatalf("expected invalid_args error, got %v", err)
+	}
+	for _, want := range []string{"done", "pending", "in_progress", "complete", "failed"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %q", want, err.Error())
+		}
+	}
+
+	// A valid status still combines with the repo filter
+	output, err := tools.Execute("eas_task_list", Args{"status": "pending", "repo": "ios"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var result taskListResult
+	json.Unmarshal([]byte(output), &result)
+	if len(result.Tasks) != 1 || result.Tasks[0].ID != "ua-003" {
+		t.Errorf("expected only ua-003, got %+v", result.Tasks)
+	}
+}
+
 func TestEASTaskListFilterByRepo(t *testing.T) {
 	taskReg := setupTestRegistry()
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_list")
 
 	output, _ := tool.Execute(Args{"repo": "android"})
 
-	var tasks []map[string]any
-	json.Unmarshal([]byte(output), &tasks)
+	var result taskListResult
+	json.Unmarshal([]byte(output), &result)
+	tasks := result.Tasks
 
 	if len(tasks) != 2 {
 		t.Errorf("expected 2 android tasks, got %d", len(tasks))
 	}
 }
 
+func TestEASTaskListSort(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	reg := task.NewRegistry()
+	for _, tk := range []struct {
+		id       string
+		priority int
+		created  time.Duration
+		updated  time.Duration
+	}{
+		{"t-a", 2, 3 * time.Hour, 1 * time.Hour},
+		{"t-b", 1, 1 * time.Hour, 2 * time.Hour},
+		{"t-c", 3, 2 * time.Hour, 3 * time.Hour},
+		{"t-d", 1, 4 * time.Hour, 0},
+	} {
+		nt := task.New(tk.id, tk.id)
+		nt.Priority = tk.priority
+		nt.CreatedAt = base.Add(tk.created)
+		nt.UpdatedAt = base.Add(tk.updated)
+		reg.Add(nt)
+	}
+	tools := NewEASTools(reg, nil, nil)
+
+	tests := []struct {
+		name string
+		args Args
+		want []string
+	}{
+		{"default is priority desc", Args{}, []string{"t-b", "t-d", "t-a", "t-c"}},
+		{"priority", Args{"sort": "priority"}, []string{"t-b", "t-d", "t-a", "t-c"}},
+		{"priority asc", Args{"sort": "priority", "desc": false}, []string{"t-c", "t-a", "t-b", "t-d"}},
+		{"created", Args{"sort": "created"}, []string{"t-b", "t-c", "t-a", "t-d"}},
+		{"created desc", Args{"sort": "created", "desc": true}, []string{"t-d", "t-a", "t-c", "t-b"}},
+		{"updated", Args{"sort": "updated"}, []string{"t-d", "t-a", "t-b", "t-c"}},
+		{"updated desc", Args{"sort": "updated", "desc": true}, []string{"t-c", "t-b", "t-a", "t-d"}},
+		{"id", Args{"sort": "id"}, []string{"t-a", "t-b", "t-c", "t-d"}},
+		{"id desc", Args{"sort": "id", "desc": true}, []string{"t-d", "t-c", "t-b", "t-a"}},
+		{"ascending default", Args{"desc": false}, []string{"t-c", "t-a", "t-b", "t-d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := tools.Execute("eas_task_list", tt.args)
+			if err != nil {
+				t.Fatalf("execution failed: %v", err)
+			}
+			var result taskListResult
+			if err := json.Unmarshal([]byte(output), &result); err != nil {
+				t.Fatalf("invalid JSON output: %v", err)
+			}
+
+			var got []string
+			for _, tk := range result.Tasks {
+				got = append(got, tk.ID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestEASTaskListInvalidSort(t *testing.T) {
+	tools := NewEASTools(setupTestRegistry(), nil, nil)
+
+	_, err := tools.Execute("eas_task_list", Args{"sort": "title"})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != CodeInvalidArgs {
+		t.Fatalf("expected invalid_args error, got %v", err)
+	}
+	for _, want := range []string{"title", "priority", "created", "updated", "id"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected error to mention %q, got %q", want, err.Error())
+		}
+	}
+}
+
 func TestEASTaskGet(t *testing.T) {
 	taskReg := setupTestRegistry()
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_get")
 
 	output, err := tool.Execute(Args{"task_id": "ua-001"})
@@ -109,9 +271,72 @@ func TestEASTaskGet(t *testing.T) {
 	}
 }
 
+func TestEASTaskGetRelated(t *testing.T) {
+	taskReg := setupTestRegistry()
+	rel := task.New("ua-010", "Document OAuth")
+	rel.Related = []string{"ua-001"}
+	if err := taskReg.Add(rel); err != nil {
+		t.Fatal(err)
+	}
+	tools := NewEASTools(taskReg, nil, nil)
+	tool, _ := tools.Get("eas_task_get")
+
+	output, err := tool.Execute(Args{"task_id": "ua-001"})
+	if err != nil {
+		t.Fatalf("execution failed: %v", err)
+	}
+
+	var taskData struct {
+		ID           string        `json:"id"`
+		RelatedTasks []relatedTask `json:"related_tasks"`
+	}
+	json.Unmarshal([]byte(output), &taskData)
+
+	if taskData.ID != "ua-001" || len(taskData.RelatedTasks) != 1 {
+		t.Fatalf("expected ua-001 with one related task, got %s", output)
+	}
+	if got := taskData.RelatedTasks[0]; got.ID != "ua-010" || got.Status != task.StatusPending {
+		t.Errorf("unexpected related task: %+v", got)
+	}
+}
+
+func TestEASTaskNote(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tools := NewEASTools(taskReg, nil, nil)
+
+	if _, err := tools.Execute("eas_task_note", Args{"task_id": "ua-001", "text": "Reopened: token refresh was missing"}); err != nil {
+		t.Fatalf("eas_task_note failed: %v", err)
+	}
+	if _, err := tools.Execute("eas_task_note", Args{"task_id": "ua-001", "text": "Needs the staging client ID", "author": "dana"}); err != nil {
+		t.Fatalf("eas_task_note failed: %v", err)
+	}
+	if _, err := tools.Execute("eas_task_note", Args{"task_id": "ua-001", "text": "  "}); err == nil {
+		t.Error("expected error for an empty note")
+	}
+
+	output, err := tools.Execute("eas_task_get", Args{"task_id": "ua-001"})
+	if err != nil {
+		t.Fatalf("eas_task_get failed: %v", err)
+	}
+	var taskData struct {
+		Notes []task.Note `json:"notes"`
+	}
+	json.Unmarshal([]byte(output), &taskData)
+
+	if len(taskData.Notes) != 2 {
+		t.Fatalf("expected 2 notes in eas_task_get output, got %s", output)
+	}
+	if n := taskData.Notes[0]; n.Author != "agent" || n.Message != "Reopened: token refresh was missing" || n.Time.IsZero() {
+		t.Errorf("unexpected first note: %+v", n)
+	}
+	if n := taskData.Notes[1]; n.Author != "dana" || n.Message != "Needs the staging client ID" {
+		t.Errorf("unexpected second note: %+v", n)
+	}
+}
+
 func TestEASTaskGetNotFound(t *testing.T) {
 	taskReg := setupTestRegistry()
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_get")
 
 	_, err := tool.Execute(Args{"task_id": "nonexistent"})
@@ -122,7 +347,7 @@ func TestEASTaskGetNotFound(t *testing.T) {
 
 func TestEASTaskClaim(t *testing.T) {
 	taskReg := setupTestRegistry()
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_claim")
 
 	output, err := tool.Execute(Args{"task_id": "ua-001"})
@@ -141,6 +366,49 @@ func TestEASTaskClaim(t *testing.T) {
 	}
 }
 
+func TestEASTaskClaimQuotaExhausted(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.RecordError("claude", time.Hour)
+
+	guard := &QuotaGuard{
+		Tracker: tracker,
+		Backend: func(t *task.Task) string { return "claude" },
+	}
+	tool, _ := NewEASTools(taskReg, nil, guard).Get("eas_task_claim")
+
+	_, err := tool.Execute(Args{"task_id": "ua-001"})
+	var toolErr *ToolError
+	if !errors.As(err, &toolErr) || toolErr.Code != CodeQuotaExhausted {
+		t.Fatalf("expected quota_exhausted error, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "retry after") {
+		t.Errorf("expected retry-after message, got %q", err.Error())
+	}
+
+	// The task was not claimed
+	tk, _ := taskReg.Get("ua-001")
+	if tk.Status != task.StatusPending {
+		t.Errorf("expected task still pending, got %s", tk.Status)
+	}
+}
+
+func TestEASTaskClaimQuotaHealthy(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tracker := quota.New(filepath.Join(t.TempDir(), "quota.json"))
+	tracker.RecordError("copilot", time.Hour)
+
+	guard := &QuotaGuard{
+		Tracker: tracker,
+		Backend: func(t *task.Task) string { return "claude" },
+	}
+	tool, _ := NewEASTools(taskReg, nil, guard).Get("eas_task_claim")
+
+	if _, err := tool.Execute(Args{"task_id": "ua-001"}); err != nil {
+		t.Fatalf("expected claim to succeed, got %v", err)
+	}
+}
+
 func TestEASTaskClaimNotPending(t *testing.T) {
 	taskReg := setupTestRegistry()
 
@@ -149,7 +417,7 @@ func TestEASTaskClaimNotPending(t *testing.T) {
 	task1.SetStatus(task.StatusInProgress)
 	taskReg.Update(task1)
 
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_claim")
 
 	_, err := tool.Execute(Args{"task_id": "ua-001"})
@@ -160,7 +428,7 @@ func TestEASTaskClaimNotPending(t *testing.T) {
 
 func TestEASTaskClaimDepsIncomplete(t *testing.T) {
 	taskReg := setupTestRegistry()
-	tools := NewEASTools(taskReg, nil)
+	tools := NewEASTools(taskReg, nil, nil)
 	tool, _ := tools.Get("eas_task_claim")
 
 	// ua-002 depends on ua-001 which is not complete
@@ -170,13 +438,34 @@ func TestEASTaskClaimDepsIncomplete(t *testing.T) {
 	}
 }
 
+func TestEASTaskClaimOptionalDepFailed(t *testing.T) {
+	taskReg := setupTestRegistry()
+	opt := task.New("ua-004", "Optional analytics")
+	opt.OptionalDeps = []string{"ua-003"}
+	taskReg.Add(opt)
+	tools := NewEASTools(taskReg, nil, nil)
+
+	if _, err := tools.Execute("eas_task_claim", Args{"task_id": "ua-004"}); err == nil {
+		t.Fatal("expected error while the optional dependency is pending")
+	}
+
+	dep, _ := taskReg.Get("ua-003")
+	dep.SetStatus(task.StatusInProgress)
+	dep.SetStatus(task.StatusFailed)
+	taskReg.Update(dep)
+
+	if _, err := tools.Execute("eas_task_claim", Args{"task_id": "ua-004"}); err != nil {
+		t.Errorf("expected a failed optional dependency not to block the claim, got %v", err)
+	}
+}
+
 func TestEASTaskComplete(t *testing.T) {
 	taskReg := setupTestRegistry()
 
 	// Create a mock test runner that always passes
 	testRunner := &MockTestRunner{pass: true, output: "All tests passed"}
 
-	tools := NewEASTools(taskReg, testRunner)
+	tools := NewEASTools(taskReg, testRunner, nil)
 	
 	// First claim the task
 	claimTool, _ := tools.Get("eas_task_claim")
@@ -206,7 +495,7 @@ func TestEASTaskCompleteTestsFail(t *testing.T) {
 	// Create a mock test runner that fails
 	testRunner := &MockTestRunner{pass: false, output: "FAIL: TestAuth"}
 
-	tools := NewEASTools(taskReg, testRunner)
+	tools := NewEASTools(taskReg, testRunner, nil)
 	
 	// Claim first
 	claimTool, _ := tools.Get("eas_task_claim")
@@ -230,7 +519,7 @@ func TestEASRunTests(t *testing.T) {
 	taskReg := setupTestRegistry()
 	testRunner := &MockTestRunner{pass: true, output: "PASS: 5 tests"}
 
-	tools := NewEASTools(taskReg, testRunner)
+	tools := NewEASTools(taskReg, testRunner, nil)
 	tool, _ := tools.Get("eas_run_tests")
 
 	output, err := tool.Execute(Args{"task_id": "ua-001"})
@@ -243,12 +532,130 @@ func TestEASRunTests(t *testing.T) {
 	}
 }
 
+func TestEASHelp(t *testing.T) {
+	reg := NewEASTools(setupTestRegistry(), nil, nil)
+
+	// Tools added after construction are listed too
+	reg.Register(New("eas_extra", "Dynamically added", nil, func(args Args) (string, error) {
+		return "", nil
+	}))
+
+	output, err := reg.Execute("eas_help", Args{})
+	if err != nil {
+		t.Fatalf("eas_help failed: %v", err)
+	}
+
+	var result struct {
+		Tools []ToolInfo `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		t.Fatalf("failed to parse output: %v", err)
+	}
+
+	listed := map[string]ToolInfo{}
+	for _, info := range result.Tools {
+		listed[info.Name] = info
+	}
+	for _, tool := range reg.List() {
+		info, ok := listed[tool.Name]
+		if !ok {
+			t.Errorf("tool %s missing from eas_help output", tool.Name)
+			continue
+		}
+		if info.Description != tool.Description {
+			t.Errorf("tool %s: expected description %q, got %q", tool.Name, tool.Description, info.Description)
+		}
+		if info.Schema != nil {
+			t.Errorf("tool %s: expected no schema by default", tool.Name)
+		}
+	}
+	if len(result.Tools) != len(reg.List()) {
+		t.Errorf("expected %d tools, got %d", len(reg.List()), len(result.Tools))
+	}
+}
+
+func TestEASHelpSchemas(t *testing.T) {
+	reg := NewEASTools(setupTestRegistry(), nil, nil)
+
+	output, err := reg.Execute("eas_help", Args{"schemas": true})
+	if err != nil {
+		t.Fatalf("eas_help failed: %v", err)
+	}
+
+	var result struct {
+		Tools []ToolInfo `json:"tools"`
+	}
+	json.Unmarshal([]byte(output), &result)
+
+	for _, info := range result.Tools {
+		if info.Schema == nil {
+			t.Errorf("tool %s: expected schema", info.Name)
+		}
+	}
+}
+
+func TestEASTypedErrors(t *testing.T) {
+	reg := NewEASTools(setupTestRegistry(), nil, nil)
+
+	tests := []struct {
+		tool     string
+		args     Args
+		wantCode string
+	}{
+		{"eas_task_get", Args{"task_id": "ua-999"}, CodeNotFound},
+		{"eas_task_get", Args{}, CodeInvalidArgs},
+		{"eas_task_claim", Args{"task_id": "ua-002"}, CodeConflict}, // dep incomplete
+		{"eas_task_complete", Args{"task_id": "ua-001"}, CodeConflict},
+		{"eas_task_list", Args{"limit": -1}, CodeInvalidArgs},
+	}
+
+	for _, tt := range tests {
+		_, err := reg.Execute(tt.tool, tt.args)
+		var toolErr *ToolError
+		if !errors.As(err, &toolErr) {
+			t.Errorf("%s %v: expected *ToolError, got %v", tt.tool, tt.args, err)
+			continue
+		}
+		if toolErr.Code != tt.wantCode {
+			t.Errorf("%s %v: expected code %q, got %q", tt.tool, tt.args, tt.wantCode, toolErr.Code)
+		}
+	}
+}
+
+func TestRunTestsCancel(t *testing.T) {
+	runner := &blockingTestRunner{started: make(chan struct{})}
+	reg := NewEASTools(setupTestRegistry(), runner, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-runner.started
+		cancel()
+	}()
+
+	_, err := reg.ExecuteContext(ctx, "eas_run_tests", Args{"task_id": "ua-001"})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
 // MockTestRunner is a test double for the test runner
 type MockTestRunner struct {
 	pass   bool
 	output string
 }
 
-func (m *MockTestRunner) Run(taskID string) (bool, string, error) {
+func (m *MockTestRunner) Run(ctx context.Context, taskID string) (bool, string, error) {
 	return m.pass, m.output, nil
 }
+
+// blockingTestRunner blocks until its context is cancelled, signalling
+// started once the run is underway.
+type blockingTestRunner struct {
+	started chan struct{}
+}
+
+func (b *blockingTestRunner) Run(ctx context.Context, taskID string) (bool, string, error) {
+	close(b.started)
+	<-ctx.Done()
+	return false, "", ctx.Err()
+}
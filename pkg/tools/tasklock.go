@@ -0,0 +1,57 @@
+package tools
+
+import "sync"
+
+// taskLocks serializes tool calls on the same task. The registry guards
+// each Get and Update, but a tool reads a task, checks it and writes it
+// back in separate steps, so two calls on one task (a claim racing a
+// complete, say) could otherwise interleave between the check and the
+// write. Calls on different tasks still run concurrently.
+type taskLocks struct {
+	mu    sync.Mutex
+	locks map[string]*taskLock
+}
+
+// taskLock is one task's mutex and the number of calls holding or waiting
+// for it, so it can be dropped once no call needs it.
+type taskLock struct {
+	sync.Mutex
+	refs int
+}
+
+func newTaskLocks() *taskLocks {
+	return &taskLocks{locks: make(map[string]*taskLock)}
+}
+
+// lock blocks until no other call holds id and returns the func that
+// releases it.
+func (l *taskLocks) lock(id string) func() {
+	l.mu.Lock()
+	tl, ok := l.locks[id]
+	if !ok {
+		tl = &taskLock{}
+		l.locks[id] = tl
+	}
+	tl.refs++
+	l.mu.Unlock()
+
+	tl.Lock()
+	return func() {
+		tl.Unlock()
+		l.mu.Lock()
+		if tl.refs--; tl.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// guard locks the task named by the task_id argument. Calls without one
+// are left to the handler to reject. A nil taskLocks locks nothing.
+func (l *taskLocks) guard(args Args) func() {
+	id, ok := args.String("task_id")
+	if l == nil || !ok || id == "" {
+		return func() {}
+	}
+	return l.lock(id)
+}
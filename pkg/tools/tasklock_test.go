@@ -0,0 +1,121 @@
+package tools
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestTaskLocks(t *testing.T) {
+	locks := newTaskLocks()
+
+	// Different tasks do not block each other
+	unlockA := locks.lock("ua-001")
+	unlockB := locks.lock("ua-002")
+	unlockB()
+	unlockA()
+
+	var holders, maxHolders int32
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer locks.lock("ua-001")()
+			n := atomic.AddInt32(&holders, 1)
+			for {
+				m := atomic.LoadInt32(&maxHolders)
+				if n <= m || atomic.CompareAndSwapInt32(&maxHolders, m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt32(&holders, -1)
+		}()
+	}
+	wg.Wait()
+
+	if maxHolders != 1 {
+		t.Errorf("expected one holder at a time, got %d", maxHolders)
+	}
+	if len(locks.locks) != 0 {
+		t.Errorf("expected released locks to be dropped, got %d", len(locks.locks))
+	}
+}
+
+func TestTaskLocksDisabled(t *testing.T) {
+	// A nil taskLocks, as NewEASToolsWithConfig uses for ConcurrentTaskCalls,
+	// lets calls on the same task hold their guards at once
+	var locks *taskLocks
+	unlockA := locks.guard(Args{"task_id": "ua-001"})
+	unlockB := locks.guard(Args{"task_id": "ua-001"})
+	unlockB()
+	unlockA()
+}
+
+// slowTestRunner passes after a short delay, widening the window between
+// eas_task_complete's status check and its update.
+type slowTestRunner struct{}
+
+func (slowTestRunner) Run(ctx context.Context, taskID string) (bool, string, error) {
+	time.Sleep(5 * time.Millisecond)
+	return true, "ok", nil
+}
+
+func TestEASToolsConcurrentClaimComplete(t *testing.T) {
+	taskReg := setupTestRegistry()
+	tools := NewEASTools(taskReg, slowTestRunner{}, nil)
+
+	const callers = 8
+	var claimed, completed int32
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < callers; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := tools.Execute("eas_task_claim", Args{"task_id": "ua-001"}); err == nil {
+				atomic.AddInt32(&claimed, 1)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := tools.Execute("eas_task_complete", Args{"task_id": "ua-001"}); err == nil {
+				atomic.AddInt32(&completed, 1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if claimed != 1 {
+		t.Errorf("expected exactly one claim to succeed, got %d", claimed)
+	}
+	if completed > 1 {
+		t.Errorf("expected at most one complete to succeed, got %d", completed)
+	}
+
+	want := task.StatusInProgress
+	if completed == 1 {
+		want = task.StatusComplete
+	}
+	got, _ := taskReg.Get("ua-001")
+	if got.Status != want {
+		t.Errorf("expected final status %s, got %s", want, got.Status)
+	}
+	found := false
+	for _, tk := range taskReg.ListByStatus(want) {
+		if tk.ID == "ua-001" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the registry to list ua-001 as %s", want)
+	}
+}
@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// testWaitDelay bounds how long a cancelled test command's output is
+// drained before Run returns.
+const testWaitDelay = 2 * time.Second
+
+// TestCommandResolver returns the working directory and shell command used
+// to test a task. An empty command means no tests are configured.
+type TestCommandResolver func(t *task.Task) (dir, command string, err error)
+
+// CompletionCheckResolver returns the working directory and shell commands
+// that must pass before a task is completed, in the order they run.
+type CompletionCheckResolver func(t *task.Task) (dir string, commands []string, err error)
+
+// CommandTestRunner runs a task's configured test command through the shell.
+// A zero exit code passes; any other exit code fails. Combined stdout and
+// stderr are returned as the output.
+type CommandTestRunner struct {
+	taskReg *task.Registry
+	resolve TestCommandResolver
+	checks  CompletionCheckResolver
+}
+
+// NewCommandTestRunner creates a test runner that looks up tasks in taskReg
+// and resolves their test command with resolve.
+func NewCommandTestRunner(taskReg *task.Registry, resolve TestCommandResolver) *CommandTestRunner {
+	return &CommandTestRunner{
+		taskReg: taskReg,
+		resolve: resolve,
+	}
+}
+
+// Run runs the test command for taskID. Cancelling ctx kills the command
+// and returns ctx's error.
+func (r *CommandTestRunner) Run(ctx context.Context, taskID string) (bool, string, error) {
+	t, err := r.taskReg.Get(taskID)
+	if err != nil {
+		return false, "", err
+	}
+
+	dir, command, err := r.resolve(t)
+	if err != nil {
+		return false, "", err
+	}
+	if command == "" {
+		return true, "No test command configured", nil
+	}
+	return runShell(ctx, dir, command)
+}
+
+// SetCompletionChecks sets how the runner finds a task's completion checks.
+// Without it, CheckCompletion runs no checks.
+func (r *CommandTestRunner) SetCompletionChecks(resolve CompletionCheckResolver) {
+	r.checks = resolve
+}
+
+// CheckCompletion runs taskID's completion checks in order, stopping at the
+// first that fails and returning its command and output. failed is empty
+// when every check passes. Cancelling ctx kills the running check and
+// returns ctx's error.
+func (r *CommandTestRunner) CheckCompletion(ctx context.Context, taskID string) (failed, output string, err error) {
+	if r.checks == nil {
+		return "", "", nil
+	}
+	t, err := r.taskReg.Get(taskID)
+	if err != nil {
+		return "", "", err
+	}
+
+	dir, commands, err := r.checks(t)
+	if err != nil {
+		return "", "", err
+	}
+	for _, command := range commands {
+		pass, output, err := runShell(ctx, dir, command)
+		if err != nil {
+			return "", output, err
+		}
+		if !pass {
+			return command, output, nil
+		}
+	}
+	return "", "", nil
+}
+
+// runShell runs command through the shell in dir, reporting whether it
+// exited zero along with its combined output.
+func runShell(ctx context.Context, dir, command string) (bool, string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	// Children of the shell may keep the output pipe open after it is
+	// killed; stop waiting for them shortly after cancellation.
+	cmd.WaitDelay = testWaitDelay
+	output, err := cmd.CombinedOutput()
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return false, string(output), ctxErr
+	}
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return false, string(output), nil
+		}
+		return false, string(output), fmt.Errorf("failed to run %q: %w", command, err)
+	}
+
+	return true, string(output), nil
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestCommandTestRunnerPassFail(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "marker"), []byte("here"), 0644)
+
+	tests := []struct {
+		name     string
+		command  string
+		wantPass bool
+		wantOut  string
+	}{
+		{"exit 0 passes", "echo ok; exit 0", true, "ok"},
+		{"exit 1 fails", "echo FAIL: TestAuth >&2; exit 1", false, "FAIL: TestAuth"},
+		{"runs in task dir", "cat marker", true, "here"},
+		{"no command configured", "", true, "No test command configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reg := task.NewRegistry()
+			reg.Add(task.New("t-001", "Test"))
+
+			runner := NewCommandTestRunner(reg, func(tk *task.Task) (string, string, error) {
+				return dir, tt.command, nil
+			})
+
+			pass, output, err := runner.Run(context.Background(), "t-001")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if pass != tt.wantPass {
+				t.Errorf("expected pass=%v, got %v (output %q)", tt.wantPass, pass, output)
+			}
+			if !strings.Contains(output, tt.wantOut) {
+				t.Errorf("expected output to contain %q, got %q", tt.wantOut, output)
+			}
+		})
+	}
+}
+
+func TestCommandTestRunnerErrors(t *testing.T) {
+	reg := task.NewRegistry()
+	reg.Add(task.New("t-001", "Test"))
+
+	runner := NewCommandTestRunner(reg, func(tk *task.Task) (string, string, error) {
+		return "", "", errors.New("unknown repo \"web\"")
+	})
+
+	if _, _, err := runner.Run(context.Background(), "t-404"); err == nil {
+		t.Error("expected error for unknown task")
+	}
+	if _, _, err := runner.Run(context.Background(), "t-001"); err == nil || !strings.Contains(err.Error(), "web") {
+		t.Errorf("expected resolver error, got %v", err)
+	}
+}
+
+func TestCommandTestRunnerCancel(t *testing.T) {
+	reg := task.NewRegistry()
+	reg.Add(task.New("t-001", "Test"))
+
+	runner := NewCommandTestRunner(reg, func(tk *task.Task) (string, string, error) {
+		return t.TempDir(), "sleep 30", nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, _, err := runner.Run(ctx, "t-001")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected command to be killed, ran for %s", elapsed)
+	}
+}
+
+func TestCommandTestRunnerGatesCompletion(t *testing.T) {
+	reg := task.NewRegistry()
+	tk := task.New("t-001", "Test")
+	tk.Status = task.StatusInProgress
+	reg.Add(tk)
+
+	runner := NewCommandTestRunner(reg, func(tk *task.Task) (string, string, error) {
+		return t.TempDir(), "exit 1", nil
+	})
+	tools := NewEASTools(reg, runner, nil)
+
+	if _, err := tools.Execute("eas_task_complete", Args{"task_id": "t-001"}); err == nil {
+		t.Error("expected completion to fail when the test command fails")
+	}
+}
+
+func TestCommandTestRunnerCompletionChecks(t *testing.T) {
+	tests := []struct {
+		name       string
+		checks     []string
+		wantErr    string
+		wantStatus task.Status
+	}{
+		{"passing check", []string{"true", "echo lint ok"}, "", task.StatusComplete},
+		{"failing check", []string{"true", "echo missing docs; exit 2", "touch never-run"}, `completion check "echo missing docs; exit 2" failed`, task.StatusInProgress},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			reg := task.NewRegistry()
+			tk := task.New("t-001", "Test")
+			tk.Status = task.StatusInProgress
+			reg.Add(tk)
+
+			runner := NewCommandTestRunner(reg, func(tk *task.Task) (string, string, error) {
+				return dir, "exit 0", nil
+			})
+			runner.SetCompletionChecks(func(tk *task.Task) (string, []string, error) {
+				return dir, tt.checks, nil
+			})
+			tools := NewEASTools(reg, runner, nil)
+
+			_, err := tools.Execute("eas_task_complete", Args{"task_id": "t-001"})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) || !strings.Contains(err.Error(), "missing docs") {
+					t.Fatalf("expected error naming the failed check, got %v", err)
+				}
+				if _, statErr := os.Stat(filepath.Join(dir, "never-run")); statErr == nil {
+					t.Error("expected checks after the failing one to be skipped")
+				}
+			}
+			if got, _ := reg.Get("t-001"); got.Status != tt.wantStatus {
+				t.Errorf("expected status %s, got %s", tt.wantStatus, got.Status)
+			}
+		})
+	}
+}
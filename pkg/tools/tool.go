@@ -2,9 +2,15 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"runtime/debug"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/richgo/flo/pkg/logging"
 )
 
 // Args represents the arguments passed to a tool handler.
@@ -13,23 +19,71 @@ type Args map[string]any
 // Handler is the function signature for tool handlers.
 type Handler func(args Args) (string, error)
 
+// HandlerContext is the function signature for tool handlers that honour
+// cancellation. Long-running handlers should return once ctx is done.
+type HandlerContext func(ctx context.Context, args Args) (string, error)
+
 // Tool represents an operation that agents can invoke.
+// HandlerContext takes precedence over Handler when both are set.
 type Tool struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	Schema      map[string]any `json:"schema,omitempty"`
-	Handler     Handler        `json:"-"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	Schema         map[string]any `json:"schema,omitempty"`
+	Handler        Handler        `json:"-"`
+	HandlerContext HandlerContext `json:"-"`
 }
 
+// Tool error codes, carried by ToolError so callers can tell failures apart.
+const (
+	CodeNotFound       = "not_found"
+	CodeInvalidArgs    = "invalid_args"
+	CodeUnauthorized   = "unauthorized"
+	CodeConflict       = "conflict"
+	CodeQuotaExhausted = "quota_exhausted"
+	CodeInternal       = "internal"
+)
+
 // ToolError represents an error from tool execution.
 type ToolError struct {
-	Message string
+	Code    string         // Machine-readable error code (e.g., CodeNotFound)
+	Message string         // Human-readable message
+	Details map[string]any // Optional structured context
 }
 
 func (e *ToolError) Error() string {
 	return e.Message
 }
 
+// WithDetail sets a detail on the error and returns it for chaining.
+func (e *ToolError) WithDetail(key string, value any) *ToolError {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+// ErrNotFound returns a ToolError for a missing tool or resource.
+func ErrNotFound(format string, a ...any) *ToolError {
+	return &ToolError{Code: CodeNotFound, Message: fmt.Sprintf(format, a...)}
+}
+
+// ErrInvalidArgs returns a ToolError for missing or malformed arguments.
+func ErrInvalidArgs(format string, a ...any) *ToolError {
+	return &ToolError{Code: CodeInvalidArgs, Message: fmt.Sprintf(format, a...)}
+}
+
+// ErrUnauthorized returns a ToolError for a denied operation.
+func ErrUnauthorized(format string, a ...any) *ToolError {
+	return &ToolError{Code: CodeUnauthorized, Message: fmt.Sprintf(format, a...)}
+}
+
+// ErrConflict returns a ToolError for an operation the resource's current
+// state does not allow.
+func ErrConflict(format string, a ...any) *ToolError {
+	return &ToolError{Code: CodeConflict, Message: fmt.Sprintf(format, a...)}
+}
+
 // New creates a new Tool with the given parameters.
 func New(name, description string, schema map[string]any, handler Handler) *Tool {
 	return &Tool{
@@ -40,26 +94,60 @@ func New(name, description string, schema map[string]any, handler Handler) *Tool
 	}
 }
 
-// Execute runs the tool with the given arguments.
-// It validates arguments against the schema (if present) before calling the handler.
+// NewContext creates a new Tool whose handler receives a context.
+func NewContext(name, description string, schema map[string]any, handler HandlerContext) *Tool {
+	return &Tool{
+		Name:           name,
+		Description:    description,
+		Schema:         schema,
+		HandlerContext: handler,
+	}
+}
+
+// Execute runs the tool with the given arguments and a background context.
 func (t *Tool) Execute(args Args) (string, error) {
+	return t.ExecuteContext(context.Background(), args)
+}
+
+// ExecuteContext runs the tool with the given arguments.
+// It validates arguments against the schema (if present) before calling the handler.
+// Validation failures are returned as CodeInvalidArgs ToolErrors; a ToolError
+// returned by the handler is passed through unchanged. A context that is
+// already done fails before the handler runs; a plain Handler is not
+// interrupted by later cancellation. A handler that panics fails with a
+// CodeInternal ToolError instead of taking down the caller.
+func (t *Tool) ExecuteContext(ctx context.Context, args Args) (result string, err error) {
 	if t.Schema != nil {
 		if err := t.validateArgs(args); err != nil {
-			return "", fmt.Errorf("argument validation failed: %w", err)
+			return "", ErrInvalidArgs("argument validation failed: %v", err).WithDetail("tool", t.Name)
 		}
 	}
 
-	if t.Handler == nil {
-		return "", fmt.Errorf("tool '%s' has no handler", t.Name)
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
 
-	return t.Handler(args)
+	defer func() {
+		if r := recover(); r != nil {
+			logging.L().Error("tool handler panicked", "tool", t.Name, "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			result, err = "", (&ToolError{Code: CodeInternal, Message: fmt.Sprintf("tool '%s' panicked: %v", t.Name, r)}).WithDetail("tool", t.Name)
+		}
+	}()
+
+	switch {
+	case t.HandlerContext != nil:
+		return t.HandlerContext(ctx, args)
+	case t.Handler != nil:
+		return t.Handler(args)
+	default:
+		return "", fmt.Errorf("tool '%s' has no handler", t.Name)
+	}
 }
 
 // validateArgs validates arguments against the JSON schema.
 func (t *Tool) validateArgs(args Args) error {
 	schema := t.Schema
-	
+
 	// Check if it's an object schema
 	schemaType, _ := schema["type"].(string)
 	if schemaType != "object" {
@@ -175,7 +263,7 @@ func (r *Registry) Get(name string) (*Tool, error) {
 
 	tool, exists := r.tools[name]
 	if !exists {
-		return nil, fmt.Errorf("tool '%s' not found", name)
+		return nil, ErrNotFound("tool '%s' not found", name).WithDetail("tool", name)
 	}
 	return tool, nil
 }
@@ -192,11 +280,94 @@ func (r *Registry) List() []*Tool {
 	return tools
 }
 
+// ToolInfo summarizes a registered tool for listings.
+type ToolInfo struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schema      map[string]any `json:"schema,omitempty"`
+}
+
+// Describe returns the registered tools sorted by name, with their schemas
+// when includeSchemas is set.
+func (r *Registry) Describe(includeSchemas bool) []ToolInfo {
+	tools := r.List()
+	sort.Slice(tools, func(i, j int) bool {
+		return tools[i].Name < tools[j].Name
+	})
+
+	infos := make([]ToolInfo, 0, len(tools))
+	for _, tool := range tools {
+		info := ToolInfo{Name: tool.Name, Description: tool.Description}
+		if includeSchemas {
+			info.Schema = tool.Schema
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// SchemaExportVersion is the version of the ExportSchemas format. It changes
+// only when the shape of the export does.
+const SchemaExportVersion = 1
+
+// schemaExport is the document written by ExportSchemas.
+type schemaExport struct {
+	Version int              `json:"version"`
+	Tools   []exportedSchema `json:"tools"`
+}
+
+// exportedSchema is one tool in a schema export. Unlike ToolInfo, the
+// schema is always present, as {} for tools without one.
+type exportedSchema struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	Schema      map[string]any `json:"schema"`
+}
+
+// ExportSchemas returns the name, description and schema of every
+// registered tool as indented JSON for client code generation. Tools are
+// sorted by name and map keys are sorted, so the output for an unchanged
+// registry is identical byte for byte.
+func (r *Registry) ExportSchemas() ([]byte, error) {
+	export := schemaExport{Version: SchemaExportVersion, Tools: []exportedSchema{}}
+	for _, info := range r.Describe(true) {
+		schema := info.Schema
+		if schema == nil {
+			schema = map[string]any{}
+		}
+		export.Tools = append(export.Tools, exportedSchema{
+			Name:        info.Name,
+			Description: info.Description,
+			Schema:      schema,
+		})
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode tool schemas: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
 // Execute runs a tool by name with the given arguments.
 func (r *Registry) Execute(name string, args Args) (string, error) {
+	return r.ExecuteContext(context.Background(), name, args)
+}
+
+// ExecuteContext runs a tool by name with the given arguments, passing ctx
+// to its handler.
+func (r *Registry) ExecuteContext(ctx context.Context, name string, args Args) (string, error) {
 	tool, err := r.Get(name)
 	if err != nil {
 		return "", err
 	}
-	return tool.Execute(args)
+
+	start := time.Now()
+	result, err := tool.ExecuteContext(ctx, args)
+	if err != nil {
+		logging.L().Debug("tool failed", "tool", name, "duration", time.Since(start), "error", err.Error())
+	} else {
+		logging.L().Debug("tool executed", "tool", name, "duration", time.Since(start))
+	}
+	return result, err
 }
@@ -1,6 +1,10 @@
 package tools
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"strings"
 	"testing"
 )
@@ -65,6 +69,42 @@ func TestToolExecuteWithSchema(t *testing.T) {
 	}
 }
 
+func TestToolExecuteContextCancel(t *testing.T) {
+	started := make(chan struct{})
+	tool := NewContext("wait", "Waits for cancellation", nil, func(ctx context.Context, args Args) (string, error) {
+		close(started)
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	if _, err := tool.ExecuteContext(ctx, Args{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestToolExecuteContextAdaptsHandler(t *testing.T) {
+	tool := New("greet", "Greets a person", nil, func(args Args) (string, error) {
+		return "hi", nil
+	})
+
+	result, err := tool.ExecuteContext(context.Background(), Args{})
+	if err != nil || result != "hi" {
+		t.Fatalf("expected plain handler to run, got %q, %v", result, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := tool.ExecuteContext(ctx, Args{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected cancelled context to fail before the handler, got %v", err)
+	}
+}
+
 func TestToolRegistryRegisterAndGet(t *testing.T) {
 	reg := NewRegistry()
 
@@ -166,3 +206,119 @@ func TestToolHandlerError(t *testing.T) {
 		t.Errorf("expected error message 'intentional failure', got '%s'", err.Error())
 	}
 }
+
+func TestToolHandlerPanic(t *testing.T) {
+	// Each handler's panic message
+	handlers := map[string]*Tool{
+		"assignment to entry in nil map": New("buggy", "Panics", nil, func(args Args) (string, error) {
+			var m map[string]int
+			m["boom"]++
+			return "unreachable", nil
+		}),
+		"boom": NewContext("buggy", "Panics", nil, func(ctx context.Context, args Args) (string, error) {
+			panic("boom")
+		}),
+	}
+
+	for msg, tool := range handlers {
+		t.Run(msg, func(t *testing.T) {
+			result, err := tool.Execute(Args{})
+
+			var toolErr *ToolError
+			if !errors.As(err, &toolErr) {
+				t.Fatalf("expected *ToolError, got %T: %v", err, err)
+			}
+			if toolErr.Code != CodeInternal || !strings.Contains(toolErr.Message, msg) || toolErr.Details["tool"] != "buggy" {
+				t.Errorf("expected internal error with the panic message, got %+v", toolErr)
+			}
+			if result != "" {
+				t.Errorf("expected no result, got %q", result)
+			}
+		})
+	}
+}
+
+func TestToolErrorCodeSurvivesRegistryExecute(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(New("lookup", "Finds things", map[string]any{
+		"type":     "object",
+		"required": []any{"id"},
+	}, func(args Args) (string, error) {
+		return "", ErrNotFound("thing '%v' not found", args["id"]).WithDetail("id", args["id"])
+	}))
+
+	tests := []struct {
+		name     string
+		tool     string
+		args     Args
+		wantCode string
+	}{
+		{"handler error", "lookup", Args{"id": "x"}, CodeNotFound},
+		{"validation error", "lookup", Args{}, CodeInvalidArgs},
+		{"unknown tool", "missing", Args{}, CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := reg.Execute(tt.tool, tt.args)
+
+			var toolErr *ToolError
+			if !errors.As(err, &toolErr) {
+				t.Fatalf("expected *ToolError, got %T: %v", err, err)
+			}
+			if toolErr.Code != tt.wantCode {
+				t.Errorf("expected code %q, got %q", tt.wantCode, toolErr.Code)
+			}
+		})
+	}
+
+	_, err := reg.Execute("lookup", Args{"id": "x"})
+	var toolErr *ToolError
+	errors.As(err, &toolErr)
+	if toolErr.Details["id"] != "x" {
+		t.Errorf("expected details to survive, got %v", toolErr.Details)
+	}
+}
+
+func TestToolRegistryExportSchemasStable(t *testing.T) {
+	export := func() []byte {
+		// Map iteration order differs between registries; the export must not
+		// depend on it
+		reg := NewEASTools(setupTestRegistry(), nil, nil)
+		reg.Register(New("aa_plain", "No schema", nil, nil))
+		data, err := reg.ExportSchemas()
+		if err != nil {
+			t.Fatalf("ExportSchemas failed: %v", err)
+		}
+		return data
+	}
+
+	first := export()
+	for i := 0; i < 20; i++ {
+		if again := export(); !bytes.Equal(first, again) {
+			t.Fatalf("export changed between runs:\n%s\n---\n%s", first, again)
+		}
+	}
+
+	var doc struct {
+		Version int `json:"version"`
+		Tools   []struct {
+			Name   string         `json:"name"`
+			Schema map[string]any `json:"schema"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal(first, &doc); err != nil {
+		t.Fatalf("export is not valid JSON: %v", err)
+	}
+	if doc.Version != SchemaExportVersion || len(doc.Tools) < 2 {
+		t.Fatalf("unexpected export: %s", first)
+	}
+	if doc.Tools[0].Name != "aa_plain" || doc.Tools[0].Schema == nil {
+		t.Errorf("expected aa_plain first with an empty schema, got %+v", doc.Tools[0])
+	}
+	for i := 1; i < len(doc.Tools); i++ {
+		if doc.Tools[i-1].Name >= doc.Tools[i].Name {
+			t.Errorf("tools not sorted: %s before %s", doc.Tools[i-1].Name, doc.Tools[i].Name)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package workspace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// ErrEditUnchanged is returned by EditTask when the editor exits without
+// changing the task file.
+var ErrEditUnchanged = errors.New("task unchanged")
+
+// TaskEditor opens the file at path for editing and returns once the
+// editor exits. An error, such as a non-zero exit, aborts the edit.
+type TaskEditor func(path string) error
+
+// EditTask writes the task id to a temporary TASK-<id>.md, opens it with
+// edit and applies the saved result to the registry, the task file and
+// the workspace. When the edited task cannot be applied, for example
+// because it names an unknown dependency or creates a cycle, retry is
+// called with the error: returning true reopens the file with the edits
+// kept, returning false gives up with that error. Nothing is changed
+// unless the edit is applied.
+func (w *Workspace) EditTask(id string, edit TaskEditor, retry func(error) bool) (*task.Task, error) {
+	t, err := w.Tasks.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "flo-edit-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create edit directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, fmt.Sprintf("TASK-%s.md", id))
+	content := []byte(taskMarkdown(t))
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write task file: %w", err)
+	}
+
+	for {
+		if err := edit(path); err != nil {
+			return nil, fmt.Errorf("editor failed, task %s not changed: %w", id, err)
+		}
+		edited, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read edited task: %w", err)
+		}
+		if bytes.Equal(edited, content) {
+			return nil, ErrEditUnchanged
+		}
+		content = edited
+
+		updated, err := w.applyEdit(t, path)
+		if err == nil {
+			audit.Info("workspace.edit_task", "Task edited", map[string]interface{}{
+				"task_id": id,
+			})
+			return updated, nil
+		}
+		if retry == nil || !retry(err) {
+			return nil, err
+		}
+	}
+}
+
+// applyEdit parses the edited task file at path and, if it is valid,
+// replaces orig with it. The edit may change everything taskMarkdown
+// writes except the ID; a status change must be a valid transition.
+func (w *Workspace) applyEdit(orig *task.Task, path string) (*task.Task, error) {
+	parsed, err := task.ParseTaskFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.ID != orig.ID {
+		return nil, fmt.Errorf("task ID cannot be changed (was %s, now %s)", orig.ID, parsed.ID)
+	}
+
+	updated := *orig
+	updated.Title = parsed.Title
+	updated.Description = parsed.Description
+	updated.Model = parsed.Model
+	updated.Fallback = parsed.Fallback
+	updated.Fallbacks = parsed.Fallbacks
+	updated.Type = parsed.Type
+	updated.Priority = parsed.Priority
+	updated.Repo = parsed.Repo
+	updated.Group = parsed.Group
+	updated.Labels = parsed.Labels
+	updated.SpecRef = parsed.SpecRef
+	updated.EstimatedMinutes = parsed.EstimatedMinutes
+	updated.MaxRetries = parsed.MaxRetries
+	updated.Issue = parsed.Issue
+	updated.Env = parsed.Env
+	updated.Deps = parsed.Deps
+	updated.OptionalDeps = parsed.OptionalDeps
+	updated.Related = parsed.Related
+	updated.UpdatedAt = time.Now()
+	if parsed.Status != orig.Status {
+		if err := updated.SetStatus(parsed.Status); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := updated.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid task: %w", err)
+	}
+	for _, ref := range append([]string{updated.Model}, updated.FallbackChain()...) {
+		if err := config.ValidateModelRef(ref); err != nil {
+			return nil, err
+		}
+	}
+	if err := w.Tasks.Update(&updated); err != nil {
+		return nil, err
+	}
+	if err := w.writeTaskFile(&updated); err != nil {
+		return nil, w.restoreTask(orig, err)
+	}
+	if err := w.Save(); err != nil {
+		return nil, w.restoreTask(orig, err)
+	}
+	return &updated, nil
+}
+
+// restoreTask puts orig back in the registry and its task file after an
+// edit failed to apply with err, and returns err.
+func (w *Workspace) restoreTask(orig *task.Task, err error) error {
+	if rerr := w.Tasks.Update(orig); rerr != nil {
+		audit.Error("workspace.edit_task", "Failed to restore task", map[string]interface{}{
+			"task_id": orig.ID,
+			"error":   rerr.Error(),
+		})
+	}
+	if rerr := w.writeTaskFile(orig); rerr != nil {
+		audit.Error("workspace.edit_task", "Failed to restore task file", map[string]interface{}{
+			"task_id": orig.ID,
+			"error":   rerr.Error(),
+		})
+	}
+	return err
+}
@@ -0,0 +1,164 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// replaceEditor returns a TaskEditor that replaces old with new in the
+// file, as a person editing it would.
+func replaceEditor(old, new string) TaskEditor {
+	return func(path string) error {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(strings.Replace(string(data), old, new, 1)), 0644)
+	}
+}
+
+func TestWorkspaceEditTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	tk, _ := ws.CreateTask("Add login", "", nil, 0)
+	tk.Description = "Old description"
+	ws.Tasks.Update(tk)
+
+	edit := func(path string) error {
+		if err := replaceEditor("Old description", "Use OAuth, not passwords")(path); err != nil {
+			return err
+		}
+		return replaceEditor("status: pending", "status: pending\npriority: 2")(path)
+	}
+	updated, err := ws.EditTask(tk.ID, edit, nil)
+	if err != nil {
+		t.Fatalf("EditTask failed: %v", err)
+	}
+	if updated.Description != "Use OAuth, not passwords" || updated.Priority != 2 {
+		t.Errorf("expected edited description and priority, got %q and %d", updated.Description, updated.Priority)
+	}
+
+	// The edit is saved to the registry and the task file
+	reloaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	got, _ := reloaded.GetTask(tk.ID)
+	if got.Description != "Use OAuth, not passwords" || got.Priority != 2 {
+		t.Errorf("expected the edit to persist, got %q and %d", got.Description, got.Priority)
+	}
+	fromFile, err := task.ParseTaskFile(ws.taskFilePath(tk.ID))
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if !strings.HasPrefix(fromFile.Description, "Use OAuth, not passwords") {
+		t.Errorf("expected the task file to hold the new description, got %q", fromFile.Description)
+	}
+}
+
+func TestWorkspaceEditTaskRetry(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	dep, _ := ws.CreateTask("Schema", "", nil, 0)
+	tk, _ := ws.CreateTask("Migration", "", []string{dep.ID}, 0)
+
+	// Making the dependency depend on its dependent is a cycle
+	cycle := replaceEditor("status: pending", "status: pending\ndeps:\n  - "+tk.ID)
+	var retried []error
+	edits := 0
+	edit := func(path string) error {
+		edits++
+		if edits == 1 {
+			return cycle(path)
+		}
+		return replaceEditor("\ndeps:\n  - "+tk.ID, "\npriority: 1")(path)
+	}
+	updated, err := ws.EditTask(dep.ID, edit, func(err error) bool {
+		retried = append(retried, err)
+		return true
+	})
+	if err != nil {
+		t.Fatalf("EditTask failed: %v", err)
+	}
+	if len(retried) != 1 || !strings.Contains(retried[0].Error(), "circular") {
+		t.Errorf("expected one retry for the cycle, got %v", retried)
+	}
+	if len(updated.Deps) != 0 || updated.Priority != 1 {
+		t.Errorf("expected the second edit to apply, got deps %v priority %d", updated.Deps, updated.Priority)
+	}
+
+	// Giving up leaves the task as it was
+	_, err = ws.EditTask(dep.ID, replaceEditor("status: pending", "status: pending\ndeps:\n  - t-404"), func(error) bool {
+		return false
+	})
+	if err == nil || !strings.Contains(err.Error(), "t-404") {
+		t.Fatalf("expected unknown dependency error, got %v", err)
+	}
+	got, _ := ws.GetTask(dep.ID)
+	if len(got.Deps) != 0 {
+		t.Errorf("expected the rejected edit not to apply, got deps %v", got.Deps)
+	}
+}
+
+func TestWorkspaceEditTaskAborts(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	tk, _ := ws.CreateTask("Add login", "", nil, 0)
+
+	tests := []struct {
+		name    string
+		edit    TaskEditor
+		wantErr string
+	}{
+		{"unchanged", func(string) error { return nil }, ErrEditUnchanged.Error()},
+		{"editor fails", func(string) error { return errors.New("exit status 1") }, "exit status 1"},
+		{"id changed", replaceEditor("id: "+tk.ID, "id: t-999"), "cannot be changed"},
+		{"title removed", replaceEditor("# Add login", ""), "title"},
+		{"invalid transition", replaceEditor("status: pending", "status: complete"), "transition"},
+		{"bad model ref", replaceEditor("status: pending", "status: pending\nmodel: claud/opus"), "claud"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ws.EditTask(tk.ID, tt.edit, nil)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+			got, _ := ws.GetTask(tk.ID)
+			if got.Title != "Add login" || got.Status != task.StatusPending || got.Model != "" {
+				t.Errorf("expected the task to be unchanged, got %+v", got)
+			}
+		})
+	}
+}
+
+func TestWorkspaceEditTaskSaveFailure(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	tk, _ := ws.CreateTask("Add login", "", nil, 0)
+
+	// A directory in place of config.yaml makes Save fail
+	configPath := filepath.Join(ws.Root, ".flo", "config.yaml")
+	if err := os.Remove(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(configPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ws.EditTask(tk.ID, replaceEditor("# Add login", "# Add OAuth login"), nil); err == nil {
+		t.Fatal("expected EditTask to fail when saving fails")
+	}
+	if got, _ := ws.GetTask(tk.ID); got.Title != "Add login" {
+		t.Errorf("expected the registry to keep the old title, got %q", got.Title)
+	}
+	fromFile, err := task.ParseTaskFile(ws.taskFilePath(tk.ID))
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if fromFile.Title != "Add login" {
+		t.Errorf("expected the task file to keep the old title, got %q", fromFile.Title)
+	}
+}
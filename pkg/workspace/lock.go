@@ -0,0 +1,167 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/richgo/flo/pkg/audit"
+)
+
+const lockFileName = "lock"
+
+// lockPIDEnv is set to the PID of a process holding the workspace lock, so
+// the processes it starts, such as an agent's MCP server, can tell its lock
+// from one held by an unrelated process.
+const lockPIDEnv = "FLO_LOCK_PID"
+
+// LockedError is returned by Lock when another live process holds the lock.
+type LockedError struct {
+	PID int
+
+	// Inherited is set when the holder is the flo process that started
+	// this one, for example flo work running an agent whose MCP server
+	// calls back into the workspace.
+	Inherited bool
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("workspace is locked by PID %d (use --force to break the lock)", e.PID)
+}
+
+// LockPath returns the path of the workspace lock file.
+func (w *Workspace) LockPath() string {
+	return filepath.Join(w.Root, easDir, lockFileName)
+}
+
+// Lock acquires the advisory workspace lock, recording the current PID in
+// .flo/lock. A lock left behind by a process that is no longer running is
+// treated as stale and replaced. Once locked, tasks are reloaded from disk
+// so the holder sees changes made before it acquired the lock.
+func (w *Workspace) Lock() error {
+	if w.locked {
+		return nil
+	}
+
+	path := w.LockPath()
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, werr := fmt.Fprintf(f, "%d\n", os.Getpid())
+			cerr := f.Close()
+			if werr != nil || cerr != nil {
+				os.Remove(path)
+				return fmt.Errorf("failed to write lock file: %w", errors.Join(werr, cerr))
+			}
+			w.locked = true
+			os.Setenv(lockPIDEnv, strconv.Itoa(os.Getpid()))
+			audit.Info("workspace.lock", "Workspace locked", map[string]interface{}{
+				"pid": os.Getpid(),
+			})
+			return w.reloadTasks()
+		}
+		if !os.IsExist(err) {
+			return fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		pid := readLockPID(path)
+		if pid > 0 && processAlive(pid) {
+			return &LockedError{PID: pid, Inherited: os.Getenv(lockPIDEnv) == strconv.Itoa(pid)}
+		}
+
+		audit.Warn("workspace.lock", "Removing stale lock", map[string]interface{}{
+			"pid": pid,
+		})
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove stale lock: %w", err)
+		}
+	}
+
+	return fmt.Errorf("failed to acquire workspace lock at %s", path)
+}
+
+// Unlock releases the workspace lock if this Workspace holds it.
+func (w *Workspace) Unlock() error {
+	if !w.locked {
+		return nil
+	}
+	w.locked = false
+	os.Unsetenv(lockPIDEnv)
+
+	if err := os.Remove(w.LockPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	audit.Info("workspace.unlock", "Workspace unlocked", map[string]interface{}{
+		"pid": os.Getpid(),
+	})
+	return nil
+}
+
+// WithLock runs fn holding the workspace lock, on tasks reloaded from
+// disk, and saves the workspace if fn succeeds. If the lock is held by the
+// flo process that started this one, fn runs on the tasks as they are and
+// nothing is saved, since that process owns the run and saves its outcome.
+func (w *Workspace) WithLock(fn func() error) error {
+	if err := w.Lock(); err != nil {
+		var locked *LockedError
+		if errors.As(err, &locked) && locked.Inherited {
+			return fn()
+		}
+		return err
+	}
+	defer w.Unlock()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	return w.Save()
+}
+
+// BreakLock removes the workspace lock regardless of who holds it.
+func (w *Workspace) BreakLock() error {
+	pid := readLockPID(w.LockPath())
+	if err := os.Remove(w.LockPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	audit.Warn("workspace.lock", "Lock broken", map[string]interface{}{
+		"pid": pid,
+	})
+	return nil
+}
+
+// reloadTasks re-reads the task manifest from disk into the existing
+// registry, so tools and hooks bound to w.Tasks see the reloaded tasks.
+func (w *Workspace) reloadTasks() error {
+	manifestPath := filepath.Join(w.Root, easDir, tasksDir, manifestFile)
+	if _, err := os.Stat(manifestPath); err != nil {
+		return nil
+	}
+
+	if err := w.Tasks.Load(manifestPath); err != nil {
+		return fmt.Errorf("failed to load tasks: %w", err)
+	}
+	return nil
+}
+
+// readLockPID returns the PID recorded in a lock file, or 0 if unreadable.
+func readLockPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// processAlive reports whether a process with the given PID exists.
+func processAlive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
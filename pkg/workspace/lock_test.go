@@ -0,0 +1,149 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLockExclusive(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir, "test", "claude")
+
+	first, _ := Load(tmpDir)
+	second, _ := Load(tmpDir)
+
+	if err := first.Lock(); err != nil {
+		t.Fatalf("first Lock failed: %v", err)
+	}
+
+	err := second.Lock()
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected LockedError while first lock held, got %v", err)
+	}
+	if locked.PID != os.Getpid() {
+		t.Errorf("expected lock held by PID %d, got %d", os.Getpid(), locked.PID)
+	}
+
+	if err := first.Unlock(); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := second.Lock(); err != nil {
+		t.Errorf("expected Lock to succeed after Unlock, got %v", err)
+	}
+	second.Unlock()
+
+	if _, err := os.Stat(first.LockPath()); !os.IsNotExist(err) {
+		t.Error("expected lock file removed after Unlock")
+	}
+}
+
+func TestLockReplacesStaleLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	// A PID beyond the kernel's pid_max can never be alive
+	os.WriteFile(ws.LockPath(), []byte(fmt.Sprintf("%d\n", 1<<30)), 0644)
+
+	if err := ws.Lock(); err != nil {
+		t.Fatalf("expected stale lock to be replaced, got %v", err)
+	}
+	defer ws.Unlock()
+
+	if pid := readLockPID(ws.LockPath()); pid != os.Getpid() {
+		t.Errorf("expected lock to record PID %d, got %d", os.Getpid(), pid)
+	}
+}
+
+func TestBreakLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir, "test", "claude")
+
+	holder, _ := Load(tmpDir)
+	other, _ := Load(tmpDir)
+	holder.Lock()
+
+	if err := other.BreakLock(); err != nil {
+		t.Fatalf("BreakLock failed: %v", err)
+	}
+	if err := other.Lock(); err != nil {
+		t.Errorf("expected Lock to succeed after BreakLock, got %v", err)
+	}
+	other.Unlock()
+}
+
+func TestLockReloadsTasks(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir, "test", "claude")
+
+	stale, _ := Load(tmpDir)
+	writer, _ := Load(tmpDir)
+	writer.CreateTask("Added elsewhere", "", nil, 0)
+
+	if err := stale.Lock(); err != nil {
+		t.Fatalf("Lock failed: %v", err)
+	}
+	defer stale.Unlock()
+
+	if _, err := stale.GetTask("t-001"); err != nil {
+		t.Errorf("expected Lock to reload tasks from disk: %v", err)
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	tmpDir := t.TempDir()
+	Init(tmpDir, "test", "claude")
+	ws, _ := Load(tmpDir)
+	writer, _ := Load(tmpDir)
+	writer.CreateTask("Added elsewhere", "", nil, 0)
+
+	// fn sees tasks saved by others, and its changes are saved
+	err := ws.WithLock(func() error {
+		tk, err := ws.GetTask("t-001")
+		if err != nil {
+			return err
+		}
+		tk.Title = "Renamed"
+		return ws.Tasks.Update(tk)
+	})
+	if err != nil {
+		t.Fatalf("WithLock failed: %v", err)
+	}
+	if _, err := os.Stat(ws.LockPath()); !os.IsNotExist(err) {
+		t.Error("expected the lock released after WithLock")
+	}
+	reloaded, _ := Load(tmpDir)
+	if got, _ := reloaded.GetTask("t-001"); got == nil || got.Title != "Renamed" {
+		t.Errorf("expected the change saved, got %+v", got)
+	}
+
+	// A lock held by an unrelated process is an error
+	holder, _ := Load(tmpDir)
+	holder.Lock()
+	os.Unsetenv(lockPIDEnv)
+	ran := false
+	err = ws.WithLock(func() error { ran = true; return nil })
+	var locked *LockedError
+	if !errors.As(err, &locked) || ran {
+		t.Errorf("expected LockedError without running fn, got %v (ran %v)", err, ran)
+	}
+
+	// The lock of the process that started this one is shared, without saving
+	os.Setenv(lockPIDEnv, fmt.Sprint(os.Getpid()))
+	err = ws.WithLock(func() error {
+		ran = true
+		tk, _ := ws.GetTask("t-001")
+		tk.Title = "Unsaved"
+		return ws.Tasks.Update(tk)
+	})
+	if err != nil || !ran {
+		t.Errorf("expected fn to run under the inherited lock, got %v (ran %v)", err, ran)
+	}
+	holder.Unlock()
+	reloaded, _ = Load(tmpDir)
+	if got, _ := reloaded.GetTask("t-001"); got == nil || got.Title != "Renamed" {
+		t.Errorf("expected nothing saved under the inherited lock, got %+v", got)
+	}
+}
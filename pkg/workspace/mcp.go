@@ -0,0 +1,46 @@
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/richgo/flo/pkg/mcp"
+)
+
+// MCP client config location, relative to the workspace root.
+const (
+	mcpConfigDir  = ".eas"
+	mcpConfigFile = "mcp.json"
+)
+
+// MCPConfigPath returns the path of the MCP client config that points
+// backends at the EAS MCP server.
+func (w *Workspace) MCPConfigPath() string {
+	return filepath.Join(w.Root, mcpConfigDir, mcpConfigFile)
+}
+
+// WriteMCPConfig writes the MCP client config from the workspace's mcp
+// settings, creating its directory if needed. It is cheap enough to call
+// before claiming a task, so an unwritable path fails before any work starts.
+func (w *Workspace) WriteMCPConfig() error {
+	path := w.MCPConfigPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("cannot create MCP config directory %s: %w", filepath.Dir(path), err)
+	}
+
+	opts := mcp.LaunchOptions{Dir: w.Root}
+	if m := w.Config.MCP; m != nil {
+		opts.Name = m.Name
+		opts.Command = m.Command
+		opts.ExtraArgs = m.ExtraArgs
+		opts.Servers = make(map[string]mcp.ServerSpec, len(m.Servers))
+		for name, spec := range m.Servers {
+			opts.Servers[name] = mcp.ServerSpec(spec)
+		}
+	}
+	if err := mcp.WriteClientConfig(path, opts); err != nil {
+		return fmt.Errorf("cannot write MCP config %s (check the mcp settings in config.yaml and that the directory is writable): %w", path, err)
+	}
+	return nil
+}
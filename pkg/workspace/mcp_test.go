@@ -0,0 +1,98 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestWriteMCPConfigCreatesDir(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+
+	if err := ws.WriteMCPConfig(); err != nil {
+		t.Fatalf("WriteMCPConfig failed: %v", err)
+	}
+	if _, err := os.Stat(ws.MCPConfigPath()); err != nil {
+		t.Errorf("expected MCP config to be written: %v", err)
+	}
+}
+
+func TestClaimTaskUnwritableMCPConfig(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, ws *Workspace)
+	}{
+		{
+			name: "config path is a directory",
+			setup: func(t *testing.T, ws *Workspace) {
+				if err := os.MkdirAll(ws.MCPConfigPath(), 0755); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "config directory is read-only",
+			setup: func(t *testing.T, ws *Workspace) {
+				if os.Geteuid() == 0 {
+					t.Skip("permissions are not enforced for root")
+				}
+				dir := filepath.Dir(ws.MCPConfigPath())
+				if err := os.MkdirAll(dir, 0555); err != nil {
+					t.Fatal(err)
+				}
+				t.Cleanup(func() { os.Chmod(dir, 0755) })
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			ws, _ := Init(tmpDir, "test", "claude")
+			ws.CreateTask("First", "", nil, 0)
+			tt.setup(t, ws)
+
+			err := ws.ClaimTask("t-001", ws.WriteMCPConfig)
+			if err == nil || !strings.Contains(err.Error(), ws.MCPConfigPath()) {
+				t.Fatalf("expected error naming the MCP config path, got %v", err)
+			}
+
+			// Neither the registry nor the task file records a claim
+			got, _ := ws.Tasks.Get("t-001")
+			if got.Status != task.StatusPending {
+				t.Errorf("expected task to stay pending, got %s", got.Status)
+			}
+			reloaded, err := Load(tmpDir)
+			if err != nil {
+				t.Fatalf("Load failed: %v", err)
+			}
+			if got, _ := reloaded.Tasks.Get("t-001"); got.Status != task.StatusPending {
+				t.Errorf("expected saved task to stay pending, got %s", got.Status)
+			}
+		})
+	}
+}
+
+func TestRunReadyPreflightFailureLeavesTaskPending(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	ws.CreateTask("First", "", nil, 0)
+	os.MkdirAll(ws.MCPConfigPath(), 0755)
+
+	backend := agent.NewMockBackend()
+	opts := RunOptions{Preflight: func(*task.Task) error { return ws.WriteMCPConfig() }}
+	if _, err := ws.RunReady(context.Background(), opts, mockRun(backend, nil)); err == nil {
+		t.Fatal("expected RunReady to fail on the preflight")
+	}
+
+	if calls := backend.GetCalls(); len(calls) != 0 {
+		t.Errorf("expected no runs, got %d", len(calls))
+	}
+	if got, _ := ws.Tasks.Get("t-001"); got.Status != task.StatusPending {
+		t.Errorf("expected task to stay pending, got %s", got.Status)
+	}
+}
@@ -0,0 +1,90 @@
+package workspace
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsafePath is returned by ResolveSafe for a path outside the workspace.
+var ErrUnsafePath = errors.New("path escapes the workspace")
+
+// ResolveSafe joins rel onto the workspace root and returns the result,
+// rejecting absolute paths, paths that climb out of the root with "..",
+// and paths whose existing part resolves through a symlink to somewhere
+// outside it. The path need not exist yet, so a tool may resolve a file
+// before creating it.
+func (w *Workspace) ResolveSafe(rel string) (string, error) {
+	if rel == "" {
+		return "", fmt.Errorf("%w: empty path", ErrUnsafePath)
+	}
+	if filepath.IsAbs(rel) || filepath.VolumeName(rel) != "" {
+		return "", fmt.Errorf("%w: %s is absolute", ErrUnsafePath, rel)
+	}
+
+	joined := filepath.Join(w.Root, rel)
+	if !within(w.Root, joined) {
+		return "", fmt.Errorf("%w: %s", ErrUnsafePath, rel)
+	}
+
+	root, err := filepath.EvalSymlinks(w.Root)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve workspace root: %w", err)
+	}
+	resolved, err := evalExisting(joined)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", rel, err)
+	}
+	if !within(root, resolved) {
+		return "", fmt.Errorf("%w: %s links outside it", ErrUnsafePath, rel)
+	}
+	return joined, nil
+}
+
+// within reports whether path is root or inside it. Both must be clean.
+func within(root, path string) bool {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// maxDanglingLinks bounds how many dangling symlinks evalExisting follows,
+// so a link cycle cannot loop forever.
+const maxDanglingLinks = 40
+
+// evalExisting resolves the symlinks in the longest existing prefix of
+// path and appends the part that does not exist yet. A dangling symlink is
+// followed to its target, since creating the path would create the target.
+func evalExisting(path string) (string, error) {
+	var missing []string
+	links := 0
+	for {
+		resolved, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...), nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		if target, lerr := os.Readlink(path); lerr == nil {
+			if links++; links > maxDanglingLinks {
+				return "", fmt.Errorf("too many links resolving %s", path)
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(path), target)
+			}
+			path = target
+			continue
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return "", err
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
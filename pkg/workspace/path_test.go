@@ -0,0 +1,84 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSafe(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	outside := t.TempDir()
+
+	if err := os.MkdirAll(filepath.Join(ws.Root, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ws.Root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(outside, "new.txt"), filepath.Join(ws.Root, "dangling")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("docs", filepath.Join(ws.Root, "inside")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		rel     string
+		want    string
+		wantErr bool
+	}{
+		{"spec file", ".eas/SPEC.md", filepath.Join(ws.Root, ".eas", "SPEC.md"), false},
+		{"file not created yet", "docs/notes/today.md", filepath.Join(ws.Root, "docs", "notes", "today.md"), false},
+		{"dot dot that stays inside", "docs/../.eas/SPEC.md", filepath.Join(ws.Root, ".eas", "SPEC.md"), false},
+		{"symlink inside the workspace", "inside/readme.md", filepath.Join(ws.Root, "inside", "readme.md"), false},
+		{"empty", "", "", true},
+		{"parent directory", "..", "", true},
+		{"climbs out", "../secret.txt", "", true},
+		{"climbs out from a subdirectory", "docs/../../secret.txt", "", true},
+		{"absolute", "/etc/passwd", "", true},
+		{"symlinked directory outside", "escape/secret.txt", "", true},
+		{"dangling symlink outside", "dangling", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ws.ResolveSafe(tt.rel)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsafePath) {
+					t.Fatalf("ResolveSafe(%q) = %q, %v, want ErrUnsafePath", tt.rel, got, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveSafe(%q): %v", tt.rel, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveSafe(%q) = %q, want %q", tt.rel, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadSpecRejectsSymlinkOutside(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	secret := filepath.Join(t.TempDir(), "secret.md")
+	if err := os.WriteFile(secret, []byte("# Secret\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(ws.SpecPath()); err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, ws.SpecPath()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ws.ReadSpec(); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("expected ErrUnsafePath reading a spec linked outside the workspace, got %v", err)
+	}
+	if _, err := ws.ReadSpecSection("../SPEC.md#secret"); !errors.Is(err, ErrUnsafePath) {
+		t.Errorf("expected ErrUnsafePath for a spec ref outside the workspace, got %v", err)
+	}
+}
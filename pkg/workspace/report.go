@@ -0,0 +1,63 @@
+package workspace
+
+import (
+	"sort"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// Report compares estimated and actual durations for completed tasks.
+type Report struct {
+	Tasks            []ReportEntry `json:"tasks"`
+	EstimatedMinutes int           `json:"estimated_minutes"` // Sum over estimated tasks
+	ActualMinutes    float64       `json:"actual_minutes"`    // Sum over estimated tasks
+	Accuracy         float64       `json:"accuracy"`          // Actual / estimated (0 = no estimates)
+}
+
+// ReportEntry is one completed task in a Report.
+type ReportEntry struct {
+	ID               string  `json:"id"`
+	Title            string  `json:"title"`
+	UsedBackend      string  `json:"used_backend,omitempty"`
+	UsedModel        string  `json:"used_model,omitempty"`
+	EstimatedMinutes int     `json:"estimated_minutes,omitempty"`
+	ActualMinutes    float64 `json:"actual_minutes"`
+	Ratio            float64 `json:"ratio,omitempty"` // Actual / estimated (0 = no estimate)
+}
+
+// Report builds an estimate-vs-actual report over completed tasks with
+// recorded start and completion times. Only tasks with an estimate count
+// towards the aggregate accuracy.
+func (w *Workspace) Report() *Report {
+	report := &Report{Tasks: []ReportEntry{}}
+
+	for _, t := range w.Tasks.ListByStatus(task.StatusComplete) {
+		if t.StartedAt == nil || t.CompletedAt == nil {
+			continue
+		}
+
+		entry := ReportEntry{
+			ID:               t.ID,
+			Title:            t.Title,
+			UsedBackend:      t.UsedBackend,
+			UsedModel:        t.UsedModel,
+			EstimatedMinutes: t.EstimatedMinutes,
+			ActualMinutes:    t.Duration().Minutes(),
+		}
+		if t.EstimatedMinutes > 0 {
+			entry.Ratio = entry.ActualMinutes / float64(t.EstimatedMinutes)
+			report.EstimatedMinutes += t.EstimatedMinutes
+			report.ActualMinutes += entry.ActualMinutes
+		}
+		report.Tasks = append(report.Tasks, entry)
+	}
+	sort.Slice(report.Tasks, func(i, j int) bool {
+		return report.Tasks[i].ID < report.Tasks[j].ID
+	})
+
+	if report.EstimatedMinutes > 0 {
+		report.Accuracy = report.ActualMinutes / float64(report.EstimatedMinutes)
+	}
+
+	return report
+}
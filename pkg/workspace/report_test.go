@@ -0,0 +1,74 @@
+package workspace
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/richgo/flo/pkg/task"
+)
+
+// completeIn marks a task complete with a fixed duration.
+func completeIn(tk *task.Task, estimate int, d time.Duration) {
+	start := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(d)
+	tk.Status = task.StatusComplete
+	tk.EstimatedMinutes = estimate
+	tk.StartedAt = &start
+	tk.CompletedAt = &end
+}
+
+func TestReport(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	t1, _ := ws.CreateTask("Under estimate", "", nil, 0)
+	t2, _ := ws.CreateTask("Over estimate", "", nil, 0)
+	t3, _ := ws.CreateTask("No estimate", "", nil, 0)
+	ws.CreateTask("Still pending", "", nil, 0)
+
+	completeIn(t1, 30, 60*time.Minute)
+	completeIn(t2, 90, 30*time.Minute)
+	completeIn(t3, 0, 15*time.Minute)
+	t1.RecordRun("copilot", "gpt-4", 500)
+
+	report := ws.Report()
+
+	if len(report.Tasks) != 3 {
+		t.Fatalf("expected 3 completed tasks, got %d", len(report.Tasks))
+	}
+	if report.Tasks[0].ID != "t-001" || report.Tasks[0].Ratio != 2 {
+		t.Errorf("expected t-001 ratio 2, got %+v", report.Tasks[0])
+	}
+	if report.Tasks[0].UsedBackend != "copilot" || report.Tasks[0].UsedModel != "gpt-4" {
+		t.Errorf("expected t-001 completed by copilot/gpt-4, got %+v", report.Tasks[0])
+	}
+	if report.Tasks[1].ID != "t-002" || math.Abs(report.Tasks[1].Ratio-1.0/3) > 1e-9 {
+		t.Errorf("expected t-002 ratio 1/3, got %+v", report.Tasks[1])
+	}
+	if report.Tasks[2].ActualMinutes != 15 || report.Tasks[2].Ratio != 0 {
+		t.Errorf("expected t-003 15m with no ratio, got %+v", report.Tasks[2])
+	}
+
+	// Unestimated tasks are excluded from the aggregate
+	if report.EstimatedMinutes != 120 {
+		t.Errorf("expected 120 estimated minutes, got %d", report.EstimatedMinutes)
+	}
+	if report.ActualMinutes != 90 {
+		t.Errorf("expected 90 actual minutes, got %v", report.ActualMinutes)
+	}
+	if report.Accuracy != 0.75 {
+		t.Errorf("expected accuracy 0.75, got %v", report.Accuracy)
+	}
+}
+
+func TestReportEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	ws.CreateTask("Pending", "", nil, 0)
+
+	report := ws.Report()
+	if len(report.Tasks) != 0 || report.Accuracy != 0 {
+		t.Errorf("expected empty report, got %+v", report)
+	}
+}
@@ -0,0 +1,128 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// ResumeOptions controls how ResumeInProgress handles interrupted tasks.
+type ResumeOptions struct {
+	Reset bool // Move tasks back to pending instead of re-running them
+}
+
+// ResumeSummary reports the outcome of ResumeInProgress.
+type ResumeSummary struct {
+	Reset     []string
+	Completed []string
+	Failed    []string
+	Skipped   []string
+	Errors    map[string]error
+}
+
+// InProgressTasks returns all in_progress tasks sorted by ID.
+func (w *Workspace) InProgressTasks() []*task.Task {
+	tasks := w.Tasks.ListByStatus(task.StatusInProgress)
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].ID < tasks[j].ID
+	})
+	return tasks
+}
+
+// ResumeInProgress recovers tasks left in_progress by an interrupted run.
+// By default each task is re-run with run and marked complete or failed;
+// with Reset it is moved back to pending so it can be claimed again. Tasks
+// whose dependencies are no longer complete are skipped and left untouched.
+func (w *Workspace) ResumeInProgress(ctx context.Context, opts ResumeOptions, run RunFunc) (*ResumeSummary, error) {
+	summary := &ResumeSummary{
+		Reset:     []string{},
+		Completed: []string{},
+		Failed:    []string{},
+		Skipped:   []string{},
+		Errors:    make(map[string]error),
+	}
+
+	for _, t := range w.InProgressTasks() {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		if incomplete := w.incompleteDeps(t); len(incomplete) > 0 {
+			summary.Skipped = append(summary.Skipped, t.ID)
+			summary.Errors[t.ID] = fmt.Errorf("dependencies no longer complete: %s", strings.Join(incomplete, ", "))
+			audit.Warn("workspace.resume", "Skipped task with incomplete dependencies", map[string]interface{}{
+				"task_id": t.ID,
+				"deps":    incomplete,
+			})
+			continue
+		}
+
+		if opts.Reset {
+			if err := t.Reset(); err != nil {
+				return summary, err
+			}
+			t.AddHistory("reset to pending by flo resume")
+			if err := w.saveTask(t); err != nil {
+				return summary, err
+			}
+			summary.Reset = append(summary.Reset, t.ID)
+			continue
+		}
+
+		t.AddHistory("resumed by flo resume")
+		if err := w.saveTask(t); err != nil {
+			return summary, err
+		}
+
+		runErr, err := w.runClaimed(ctx, t, run, "workspace.resume")
+		if err != nil {
+			return summary, err
+		}
+		if runErr != nil {
+			summary.Failed = append(summary.Failed, t.ID)
+			summary.Errors[t.ID] = runErr
+			continue
+		}
+		summary.Completed = append(summary.Completed, t.ID)
+	}
+
+	audit.Info("workspace.resume", "Interrupted tasks recovered", map[string]interface{}{
+		"reset":     len(summary.Reset),
+		"completed": len(summary.Completed),
+		"failed":    len(summary.Failed),
+		"skipped":   len(summary.Skipped),
+	})
+
+	return summary, nil
+}
+
+// incompleteDeps returns the IDs of t's dependencies that are not complete
+// and of its optional dependencies that have not finished.
+func (w *Workspace) incompleteDeps(t *task.Task) []string {
+	var incomplete []string
+	for _, depID := range t.Deps {
+		dep, err := w.Tasks.Get(depID)
+		if err != nil || !dep.IsComplete() {
+			incomplete = append(incomplete, depID)
+		}
+	}
+	for _, depID := range t.OptionalDeps {
+		dep, err := w.Tasks.Get(depID)
+		if err != nil || !dep.IsTerminal() {
+			incomplete = append(incomplete, depID)
+		}
+	}
+	return incomplete
+}
+
+// saveTask stores an updated task and saves the workspace.
+func (w *Workspace) saveTask(t *task.Task) error {
+	if err := w.Tasks.Update(t); err != nil {
+		return err
+	}
+	return w.Save()
+}
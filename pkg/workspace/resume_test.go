@@ -0,0 +1,121 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/task"
+)
+
+func TestResumeInProgressReset(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("Interrupted", "", nil, 0)
+	ws.CreateTask("Untouched", "", nil, 0)
+	ws.SetTaskStatus("t-001", string(task.StatusInProgress))
+
+	backend := agent.NewMockBackend()
+	summary, err := ws.ResumeInProgress(context.Background(), ResumeOptions{Reset: true}, mockRun(backend, nil))
+	if err != nil {
+		t.Fatalf("ResumeInProgress failed: %v", err)
+	}
+
+	if len(summary.Reset) != 1 || summary.Reset[0] != "t-001" {
+		t.Errorf("expected t-001 reset, got %v", summary.Reset)
+	}
+	if calls := backend.GetCalls(); len(calls) != 0 {
+		t.Errorf("expected no agent runs with reset, got %d", len(calls))
+	}
+
+	// Reload to check the reset and note were persisted
+	ws2, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	tk, _ := ws2.GetTask("t-001")
+	if tk.Status != task.StatusPending {
+		t.Errorf("expected t-001 pending, got %s", tk.Status)
+	}
+	if len(tk.History) != 1 || tk.History[0].Message != "reset to pending by flo resume" {
+		t.Errorf("expected reset note in history, got %+v", tk.History)
+	}
+
+	// A reset task can be claimed again
+	if err := ws2.SetTaskStatus("t-001", string(task.StatusInProgress)); err != nil {
+		t.Errorf("expected reset task to be claimable: %v", err)
+	}
+}
+
+func TestResumeInProgressRerun(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("First", "", nil, 0)
+	ws.CreateTask("Second", "", nil, 0)
+	ws.SetTaskStatus("t-001", string(task.StatusInProgress))
+	ws.SetTaskStatus("t-002", string(task.StatusInProgress))
+
+	backend := agent.NewMockBackend()
+	summary, err := ws.ResumeInProgress(context.Background(), ResumeOptions{}, mockRun(backend, map[string]bool{"t-002": true}))
+	if err != nil {
+		t.Fatalf("ResumeInProgress failed: %v", err)
+	}
+
+	if fmt.Sprint(callOrder(backend)) != fmt.Sprint([]string{"t-001", "t-002"}) {
+		t.Errorf("expected both tasks re-run in order, got %v", callOrder(backend))
+	}
+	if len(summary.Completed) != 1 || summary.Completed[0] != "t-001" {
+		t.Errorf("expected t-001 completed, got %v", summary.Completed)
+	}
+	if len(summary.Failed) != 1 || summary.Failed[0] != "t-002" {
+		t.Errorf("expected t-002 failed, got %v", summary.Failed)
+	}
+
+	tk, _ := ws.GetTask("t-001")
+	if tk.Status != task.StatusComplete {
+		t.Errorf("expected t-001 complete, got %s", tk.Status)
+	}
+	if len(tk.History) != 1 || tk.History[0].Message != "resumed by flo resume" {
+		t.Errorf("expected resume note in history, got %+v", tk.History)
+	}
+	tk, _ = ws.GetTask("t-002")
+	if tk.Status != task.StatusFailed {
+		t.Errorf("expected t-002 failed, got %s", tk.Status)
+	}
+}
+
+func TestResumeInProgressSkipsRegressedDeps(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("Dep", "", nil, 0)
+	ws.CreateTask("Dependent", "", []string{"t-001"}, 0)
+
+	// t-002 was claimed, then its dependency was reset by hand
+	dependent, _ := ws.GetTask("t-002")
+	dependent.Status = task.StatusInProgress
+	ws.Tasks.Update(dependent)
+
+	backend := agent.NewMockBackend()
+	summary, err := ws.ResumeInProgress(context.Background(), ResumeOptions{}, mockRun(backend, nil))
+	if err != nil {
+		t.Fatalf("ResumeInProgress failed: %v", err)
+	}
+
+	if len(summary.Skipped) != 1 || summary.Skipped[0] != "t-002" {
+		t.Errorf("expected t-002 skipped, got %v", summary.Skipped)
+	}
+	if summary.Errors["t-002"] == nil {
+		t.Error("expected error recorded for skipped task")
+	}
+	if calls := backend.GetCalls(); len(calls) != 0 {
+		t.Errorf("expected no agent runs, got %d", len(calls))
+	}
+	tk, _ := ws.GetTask("t-002")
+	if tk.Status != task.StatusInProgress {
+		t.Errorf("expected t-002 left in_progress, got %s", tk.Status)
+	}
+}
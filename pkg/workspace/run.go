@@ -0,0 +1,156 @@
+package workspace
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// RunFunc executes a claimed task. A nil error means the task succeeded.
+type RunFunc func(ctx context.Context, t *task.Task) error
+
+// RunOptions controls how RunReady drains the ready queue.
+type RunOptions struct {
+	Max       int                      // Maximum number of tasks to run (0 = no limit)
+	KeepGoing bool                     // Continue past failed tasks
+	Preflight func(t *task.Task) error // Checked before each claim; an error stops the run with the task unclaimed
+}
+
+// RunSummary reports the outcome of RunReady.
+type RunSummary struct {
+	Completed []string
+	Failed    []string
+	Errors    map[string]error
+}
+
+// RunReady repeatedly picks the highest-priority ready task, claims it,
+// and runs it with run until no tasks are ready, Max is reached, or a task
+// fails (unless KeepGoing is set). Tasks unblocked by a completion are
+// picked up on the next iteration.
+func (w *Workspace) RunReady(ctx context.Context, opts RunOptions, run RunFunc) (*RunSummary, error) {
+	summary := &RunSummary{
+		Completed: []string{},
+		Failed:    []string{},
+		Errors:    make(map[string]error),
+	}
+
+	for opts.Max <= 0 || len(summary.Completed)+len(summary.Failed) < opts.Max {
+		if err := ctx.Err(); err != nil {
+			return summary, err
+		}
+
+		next := nextReady(w.GetReadyTasks())
+		if next == nil {
+			break
+		}
+
+		var preflight func() error
+		if opts.Preflight != nil {
+			preflight = func() error { return opts.Preflight(next) }
+		}
+		if err := w.ClaimTask(next.ID, preflight); err != nil {
+			return summary, err
+		}
+
+		runErr, err := w.runClaimed(ctx, next, run, "workspace.run_ready")
+		if err != nil {
+			return summary, err
+		}
+		if runErr == nil {
+			summary.Completed = append(summary.Completed, next.ID)
+			continue
+		}
+
+		summary.Failed = append(summary.Failed, next.ID)
+		summary.Errors[next.ID] = runErr
+		if !opts.KeepGoing {
+			break
+		}
+	}
+
+	audit.Info("workspace.run_ready", "Ready queue drained", map[string]interface{}{
+		"completed": len(summary.Completed),
+		"failed":    len(summary.Failed),
+	})
+
+	return summary, nil
+}
+
+// ClaimTask marks a task in_progress once preflight, if non-nil, succeeds.
+// A failed preflight leaves the task untouched so it can be picked up again
+// after the problem is fixed.
+func (w *Workspace) ClaimTask(id string, preflight func() error) error {
+	if preflight != nil {
+		if err := preflight(); err != nil {
+			return fmt.Errorf("not claiming task %s: %w", id, err)
+		}
+	}
+	return w.SetTaskStatus(id, string(task.StatusInProgress))
+}
+
+// runClaimed runs an in_progress task and records the outcome, marking it
+// complete or failed unless run already moved it on. runErr is the task's
+// own failure; err is a failure to persist the outcome, or the context error
+// if the run was cancelled, in which case the task is reverted to pending.
+func (w *Workspace) runClaimed(ctx context.Context, t *task.Task, run RunFunc, op string) (runErr, err error) {
+	runErr = run(ctx, t)
+
+	// An interrupted run is not the task's fault; put it back in the queue
+	if ctxErr := ctx.Err(); ctxErr != nil && t.Status == task.StatusInProgress {
+		if err := w.RevertInterrupted(t); err != nil {
+			return nil, err
+		}
+		return nil, ctxErr
+	}
+
+	if runErr == nil {
+		if t.Status == task.StatusInProgress {
+			err = w.SetTaskStatus(t.ID, string(task.StatusComplete))
+		}
+		return nil, err
+	}
+
+	if t.Status == task.StatusInProgress {
+		err = w.SetTaskStatus(t.ID, string(task.StatusFailed))
+	}
+	audit.Warn(op, "Task failed", map[string]interface{}{
+		"task_id": t.ID,
+		"error":   runErr.Error(),
+	})
+	return runErr, err
+}
+
+// RevertInterrupted moves an in_progress task whose run was cancelled back to
+// pending, notes the interruption in its history, and saves.
+func (w *Workspace) RevertInterrupted(t *task.Task) error {
+	if err := t.Reset(); err != nil {
+		return err
+	}
+	t.AddHistory("interrupted; reverted to pending")
+	if err := w.saveTask(t); err != nil {
+		return err
+	}
+
+	audit.Warn("workspace.interrupt", "Task reverted after interruption", map[string]interface{}{
+		"task_id": t.ID,
+	})
+	return nil
+}
+
+// nextReady returns the highest-priority task (lowest Priority value),
+// breaking ties by ID so runs are deterministic.
+func nextReady(ready []*task.Task) *task.Task {
+	if len(ready) == 0 {
+		return nil
+	}
+	sort.Slice(ready, func(i, j int) bool {
+		if ready[i].Priority != ready[j].Priority {
+			return ready[i].Priority < ready[j].Priority
+		}
+		return ready[i].ID < ready[j].ID
+	})
+	return ready[0]
+}
@@ -0,0 +1,192 @@
+package workspace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/agent"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// mockRun runs tasks through a mock backend, failing any task in fail.
+func mockRun(backend *agent.MockBackend, fail map[string]bool) RunFunc {
+	return func(ctx context.Context, t *task.Task) error {
+		session, err := backend.CreateSession(ctx, t, "")
+		if err != nil {
+			return err
+		}
+		defer session.Destroy(ctx)
+
+		result, err := session.Run(ctx, "Implement "+t.Title)
+		if err != nil {
+			return err
+		}
+		if !result.Success || fail[t.ID] {
+			return fmt.Errorf("agent failed on %s", t.ID)
+		}
+		return nil
+	}
+}
+
+func callOrder(backend *agent.MockBackend) []string {
+	var ids []string
+	for _, call := range backend.GetCalls() {
+		ids = append(ids, call.TaskID)
+	}
+	return ids
+}
+
+func TestRunReadyDependencyChain(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	// t-001 <- t-002 <- t-003, plus an independent low-priority t-004
+	ws.CreateTask("First", "", nil, 0)
+	ws.CreateTask("Second", "", []string{"t-001"}, 0)
+	ws.CreateTask("Third", "", []string{"t-002"}, 0)
+	ws.CreateTask("Independent", "", nil, 5)
+
+	backend := agent.NewMockBackend()
+	summary, err := ws.RunReady(context.Background(), RunOptions{}, mockRun(backend, nil))
+	if err != nil {
+		t.Fatalf("RunReady failed: %v", err)
+	}
+
+	if len(summary.Completed) != 4 || len(summary.Failed) != 0 {
+		t.Errorf("expected 4 completed and 0 failed, got %v / %v", summary.Completed, summary.Failed)
+	}
+
+	// Priority 0 chain runs before the priority 5 task as it unblocks
+	want := []string{"t-001", "t-002", "t-003", "t-004"}
+	got := callOrder(backend)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("expected run order %v, got %v", want, got)
+	}
+
+	for _, id := range want {
+		tk, _ := ws.GetTask(id)
+		if tk.Status != task.StatusComplete {
+			t.Errorf("expected %s complete, got %s", id, tk.Status)
+		}
+	}
+}
+
+func TestRunReadyStopsOnFailure(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("First", "", nil, 0)
+	ws.CreateTask("Second", "", []string{"t-001"}, 0)
+	ws.CreateTask("Other", "", nil, 1)
+
+	backend := agent.NewMockBackend()
+	summary, err := ws.RunReady(context.Background(), RunOptions{}, mockRun(backend, map[string]bool{"t-001": true}))
+	if err != nil {
+		t.Fatalf("RunReady failed: %v", err)
+	}
+
+	if len(summary.Failed) != 1 || summary.Failed[0] != "t-001" {
+		t.Errorf("expected t-001 failed, got %v", summary.Failed)
+	}
+	if len(summary.Completed) != 0 {
+		t.Errorf("expected nothing completed after failure, got %v", summary.Completed)
+	}
+	if summary.Errors["t-001"] == nil {
+		t.Error("expected error recorded for t-001")
+	}
+
+	tk, _ := ws.GetTask("t-001")
+	if tk.Status != task.StatusFailed {
+		t.Errorf("expected t-001 failed, got %s", tk.Status)
+	}
+}
+
+func TestRunReadyKeepGoing(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("First", "", nil, 0)
+	ws.CreateTask("Second", "", []string{"t-001"}, 0)
+	ws.CreateTask("Other", "", nil, 1)
+
+	backend := agent.NewMockBackend()
+	opts := RunOptions{KeepGoing: true}
+	summary, err := ws.RunReady(context.Background(), opts, mockRun(backend, map[string]bool{"t-001": true}))
+	if err != nil {
+		t.Fatalf("RunReady failed: %v", err)
+	}
+
+	// t-002 stays blocked behind the failed t-001; t-003 still runs
+	if len(summary.Completed) != 1 || summary.Completed[0] != "t-003" {
+		t.Errorf("expected t-003 completed, got %v", summary.Completed)
+	}
+	tk, _ := ws.GetTask("t-002")
+	if tk.Status != task.StatusPending {
+		t.Errorf("expected t-002 still pending, got %s", tk.Status)
+	}
+}
+
+func TestRunReadyMax(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("First", "", nil, 0)
+	ws.CreateTask("Second", "", []string{"t-001"}, 0)
+	ws.CreateTask("Third", "", []string{"t-002"}, 0)
+
+	backend := agent.NewMockBackend()
+	summary, err := ws.RunReady(context.Background(), RunOptions{Max: 2}, mockRun(backend, nil))
+	if err != nil {
+		t.Fatalf("RunReady failed: %v", err)
+	}
+
+	if len(summary.Completed) != 2 {
+		t.Errorf("expected 2 completed with --max 2, got %v", summary.Completed)
+	}
+	tk, _ := ws.GetTask("t-003")
+	if tk.Status != task.StatusPending {
+		t.Errorf("expected t-003 still pending, got %s", tk.Status)
+	}
+}
+
+func TestRunReadyCancelRevertsTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("Interrupted", "", nil, 0)
+	ws.CreateTask("Never started", "", nil, 1)
+
+	// Simulate SIGINT arriving while the agent is running
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	run := func(ctx context.Context, tk *task.Task) error {
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	summary, err := ws.RunReady(ctx, RunOptions{KeepGoing: true}, run)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if len(summary.Completed) != 0 || len(summary.Failed) != 0 {
+		t.Errorf("expected no completed or failed tasks, got %v / %v", summary.Completed, summary.Failed)
+	}
+
+	// Reload to check the revert was persisted
+	ws2, _ := Load(tmpDir)
+	tk, _ := ws2.GetTask("t-001")
+	if tk.Status != task.StatusPending {
+		t.Errorf("expected t-001 reverted to pending, got %s", tk.Status)
+	}
+	if len(tk.History) != 1 || !strings.Contains(tk.History[0].Message, "interrupted") {
+		t.Errorf("expected interruption note, got %+v", tk.History)
+	}
+	tk, _ = ws2.GetTask("t-002")
+	if tk.Status != task.StatusPending {
+		t.Errorf("expected t-002 untouched, got %s", tk.Status)
+	}
+}
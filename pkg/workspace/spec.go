@@ -0,0 +1,163 @@
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode"
+
+	"github.com/richgo/flo/pkg/audit"
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+// PromptSpec returns the spec to put in t's prompt, following the config's
+// spec_inclusion: the whole spec, the section t.SpecRef points at, or
+// nothing. A section that cannot be found falls back to the whole spec.
+func (w *Workspace) PromptSpec(t *task.Task) string {
+	switch w.Config.SpecInclusionFor(t) {
+	case config.SpecNone:
+		return ""
+	case config.SpecSection:
+		section, err := w.ReadSpecSection(t.SpecRef)
+		if err == nil {
+			return section
+		}
+		audit.Warn("workspace.prompt_spec", "Spec section not found, using the whole spec", map[string]interface{}{
+			"task_id":  t.ID,
+			"spec_ref": t.SpecRef,
+			"error":    err.Error(),
+		})
+	}
+	spec, _ := w.ReadSpec()
+	return spec
+}
+
+// ReadSpecSection returns the part of SPEC.md a task's SpecRef points at.
+// ref is "SPEC.md#anchor" or "#anchor", where anchor is the slug of a
+// heading as GitHub renders it (e.g. "#oauth-flow" for "## OAuth Flow").
+// The section runs from its heading to the next heading of the same or a
+// higher level. A ref without an anchor returns the whole spec.
+func (w *Workspace) ReadSpecSection(ref string) (string, error) {
+	file, anchor, hasAnchor := strings.Cut(ref, "#")
+	if file != "" {
+		if path.Base(file) != specFile {
+			return "", fmt.Errorf("spec ref '%s' must point at %s", ref, specFile)
+		}
+		if _, err := w.ResolveSafe(file); err != nil {
+			return "", fmt.Errorf("spec ref '%s': %w", ref, err)
+		}
+	}
+
+	spec, err := w.ReadSpec()
+	if err != nil {
+		return "", err
+	}
+	if !hasAnchor || anchor == "" {
+		return spec, nil
+	}
+
+	section, ok := specSection(spec, anchor)
+	if !ok {
+		return "", fmt.Errorf("spec ref '%s': no heading in %s matches '#%s'", ref, specFile, slugify(anchor))
+	}
+	return section, nil
+}
+
+// specSection returns the section of markdown under the heading whose slug
+// matches anchor. Lines inside fenced code blocks are not headings.
+func specSection(markdown, anchor string) (string, bool) {
+	want := slugify(anchor)
+	lines := strings.SplitAfter(markdown, "\n")
+
+	start, level := -1, 0
+	fence := "" // Marker of the open code fence, if any
+	for i, line := range lines {
+		line = strings.TrimRight(line, "\r\n")
+		if marker, ok := codeFence(line); ok {
+			switch {
+			case fence == "":
+				fence = marker
+			case strings.HasPrefix(marker, fence):
+				fence = ""
+			}
+			continue
+		}
+		if fence != "" {
+			continue
+		}
+
+		n, text, ok := heading(line)
+		if !ok {
+			continue
+		}
+		if start >= 0 && n <= level {
+			return strings.TrimRight(strings.Join(lines[start:i], ""), "\n") + "\n", true
+		}
+		if start < 0 && slugify(text) == want {
+			start, level = i, n
+		}
+	}
+
+	if start < 0 {
+		return "", false
+	}
+	return strings.TrimRight(strings.Join(lines[start:], ""), "\n") + "\n", true
+}
+
+// leadingSpaces returns line without the up to 3 spaces of indentation
+// markdown allows before a heading or code fence. A line indented further
+// is an indented code block, so ok is false.
+func leadingSpaces(line string) (rest string, ok bool) {
+	rest = strings.TrimLeft(line, " ")
+	return rest, len(line)-len(rest) <= 3
+}
+
+// codeFence reports whether line opens or closes a fenced code block,
+// returning its run of backticks or tildes.
+func codeFence(line string) (marker string, ok bool) {
+	rest, ok := leadingSpaces(line)
+	if !ok {
+		return "", false
+	}
+	for _, c := range []string{"`", "~"} {
+		marker = rest[:len(rest)-len(strings.TrimLeft(rest, c))]
+		if len(marker) >= 3 {
+			return marker, true
+		}
+	}
+	return "", false
+}
+
+// heading parses an ATX markdown heading, returning its level and text.
+// The # must start the line, after at most 3 spaces.
+func heading(line string) (level int, text string, ok bool) {
+	line, ok = leadingSpaces(line)
+	if !ok {
+		return 0, "", false
+	}
+	level = len(line) - len(strings.TrimLeft(line, "#"))
+	if level == 0 || level > 6 {
+		return 0, "", false
+	}
+	rest := line[level:]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return 0, "", false // "#hashtag" is not a heading
+	}
+	return level, strings.TrimSpace(strings.TrimRight(strings.TrimSpace(rest), "#")), true
+}
+
+// slugify converts heading text to its anchor the way GitHub does:
+// lowercased, spaces turned into hyphens, and other punctuation dropped.
+func slugify(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(text)) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,148 @@
+package workspace
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
+)
+
+const multiSectionSpec = `# Login Feature
+
+Overview of the feature.
+
+## Session Storage
+
+Sessions live in Redis.
+
+## OAuth Flow
+
+Users sign in with GitHub.
+
+### Token Refresh
+
+Refresh tokens rotate on use.
+
+` + "```" + `
+## Not a heading
+` + "```" + `
+
+## Rollout
+
+Behind a flag.
+
+    ## Indented code
+
+~~~
+` + "```" + `
+## Fenced in tildes
+~~~
+
+   ## Metrics
+
+Latency dashboards.
+`
+
+func TestReadSpecSection(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	if err := os.WriteFile(ws.SpecPath(), []byte(multiSectionSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		ref     string
+		want    string // Prefix of the section
+		wantEnd string // Suffix of the section
+		wantErr bool
+	}{
+		// A middle section runs through its subsections up to the next ## heading
+		{ref: "SPEC.md#oauth-flow", want: "## OAuth Flow\n", wantEnd: "```\n## Not a heading\n```\n"},
+		{ref: "#token-refresh", want: "### Token Refresh\n", wantEnd: "```\n## Not a heading\n```\n"},
+		{ref: "#Session Storage", want: "## Session Storage\n", wantEnd: "Sessions live in Redis.\n"},
+		{ref: "#rollout", want: "## Rollout\n", wantEnd: "## Fenced in tildes\n~~~\n"},
+		{ref: "#metrics", want: "   ## Metrics\n", wantEnd: "Latency dashboards.\n"},
+		{ref: "SPEC.md", want: "# Login Feature\n", wantEnd: "Latency dashboards.\n"},
+		{ref: "#not-a-heading", wantErr: true},
+		{ref: "#indented-code", wantErr: true},
+		{ref: "#fenced-in-tildes", wantErr: true},
+		{ref: "SPEC.md#billing", wantErr: true},
+		{ref: "DESIGN.md#oauth-flow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			got, err := ws.ReadSpecSection(tt.ref)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got section %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ReadSpecSection failed: %v", err)
+			}
+			if !strings.HasPrefix(got, tt.want) || !strings.HasSuffix(got, tt.wantEnd) {
+				t.Errorf("unexpected section:\n%s", got)
+			}
+			if tt.ref != "SPEC.md" && strings.Contains(got, "Overview") {
+				t.Errorf("section includes the spec overview:\n%s", got)
+			}
+		})
+	}
+}
+
+func TestPromptSpec(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	if err := os.WriteFile(ws.SpecPath(), []byte(multiSectionSpec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	withRef := task.New("t-001", "OAuth")
+	withRef.SpecRef = "SPEC.md#oauth-flow"
+	badRef := task.New("t-002", "Billing")
+	badRef.SpecRef = "#billing"
+	noRef := task.New("t-003", "Rollout")
+
+	section, _ := ws.ReadSpecSection(withRef.SpecRef)
+	tests := []struct {
+		inclusion string
+		task      *task.Task
+		want      string
+	}{
+		{"", withRef, section},
+		{"", noRef, multiSectionSpec},
+		{config.SpecSection, withRef, section},
+		{config.SpecSection, noRef, multiSectionSpec},
+		{config.SpecSection, badRef, multiSectionSpec}, // Falls back to the whole spec
+		{config.SpecFull, withRef, multiSectionSpec},
+		{config.SpecNone, withRef, ""},
+		{config.SpecNone, noRef, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.inclusion+"/"+tt.task.ID, func(t *testing.T) {
+			ws.Config.SpecInclusion = tt.inclusion
+			if got := ws.PromptSpec(tt.task); got != tt.want {
+				t.Errorf("PromptSpec() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+	if strings.Contains(section, "Session Storage") || strings.Contains(section, "Rollout") {
+		t.Errorf("section includes other sections:\n%s", section)
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"OAuth Flow":          "oauth-flow",
+		"API: v2 (draft)":     "api-v2-draft",
+		"snake_case and-dash": "snake_case-and-dash",
+	}
+	for in, want := range tests {
+		if got := slugify(in); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
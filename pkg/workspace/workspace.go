@@ -2,11 +2,15 @@
 package workspace
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"sort"
+	"strings"
 
+	"github.com/richgo/flo/pkg/agent"
 	"github.com/richgo/flo/pkg/audit"
 	"github.com/richgo/flo/pkg/config"
 	"github.com/richgo/flo/pkg/task"
@@ -27,28 +31,96 @@ type Workspace struct {
 	Backend  string
 	Config   *config.Config
 	Tasks    *task.Registry
-	nextID   int
+	locked   bool
 }
 
 // Status holds workspace status information.
 type Status struct {
-	Feature        string
-	Backend        string
-	TotalTasks     int
-	PendingTasks   int
-	InProgressTasks int
-	CompleteTasks  int
-	FailedTasks    int
-	ReadyTasks     int
+	Feature         string          `json:"feature"`
+	Backend         string          `json:"backend"`
+	Group           string          `json:"group,omitempty"` // Set when the status covers one group
+	TotalTasks      int             `json:"total_tasks"`
+	PendingTasks    int             `json:"pending_tasks"`
+	InProgressTasks int             `json:"in_progress_tasks"`
+	CompleteTasks   int             `json:"complete_tasks"`
+	FailedTasks     int             `json:"failed_tasks"`
+	ReadyTasks      int             `json:"ready_tasks"`
+	BlockedTasks    int             `json:"blocked_tasks"`
+	Pending         []PendingTask   `json:"pending"`
+	Completed       []CompletedTask `json:"completed"`
+	Failed          []FailedTask    `json:"failed"`
+}
+
+// FailedTask describes a failed task and why its last run failed.
+type FailedTask struct {
+	ID       string               `json:"id"`
+	Title    string               `json:"title"`
+	Category task.FailureCategory `json:"failure_category,omitempty"`
+	Reason   string               `json:"failure_reason,omitempty"`
+}
+
+// CompletedTask describes a complete task and what ran it.
+type CompletedTask struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	UsedBackend string `json:"used_backend,omitempty"`
+	UsedModel   string `json:"used_model,omitempty"`
+}
+
+// PendingTask describes a pending task and what it is waiting on.
+type PendingTask struct {
+	ID        string   `json:"id"`
+	Title     string   `json:"title"`
+	Ready     bool     `json:"ready"`
+	BlockedBy []string `json:"blocked_by"`
+	Related   []string `json:"related,omitempty"` // Linked tasks; informational only
+}
+
+// InitOptions configures a new workspace.
+type InitOptions struct {
+	Feature string
+	Backend string                 // Default backend; must be registered (default "claude")
+	Repos   map[string]config.Repo // Repos to add to the config
+	Force   bool                   // Rewrite the config of an existing workspace
 }
 
 // Init initializes a new workspace in the given directory.
 func Init(root, feature, backend string) (*Workspace, error) {
+	return InitWithOptions(root, InitOptions{Feature: feature, Backend: backend})
+}
+
+// InitWithOptions initializes a workspace in root. An existing workspace is
+// refused unless opts.Force is set, in which case its config is rewritten
+// and its SPEC.md and tasks are kept.
+func InitWithOptions(root string, opts InitOptions) (*Workspace, error) {
 	easPath := filepath.Join(root, easDir)
-	
+
+	if opts.Feature == "" {
+		return nil, fmt.Errorf("feature name is required")
+	}
+
 	// Check if already initialized
-	if _, err := os.Stat(easPath); err == nil {
-		return nil, fmt.Errorf("workspace already initialized at %s", root)
+	if _, err := os.Stat(easPath); err == nil && !opts.Force {
+		return nil, fmt.Errorf("workspace already initialized at %s (use --reinit to overwrite its config)", root)
+	}
+
+	// Create config
+	cfg := config.New(opts.Feature)
+	if opts.Backend != "" {
+		cfg.Backend = opts.Backend
+	}
+	if !agent.IsRegistered(cfg.Backend) {
+		backends := agent.ListBackends()
+		return nil, fmt.Errorf("unknown backend '%s' (available: %s)", cfg.Backend, strings.Join(backends, ", "))
+	}
+	if len(opts.Repos) > 0 {
+		cfg.Repos = make(map[string]config.Repo, len(opts.Repos))
+		for name, repo := range opts.Repos {
+			cfg.Repos[name] = repo
+		}
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
 	}
 
 	// Create directory structure
@@ -56,15 +128,14 @@ func Init(root, feature, backend string) (*Workspace, error) {
 		return nil, fmt.Errorf("failed to create workspace: %w", err)
 	}
 
-	// Create config
-	cfg := config.New(feature)
-	cfg.Backend = backend
 	if err := cfg.Save(filepath.Join(easPath, configFile)); err != nil {
 		return nil, fmt.Errorf("failed to save config: %w", err)
 	}
 
 	// Create SPEC.md template
-	specContent := fmt.Sprintf(`# Feature: %s
+	specPath := filepath.Join(easPath, specFile)
+	if _, err := os.Stat(specPath); os.IsNotExist(err) {
+		specContent := fmt.Sprintf(`# Feature: %s
 
 ## Overview
 
@@ -82,14 +153,20 @@ _Describe the feature here._
 ## Technical Notes
 
 _Add technical details here._
-`, feature)
-	if err := os.WriteFile(filepath.Join(easPath, specFile), []byte(specContent), 0644); err != nil {
-		return nil, fmt.Errorf("failed to create SPEC.md: %w", err)
+`, opts.Feature)
+		if err := os.WriteFile(specPath, []byte(specContent), 0644); err != nil {
+			return nil, fmt.Errorf("failed to create SPEC.md: %w", err)
+		}
 	}
 
-	// Create empty task registry
+	// Create an empty task registry, keeping any existing tasks
 	taskReg := task.NewRegistry()
-	if err := taskReg.Save(filepath.Join(easPath, tasksDir, manifestFile)); err != nil {
+	manifestPath := filepath.Join(easPath, tasksDir, manifestFile)
+	if _, err := os.Stat(manifestPath); err == nil {
+		if err := taskReg.Load(manifestPath); err != nil {
+			return nil, fmt.Errorf("failed to load tasks: %w", err)
+		}
+	} else if err := taskReg.Save(manifestPath); err != nil {
 		return nil, fmt.Errorf("failed to save task manifest: %w", err)
 	}
 
@@ -99,24 +176,29 @@ _Add technical details here._
 		fmt.Fprintf(os.Stderr, "Warning: failed to initialize audit log: %v\n", err)
 	} else {
 		audit.Info("workspace.init", "Workspace initialized", map[string]interface{}{
-			"feature": feature,
-			"backend": backend,
+			"feature": opts.Feature,
+			"backend": cfg.Backend,
 			"root":    root,
+			"force":   opts.Force,
 		})
 	}
 
 	return &Workspace{
 		Root:    root,
-		Feature: feature,
-		Backend: backend,
+		Feature: opts.Feature,
+		Backend: cfg.Backend,
 		Config:  cfg,
 		Tasks:   taskReg,
-		nextID:  1,
 	}, nil
 }
 
 // Load loads an existing workspace from the given directory.
 func Load(root string) (*Workspace, error) {
+	return LoadProfile(root, "")
+}
+
+// LoadProfile loads an existing workspace, applying the named config profile.
+func LoadProfile(root, profile string) (*Workspace, error) {
 	easPath := filepath.Join(root, easDir)
 	
 	// Check if initialized
@@ -125,13 +207,16 @@ func Load(root string) (*Workspace, error) {
 	}
 
 	// Load config
-	cfg, err := config.Load(filepath.Join(easPath, configFile))
+	cfg, err := config.LoadProfile(filepath.Join(easPath, configFile), profile)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
 	// Load task registry
 	taskReg := task.NewRegistry()
+	if err := taskReg.SetIDPattern(cfg.TaskIDPattern); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
 	manifestPath := filepath.Join(easPath, tasksDir, manifestFile)
 	if _, err := os.Stat(manifestPath); err == nil {
 		if err := taskReg.Load(manifestPath); err != nil {
@@ -139,17 +224,6 @@ func Load(root string) (*Workspace, error) {
 		}
 	}
 
-	// Find highest task ID for next ID generation
-	nextID := 1
-	for _, t := range taskReg.List() {
-		var id int
-		if _, err := fmt.Sscanf(t.ID, "t-%d", &id); err == nil {
-			if id >= nextID {
-				nextID = id + 1
-			}
-		}
-	}
-
 	// Initialize audit logger
 	if err := audit.Init(root); err != nil {
 		// Log initialization failure but don't fail workspace load
@@ -158,6 +232,7 @@ func Load(root string) (*Workspace, error) {
 		audit.Info("workspace.load", "Workspace loaded", map[string]interface{}{
 			"feature":    cfg.Feature,
 			"backend":    cfg.Backend,
+			"profile":    profile,
 			"task_count": len(taskReg.List()),
 		})
 	}
@@ -168,7 +243,6 @@ func Load(root string) (*Workspace, error) {
 		Backend: cfg.Backend,
 		Config:  cfg,
 		Tasks:   taskReg,
-		nextID:  nextID,
 	}, nil
 }
 
@@ -204,38 +278,93 @@ func (w *Workspace) CreateTask(title, repo string, deps []string, priority int)
 
 // CreateTaskWithType creates a new task with a specific type.
 func (w *Workspace) CreateTaskWithType(title, taskType, repo string, deps []string, priority int) (*task.Task, error) {
-	id := fmt.Sprintf("t-%03d", w.nextID)
-	w.nextID++
-
-	t := task.New(id, title)
+	t := w.NewTask("", title, taskType)
 	t.Repo = repo
 	t.Deps = deps
 	t.Priority = priority
+
+	if err := w.AddTask(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// NewTask builds a task without adding it to the workspace. An empty id
+// uses the next sequential t-NNN ID, and the task type's model and fallback
+// are applied from config.
+func (w *Workspace) NewTask(id, title, taskType string) *task.Task {
+	if id == "" {
+		id = task.NextID(w.Tasks, task.DefaultIDPrefix)
+	}
+
+	t := task.New(id, title)
 	t.Type = taskType
-	t.CreatedAt = time.Now()
-	t.UpdatedAt = time.Now()
+
+	// Record which spec the task was created against
+	if hash, err := w.SpecHash(); err == nil {
+		t.SpecHashAtCreate = hash
+	}
 
 	// Set model based on task type
 	if taskType != "" && w.Config.TaskTypes != nil {
 		if typeConfig, ok := w.Config.TaskTypes[taskType]; ok {
 			t.Model = typeConfig.Model
+			t.Fallback = typeConfig.Fallback
+			t.Fallbacks = append([]string(nil), typeConfig.Fallbacks...)
+		}
+	}
+
+	return t
+}
+
+// ValidateNewTask checks that a task could be added to the workspace
+// without modifying anything.
+func (w *Workspace) ValidateNewTask(t *task.Task) error {
+	if err := t.Validate(); err != nil {
+		return fmt.Errorf("invalid task: %w", err)
+	}
+	if _, err := w.Tasks.Get(t.ID); err == nil {
+		return fmt.Errorf("task %s already exists; choose a different ID", t.ID)
+	}
+	for _, dep := range t.AllDeps() {
+		if _, err := w.Tasks.Get(dep); err != nil {
+			return fmt.Errorf("unknown dependency %s: no task with that ID exists", dep)
 		}
 	}
+	for _, rel := range t.Related {
+		if _, err := w.Tasks.Get(rel); err != nil {
+			return fmt.Errorf("unknown related task %s: no task with that ID exists", rel)
+		}
+	}
+	return nil
+}
+
+// AddTask adds a fully constructed task to the workspace, writes its
+// task.md file, and saves.
+func (w *Workspace) AddTask(t *task.Task) error {
+	if err := w.ValidateNewTask(t); err != nil {
+		audit.Error("workspace.create_task", "Task rejected", map[string]interface{}{
+			"task_id": t.ID,
+			"title":   t.Title,
+			"error":   err.Error(),
+		})
+		return err
+	}
 
 	if err := w.Tasks.Add(t); err != nil {
-		w.nextID-- // Rollback ID
 		audit.Error("workspace.create_task", "Failed to add task", map[string]interface{}{
-			"task_id": id,
-			"title":   title,
+			"task_id": t.ID,
+			"title":   t.Title,
 			"error":   err.Error(),
 		})
-		return nil, err
+		return err
 	}
 
 	// Write task.md file
 	if err := w.writeTaskFile(t); err != nil {
 		audit.Error("workspace.create_task", "Failed to write task file", map[string]interface{}{
-			"task_id": id,
+			"task_id": t.ID,
 			"error":   err.Error(),
 		})
 		// Don't fail the task creation if file write fails
@@ -244,23 +373,126 @@ func (w *Workspace) CreateTaskWithType(title, taskType, repo string, deps []stri
 	// Auto-save
 	if err := w.Save(); err != nil {
 		audit.Error("workspace.create_task", "Failed to save after task creation", map[string]interface{}{
-			"task_id": id,
+			"task_id": t.ID,
 			"error":   err.Error(),
 		})
-		return nil, err
+		return err
 	}
 
 	audit.Info("workspace.create_task", "Task created", map[string]interface{}{
-		"task_id":  id,
-		"title":    title,
-		"type":     taskType,
+		"task_id":  t.ID,
+		"title":    t.Title,
+		"type":     t.Type,
 		"model":    t.Model,
-		"repo":     repo,
-		"deps":     deps,
-		"priority": priority,
+		"repo":     t.Repo,
+		"deps":     t.Deps,
+		"priority": t.Priority,
 	})
 
-	return t, nil
+	return nil
+}
+
+// ImportTasks adds tasks read from a bundle file. Nothing is added unless
+// CheckImport accepts all of them. Tasks without a model get their task
+// type's model and fallbacks from config.
+func (w *Workspace) ImportTasks(tasks []*task.Task) error {
+	if err := w.CheckImport(tasks); err != nil {
+		return err
+	}
+
+	hash, hashErr := w.SpecHash()
+	for _, t := range tasks {
+		if hashErr == nil && t.SpecHashAtCreate == "" {
+			t.SpecHashAtCreate = hash
+		}
+		if typeConfig, ok := w.Config.TaskTypes[t.Type]; ok && t.Type != "" && t.Model == "" {
+			t.Model = typeConfig.Model
+			t.Fallback = typeConfig.Fallback
+			t.Fallbacks = append([]string(nil), typeConfig.Fallbacks...)
+		}
+
+		// Related links may point forward; restore them once all are added
+		related := t.Related
+		t.Related = nil
+		if err := w.Tasks.Add(t); err != nil {
+			return err
+		}
+		t.Related = related
+	}
+
+	for _, t := range tasks {
+		if len(t.Related) > 0 {
+			if err := w.Tasks.Update(t); err != nil {
+				return err
+			}
+		}
+		if err := w.writeTaskFile(t); err != nil {
+			audit.Error("workspace.import_tasks", "Failed to write task file", map[string]interface{}{
+				"task_id": t.ID,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	if err := w.Save(); err != nil {
+		return err
+	}
+
+	audit.Info("workspace.import_tasks", "Tasks imported", map[string]interface{}{
+		"count": len(tasks),
+	})
+	return nil
+}
+
+// CheckImport checks that tasks can be imported together: IDs must be valid
+// and new, and deps and related links must name existing tasks or tasks in
+// the import, with deps ordered before their dependents as
+// task.ParseTaskBundle returns them.
+func (w *Workspace) CheckImport(tasks []*task.Task) error {
+	batch := make(map[string]bool, len(tasks))
+	for _, t := range tasks {
+		batch[t.ID] = true
+	}
+	added := make(map[string]bool, len(tasks))
+	known := func(id string, seen map[string]bool) bool {
+		if seen[id] {
+			return true
+		}
+		_, err := w.Tasks.Get(id)
+		return err == nil
+	}
+	for _, t := range tasks {
+		if err := t.Validate(); err != nil {
+			return fmt.Errorf("invalid task %s: %w", t.ID, err)
+		}
+		if err := w.Tasks.CheckID(t.ID); err != nil {
+			return err
+		}
+		if _, err := w.Tasks.Get(t.ID); err == nil || added[t.ID] {
+			return fmt.Errorf("task %s already exists; choose a different ID", t.ID)
+		}
+		for _, dep := range t.AllDeps() {
+			if !known(dep, added) {
+				return fmt.Errorf("task %s: unknown dependency %s: no task with that ID exists", t.ID, dep)
+			}
+		}
+		for _, rel := range t.Related {
+			if rel == t.ID {
+				return fmt.Errorf("task %s cannot be related to itself", t.ID)
+			}
+			if !known(rel, batch) {
+				return fmt.Errorf("task %s: unknown related task %s: no task with that ID exists", t.ID, rel)
+			}
+		}
+		added[t.ID] = true
+	}
+	return nil
+}
+
+// MigrateTasks applies migrations to every task in the workspace's tasks
+// manifest and saves it, keeping the previous manifest as a .bak file.
+func (w *Workspace) MigrateTasks(migrations ...task.TaskMigration) error {
+	return w.Tasks.MigrateFile(filepath.Join(w.Root, easDir, tasksDir, manifestFile), migrations...)
 }
 
 // GetTask returns a task by ID.
@@ -268,6 +500,132 @@ func (w *Workspace) GetTask(id string) (*task.Task, error) {
 	return w.Tasks.Get(id)
 }
 
+// DeleteTask deletes a task and its task file, refusing if other tasks
+// depend on it unless cascade is set, in which case its dependents are
+// deleted too. It returns the deleted IDs. Task files are only touched once
+// the deletion is saved; if saving fails the tasks are reloaded from disk.
+func (w *Workspace) DeleteTask(id string, cascade bool) ([]string, error) {
+	ids := []string{id}
+	if cascade {
+		var err error
+		if ids, err = w.Tasks.CascadeOrder(id); err != nil {
+			return nil, err
+		}
+	}
+
+	// Tasks that keep a related link to a deleted task need their files rewritten
+	deleted := make(map[string]bool, len(ids))
+	for _, del := range ids {
+		deleted[del] = true
+	}
+	var relinked []*task.Task
+	for _, t := range w.Tasks.List() {
+		if deleted[t.ID] {
+			continue
+		}
+		for _, rel := range t.Related {
+			if deleted[rel] {
+				relinked = append(relinked, t)
+				break
+			}
+		}
+	}
+
+	var err error
+	if cascade {
+		ids, err = w.Tasks.DeleteCascade(id)
+	} else {
+		err = w.Tasks.Delete(id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := w.Save(); err != nil {
+		if reloadErr := w.reloadTasks(); reloadErr != nil {
+			audit.Error("workspace.delete_task", "Failed to restore tasks", map[string]interface{}{
+				"task_id": id,
+				"error":   reloadErr.Error(),
+			})
+		}
+		return nil, err
+	}
+
+	for _, del := range ids {
+		if err := os.Remove(w.taskFilePath(del)); err != nil && !os.IsNotExist(err) {
+			audit.Error("workspace.delete_task", "Failed to remove task file", map[string]interface{}{
+				"task_id": del,
+				"error":   err.Error(),
+			})
+		}
+	}
+	for _, t := range relinked {
+		if err := w.writeTaskFile(t); err != nil {
+			audit.Error("workspace.delete_task", "Failed to write task file", map[string]interface{}{
+				"task_id": t.ID,
+				"error":   err.Error(),
+			})
+		}
+	}
+
+	audit.Info("workspace.delete_task", "Task deleted", map[string]interface{}{
+		"task_id": id,
+		"deleted": ids,
+	})
+	return ids, nil
+}
+
+// RepoPath returns the working directory for the named repo. An empty name
+// means the workspace root. Relative repo paths are resolved against the
+// workspace root; a repo without a path defaults to a directory named after
+// it. Repos not present in config are rejected.
+func (w *Workspace) RepoPath(name string) (string, error) {
+	if name == "" {
+		return w.Root, nil
+	}
+
+	repo, ok := w.Config.Repos[name]
+	if !ok {
+		known := make([]string, 0, len(w.Config.Repos))
+		for n := range w.Config.Repos {
+			known = append(known, n)
+		}
+		sort.Strings(known)
+		if len(known) == 0 {
+			return "", fmt.Errorf("unknown repo %q: no repos are configured", name)
+		}
+		return "", fmt.Errorf("unknown repo %q: configured repos are %s", name, strings.Join(known, ", "))
+	}
+
+	path := repo.Path
+	if path == "" {
+		path = name
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(w.Root, path)
+	}
+	return path, nil
+}
+
+// TestCommand returns the directory and configured test command for a task.
+// It satisfies tools.TestCommandResolver.
+func (w *Workspace) TestCommand(t *task.Task) (string, string, error) {
+	dir, err := w.RepoPath(t.Repo)
+	if err != nil {
+		return "", "", err
+	}
+	return dir, w.Config.TestCommandFor(t), nil
+}
+
+// CompletionChecks returns the directory and completion check commands for
+// a task. It satisfies tools.CompletionCheckResolver.
+func (w *Workspace) CompletionChecks(t *task.Task) (string, []string, error) {
+	dir, err := w.RepoPath(t.Repo)
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, w.Config.CompletionChecksFor(t), nil
+}
+
 // ListTasks returns tasks with optional filters.
 func (w *Workspace) ListTasks(status, repo string) []*task.Task {
 	if status != "" && repo != "" {
@@ -294,6 +652,23 @@ func (w *Workspace) GetReadyTasks() []*task.Task {
 	return w.Tasks.GetReady()
 }
 
+// AddTaskNote appends a note from author to a task and saves.
+func (w *Workspace) AddTaskNote(id, author, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("note text cannot be empty")
+	}
+	t, err := w.Tasks.Get(id)
+	if err != nil {
+		return err
+	}
+
+	t.AddNote(author, text)
+	if err := w.Tasks.Update(t); err != nil {
+		return err
+	}
+	return w.Save()
+}
+
 // SetTaskStatus updates the status of a task and saves.
 func (w *Workspace) SetTaskStatus(id string, status string) error {
 	t, err := w.Tasks.Get(id)
@@ -325,8 +700,29 @@ func (w *Workspace) SetTaskStatus(id string, status string) error {
 
 // Status returns the current workspace status.
 func (w *Workspace) Status() *Status {
-	tasks := w.Tasks.List()
-	
+	return w.statusOf(w.Tasks.List())
+}
+
+// GroupStatus returns the status of the tasks in group. Tasks outside the
+// group still count when deciding whether a group task is blocked.
+func (w *Workspace) GroupStatus(group string) *Status {
+	status := w.statusOf(w.Tasks.ListByGroup(group))
+	status.Group = group
+	return status
+}
+
+// relatedIDs returns the IDs of the tasks related to id.
+func (w *Workspace) relatedIDs(id string) []string {
+	related, _ := w.Tasks.GetRelated(id)
+	var ids []string
+	for _, rel := range related {
+		ids = append(ids, rel.ID)
+	}
+	return ids
+}
+
+// statusOf summarizes tasks.
+func (w *Workspace) statusOf(tasks []*task.Task) *Status {
 	status := &Status{
 		Feature:    w.Feature,
 		Backend:    w.Backend,
@@ -346,7 +742,61 @@ func (w *Workspace) Status() *Status {
 		}
 	}
 
-	status.ReadyTasks = len(w.GetReadyTasks())
+	blocked := w.Tasks.BlockedBy()
+	status.Pending = make([]PendingTask, 0, len(blocked))
+	for _, t := range tasks {
+		deps, ok := blocked[t.ID]
+		if !ok {
+			continue
+		}
+		if len(deps) == 0 {
+			status.ReadyTasks++
+		} else {
+			status.BlockedTasks++
+		}
+		status.Pending = append(status.Pending, PendingTask{
+			ID:        t.ID,
+			Title:     t.Title,
+			Ready:     len(deps) == 0,
+			BlockedBy: deps,
+			Related:   w.relatedIDs(t.ID),
+		})
+	}
+	sort.Slice(status.Pending, func(i, j int) bool {
+		return status.Pending[i].ID < status.Pending[j].ID
+	})
+
+	status.Completed = make([]CompletedTask, 0, status.CompleteTasks)
+	for _, t := range tasks {
+		if t.Status != task.StatusComplete {
+			continue
+		}
+		status.Completed = append(status.Completed, CompletedTask{
+			ID:          t.ID,
+			Title:       t.Title,
+			UsedBackend: t.UsedBackend,
+			UsedModel:   t.UsedModel,
+		})
+	}
+	sort.Slice(status.Completed, func(i, j int) bool {
+		return status.Completed[i].ID < status.Completed[j].ID
+	})
+
+	status.Failed = make([]FailedTask, 0, status.FailedTasks)
+	for _, t := range tasks {
+		if t.Status != task.StatusFailed {
+			continue
+		}
+		status.Failed = append(status.Failed, FailedTask{
+			ID:       t.ID,
+			Title:    t.Title,
+			Category: t.FailureCategory,
+			Reason:   t.FailureReason,
+		})
+	}
+	sort.Slice(status.Failed, func(i, j int) bool {
+		return status.Failed[i].ID < status.Failed[j].ID
+	})
 
 	return status
 }
@@ -358,18 +808,90 @@ func (w *Workspace) SpecPath() string {
 
 // ReadSpec reads the SPEC.md contents.
 func (w *Workspace) ReadSpec() (string, error) {
-	data, err := os.ReadFile(w.SpecPath())
+	data, err := w.readSpecFile()
 	if err != nil {
 		return "", err
 	}
 	return string(data), nil
 }
 
+// SpecHash returns the hex-encoded SHA-256 hash of SPEC.md.
+func (w *Workspace) SpecHash() (string, error) {
+	data, err := w.readSpecFile()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readSpecFile reads SPEC.md, refusing one that links outside the
+// workspace.
+func (w *Workspace) readSpecFile() ([]byte, error) {
+	path, err := w.ResolveSafe(filepath.Join(easDir, specFile))
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+// SpecDiff reports how tasks relate to the current SPEC.md.
+type SpecDiff struct {
+	CurrentHash string   `json:"current_hash"`
+	Changed     bool     `json:"changed"`
+	Stale       []string `json:"stale"`     // Created against a different spec
+	Untracked   []string `json:"untracked"` // Created before spec hashes were recorded
+}
+
+// SpecDiff compares the current spec hash against the hash each task
+// recorded when it was created.
+func (w *Workspace) SpecDiff() (*SpecDiff, error) {
+	hash, err := w.SpecHash()
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash spec: %w", err)
+	}
+
+	diff := &SpecDiff{
+		CurrentHash: hash,
+		Stale:       []string{},
+		Untracked:   []string{},
+	}
+	for _, t := range w.Tasks.List() {
+		switch t.SpecHashAtCreate {
+		case "":
+			diff.Untracked = append(diff.Untracked, t.ID)
+		case hash:
+		default:
+			diff.Stale = append(diff.Stale, t.ID)
+		}
+	}
+	sort.Strings(diff.Stale)
+	sort.Strings(diff.Untracked)
+	diff.Changed = len(diff.Stale) > 0
+
+	return diff, nil
+}
+
+// taskFilePath returns the path of the task.md file for the task id.
+func (w *Workspace) taskFilePath(id string) string {
+	return filepath.Join(w.Root, easDir, tasksDir, fmt.Sprintf("TASK-%s.md", id))
+}
+
 // writeTaskFile writes a task.md file with YAML frontmatter.
 func (w *Workspace) writeTaskFile(t *task.Task) error {
-	easPath := filepath.Join(w.Root, easDir)
-	taskPath := filepath.Join(easPath, tasksDir, fmt.Sprintf("TASK-%s.md", t.ID))
+	taskPath := w.taskFilePath(t.ID)
+	content := taskMarkdown(t) + tddSection
+
+	if err := os.WriteFile(taskPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write task file: %w", err)
+	}
+
+	return nil
+}
 
+// taskMarkdown renders t as YAML frontmatter followed by its title and
+// description, the part of a task.md file that ParseTaskFile reads back.
+func taskMarkdown(t *task.Task) string {
 	// Build YAML frontmatter
 	frontmatter := fmt.Sprintf(`---
 id: %s
@@ -381,6 +903,12 @@ status: %s`, t.ID, t.Status)
 	if t.Fallback != "" {
 		frontmatter += fmt.Sprintf("\nfallback: %s", t.Fallback)
 	}
+	if len(t.Fallbacks) > 0 {
+		frontmatter += "\nfallbacks:"
+		for _, ref := range t.Fallbacks {
+			frontmatter += fmt.Sprintf("\n  - %s", ref)
+		}
+	}
 	if t.Type != "" {
 		frontmatter += fmt.Sprintf("\ntype: %s", t.Type)
 	}
@@ -390,12 +918,56 @@ status: %s`, t.ID, t.Status)
 	if t.Repo != "" {
 		frontmatter += fmt.Sprintf("\nrepo: %s", t.Repo)
 	}
+	if t.Group != "" {
+		frontmatter += fmt.Sprintf("\ngroup: %s", t.Group)
+	}
+	if len(t.Labels) > 0 {
+		frontmatter += "\nlabels:"
+		for _, label := range t.Labels {
+			frontmatter += fmt.Sprintf("\n  - %q", label)
+		}
+	}
+	if t.SpecRef != "" {
+		frontmatter += fmt.Sprintf("\nspec_ref: %q", t.SpecRef)
+	}
+	if t.EstimatedMinutes > 0 {
+		frontmatter += fmt.Sprintf("\nestimated_minutes: %d", t.EstimatedMinutes)
+	}
+	if t.MaxRetries > 0 {
+		frontmatter += fmt.Sprintf("\nmax_retries: %d", t.MaxRetries)
+	}
+	if t.Issue > 0 {
+		frontmatter += fmt.Sprintf("\nissue: %d", t.Issue)
+	}
+	if len(t.Env) > 0 {
+		keys := make([]string, 0, len(t.Env))
+		for key := range t.Env {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		frontmatter += "\nenv:"
+		for _, key := range keys {
+			frontmatter += fmt.Sprintf("\n  %s: %q", key, t.Env[key])
+		}
+	}
 	if len(t.Deps) > 0 {
 		frontmatter += "\ndeps:"
 		for _, dep := range t.Deps {
 			frontmatter += fmt.Sprintf("\n  - %s", dep)
 		}
 	}
+	if len(t.OptionalDeps) > 0 {
+		frontmatter += "\noptional_deps:"
+		for _, dep := range t.OptionalDeps {
+			frontmatter += fmt.Sprintf("\n  - %s", dep)
+		}
+	}
+	if len(t.Related) > 0 {
+		frontmatter += "\nrelated:"
+		for _, rel := range t.Related {
+			frontmatter += fmt.Sprintf("\n  - %s", rel)
+		}
+	}
 
 	frontmatter += "\n---\n\n"
 
@@ -405,8 +977,11 @@ status: %s`, t.ID, t.Status)
 		body += fmt.Sprintf("\n%s\n", t.Description)
 	}
 
-	// Add TDD enforcement section
-	body += `
+	return frontmatter + body
+}
+
+// tddSection is the TDD enforcement section appended to every task.md file.
+const tddSection = `
 ## TDD Requirements
 
 **This task MUST follow Test-Driven Development:**
@@ -433,12 +1008,3 @@ status: %s`, t.ID, t.Status)
 - [ ] Coverage maintained or improved
 - [ ] No regressions introduced
 `
-
-	content := frontmatter + body
-
-	if err := os.WriteFile(taskPath, []byte(content), 0644); err != nil {
-		return fmt.Errorf("failed to write task file: %w", err)
-	}
-
-	return nil
-}
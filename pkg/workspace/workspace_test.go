@@ -1,9 +1,14 @@
 package workspace
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/richgo/flo/pkg/config"
+	"github.com/richgo/flo/pkg/task"
 )
 
 func TestInit(t *testing.T) {
@@ -60,6 +65,91 @@ func TestInitAlreadyExists(t *testing.T) {
 	}
 }
 
+func TestInitWithOptions(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ws, err := InitWithOptions(tmpDir, InitOptions{
+		Feature: "checkout",
+		Backend: "copilot",
+		Repos: map[string]config.Repo{
+			"android": {Path: "../android"},
+			"ios":     {},
+		},
+	})
+	if err != nil {
+		t.Fatalf("InitWithOptions failed: %v", err)
+	}
+
+	for _, rel := range []string{".flo/config.yaml", ".flo/SPEC.md", ".flo/tasks"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, rel)); err != nil {
+			t.Errorf("expected %s to exist: %v", rel, err)
+		}
+	}
+
+	loaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if loaded.Feature != "checkout" || loaded.Backend != "copilot" {
+		t.Errorf("expected checkout/copilot, got %s/%s", loaded.Feature, loaded.Backend)
+	}
+	if len(loaded.Config.Repos) != 2 || loaded.Config.Repos["android"].Path != "../android" {
+		t.Errorf("unexpected repos: %+v", loaded.Config.Repos)
+	}
+	if len(ws.Tasks.List()) != 0 {
+		t.Errorf("expected no tasks, got %d", len(ws.Tasks.List()))
+	}
+}
+
+func TestInitWithOptionsInvalidBackend(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	_, err := InitWithOptions(tmpDir, InitOptions{Feature: "checkout", Backend: "nope"})
+	if err == nil {
+		t.Fatal("expected error for unknown backend")
+	}
+	if !strings.Contains(err.Error(), "nope") {
+		t.Errorf("expected error to name the backend, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, ".flo")); !os.IsNotExist(err) {
+		t.Error("expected no workspace to be created for an invalid backend")
+	}
+}
+
+func TestInitWithOptionsForce(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	ws, err := Init(tmpDir, "first", "claude")
+	if err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	if _, err := ws.CreateTask("Keep me", "", nil, 1); err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	specPath := filepath.Join(tmpDir, ".flo", "SPEC.md")
+	if err := os.WriteFile(specPath, []byte("# Edited spec\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := InitWithOptions(tmpDir, InitOptions{Feature: "second", Backend: "copilot"}); err == nil {
+		t.Fatal("expected error without force")
+	}
+
+	ws, err = InitWithOptions(tmpDir, InitOptions{Feature: "second", Backend: "copilot", Force: true})
+	if err != nil {
+		t.Fatalf("InitWithOptions with force failed: %v", err)
+	}
+	if ws.Feature != "second" || ws.Backend != "copilot" {
+		t.Errorf("expected rewritten config, got %s/%s", ws.Feature, ws.Backend)
+	}
+	if len(ws.Tasks.List()) != 1 {
+		t.Errorf("expected existing task kept, got %d tasks", len(ws.Tasks.List()))
+	}
+	if data, _ := os.ReadFile(specPath); string(data) != "# Edited spec\n" {
+		t.Errorf("expected SPEC.md kept, got %q", data)
+	}
+}
+
 func TestLoad(t *testing.T) {
 	tmpDir := t.TempDir()
 
@@ -206,6 +296,50 @@ func TestWorkspaceStatus(t *testing.T) {
 	if status.ReadyTasks != 2 {
 		t.Errorf("expected 2 ready tasks, got %d", status.ReadyTasks)
 	}
+	if status.BlockedTasks != 0 {
+		t.Errorf("expected 0 blocked tasks, got %d", status.BlockedTasks)
+	}
+
+	ws.CreateTask("Task 3", "", []string{"t-001"}, 0)
+	status = ws.Status()
+	if status.ReadyTasks != 2 || status.BlockedTasks != 1 {
+		t.Errorf("expected 2 ready and 1 blocked, got %d ready and %d blocked", status.ReadyTasks, status.BlockedTasks)
+	}
+	if len(status.Completed) != 0 {
+		t.Errorf("expected no completed tasks, got %+v", status.Completed)
+	}
+
+	t2, _ := ws.GetTask("t-002")
+	t2.SetStatus(task.StatusInProgress)
+	t2.RecordRun("copilot", "gpt-4", 100)
+	t2.SetStatus(task.StatusComplete)
+	status = ws.Status()
+	if len(status.Completed) != 1 {
+		t.Fatalf("expected 1 completed task, got %+v", status.Completed)
+	}
+	if c := status.Completed[0]; c.ID != "t-002" || c.UsedBackend != "copilot" || c.UsedModel != "gpt-4" {
+		t.Errorf("expected t-002 completed by copilot/gpt-4, got %+v", c)
+	}
+
+	// Failed tasks carry their failure category, which survives a reload
+	t1, _ := ws.GetTask("t-001")
+	t1.SetStatus(task.StatusInProgress)
+	t1.RecordFailure(task.FailureQuota, "quota exhausted for backend claude")
+	t1.SetStatus(task.StatusFailed)
+	if err := ws.Save(); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	status = reloaded.Status()
+	if len(status.Failed) != 1 {
+		t.Fatalf("expected 1 failed task, got %+v", status.Failed)
+	}
+	if f := status.Failed[0]; f.ID != "t-001" || f.Category != task.FailureQuota || f.Reason != "quota exhausted for backend claude" {
+		t.Errorf("expected t-001 failed on quota, got %+v", f)
+	}
 }
 
 func TestWorkspaceTaskMDGeneration(t *testing.T) {
@@ -287,3 +421,512 @@ func contains(s, substr string) bool {
 	}
 	return false
 }
+
+func TestWorkspaceAddTask(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	tk := ws.NewTask("api-001", "Build API", "build")
+	tk.Description = "REST endpoints"
+	if err := ws.AddTask(tk); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	// Task file written with the chosen ID
+	taskPath := filepath.Join(tmpDir, ".flo", "tasks", "TASK-api-001.md")
+	if _, err := os.Stat(taskPath); os.IsNotExist(err) {
+		t.Error("task.md not created")
+	}
+
+	// Persisted across reload
+	ws2, _ := Load(tmpDir)
+	got, err := ws2.GetTask("api-001")
+	if err != nil {
+		t.Fatalf("task not persisted: %v", err)
+	}
+	if got.Model != "claude/sonnet" {
+		t.Errorf("expected model from task type, got %q", got.Model)
+	}
+
+	// Duplicate ID rejected
+	err = ws.AddTask(ws.NewTask("api-001", "Again", ""))
+	if err == nil || !contains(err.Error(), "already exists") {
+		t.Errorf("expected duplicate ID error, got %v", err)
+	}
+
+	// Unknown dep rejected
+	bad := ws.NewTask("api-002", "Bad deps", "")
+	bad.Deps = []string{"nope"}
+	err = ws.AddTask(bad)
+	if err == nil || !contains(err.Error(), "unknown dependency nope") {
+		t.Errorf("expected unknown dependency error, got %v", err)
+	}
+}
+
+func TestWorkspaceAddTaskAdvancesSequence(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	if err := ws.AddTask(ws.NewTask("t-005", "Explicit", "")); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	next, err := ws.CreateTask("Generated", "", nil, 0)
+	if err != nil {
+		t.Fatalf("CreateTask failed: %v", err)
+	}
+	if next.ID != "t-006" {
+		t.Errorf("expected generated ID t-006, got %s", next.ID)
+	}
+}
+
+func TestWorkspaceValidateNewTaskDoesNotWrite(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	tk := ws.NewTask("", "Dry run", "")
+	if err := ws.ValidateNewTask(tk); err != nil {
+		t.Fatalf("ValidateNewTask failed: %v", err)
+	}
+	if len(ws.ListTasks("", "")) != 0 {
+		t.Error("ValidateNewTask should not add the task")
+	}
+}
+
+func TestWorkspaceStatusJSON(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.CreateTask("Task 1", "", nil, 0)
+	ws.CreateTask("Task 2", "", []string{"t-001"}, 0)
+
+	data, err := json.Marshal(ws.Status())
+	if err != nil {
+		t.Fatalf("failed to marshal status: %v", err)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("status is not valid JSON: %v", err)
+	}
+
+	for _, field := range []string{"feature", "backend", "total_tasks", "pending_tasks", "ready_tasks", "blocked_tasks", "pending"} {
+		if _, ok := got[field]; !ok {
+			t.Errorf("status JSON missing field %q: %s", field, data)
+		}
+	}
+	if got["feature"] != "test" || got["total_tasks"] != float64(2) {
+		t.Errorf("unexpected status JSON: %s", data)
+	}
+
+	pending, _ := got["pending"].([]any)
+	if len(pending) != 2 {
+		t.Fatalf("expected 2 pending entries, got %v", got["pending"])
+	}
+	second, _ := pending[1].(map[string]any)
+	if second["id"] != "t-002" || second["ready"] != false {
+		t.Errorf("unexpected pending entry: %v", second)
+	}
+	blockedBy, _ := second["blocked_by"].([]any)
+	if len(blockedBy) != 1 || blockedBy[0] != "t-001" {
+		t.Errorf("expected t-002 blocked by t-001, got %v", second["blocked_by"])
+	}
+}
+
+func TestWorkspaceRepoPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.Config.Repos = map[string]config.Repo{
+		"android": {URL: "git@github.com:org/android.git", Path: "repos/android"},
+		"ios":     {URL: "git@github.com:org/ios.git"},
+		"shared":  {URL: "git@github.com:org/shared.git", Path: "/src/shared"},
+	}
+
+	tk, _ := ws.CreateTask("Android work", "android", nil, 0)
+	if got, err := ws.RepoPath(tk.Repo); err != nil || got != filepath.Join(tmpDir, "repos", "android") {
+		t.Errorf("expected task repo to map to configured path, got %q (%v)", got, err)
+	}
+
+	tests := []struct {
+		repo string
+		want string
+	}{
+		{"", tmpDir},
+		{"android", filepath.Join(tmpDir, "repos", "android")},
+		{"ios", filepath.Join(tmpDir, "ios")},
+		{"shared", "/src/shared"},
+	}
+
+	for _, tt := range tests {
+		got, err := ws.RepoPath(tt.repo)
+		if err != nil {
+			t.Errorf("RepoPath(%q) failed: %v", tt.repo, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("RepoPath(%q) = %q, want %q", tt.repo, got, tt.want)
+		}
+	}
+
+	_, err := ws.RepoPath("web")
+	if err == nil {
+		t.Fatal("expected error for unknown repo")
+	}
+	if !strings.Contains(err.Error(), "web") || !strings.Contains(err.Error(), "android") {
+		t.Errorf("expected error to name the repo and list known repos, got: %v", err)
+	}
+}
+
+func TestWorkspaceSpecHashDiff(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	before, err := ws.SpecHash()
+	if err != nil {
+		t.Fatalf("SpecHash failed: %v", err)
+	}
+
+	old, _ := ws.CreateTask("Against original spec", "", nil, 0)
+	if old.SpecHashAtCreate != before {
+		t.Errorf("expected task to record spec hash %s, got %s", before, old.SpecHashAtCreate)
+	}
+
+	diff, err := ws.SpecDiff()
+	if err != nil {
+		t.Fatalf("SpecDiff failed: %v", err)
+	}
+	if diff.Changed || len(diff.Stale) != 0 {
+		t.Errorf("expected no drift before edit, got %+v", diff)
+	}
+
+	// Edit the spec
+	os.WriteFile(ws.SpecPath(), []byte("# Spec\n\n## Goal\nSomething new\n"), 0644)
+
+	after, _ := ws.SpecHash()
+	if after == before {
+		t.Fatal("expected hash to change after spec edit")
+	}
+
+	ws.CreateTask("Against edited spec", "", nil, 0)
+	legacy, _ := ws.CreateTask("Legacy", "", nil, 0)
+	legacy.SpecHashAtCreate = ""
+
+	// Hash survives a save/load round trip
+	ws.Save()
+	ws2, _ := Load(tmpDir)
+
+	diff, err = ws2.SpecDiff()
+	if err != nil {
+		t.Fatalf("SpecDiff failed: %v", err)
+	}
+	if !diff.Changed || diff.CurrentHash != after {
+		t.Errorf("expected drift against hash %s, got %+v", after, diff)
+	}
+	if len(diff.Stale) != 1 || diff.Stale[0] != old.ID {
+		t.Errorf("expected only %s stale, got %v", old.ID, diff.Stale)
+	}
+	if len(diff.Untracked) != 1 || diff.Untracked[0] != legacy.ID {
+		t.Errorf("expected %s untracked, got %v", legacy.ID, diff.Untracked)
+	}
+}
+
+func TestWorkspaceTestCommand(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+
+	ws.Config.TDD.TestCommand = "go test ./..."
+	ws.Config.Repos = map[string]config.Repo{
+		"android": {URL: "git@github.com:org/android.git", Path: "android", TestCommand: "./gradlew test"},
+	}
+
+	tk, _ := ws.CreateTask("Android work", "android", nil, 0)
+	dir, command, err := ws.TestCommand(tk)
+	if err != nil {
+		t.Fatalf("TestCommand failed: %v", err)
+	}
+	if dir != filepath.Join(tmpDir, "android") || command != "./gradlew test" {
+		t.Errorf("expected android dir and gradle command, got %q %q", dir, command)
+	}
+
+	tk.Repo = "web"
+	if _, _, err := ws.TestCommand(tk); err == nil {
+		t.Error("expected error for unknown repo")
+	}
+}
+
+func TestWorkspaceGroupStatus(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+
+	setup, _ := ws.CreateTask("Setup", "", nil, 0)
+	login := ws.NewTask("", "Login", "")
+	login.Group = "auth"
+	login.Deps = []string{setup.ID}
+	if err := ws.AddTask(login); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	logout := ws.NewTask("", "Logout", "")
+	logout.Group = "auth"
+	if err := ws.AddTask(logout); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+	ws.SetTaskStatus(logout.ID, "in_progress")
+	ws.SetTaskStatus(logout.ID, "complete")
+
+	status := ws.GroupStatus("auth")
+	if status.Group != "auth" || status.TotalTasks != 2 || status.CompleteTasks != 1 || status.PendingTasks != 1 {
+		t.Errorf("unexpected group status: %+v", status)
+	}
+	// The login task is blocked by a dependency outside the group
+	if status.BlockedTasks != 1 || len(status.Pending) != 1 || status.Pending[0].BlockedBy[0] != setup.ID {
+		t.Errorf("expected login blocked by %s, got %+v", setup.ID, status.Pending)
+	}
+
+	// Group is written to the task file frontmatter
+	parsed, err := task.ParseTaskFile(filepath.Join(ws.Root, ".flo", "tasks", "TASK-"+login.ID+".md"))
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if parsed.Group != "auth" {
+		t.Errorf("expected group auth in frontmatter, got %q", parsed.Group)
+	}
+}
+
+func TestWorkspaceRelatedTasks(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+
+	schema, _ := ws.CreateTask("Schema", "", nil, 0)
+	docs := ws.NewTask("", "Docs", "")
+	docs.Related = []string{schema.ID}
+	if err := ws.AddTask(docs); err != nil {
+		t.Fatalf("AddTask failed: %v", err)
+	}
+
+	unknown := ws.NewTask("", "Orphan", "")
+	unknown.Related = []string{"t-999"}
+	if err := ws.AddTask(unknown); err == nil {
+		t.Error("expected error for an unknown related task")
+	}
+
+	status := ws.Status()
+	if status.ReadyTasks != 2 || status.BlockedTasks != 0 {
+		t.Errorf("expected related tasks to be ready, got %+v", status)
+	}
+	for _, p := range status.Pending {
+		if len(p.Related) != 1 {
+			t.Errorf("expected %s to show one related task, got %v", p.ID, p.Related)
+		}
+	}
+
+	parsed, err := task.ParseTaskFile(filepath.Join(ws.Root, ".flo", "tasks", "TASK-"+docs.ID+".md"))
+	if err != nil {
+		t.Fatalf("ParseTaskFile failed: %v", err)
+	}
+	if len(parsed.Related) != 1 || parsed.Related[0] != schema.ID {
+		t.Errorf("expected related %s in frontmatter, got %v", schema.ID, parsed.Related)
+	}
+}
+
+func TestWorkspaceImportTasks(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	ws.CreateTask("Existing", "", nil, 0)
+
+	bundle := func(ids ...string) []*task.Task {
+		var tasks []*task.Task
+		for i, id := range ids {
+			tk := task.New(id, "Task "+id)
+			if i > 0 {
+				tk.Deps = []string{ids[i-1]}
+			}
+			tasks = append(tasks, tk)
+		}
+		return tasks
+	}
+
+	// A dep on a missing task rejects the whole import
+	bad := bundle("t-010", "t-011")
+	bad[0].Deps = []string{"t-999"}
+	if err := ws.ImportTasks(bad); err == nil {
+		t.Fatal("expected error for an unknown dependency")
+	}
+	if got := len(ws.Tasks.List()); got != 1 {
+		t.Errorf("expected nothing imported, got %d tasks", got)
+	}
+
+	good := bundle("t-001", "t-010", "t-011")[1:] // t-010 depends on the existing t-001
+	good[0].Related = []string{"t-011"}           // Links may point forward
+	if err := ws.ImportTasks(good); err != nil {
+		t.Fatalf("ImportTasks failed: %v", err)
+	}
+	if got := len(ws.Tasks.List()); got != 3 {
+		t.Errorf("expected 3 tasks, got %d", got)
+	}
+	if got, _ := ws.Tasks.GetRelated("t-011"); len(got) != 1 || got[0].ID != "t-010" {
+		t.Errorf("expected t-011 related to t-010, got %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Root, ".flo", "tasks", "TASK-t-011.md")); err != nil {
+		t.Errorf("expected task file for t-011: %v", err)
+	}
+
+	if err := ws.ImportTasks(bundle("t-010")); err == nil {
+		t.Error("expected error re-importing an existing ID")
+	}
+
+	// Related links are checked up front too, before any task is added
+	for name, related := range map[string][]string{
+		"self":    {"t-020"},
+		"unknown": {"t-999"},
+	} {
+		bad := bundle("t-020", "t-021")
+		bad[1].Related = related
+		bad[0].Related = related
+		if err := ws.ImportTasks(bad); err == nil {
+			t.Errorf("%s: expected error for related link %v", name, related)
+		}
+		if _, err := ws.Tasks.Get("t-020"); err == nil {
+			t.Errorf("%s: expected nothing imported", name)
+		}
+	}
+	if err := ws.CheckImport(bundle("t-030", "t-030")); err == nil {
+		t.Error("expected error for a duplicate ID within the import")
+	}
+}
+
+func TestWorkspaceAddTaskNote(t *testing.T) {
+	tmpDir := t.TempDir()
+	ws, _ := Init(tmpDir, "test", "claude")
+	tk, _ := ws.CreateTask("Task 1", "", nil, 0)
+
+	if err := ws.AddTaskNote(tk.ID, "dana", "Reopened: flaky on CI"); err != nil {
+		t.Fatalf("AddTaskNote failed: %v", err)
+	}
+	if err := ws.AddTaskNote(tk.ID, "agent", "Fixed the race in setup"); err != nil {
+		t.Fatalf("AddTaskNote failed: %v", err)
+	}
+	if err := ws.AddTaskNote(tk.ID, "dana", ""); err == nil {
+		t.Error("expected error for an empty note")
+	}
+	if err := ws.AddTaskNote("t-404", "dana", "Missing"); err == nil {
+		t.Error("expected error for an unknown task")
+	}
+
+	// Notes round-trip through save and load in order
+	reloaded, err := Load(tmpDir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	got, _ := reloaded.GetTask(tk.ID)
+	if len(got.Notes) != 2 {
+		t.Fatalf("expected 2 notes after reload, got %+v", got.Notes)
+	}
+	if n := got.Notes[0]; n.Author != "dana" || n.Message != "Reopened: flaky on CI" || n.Time.IsZero() {
+		t.Errorf("unexpected first note: %+v", n)
+	}
+	if n := got.Notes[1]; n.Author != "agent" || n.Message != "Fixed the race in setup" {
+		t.Errorf("unexpected second note: %+v", n)
+	}
+}
+
+func TestWorkspaceDeleteTask(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	base, _ := ws.CreateTask("Base", "", nil, 0)
+	dependent, _ := ws.CreateTask("Dependent", "", []string{base.ID}, 0)
+	other := ws.NewTask("", "Other", "")
+	other.Related = []string{dependent.ID}
+	if err := ws.AddTask(other); err != nil {
+		t.Fatal(err)
+	}
+
+	// Without cascade a task with dependents is kept
+	if _, err := ws.DeleteTask(base.ID, false); err == nil {
+		t.Fatal("expected error deleting a task with dependents")
+	}
+	if _, err := os.Stat(ws.taskFilePath(base.ID)); err != nil {
+		t.Errorf("expected task file kept: %v", err)
+	}
+
+	deleted, err := ws.DeleteTask(base.ID, true)
+	if err != nil {
+		t.Fatalf("DeleteTask failed: %v", err)
+	}
+	if len(deleted) != 2 || deleted[0] != dependent.ID || deleted[1] != base.ID {
+		t.Errorf("expected %s then %s deleted, got %v", dependent.ID, base.ID, deleted)
+	}
+	for _, id := range deleted {
+		if _, err := os.Stat(ws.taskFilePath(id)); !os.IsNotExist(err) {
+			t.Errorf("expected task file for %s removed, got %v", id, err)
+		}
+	}
+	md, _ := os.ReadFile(ws.taskFilePath(other.ID))
+	if strings.Contains(string(md), "related:") {
+		t.Errorf("expected related link removed from task file:\n%s", md)
+	}
+
+	reloaded, err := Load(ws.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reloaded.Tasks.List(); len(got) != 1 || got[0].ID != other.ID {
+		t.Errorf("expected only %s after reload, got %v", other.ID, got)
+	}
+}
+
+func TestWorkspaceDeleteTaskSaveFailure(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	tk, _ := ws.CreateTask("Keep", "", nil, 0)
+
+	// A directory in place of config.yaml makes Save fail
+	configPath := filepath.Join(ws.Root, ".flo", "config.yaml")
+	if err := os.Remove(configPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(configPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ws.DeleteTask(tk.ID, false); err == nil {
+		t.Fatal("expected DeleteTask to fail when saving fails")
+	}
+	if _, err := ws.Tasks.Get(tk.ID); err != nil {
+		t.Errorf("expected task restored after failed save: %v", err)
+	}
+	if _, err := os.Stat(ws.taskFilePath(tk.ID)); err != nil {
+		t.Errorf("expected task file kept: %v", err)
+	}
+}
+
+func TestWorkspaceTaskIDPattern(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	ws.Config.TaskIDPattern = `ua-\d{3}`
+	if err := ws.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ws, err := Load(ws.Root)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if err := ws.AddTask(ws.NewTask("ua-001", "Matches", "")); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := ws.CreateTask("Default ID", "", nil, 0); err == nil {
+		t.Error("expected a t-NNN ID to be rejected by the pattern")
+	}
+}
+
+func TestWorkspaceMigrateTasks(t *testing.T) {
+	ws, _ := Init(t.TempDir(), "test", "claude")
+	ws.CreateTask("Setup", "", nil, 0)
+
+	if err := ws.MigrateTasks(task.DefaultPriority(3)); err != nil {
+		t.Fatalf("MigrateTasks failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(ws.Root, easDir, tasksDir, manifestFile+".bak")); err != nil {
+		t.Errorf("expected a backup of the tasks file: %v", err)
+	}
+
+	reloaded, _ := Load(ws.Root)
+	if got, _ := reloaded.GetTask("t-001"); got == nil || got.Priority != 3 {
+		t.Errorf("expected migrated priority 3, got %+v", got)
+	}
+}